@@ -15,22 +15,27 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/orders": {
+        "/api/admin/deleted/orders": {
             "get": {
-                "description": "Возвращает все созданные заказы",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает мягко удалённые заказы, которые ещё можно восстановить до окончательной очистки",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin"
                 ],
-                "summary": "Получить список заказов",
+                "summary": "Удалённые заказы",
                 "responses": {
                     "200": {
-                        "description": "Список заказов",
+                        "description": "Удалённые заказы с deleted_at",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -38,52 +43,57 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Ошибка кодирования ответа",
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Создает новый заказ пользователя с переданным userID",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/api/admin/deleted/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает мягко удалённых пользователей, которых ещё можно восстановить до окончательной очистки",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
-                ],
-                "summary": "Создать заказ",
-                "parameters": [
-                    {
-                        "description": "User ID",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/web.createOrderRequest"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Удалённые пользователи",
                 "responses": {
-                    "201": {
-                        "description": "Созданный заказ",
+                    "200": {
+                        "description": "Удалённые пользователи с deleted_at",
                         "schema": {
-                            "$ref": "#/definitions/model.Order"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Неверный JSON или не указан user ID",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка кодирования ответа",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
@@ -91,56 +101,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/cancel/{id}": {
-            "post": {
-                "description": "Отменяет заказ, если он в статусе \"создан\" или \"подтвержден\"",
-                "consumes": [
-                    "application/json"
+        "/api/admin/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает все ключи (без секретов) с временем последнего использования и сроком действия",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
-                ],
-                "summary": "Отмена заказа",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Список API-ключей",
                 "responses": {
-                    "204": {
-                        "description": "No Content - заказ успешно отменен"
-                    },
-                    "400": {
-                        "description": "Некорректный статус заказа для отмены",
+                    "200": {
+                        "description": "Ключи",
                         "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "405": {
-                        "description": "Метод не поддерживается",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            }
-        },
-        "/api/orders/confirm/{id}": {
+            },
             "post": {
-                "description": "Подтверждает заказ, если он находится в статусе \"создан\" (0)",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает ключ для сервиса с ролью и необязательной областью действий; секрет возвращается один раз",
                 "consumes": [
                     "application/json"
                 ],
@@ -148,30 +160,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin"
                 ],
-                "summary": "Подтверждение заказа",
+                "summary": "Выпустить API-ключ",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Параметры ключа",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.createAPIKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content - успешное подтверждение"
+                    "201": {
+                        "description": "Ключ и секрет",
+                        "schema": {
+                            "$ref": "#/definitions/web.apiKeySecretResponse"
+                        }
                     },
                     "400": {
-                        "description": "Некорректный запрос или статус заказа не позволяет подтверждение",
+                        "description": "Неверный JSON, роль, область или срок",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
@@ -179,46 +202,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/delivery/{id}": {
-            "post": {
-                "description": "Переводит заказ в статус \"доставлен\", если он в статусе \"подтвержден\"",
-                "consumes": [
-                    "application/json"
+        "/api/admin/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Ключ перестаёт приниматься; запись остаётся в списке с revoked_at",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin"
                 ],
-                "summary": "Отметить заказ как доставленный",
+                "summary": "Отозвать API-ключ",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID заказа",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content - заказ успешно доставлен"
+                    "200": {
+                        "description": "Отозванный ключ",
+                        "schema": {
+                            "$ref": "#/definitions/model.APIKey"
+                        }
                     },
-                    "400": {
-                        "description": "Некорректный запрос или заказ в нужном статусе",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "404": {
-                        "description": "Заказ не найден",
+                        "description": "Ключ не найден",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "405": {
-                        "description": "Метод не поддерживается",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
@@ -226,23 +257,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/{id}": {
-            "get": {
-                "description": "Возвращает заказ с указанным ID",
-                "consumes": [
-                    "application/json"
+        "/api/admin/keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Генерирует новый секрет; старый сразу перестаёт действовать",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Admin"
                 ],
-                "summary": "Получить заказ по ID",
+                "summary": "Перевыпустить API-ключ",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID заказа",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -250,52 +286,31 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Найденный заказ",
+                        "description": "Ключ и новый секрет",
                         "schema": {
-                            "$ref": "#/definitions/model.Order"
+                            "$ref": "#/definitions/web.apiKeySecretResponse"
                         }
                     },
-                    "400": {
-                        "description": "Некорректный ID",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "404": {
-                        "description": "Заказ не найден",
+                        "description": "Ключ не найден",
                         "schema": {
                             "type": "object"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Удаляет заказ с указанным ID",
-                "tags": [
-                    "Orders"
-                ],
-                "summary": "Удалить заказ",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "Заказ успешно удален"
                     },
-                    "400": {
-                        "description": "Некорректный ID",
+                    "409": {
+                        "description": "Ключ отозван",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
@@ -303,75 +318,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users": {
-            "get": {
-                "description": "Возвращает всех зарегистрированных пользователей",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Users"
-                ],
-                "summary": "Получить список пользователей",
-                "responses": {
-                    "200": {
-                        "description": "Список пользователей",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
-                            }
-                        }
+        "/api/admin/orders/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     },
-                    "500": {
-                        "description": "Ошибка кодирования ответа",
-                        "schema": {
-                            "type": "object"
-                        }
+                    {
+                        "APIKeyAuth": []
                     }
-                }
-            },
-            "post": {
-                "description": "Создает нового пользователя с переданным именем",
-                "consumes": [
-                    "application/json"
                 ],
+                "description": "Снимает отметку удаления с заказа",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Создать пользователя",
+                "summary": "Восстановить заказ",
                 "parameters": [
                     {
-                        "description": "Имя пользователя",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/web.createUserRequest"
-                        }
+                        "type": "string",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Созданный пользователь",
+                        "description": "Восстановленный заказ",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Order"
                         }
                     },
-                    "400": {
-                        "description": "Неверный JSON или не указано имя",
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Удалённый заказ не найден",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка кодирования ответа",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
@@ -379,19 +373,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users/{id}": {
-            "get": {
-                "description": "Возвращает пользователя по указанному ID",
-                "consumes": [
-                    "application/json"
+        "/api/admin/users/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "Снимает отметку удаления с пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Получить пользователя по ID",
+                "summary": "Восстановить пользователя",
                 "parameters": [
                     {
                         "type": "string",
@@ -403,95 +402,1002 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пользователь найден",
+                        "description": "Восстановленный пользователь",
                         "schema": {
                             "$ref": "#/definitions/model.User"
                         }
                     },
-                    "400": {
-                        "description": "Отсутствует или некорректный ID",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "object"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Удалённый пользователь не найден",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка кодирования ответа",
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет имя пользователя по указанному ID",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/api/orders/cancel/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "То же, что POST /api/orders/{id}/cancel",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Orders"
                 ],
-                "summary": "Обновить имя пользователя по ID",
+                "summary": "Отмена заказа (устаревший путь)",
+                "deprecated": true,
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя",
+                        "description": "ID заказа",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новое имя пользователя",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/web.updateUserRequest"
-                        }
+                        "type": "string",
+                        "description": "ETag заказа: смена статуса, только если заказ не менялся",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Успешное обновление без тела ответа"
+                        "description": "Заказ отменён"
                     },
-                    "400": {
-                        "description": "Неверный JSON или некорректные данные",
+                    "404": {
+                        "description": "Заказ не найден",
                         "schema": {
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "409": {
+                        "description": "Заказ уже доставлен или отменён",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Заказ изменился с момента чтения",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет пользователя по указанному ID",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/api/orders/confirm/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
                 ],
+                "description": "То же, что POST /api/orders/{id}/confirm",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Orders"
                 ],
-                "summary": "Удалить пользователя по ID",
+                "summary": "Подтверждение заказа (устаревший путь)",
+                "deprecated": true,
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag заказа: смена статуса, только если заказ не менялся",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подтверждённый заказ",
+                        "schema": {
+                            "$ref": "#/definitions/model.Order"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ не в статусе \\\"создан\\",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Заказ изменился с момента чтения",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/delivery/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "То же, что POST /api/orders/{id}/deliver",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Отметить заказ доставленным (устаревший путь)",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag заказа: смена статуса, только если заказ не менялся",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставленный заказ",
+                        "schema": {
+                            "$ref": "#/definitions/model.Order"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ не в статусе \\\"подтверждён\\",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Заказ изменился с момента чтения",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает заказы потоком в NDJSON (по заказу в строке) или CSV с колонками, которые понимает загрузка.\nФильтры те же, что у списка заказов; покупатель получает только свои заказы",
+                "produces": [
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Выгрузка заказов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ndjson (по умолчанию) или csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус: created, confirmed, delivered, cancelled или номер",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы раньше (RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заказы в выбранном формате",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный формат или неверный фильтр",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Загружает заказы из CSV (с заголовком: id, user_id, address_id, status, created_at, cancel_reason) или NDJSON с теми же полями.\nОбязателен только user_id. Каждая строка проверяется отдельно, ошибки возвращаются с номером строки;\nподходящие заказы вставляются пакетами по batch_size. С dry_run=true ничего не записывается",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Пакетная загрузка заказов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv или ndjson; по умолчанию по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить строки",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер пакета вставки (по умолчанию 500, не больше 1000)",
+                        "name": "batch_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Итог загрузки с ошибками по строкам",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный формат, нет колонки user_id или неверный batch_size",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/ws": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "WebSocket с JSON-сообщениями того же вида, что data в SSE, и сообщениями heartbeat в паузах.\nЗадаётся ровно один из order_id (один заказ, первым приходит order.snapshot) и user_id (все заказы пользователя).\nДля продолжения после переподключения передайте seq последнего сообщения в last_event_id. Сообщения клиента игнорируются",
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "События заказов (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID заказа",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Номер последнего полученного события",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer-токен, если нельзя передать заголовок",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Переход на WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/web.streamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Не задан или задан и order_id, и user_id; неверный last_event_id",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ или пользователь не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис останавливается",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Поток text/event-stream: сначала order.snapshot с текущим состоянием, затем order.confirmed, order.delivered, order.cancelled.\nУ каждого события есть id; при переподключении EventSource присылает Last-Event-ID и получает пропущенные события.\nЕсли они уже вытеснены из истории, вместо них приходит новый order.snapshot. В паузах приходит комментарий-пульс.\nБраузер не может задать заголовок Authorization для EventSource, поэтому токен можно передать в access_token",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "События заказа (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Номер последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "То же, что Last-Event-ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer-токен, если нельзя передать заголовок",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий; data каждого события — это сообщение",
+                        "schema": {
+                            "$ref": "#/definitions/web.streamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный Last-Event-ID",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис останавливается",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Накладывает JSON Merge Patch на профиль: переданные поля заменяются, null удаляет телефон\nили адреса, массив addresses заменяется целиком. С If-Match патч применяется только к этой версии",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Частично обновить профиль пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag профиля, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля профиля",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.updateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновлённый пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия профиля"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON, неизвестное поле или некорректные данные",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "412": {
+                        "description": "Профиль изменился с момента чтения (If-Match)",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "415": {
+                        "description": "Тело не application/merge-patch+json или application/json",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/orders/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Поток text/event-stream с order.created, order.confirmed, order.delivered и order.cancelled по всем заказам пользователя.\nПродолжение по Last-Event-ID как у /api/orders/{id}/events; если пропущенные события уже вытеснены из истории,\nприходит resync — список заказов нужно перечитать",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "События заказов пользователя (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Номер последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "То же, что Last-Event-ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer-токен, если нельзя передать заголовок",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий; data каждого события — это сообщение",
+                        "schema": {
+                            "$ref": "#/definitions/web.streamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный Last-Event-ID",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис останавливается",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает подписки без секретов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Список вебхуков",
+                "responses": {
+                    "200": {
+                        "description": "Подписки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Webhook"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Подписывает URL на события order.created, order.confirmed, order.delivered, order.cancelled.\nКаждое событие уходит POST-запросом с JSON-телом и заголовками X-Webhook-Event, X-Webhook-Delivery,\nX-Webhook-Timestamp и X-Webhook-Signature: sha256=\u003chex HMAC-SHA256 секретом от \"\u003ctimestamp\u003e.\u003cтело\u003e\"\u003e.\nОтвет не 2xx — повтор с удваивающейся паузой; когда попытки кончаются, доставка попадает в недоставленные.\nСекрет возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Создать вебхук",
+                "parameters": [
+                    {
+                        "description": "URL, типы событий и необязательный секрет",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.createWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Подписка с секретом",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON, URL, тип события или слишком короткий секрет",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Доставки, для которых кончились попытки или удалена подписка; их можно отправить повторно",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Недоставленные события",
+                "responses": {
+                    "200": {
+                        "description": "Недоставленные доставки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/deliveries/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Ставит доставку в очередь: первая попытка — при ближайшем проходе очереди, дальше снова полный набор повторов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Повторить доставку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID доставки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Доставка в очереди",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookDelivery"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Доставка или её подписка не найдена",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Событие уже доставлено",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает подписку без секрета",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Вебхук",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Новые события больше не отправляются, ожидающие повтора доставки уходят в недоставленные. Журнал доставок остаётся",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить вебхук",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -499,30 +1405,276 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Пользователь успешно удалён"
+                        "description": "Подписка удалена"
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Доставки подписки в порядке создания со всеми попытками: время, HTTP-код ответа и ошибка",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Журнал доставок вебхука",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, delivered или dead",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный статус",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object"
+                        }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Всегда 200, пока процесс отвечает на запросы; зависимости не проверяются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness",
+                "responses": {
+                    "200": {
+                        "description": "Процесс жив",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
             }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Пингует Postgres или Mongo и Redis; 503, если хоть одна зависимость недоступна или идёт остановка сервиса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness",
+                "responses": {
+                    "200": {
+                        "description": "Сервис готов",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис не готов",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "health.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.DependencyStatus"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Когда выпущен",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Когда перестаёт действовать (nil — бессрочный)",
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "Хеш секретной части ключа",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Публичная часть ключа, по ней ищем запись",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "Когда последний раз использовался",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Для кого выпущен ключ",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "Когда отозван",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Роль вызывающего (warehouse, courier, admin)",
+                    "type": "string"
+                },
+                "rotated_at": {
+                    "description": "Когда последний раз перевыпущен секрет",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Разрешённые действия; пусто — все действия роли",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "Город",
+                    "type": "string"
+                },
+                "country": {
+                    "description": "Страна",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID адреса в профиле пользователя",
+                    "type": "string"
+                },
+                "label": {
+                    "description": "Подпись: \"дом\", \"работа\"",
+                    "type": "string"
+                },
+                "postal_code": {
+                    "description": "Почтовый индекс",
+                    "type": "string"
+                },
+                "street": {
+                    "description": "Улица, дом, квартира",
+                    "type": "string"
+                }
+            }
+        },
         "model.Order": {
             "type": "object",
             "properties": {
+                "cancel_reason": {
+                    "description": "Причина отмены (если заказ отменен)",
+                    "type": "string"
+                },
                 "created_at": {
                     "description": "Когда заказ создан",
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "Когда запись мягко удалена (nil — активна)",
+                    "type": "string"
+                },
                 "id": {
                     "description": "Уникальный номер заказа",
                     "type": "string"
                 },
+                "shipping_address": {
+                    "description": "Копия адреса пользователя на момент заказа: последующие правки профиля её не меняют",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Address"
+                        }
+                    ]
+                },
                 "status": {
                     "description": "Статус заказа (0-3)",
                     "allOf": [
@@ -534,6 +1686,10 @@ const docTemplate = `{
                 "user_id": {
                     "description": "Кто сделал заказ",
                     "type": "string"
+                },
+                "version": {
+                    "description": "Растёт при каждой смене статуса, используется для ETag/If-Match",
+                    "type": "integer"
                 }
             }
         },
@@ -566,6 +1722,29 @@ const docTemplate = `{
         "model.User": {
             "type": "object",
             "properties": {
+                "addresses": {
+                    "description": "Сохранённые адреса доставки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Address"
+                    }
+                },
+                "created_at": {
+                    "description": "Когда пользователь создан",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Когда запись мягко удалена (nil — активна)",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email (уникальный, в нижнем регистре)",
+                    "type": "string"
+                },
+                "erased_at": {
+                    "description": "Когда личные данные обезличены по запросу пользователя",
+                    "type": "string"
+                },
                 "id": {
                     "description": "Уникальный номер пользователя",
                     "type": "string"
@@ -573,33 +1752,272 @@ const docTemplate = `{
                 "name": {
                     "description": "Имя пользователя",
                     "type": "string"
+                },
+                "phone": {
+                    "description": "Телефон в формате +79991234567",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Когда профиль последний раз менялся",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Растёт при каждом изменении, используется для ETag/If-Match",
+                    "type": "integer"
                 }
             }
         },
-        "web.createOrderRequest": {
+        "model.Webhook": {
             "type": "object",
             "properties": {
-                "user_id": {
+                "created_at": {
+                    "description": "Когда создана",
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "Кто создал подписку",
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Типы событий, например order.confirmed",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "Уникальный номер подписки",
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Ключ подписи; в ответах API виден только при создании",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Куда отправлять события",
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookAttempt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Почему попытка не удалась",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "HTTP-код ответа; 0 — ответа не было",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Попытки по порядку",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookAttempt"
+                    }
+                },
+                "created_at": {
+                    "description": "Когда событие поставлено в очередь",
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "description": "Когда подписчик принял событие",
+                    "type": "string"
+                },
+                "event": {
+                    "description": "Тип события",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный номер доставки, уходит подписчику для дедупликации",
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "Когда следующая попытка (только pending)",
+                    "type": "string"
+                },
+                "order_id": {
+                    "description": "Заказ, о котором событие",
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "Тело запроса ровно в том виде, в каком оно подписано",
+                    "type": "object"
+                },
+                "requeued_at": {
+                    "description": "Когда недоставленное событие поставлено на повтор вручную",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, delivered или dead",
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "description": "Подписка",
+                    "type": "string"
+                }
+            }
+        },
+        "service.ImportResult": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "description": "сколько заказов записано (в dry-run — сколько было бы записано)",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "сколько строк прочитано",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line": {
+                    "description": "номер строки во входных данных, в CSV считая заголовок",
+                    "type": "integer"
+                }
+            }
+        },
+        "web.apiKeySecretResponse": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "$ref": "#/definitions/model.APIKey"
+                },
+                "secret": {
                     "type": "string"
                 }
             }
         },
-        "web.createUserRequest": {
+        "web.createAPIKeyRequest": {
             "type": "object",
             "properties": {
+                "expires_in": {
+                    "description": "срок действия, например \"720h\"; пусто — бессрочный",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "role": {
+                    "description": "warehouse, courier или admin",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "например [\"order:read\", \"order:confirm\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "web.createWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "например [\"order.confirmed\", \"order.cancelled\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "ключ подписи не короче 16 символов; пусто — сгенерировать",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "куда отправлять события, http или https",
+                    "type": "string"
+                }
+            }
+        },
+        "web.streamMessage": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "order": {
+                    "description": "заказ после изменения",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Order"
+                        }
+                    ]
+                },
+                "seq": {
+                    "description": "номер события; передаётся в Last-Event-ID при переподключении",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
         "web.updateUserRequest": {
             "type": "object",
             "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Address"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "phone": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "APIKeyAuth": {
+            "description": "API-ключ сервиса (выпускается в /api/admin/keys)",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT в формате \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
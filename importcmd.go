@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// importResult — ответ POST /api/orders/import (service.ImportResult)
+type importResult struct {
+	DryRun   bool `json:"dry_run"`
+	Total    int  `json:"total"`
+	Imported int  `json:"imported"`
+	Failed   int  `json:"failed"`
+	Errors   []struct {
+		Line  int    `json:"line"`
+		ID    string `json:"id"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+// importOptions — флаги import-orders
+type importOptions struct {
+	baseURL   string
+	token     string
+	apiKey    string
+	format    string
+	dryRun    bool
+	batchSize int
+}
+
+// importOrdersCommand — команда `order-ms import-orders [flags] FILE`: отправляет CSV или NDJSON
+// в POST /api/orders/import запущенного сервиса и печатает итог с ошибками по строкам.
+// Код выхода 1, если хоть одна строка не загрузилась, 2 — неверные аргументы
+func importOrdersCommand() *cobra.Command {
+	o := &importOptions{}
+	cmd := &cobra.Command{
+		Use:   "import-orders [flags] FILE",
+		Short: "Import orders from a CSV or NDJSON file into a running service",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCode(runImportOrders(o, args[0], cmd.OutOrStdout(), cmd.ErrOrStderr()))
+		},
+	}
+	cmd.SetFlagErrorFunc(usageError)
+	f := cmd.Flags()
+	f.StringVar(&o.baseURL, "url", "http://localhost:8080", "Base URL of the running service")
+	f.StringVar(&o.token, "token", os.Getenv("ORDER_MS_TOKEN"), "Bearer token with the admin role (default $ORDER_MS_TOKEN)")
+	f.StringVar(&o.apiKey, "api-key", os.Getenv("ORDER_MS_API_KEY"), "API key instead of a bearer token (default $ORDER_MS_API_KEY)")
+	f.StringVar(&o.format, "format", "", "csv or ndjson (default: from the file extension)")
+	f.BoolVar(&o.dryRun, "dry-run", false, "Only validate rows, write nothing")
+	f.IntVar(&o.batchSize, "batch-size", 0, "Orders per insert batch (default: server default)")
+	return cmd
+}
+
+// runImportOrders отправляет файл path и возвращает код выхода команды
+func runImportOrders(o *importOptions, path string, stdout, stderr io.Writer) int {
+	format := o.format
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+		if format == "jsonl" {
+			format = "ndjson"
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer f.Close()
+
+	q := url.Values{"format": {format}}
+	if o.dryRun {
+		q.Set("dry_run", "true")
+	}
+	if o.batchSize > 0 {
+		q.Set("batch_size", strconv.Itoa(o.batchSize))
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(o.baseURL, "/")+"/api/orders/import?"+q.Encode(), f)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if o.apiKey != "" {
+		req.Header.Set("X-API-Key", o.apiKey)
+	} else if o.token != "" {
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(stderr, "import failed:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(stderr, "import failed:", err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(stderr, "import failed: %s: %s\n", resp.Status, strings.TrimSpace(string(body)))
+		return 1
+	}
+
+	var res importResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		fmt.Fprintln(stderr, "import failed: bad response:", err)
+		return 1
+	}
+	for _, e := range res.Errors {
+		if e.ID != "" {
+			fmt.Fprintf(stdout, "line %d (%s): %s\n", e.Line, e.ID, e.Error)
+		} else {
+			fmt.Fprintf(stdout, "line %d: %s\n", e.Line, e.Error)
+		}
+	}
+	verb := "imported"
+	if res.DryRun {
+		verb = "valid (dry run)"
+	}
+	fmt.Fprintf(stdout, "%d rows: %d %s, %d failed\n", res.Total, res.Imported, verb, res.Failed)
+	if res.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"order-ms/internal/ctl"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// тест команды import-orders: параметры запроса, заголовок авторизации, вывод и код выхода
+func TestRunImportOrders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "orders.csv")
+	assert.NoError(t, os.WriteFile(file, []byte("id,user_id\nOld-1,User-1\n"), 0o600))
+
+	tests := []struct {
+		name       string
+		args       []string
+		response   string
+		wantQuery  string
+		wantCode   int
+		wantOutput string
+	}{
+		{
+			name:       "all rows imported",
+			args:       []string{"--token", "t0k", file},
+			response:   `{"dry_run":false,"total":1,"imported":1,"failed":0,"errors":[]}`,
+			wantQuery:  "format=csv",
+			wantCode:   0,
+			wantOutput: "1 rows: 1 imported, 0 failed\n",
+		},
+		{
+			name:       "dry run with row errors",
+			args:       []string{"--token", "t0k", "--dry-run", "--batch-size", "10", file},
+			response:   `{"dry_run":true,"total":1,"imported":0,"failed":1,"errors":[{"line":2,"id":"Old-1","error":"user User-1 not found"}]}`,
+			wantQuery:  "batch_size=10&dry_run=true&format=csv",
+			wantCode:   1,
+			wantOutput: "line 2 (Old-1): user User-1 not found\n1 rows: 0 valid (dry run), 1 failed\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/orders/import", r.URL.Path)
+				assert.Equal(t, tc.wantQuery, r.URL.RawQuery)
+				assert.Equal(t, "Bearer t0k", r.Header.Get("Authorization"))
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, "id,user_id\nOld-1,User-1\n", string(body))
+				_, _ = io.WriteString(w, tc.response)
+			}))
+			defer srv.Close()
+
+			var stdout, stderr bytes.Buffer
+			code := ctl.Run(append([]string{"import-orders", "--url", srv.URL}, tc.args...), &stdout, &stderr, commands()...)
+			assert.Equal(t, tc.wantCode, code, stderr.String())
+			assert.Equal(t, tc.wantOutput, stdout.String())
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, ctl.Run([]string{"import-orders"}, &stdout, &stderr, commands()...))
+	assert.Contains(t, stderr.String(), "Usage:\n  order-ms import-orders [flags] FILE")
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"time"
+)
+
+// auditedRepo — обёртка над service.Repository, которая пишет в журнал аудита каждое успешное
+// изменение заказов и пользователей. Так в журнал попадают вызовы REST, gRPC и фоновых задач,
+// а у заказа складывается история статусов. Чтение и остальные методы проходят без изменений.
+type auditedRepo struct {
+	service.Repository
+	logger *slog.Logger
+}
+
+// InstrumentRepository оборачивает репозиторий журналом аудита; исполнитель берётся из контекста
+func InstrumentRepository(repo service.Repository, logger *slog.Logger) service.Repository {
+	return &auditedRepo{Repository: repo, logger: logger}
+}
+
+// record пишет запись, только если операция прошла и действительно что-то изменила
+func (r *auditedRepo) record(ctx context.Context, ok bool, err error, action service.Action, subject, details string) {
+	if err != nil || !ok {
+		return
+	}
+	service.Audit(ctx, r.Repository, r.logger, action, subject, details)
+}
+
+// детали записи о смене статуса заказа
+func status(s model.OrderStatus) string { return "status=" + s.String() }
+
+func (r *auditedRepo) Save(ctx context.Context, s model.Storable) error {
+	err := r.Repository.Save(ctx, s)
+	switch v := s.(type) {
+	case *model.Order:
+		r.record(ctx, true, err, service.ActionOrderCreate, v.Id, status(v.Status))
+	case *model.User:
+		r.record(ctx, true, err, service.ActionUserCreate, v.Id, "")
+	}
+	return err
+}
+
+func (r *auditedRepo) SaveOrder(ctx context.Context, order *model.Order) error {
+	err := r.Repository.SaveOrder(ctx, order)
+	r.record(ctx, true, err, service.ActionOrderCreate, order.Id, status(order.Status))
+	return err
+}
+
+func (r *auditedRepo) SaveOrders(ctx context.Context, orders []*model.Order) error {
+	err := r.Repository.SaveOrders(ctx, orders)
+	for _, o := range orders {
+		r.record(ctx, true, err, service.ActionOrderImport, o.Id, status(o.Status))
+	}
+	return err
+}
+
+func (r *auditedRepo) DeleteOrder(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.DeleteOrder(ctx, id)
+	r.record(ctx, ok, err, service.ActionOrderDelete, id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) RestoreOrder(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.RestoreOrder(ctx, id)
+	r.record(ctx, ok, err, service.ActionOrderRestore, id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	ok, err := r.Repository.ConfirmOrder(ctx, id, expectedVersion)
+	r.record(ctx, ok, err, service.ActionOrderConfirm, id, status(model.OrderConfirmed))
+	return ok, err
+}
+
+func (r *auditedRepo) DeliverOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	ok, err := r.Repository.DeliverOrder(ctx, id, expectedVersion)
+	r.record(ctx, ok, err, service.ActionOrderDeliver, id, status(model.OrderDelivered))
+	return ok, err
+}
+
+func (r *auditedRepo) CancelOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	ok, err := r.Repository.CancelOrder(ctx, id, expectedVersion)
+	r.record(ctx, ok, err, service.ActionOrderCancel, id, status(model.OrderCancelled))
+	return ok, err
+}
+
+func (r *auditedRepo) ExpireOrder(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.ExpireOrder(ctx, id)
+	r.record(ctx, ok, err, service.ActionOrderCancel, id, status(model.OrderCancelled)+" reason="+model.CancelReasonExpired)
+	return ok, err
+}
+
+func (r *auditedRepo) SaveUser(ctx context.Context, user *model.User) error {
+	err := r.Repository.SaveUser(ctx, user)
+	r.record(ctx, true, err, service.ActionUserCreate, user.Id, "")
+	return err
+}
+
+func (r *auditedRepo) UpdateUser(ctx context.Context, user *model.User, expectedVersion int64) (bool, error) {
+	ok, err := r.Repository.UpdateUser(ctx, user, expectedVersion)
+	r.record(ctx, ok, err, service.ActionUserUpdate, user.Id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) DeleteUser(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.DeleteUser(ctx, id)
+	r.record(ctx, ok, err, service.ActionUserDelete, id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) RestoreUser(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.RestoreUser(ctx, id)
+	r.record(ctx, ok, err, service.ActionUserRestore, id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) EraseUser(ctx context.Context, id string, at time.Time) (bool, error) {
+	ok, err := r.Repository.EraseUser(ctx, id, at)
+	r.record(ctx, ok, err, service.ActionUserErase, id, "")
+	return ok, err
+}
+
+func (r *auditedRepo) SaveWebhook(ctx context.Context, w *model.Webhook) error {
+	err := r.Repository.SaveWebhook(ctx, w)
+	r.record(ctx, true, err, service.ActionWebhookCreate, w.Id, "url="+w.URL)
+	return err
+}
+
+func (r *auditedRepo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	ok, err := r.Repository.DeleteWebhook(ctx, id)
+	r.record(ctx, ok, err, service.ActionWebhookDelete, id, "")
+	return ok, err
+}
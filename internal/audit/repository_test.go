@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-ms/internal/auth"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+	"order-ms/internal/service"
+)
+
+// тест журнала: успешные изменения записываются с исполнителем из контекста, неудачные — нет
+func TestInstrumentRepository(t *testing.T) {
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+
+	tests := []struct {
+		name        string
+		call        func(repo service.Repository, order *model.Order) error
+		wantAction  string
+		wantActor   string
+		wantDetails string
+	}{
+		{
+			name: "confirm by warehouse",
+			call: func(repo service.Repository, order *model.Order) error {
+				_, err := repo.ConfirmOrder(as("w-1", "warehouse"), order.Id, 0)
+				return err
+			},
+			wantAction:  "order:confirm",
+			wantActor:   "w-1",
+			wantDetails: "status=confirmed",
+		},
+		{
+			name: "expire by background job",
+			call: func(repo service.Repository, order *model.Order) error {
+				_, err := repo.ExpireOrder(context.Background(), order.Id)
+				return err
+			},
+			wantAction:  "order:cancel",
+			wantActor:   service.SystemActor,
+			wantDetails: "status=cancelled reason=expired",
+		},
+		{
+			name: "stale version is not recorded",
+			call: func(repo service.Repository, order *model.Order) error {
+				_, err := repo.ConfirmOrder(as("w-1", "warehouse"), order.Id, 5)
+				assert.ErrorIs(t, err, model.ErrVersionConflict)
+				return nil
+			},
+		},
+		{
+			name: "missing order is not recorded",
+			call: func(repo service.Repository, _ *model.Order) error {
+				_, err := repo.DeleteOrder(as("root", "admin"), "Order-missing")
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			base := memory.NewMemoryRepo(logging.Discard())
+			order := model.NewOrder("alice")
+			_ = base.Save(context.Background(), order) // в обход журнала
+			repo := InstrumentRepository(base, logging.Discard())
+
+			assert.NoError(t, tc.call(repo, order))
+
+			entries, err := base.GetAuditEntries(context.Background(), []string{order.Id, "Order-missing"})
+			assert.NoError(t, err)
+			if tc.wantAction == "" {
+				assert.Empty(t, entries)
+				return
+			}
+			if assert.Len(t, entries, 1) {
+				assert.Equal(t, tc.wantAction, entries[0].Action)
+				assert.Equal(t, tc.wantActor, entries[0].Actor)
+				assert.Equal(t, tc.wantDetails, entries[0].Details)
+				assert.Equal(t, order.Id, entries[0].Subject)
+			}
+		})
+	}
+}
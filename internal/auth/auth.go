@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoCredentials = errors.New("missing credentials")
+	ErrInvalidToken  = errors.New("invalid token")
+)
+
+// допустимое расхождение часов между нами и тем, кто выпустил токен
+const clockSkew = 30 * time.Second
+
+// Claims — поля JWT, которые понимает сервис
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Principal — аутентифицированный вызывающий
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scopes  []string // если не пусто, разрешены только эти действия (API-ключи с ограниченной областью)
+	Claims  *Claims  // nil, если вызывающий пришёл не с JWT
+}
+
+// HasRole проверяет, есть ли у вызывающего роль
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладёт вызывающего в контекст запроса
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext достаёт вызывающего из контекста; false — запрос анонимный
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Config — откуда брать ключи для проверки подписи
+type Config struct {
+	HMACSecret []byte // общий секрет для HS256
+	JWKSFile   string // локальный JWKS с публичными RSA-ключами для RS256
+	Issuer     string // если задан, iss должен совпадать
+	Audience   string // если задан, aud должен его содержать
+}
+
+// KeyVerifier проверяет API-ключи (реализация хранит ключи в репозитории)
+type KeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// Authenticator проверяет bearer-токены и API-ключи
+type Authenticator struct {
+	parser    *jwt.Parser
+	secret    []byte
+	keys      *KeySet
+	apiKeys   KeyVerifier
+	certRoles map[string]string // CN сертификата клиента → роль
+}
+
+func NewAuthenticator(cfg Config) (*Authenticator, error) {
+	if len(cfg.HMACSecret) == 0 && cfg.JWKSFile == "" {
+		return nil, errors.New("auth: neither HMAC secret nor JWKS file configured")
+	}
+
+	a := &Authenticator{secret: cfg.HMACSecret}
+	if cfg.JWKSFile != "" {
+		keys, err := LoadKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+	}
+
+	var methods []string
+	if len(a.secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods), // alg из заголовка токена не должен выбирать ключ за нас
+		jwt.WithLeeway(clockSkew),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+// Authenticate проверяет подпись и срок действия токена и возвращает вызывающего
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	claims := &Claims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+	return &Principal{Subject: claims.Subject, Roles: claims.Roles, Claims: claims}, nil
+}
+
+// SetKeyVerifier включает приём API-ключей (заголовок X-API-Key, metadata x-api-key)
+func (a *Authenticator) SetKeyVerifier(v KeyVerifier) {
+	a.apiKeys = v
+}
+
+// AuthenticateAPIKey проверяет API-ключ
+func (a *Authenticator) AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	if a.apiKeys == nil {
+		return nil, fmt.Errorf("%w: api keys are not accepted", ErrInvalidToken)
+	}
+	return a.apiKeys.VerifyAPIKey(ctx, key)
+}
+
+// Credentials проверяет то, что прислал клиент: API-ключ, если он есть, иначе bearer-токен
+func (a *Authenticator) Credentials(ctx context.Context, apiKey, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		return a.AuthenticateAPIKey(ctx, apiKey)
+	}
+	return a.Authenticate(BearerToken(authorization))
+}
+
+// SetClientCertRoles включает вход по сертификату клиента (mTLS) для интеграций без токенов:
+// CN проверенного сертификата → роль, например {"warehouse-gw": "warehouse"}
+func (a *Authenticator) SetClientCertRoles(roles map[string]string) {
+	a.certRoles = roles
+}
+
+// ClientCertificate возвращает вызывающего по цепочкам, которые уже проверил TLS (tls.ConnectionState.VerifiedChains).
+// Subject вызывающего — "cert:" и CN сертификата
+func (a *Authenticator) ClientCertificate(verifiedChains [][]*x509.Certificate) (*Principal, error) {
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	cn := verifiedChains[0][0].Subject.CommonName
+	role, ok := a.certRoles[cn]
+	if !ok {
+		return nil, fmt.Errorf("%w: no role for client certificate %q", ErrInvalidToken, cn)
+	}
+	return &Principal{Subject: "cert:" + cn, Roles: []string{role}}, nil
+}
+
+// ParseCertRoles разбирает соответствие вида "warehouse-gw=warehouse,courier-app=courier" (CN=роль)
+func ParseCertRoles(s string) (map[string]string, error) {
+	roles := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		cn, role, ok := strings.Cut(part, "=")
+		cn, role = strings.TrimSpace(cn), strings.TrimSpace(role)
+		if !ok || cn == "" || role == "" {
+			return nil, fmt.Errorf("client certificate role %q: want CN=role", part)
+		}
+		roles[cn] = role
+	}
+	return roles, nil
+}
+
+// key выбирает ключ проверки по алгоритму и kid
+func (a *Authenticator) key(t *jwt.Token) (any, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.secret, nil
+	case *jwt.SigningMethodRSA:
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+}
+
+// BearerToken вырезает токен из значения заголовка Authorization
+func BearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,122 @@
+package auth_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-ms/internal/auth"
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/certs/certstest"
+)
+
+// тест проверки токенов: HS256 по секрету и RS256 по ключу из JWKS
+func TestAuthenticate(t *testing.T) {
+	rsaKey, jwksFile := authtest.RSAKey(t, "key-1")
+	otherKey, _ := authtest.RSAKey(t, "key-1")
+
+	a, err := auth.NewAuthenticator(auth.Config{HMACSecret: authtest.Secret, JWKSFile: jwksFile, Issuer: "idp"})
+	require.NoError(t, err)
+
+	withIssuer := func(c *auth.Claims) *auth.Claims { c.Issuer = "idp"; return c }
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+		subject string
+		roles   []string
+	}{
+		{
+			name:    "hs256",
+			token:   authtest.Mint(t, jwt.SigningMethodHS256, authtest.Secret, "", withIssuer(authtest.Claims("user-1", time.Hour, "customer"))),
+			subject: "user-1",
+			roles:   []string{"customer"},
+		},
+		{
+			name:    "rs256 from jwks",
+			token:   authtest.Mint(t, jwt.SigningMethodRS256, rsaKey, "key-1", withIssuer(authtest.Claims("courier-7", time.Hour, "courier"))),
+			subject: "courier-7",
+			roles:   []string{"courier"},
+		},
+		{
+			name:    "rs256 signed by unknown key",
+			token:   authtest.Mint(t, jwt.SigningMethodRS256, otherKey, "key-1", withIssuer(authtest.Claims("courier-7", time.Hour))),
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "unknown kid",
+			token:   authtest.Mint(t, jwt.SigningMethodRS256, rsaKey, "key-2", withIssuer(authtest.Claims("courier-7", time.Hour))),
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "wrong issuer",
+			token:   authtest.Mint(t, jwt.SigningMethodHS256, authtest.Secret, "", authtest.Claims("user-1", time.Hour)),
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "expired",
+			token:   authtest.Mint(t, jwt.SigningMethodHS256, authtest.Secret, "", withIssuer(authtest.Claims("user-1", -time.Hour))),
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "alg none",
+			token:   authtest.Mint(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", withIssuer(authtest.Claims("user-1", time.Hour))),
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "no token",
+			wantErr: auth.ErrNoCredentials,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(tc.token)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.subject, p.Subject)
+			assert.Equal(t, tc.roles, p.Roles)
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	assert.Equal(t, "abc", auth.BearerToken("Bearer abc"))
+	assert.Equal(t, "abc", auth.BearerToken("bearer abc"))
+	assert.Equal(t, "", auth.BearerToken("Basic abc"))
+	assert.Equal(t, "", auth.BearerToken("abc"))
+}
+
+// тест входа по сертификату клиента: роль берётся по CN
+func TestClientCertificate(t *testing.T) {
+	roles, err := auth.ParseCertRoles(" warehouse-gw=warehouse, courier-app=courier,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"warehouse-gw": "warehouse", "courier-app": "courier"}, roles)
+	_, err = auth.ParseCertRoles("warehouse-gw")
+	assert.Error(t, err)
+
+	a, err := auth.NewAuthenticator(auth.Config{HMACSecret: authtest.Secret})
+	require.NoError(t, err)
+	a.SetClientCertRoles(roles)
+	ca := certstest.NewCA(t)
+	chain := func(cn string) [][]*x509.Certificate {
+		return [][]*x509.Certificate{{ca.Client(t, cn).Leaf, ca.Cert}}
+	}
+
+	p, err := a.ClientCertificate(chain("warehouse-gw"))
+	require.NoError(t, err)
+	assert.Equal(t, "cert:warehouse-gw", p.Subject)
+	assert.Equal(t, []string{"warehouse"}, p.Roles)
+
+	_, err = a.ClientCertificate(chain("stranger"))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = a.ClientCertificate(nil)
+	assert.ErrorIs(t, err, auth.ErrNoCredentials)
+}
@@ -0,0 +1,88 @@
+// Package authtest выпускает токены для тестов, которые ходят через аутентификацию
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"order-ms/internal/auth"
+)
+
+// Secret — HS256-секрет, который понимает Authenticator из NewHS256
+var Secret = []byte("test-secret")
+
+// NewHS256 создаёт Authenticator с секретом Secret
+func NewHS256(t testing.TB) *auth.Authenticator {
+	t.Helper()
+	a, err := auth.NewAuthenticator(auth.Config{HMACSecret: Secret})
+	if err != nil {
+		t.Fatalf("authtest: %v", err)
+	}
+	return a
+}
+
+// Token выпускает HS256-токен на час для subject с ролями
+func Token(t testing.TB, subject string, roles ...string) string {
+	t.Helper()
+	return Mint(t, jwt.SigningMethodHS256, Secret, "", claims(subject, time.Hour, roles))
+}
+
+// Bearer — значение заголовка Authorization для Token
+func Bearer(t testing.TB, subject string, roles ...string) string {
+	t.Helper()
+	return "Bearer " + Token(t, subject, roles...)
+}
+
+// Mint подписывает произвольные claims; kid попадает в заголовок, если не пустой
+func Mint(t testing.TB, method jwt.SigningMethod, key any, kid string, c jwt.Claims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("authtest: sign: %v", err)
+	}
+	return s
+}
+
+// Claims собирает claims с заданным временем жизни (отрицательное — уже истёкший токен)
+func Claims(subject string, ttl time.Duration, roles ...string) *auth.Claims {
+	return claims(subject, ttl, roles)
+}
+
+func claims(subject string, ttl time.Duration, roles []string) *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Roles: roles,
+	}
+}
+
+// RSAKey генерирует RSA-ключ и пишет его публичную часть в JWKS-файл во временном каталоге теста
+func RSAKey(t testing.TB, kid string) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("authtest: generate key: %v", err)
+	}
+	data, err := auth.MarshalJWKS(map[string]*rsa.PublicKey{kid: &key.PublicKey})
+	if err != nil {
+		t.Fatalf("authtest: jwks: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("authtest: write jwks: %v", err)
+	}
+	return key, path
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// jwk — один ключ из JWKS (RFC 7517); поддерживаются только RSA-ключи
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet — публичные ключи для проверки RS256 по kid
+type KeySet struct {
+	keys map[string]*rsa.PublicKey
+}
+
+// LoadKeySet читает JWKS из файла
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+	return ParseKeySet(data)
+}
+
+// ParseKeySet разбирает JWKS; ключи не RSA и не для подписи пропускаются
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	ks := &KeySet{keys: make(map[string]*rsa.PublicKey)}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: bad modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: bad exponent: %w", k.Kid, err)
+		}
+		ks.keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("jwks: no RSA signing keys")
+	}
+	return ks, nil
+}
+
+// Key возвращает ключ по kid; если kid не указан и ключ один, берётся он
+func (ks *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	if ks == nil {
+		return nil, fmt.Errorf("no JWKS configured")
+	}
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, nil
+		}
+	}
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	return k, nil
+}
+
+// MarshalJWKS сериализует публичные ключи в JWKS (для тестов и выпуска ключей)
+func MarshalJWKS(keys map[string]*rsa.PublicKey) ([]byte, error) {
+	var set jwks
+	for kid, k := range keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	return json.Marshal(set)
+}
@@ -0,0 +1,123 @@
+// Package certs загружает сертификаты TLS для HTTP- и gRPC-серверов и перечитывает их без перезапуска
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// Config — файлы сертификатов в формате PEM
+type Config struct {
+	CertFile     string // сертификат сервера (с промежуточными)
+	KeyFile      string // его ключ
+	ClientCAFile string // CA сертификатов клиентов; если задан, включается mTLS
+	// без сертификата клиента соединение не устанавливается; иначе сертификат необязателен,
+	// и клиенты без него входят по токену или API-ключу
+	RequireClientCert bool
+}
+
+// Reloader отдаёт серверам текущие сертификаты; Reload подменяет их для новых соединений
+type Reloader struct {
+	cfg       Config
+	logger    *slog.Logger
+	cert      atomic.Pointer[tls.Certificate]
+	clientCAs atomic.Pointer[x509.CertPool]
+}
+
+// NewReloader загружает сертификаты; ошибка — если файлы не читаются или ключ не подходит к сертификату
+func NewReloader(cfg Config, logger *slog.Logger) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("certs: both certificate and key files are required")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("certs: client certificates cannot be required without a client CA")
+	}
+	r := &Reloader{cfg: cfg, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает файлы. При ошибке остаются прежние сертификаты
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("certs: load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("certs: read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("certs: no certificates in %s", r.cfg.ClientCAFile)
+		}
+	}
+	r.cert.Store(&cert)
+	r.clientCAs.Store(pool)
+	return nil
+}
+
+// TLSConfig — настройки TLS сервера, которые при каждом рукопожатии берут текущие сертификаты.
+// nextProtos — протоколы ALPN: "h2" для gRPC, "h2" и "http/1.1" для HTTP
+func (r *Reloader) TLSConfig(nextProtos ...string) *tls.Config {
+	template := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.cert.Load(), nil
+		},
+	}
+	if r.cfg.ClientCAFile == "" {
+		return template
+	}
+	clientAuth := tls.VerifyClientCertIfGiven
+	if r.cfg.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	cfg := template.Clone()
+	cfg.ClientAuth = clientAuth
+	cfg.ClientCAs = r.clientCAs.Load()
+	// CA клиентов тоже могут смениться, поэтому настройки собираются заново для каждого соединения
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := template.Clone()
+		c.ClientAuth = clientAuth
+		c.ClientCAs = r.clientCAs.Load()
+		return c, nil
+	}
+	return cfg
+}
+
+// WatchSIGHUP перечитывает сертификаты по SIGHUP, пока не отменён ctx
+func (r *Reloader) WatchSIGHUP(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	r.watch(ctx, ch)
+}
+
+func (r *Reloader) watch(ctx context.Context, ch <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if err := r.Reload(); err != nil {
+				r.logger.Error("tls certificates reload failed, keeping the previous ones", "err", err)
+				continue
+			}
+			r.logger.Info("tls certificates reloaded", "cert", r.cfg.CertFile)
+		}
+	}
+}
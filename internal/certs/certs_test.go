@@ -0,0 +1,98 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"order-ms/internal/certs/certstest"
+	"order-ms/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// current — сертификат, который сервер отдаст новому соединению
+func current(t *testing.T, cfg *tls.Config) *tls.Certificate {
+	t.Helper()
+	cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	return cert
+}
+
+// тест проверки настроек в NewReloader
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := certstest.NewCA(t)
+	certFile, keyFile := certstest.WriteFiles(t, dir, "server", ca.Server(t))
+	_, otherKey := certstest.WriteFiles(t, dir, "other", ca.Server(t))
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"cert and key", Config{CertFile: certFile, KeyFile: keyFile}, false},
+		{"no key", Config{CertFile: certFile}, true},
+		{"key does not match", Config{CertFile: certFile, KeyFile: otherKey}, true},
+		{"missing file", Config{CertFile: filepath.Join(dir, "nope.crt"), KeyFile: keyFile}, true},
+		{"require without CA", Config{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true}, true},
+		{"client CA is not PEM", Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewReloader(tc.cfg, logging.Discard())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// тест перечитывания: новый сертификат подхватывается по сигналу, битые файлы не заменяют рабочий
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := certstest.NewCA(t)
+	first := ca.Server(t)
+	certFile, keyFile := certstest.WriteFiles(t, dir, "server", first)
+	caFile := certstest.WriteCA(t, dir, "ca", ca)
+
+	r, err := NewReloader(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, RequireClientCert: true}, logging.Discard())
+	require.NoError(t, err)
+	cfg := r.TLSConfig("h2")
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.Equal(t, first.Certificate[0], current(t, cfg).Certificate[0])
+
+	// настройки для соединения сохраняют ALPN, иначе gRPC-клиенты не договорятся о h2
+	perConn, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"h2"}, perConn.NextProtos)
+	assert.NotNil(t, perConn.ClientCAs)
+
+	signals := make(chan os.Signal)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { r.watch(ctx, signals); close(done) }()
+
+	second := ca.Server(t)
+	certstest.WriteFiles(t, dir, "server", second)
+	signals <- syscall.SIGHUP
+	assert.Eventually(t, func() bool {
+		return string(current(t, cfg).Certificate[0]) == string(second.Certificate[0])
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP // второй сигнал принят только после обработки первого
+	assert.Equal(t, second.Certificate[0], current(t, cfg).Certificate[0])
+	assert.Error(t, r.Reload())
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,300 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"order-ms/pkg/client"
+	pb "order-ms/pkg/proto"
+
+	"github.com/spf13/cobra"
+)
+
+// Пользователи
+
+func usersCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "users", Short: "List, create and delete users"}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				users, err := c.ListUsers(ctx)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), o.output, messages(users), usersTable(users...))
+			})
+		},
+	}
+
+	var req pb.CreateUserRequest
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				u, err := c.CreateUser(ctx, &req)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), o.output, u, usersTable(u))
+			})
+		},
+	}
+	create.Flags().StringVar(&req.Name, "name", "", "Full name")
+	create.Flags().StringVar(&req.Email, "email", "", "Email")
+	create.Flags().StringVar(&req.Phone, "phone", "", "Phone number")
+	_ = create.MarkFlagRequired("name")
+	_ = create.MarkFlagRequired("email")
+
+	del := &cobra.Command{
+		Use:               "delete ID...",
+		Short:             "Soft-delete users; they can be restored until the retention period ends",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: o.completeUsers,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				for _, id := range args {
+					if err := c.DeleteUser(ctx, id); err != nil {
+						return fmt.Errorf("user %s: %w", id, err)
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "user %s deleted\n", id)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(list, create, del)
+	return cmd
+}
+
+func usersTable(users ...*pb.User) table {
+	t := table{header: []string{"ID", "NAME", "EMAIL", "PHONE", "CREATED"}}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{u.GetId(), u.GetName(), u.GetEmail(), u.GetPhone(), timestamp(u.GetCreatedAt())})
+	}
+	return t
+}
+
+// Заказы
+
+func ordersCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "orders", Short: "Inspect orders and change their status"}
+
+	get := &cobra.Command{
+		Use:               "get ID",
+		Short:             "Show an order",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: o.completeOrders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				order, err := c.GetOrder(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), o.output, order, ordersTable(order))
+			})
+		},
+	}
+
+	var userID, status string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List orders",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			filter := &pb.ListOrdersRequest{UserId: userID}
+			if status != "" {
+				s, err := parseStatus(status)
+				if err != nil {
+					return err
+				}
+				filter.Status = &s
+			}
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				orders, err := c.ListOrders(ctx, filter)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), o.output, messages(orders), ordersTable(orders...))
+			})
+		},
+	}
+	list.Flags().StringVar(&userID, "user", "", "Only orders of this user")
+	list.Flags().StringVar(&status, "status", "", "Only orders in this status: created, confirmed, delivered or cancelled")
+	_ = list.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(statusNames(), cobra.ShellCompDirectiveNoFileComp))
+	_ = list.RegisterFlagCompletionFunc("user", o.completeUsers)
+
+	cmd.AddCommand(get, list,
+		statusCommand(o, "confirm", "Confirm an order (warehouse)", pb.OrderStatus_ORDER_CONFIRMED),
+		statusCommand(o, "deliver", "Mark an order delivered (courier)", pb.OrderStatus_ORDER_DELIVERED),
+		statusCommand(o, "cancel", "Cancel an order", pb.OrderStatus_ORDER_CANCELLED),
+	)
+	return cmd
+}
+
+// statusCommand — смена статуса заказа; выводит заказ после смены
+func statusCommand(o *options, use, short string, to pb.OrderStatus) *cobra.Command {
+	var expectedVersion int64
+	cmd := &cobra.Command{
+		Use:               use + " ID",
+		Short:             short,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: o.completeOrders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				order, err := c.UpdateOrderStatus(ctx, args[0], to, expectedVersion)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), o.output, order, ordersTable(order))
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&expectedVersion, "expected-version", 0, "Change only if the order is still at this version")
+	return cmd
+}
+
+func ordersTable(orders ...*pb.Order) table {
+	t := table{header: []string{"ID", "USER", "STATUS", "VERSION", "CREATED"}}
+	for _, o := range orders {
+		t.rows = append(t.rows, []string{o.GetId(), o.GetUserId(), statusName(o.GetStatus()), strconv.FormatInt(o.GetVersion(), 10), timestamp(o.GetCreatedAt())})
+	}
+	return t
+}
+
+// statusName — статус без префикса ORDER_ в нижнем регистре, как в REST API
+func statusName(s pb.OrderStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "ORDER_"))
+}
+
+func statusNames() []string {
+	names := make([]string, 0, len(pb.OrderStatus_name))
+	for i := range len(pb.OrderStatus_name) {
+		names = append(names, statusName(pb.OrderStatus(i)))
+	}
+	return names
+}
+
+// parseStatus принимает "confirmed" и "ORDER_CONFIRMED"
+func parseStatus(s string) (pb.OrderStatus, error) {
+	v, ok := pb.OrderStatus_value["ORDER_"+strings.TrimPrefix(strings.ToUpper(s), "ORDER_")]
+	if !ok {
+		return 0, fmt.Errorf("unknown order status %q: want one of %s", s, strings.Join(statusNames(), ", "))
+	}
+	return pb.OrderStatus(v), nil
+}
+
+// Доставки
+
+func deliveriesCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "deliveries", Short: "Inspect deliveries"}
+
+	var filter pb.ListDeliveriesRequest
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List deliveries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				deliveries, err := c.ListDeliveries(ctx, &filter)
+				if err != nil {
+					return err
+				}
+				t := table{header: []string{"ID", "ORDER", "USER", "ADDRESS", "STATUS"}}
+				for _, d := range deliveries {
+					t.rows = append(t.rows, []string{strconv.FormatInt(d.GetId(), 10), d.GetOrderId(), d.GetUserId(), d.GetAddress(), strconv.Itoa(int(d.GetStatus()))})
+				}
+				return render(cmd.OutOrStdout(), o.output, messages(deliveries), t)
+			})
+		},
+	}
+	list.Flags().StringVar(&filter.OrderId, "order", "", "Only deliveries of this order")
+	list.Flags().StringVar(&filter.UserId, "user", "", "Only deliveries of this user")
+	_ = list.RegisterFlagCompletionFunc("order", o.completeOrders)
+	_ = list.RegisterFlagCompletionFunc("user", o.completeUsers)
+
+	cmd.AddCommand(list)
+	return cmd
+}
+
+// Сводка
+
+// stats — сводка по данным сервиса
+type stats struct {
+	Users          int            `json:"users"`
+	Orders         int            `json:"orders"`
+	OrdersByStatus map[string]int `json:"orders_by_status"`
+	Deliveries     int            `json:"deliveries"`
+}
+
+func statsCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Count users, orders by status and deliveries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.call(cmd, func(ctx context.Context, c *client.Client) error {
+				users, err := c.ListUsers(ctx)
+				if err != nil {
+					return err
+				}
+				orders, err := c.ListOrders(ctx, nil)
+				if err != nil {
+					return err
+				}
+				deliveries, err := c.ListDeliveries(ctx, nil)
+				if err != nil {
+					return err
+				}
+				s := stats{Users: len(users), Orders: len(orders), OrdersByStatus: map[string]int{}, Deliveries: len(deliveries)}
+				for _, name := range statusNames() {
+					s.OrdersByStatus[name] = 0
+				}
+				for _, order := range orders {
+					s.OrdersByStatus[statusName(order.GetStatus())]++
+				}
+
+				t := table{header: []string{"METRIC", "VALUE"}, rows: [][]string{{"users", strconv.Itoa(s.Users)}, {"orders", strconv.Itoa(s.Orders)}}}
+				for _, name := range statusNames() {
+					t.rows = append(t.rows, []string{"orders " + name, strconv.Itoa(s.OrdersByStatus[name])})
+				}
+				t.rows = append(t.rows, []string{"deliveries", strconv.Itoa(s.Deliveries)})
+				return render(cmd.OutOrStdout(), o.output, s, t)
+			})
+		},
+	}
+}
+
+// Автодополнение ID запрашивает сервис; если он недоступен, просто ничего не предлагает
+
+func (o *options) completeOrders(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var ids []string
+	_ = o.call(cmd, func(ctx context.Context, c *client.Client) error {
+		orders, err := c.ListOrders(ctx, nil)
+		for _, order := range orders {
+			ids = append(ids, order.GetId()+"\t"+order.GetUserId()+", "+statusName(order.GetStatus()))
+		}
+		return err
+	})
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (o *options) completeUsers(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var ids []string
+	_ = o.call(cmd, func(ctx context.Context, c *client.Client) error {
+		users, err := c.ListUsers(ctx)
+		for _, u := range users {
+			ids = append(ids, u.GetId()+"\t"+u.GetName())
+		}
+		return err
+	})
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,172 @@
+// Package ctl — команда `order-ms ctl` для операторов: пользователи, заказы, доставки и сводка
+// через gRPC API запущенного сервиса, с выводом таблицей, JSON или YAML
+package ctl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"order-ms/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// ExitError — ошибка команды с кодом выхода, отличным от 1 (например, 2 — неверные аргументы).
+// Err == nil — команда уже сама напечатала, что случилось
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// Run выполняет команду order-ms с аргументами без имени программы (["ctl", "orders", "list"]).
+// commands добавляются к корню рядом с ctl. Код выхода 1 — ошибка команды или вызова сервиса,
+// у ExitError — её Code
+func Run(args []string, stdout, stderr io.Writer, commands ...*cobra.Command) int {
+	root := NewCommand(stdout, stderr, commands...)
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		var exit *ExitError
+		if !errors.As(err, &exit) {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		if exit.Err != nil {
+			fmt.Fprintln(stderr, "error:", exit.Err)
+		}
+		return exit.Code
+	}
+	return 0
+}
+
+// NewCommand собирает корневую команду order-ms с подкомандами ctl, completion и commands.
+// Скрипты автодополнения (`order-ms completion bash`) вызывают `order-ms __complete`, поэтому
+// корень — сама программа, а не ctl
+func NewCommand(stdout, stderr io.Writer, commands ...*cobra.Command) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "order-ms",
+		Short:         "Order processing service",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	root.AddCommand(ctlCommand())
+	root.AddCommand(commands...)
+	return root
+}
+
+// options — флаги подключения и вывода, общие для всех подкоманд ctl
+type options struct {
+	addr     string
+	token    string
+	apiKey   string
+	tls      bool
+	caFile   string
+	certFile string
+	keyFile  string
+	timeout  time.Duration
+	output   string
+}
+
+func ctlCommand() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "ctl",
+		Short: "Manage users, orders and deliveries of a running service over gRPC",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return checkOutput(o.output)
+		},
+	}
+	f := cmd.PersistentFlags()
+	f.StringVar(&o.addr, "addr", envOr("ORDER_MS_GRPC_ADDR", "localhost:50051"), "gRPC address of the service (default $ORDER_MS_GRPC_ADDR)")
+	f.StringVar(&o.token, "token", os.Getenv("ORDER_MS_TOKEN"), "Bearer token (default $ORDER_MS_TOKEN)")
+	f.StringVar(&o.apiKey, "api-key", os.Getenv("ORDER_MS_API_KEY"), "API key instead of a bearer token (default $ORDER_MS_API_KEY)")
+	f.BoolVar(&o.tls, "tls", false, "Connect over TLS (implied by --tls-ca and --tls-cert)")
+	f.StringVar(&o.caFile, "tls-ca", "", "PEM CA bundle to verify the server (default: system roots)")
+	f.StringVar(&o.certFile, "tls-cert", "", "PEM client certificate for mutual TLS")
+	f.StringVar(&o.keyFile, "tls-key", "", "PEM key for --tls-cert")
+	f.DurationVar(&o.timeout, "timeout", 10*time.Second, "Timeout of each command")
+	f.StringVarP(&o.output, "output", "o", formatTable, "Output format: table, json or yaml")
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp))
+
+	cmd.AddCommand(usersCommand(o), ordersCommand(o), deliveriesCommand(o), statsCommand(o))
+	return cmd
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// client подключается к сервису по флагам
+func (o *options) client() (*client.Client, error) {
+	var opts []client.Option
+	if o.tls || o.caFile != "" || o.certFile != "" {
+		cfg, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+	if o.token != "" {
+		opts = append(opts, client.WithBearerToken(o.token))
+	}
+	if o.apiKey != "" {
+		opts = append(opts, client.WithAPIKey(o.apiKey))
+	}
+	return client.New(o.addr, opts...)
+}
+
+func (o *options) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.caFile != "" {
+		pem, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", o.caFile)
+		}
+	}
+	if o.certFile != "" || o.keyFile != "" {
+		if o.certFile == "" || o.keyFile == "" {
+			return nil, errors.New("--tls-cert and --tls-key go together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// call подключается к сервису и выполняет fn с таймаутом --timeout
+func (o *options) call(cmd *cobra.Command, fn func(ctx context.Context, c *client.Client) error) error {
+	c, err := o.client()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+	defer cancel()
+	return fn(ctx, c)
+}
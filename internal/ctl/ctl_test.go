@@ -0,0 +1,111 @@
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/events"
+	grpcServer "order-ms/internal/grpc"
+	"order-ms/internal/health"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// тест команд ctl против настоящего gRPC-сервера: вывод таблицей, JSON и YAML, ошибки и автодополнение
+func TestCtl(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	authn := authtest.NewHS256(t)
+	srv := grpcServer.NewGrpcServer(repo, events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authn, nil)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	ctx := context.Background()
+	order := model.NewOrder("alice")
+	require.NoError(t, repo.Save(ctx, order))
+	require.NoError(t, repo.Save(ctx, &model.User{Id: "alice", Name: "Alice", Email: "alice@example.com"}))
+
+	// флаги подключения идут сразу за ctl: в запросе автодополнения последним должно быть дополняемое слово
+	run := func(token string, args ...string) (string, string, int) {
+		i := slices.Index(args, "ctl") + 1
+		args = slices.Insert(args, i, "--addr", lis.Addr().String(), "--token", token)
+		var stdout, stderr bytes.Buffer
+		code := Run(args, &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+	admin := authtest.Token(t, "root", "admin")
+
+	out, _, code := run(admin, "ctl", "users", "list")
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `(?m)^ID\s+NAME\s+EMAIL\s+PHONE\s+CREATED$`, out)
+	assert.Regexp(t, `(?m)^alice\s+Alice\s+alice@example.com\s`, out)
+
+	out, stderr, code := run(admin, "ctl", "users", "create", "--name", "Bob", "--email", "bob@example.com", "-o", "json")
+	require.Equal(t, 0, code, stderr)
+	var created map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Equal(t, "Bob", created["name"])
+	assert.NotEmpty(t, created["id"])
+
+	out, _, code = run(authtest.Token(t, "w-1", "warehouse"), "ctl", "orders", "confirm", order.Id, "-o", "yaml")
+	assert.Equal(t, 0, code)
+	var confirmed map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(out), &confirmed))
+	assert.Equal(t, "ORDER_CONFIRMED", confirmed["status"])
+	assert.Equal(t, "alice", confirmed["user_id"])
+
+	out, _, code = run(admin, "ctl", "orders", "list", "--status", "confirmed", "-o", "json")
+	assert.Equal(t, 0, code)
+	var listed []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	if assert.Len(t, listed, 1) {
+		assert.Equal(t, order.Id, listed[0]["id"])
+	}
+
+	out, _, code = run(admin, "ctl", "orders", "cancel", order.Id)
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, order.Id+`\s+alice\s+cancelled\s`, out)
+
+	out, _, code = run(admin, "ctl", "stats", "-o", "json")
+	assert.Equal(t, 0, code)
+	assert.JSONEq(t, `{"users":2,"orders":1,"orders_by_status":{"created":0,"confirmed":0,"delivered":0,"cancelled":1},"deliveries":0}`, out)
+
+	out, _, code = run(admin, "ctl", "users", "delete", created["id"].(string))
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "user "+created["id"].(string)+" deleted\n", out)
+
+	// ошибки сервиса и неверные аргументы — код 1 и сообщение в stderr
+	_, stderr, code = run(admin, "ctl", "orders", "get", "nope")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not found")
+	_, stderr, code = run(authtest.Token(t, "alice", "customer"), "ctl", "orders", "confirm", order.Id)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "permission denied")
+	_, stderr, code = run(admin, "ctl", "orders", "list", "--status", "lost")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `unknown order status "lost"`)
+	_, stderr, code = run(admin, "ctl", "stats", "-o", "xml")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `unknown output format "xml"`)
+
+	// автодополнение ID заказов берёт их у сервиса
+	out, _, code = run(admin, "__complete", "ctl", "orders", "get", "")
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, order.Id+"\talice, cancelled\n"), out)
+	out, _, _ = run(admin, "__complete", "ctl", "orders", "list", "--status", "")
+	assert.Contains(t, out, "confirmed\n")
+}
@@ -0,0 +1,114 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var formats = []string{formatTable, formatJSON, formatYAML}
+
+func checkOutput(format string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q: want table, json or yaml", format)
+}
+
+// table — данные для вывода таблицей; data — для JSON и YAML
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// поля в JSON и YAML — как в REST API: snake_case и нулевые значения, чтобы статус ORDER_CREATED не пропадал
+var marshaler = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// render выводит data в формате format. data — proto.Message, []proto.Message или значение для encoding/json
+func render(w io.Writer, format string, data any, t table) error {
+	if format == formatTable {
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+	v, err := plain(data)
+	if err != nil {
+		return err
+	}
+	if format == formatYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// plain переводит data в map/slice, общие для JSON и YAML; сообщения proto — по правилам protojson
+func plain(data any) (any, error) {
+	switch v := data.(type) {
+	case proto.Message:
+		b, err := marshaler.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var out any
+		return out, json.Unmarshal(b, &out)
+	case []proto.Message:
+		out := make([]any, 0, len(v))
+		for _, m := range v {
+			p, err := plain(m)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p)
+		}
+		return out, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	return out, json.Unmarshal(b, &out)
+}
+
+// messages приводит срез сообщений конкретного типа к []proto.Message
+func messages[T proto.Message](items []T) []proto.Message {
+	out := make([]proto.Message, len(items))
+	for i, m := range items {
+		out[i] = m
+	}
+	return out
+}
+
+// timestamp — время для таблицы; пустое — прочерк
+func timestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().UTC().Format(time.RFC3339)
+}
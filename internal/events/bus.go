@@ -0,0 +1,187 @@
+package events
+
+import (
+	"errors"
+	"order-ms/internal/model"
+	"sync"
+	"time"
+)
+
+// типы событий заказа
+const (
+	TypeOrderCreated   = "order.created"
+	TypeOrderConfirmed = "order.confirmed"
+	TypeOrderDelivered = "order.delivered"
+	TypeOrderCancelled = "order.cancelled"
+	// синтетическое событие с текущим состоянием заказа при подписке; в историю шины не попадает
+	TypeOrderSnapshot = "order.snapshot"
+)
+
+// TypeForStatus — тип события, с которым заказ переходит в статус st
+func TypeForStatus(st model.OrderStatus) string {
+	return "order." + st.String()
+}
+
+// DefaultHistory — сколько последних событий шина хранит для продолжения подписки
+const DefaultHistory = 1024
+
+// сколько событий может ждать в канале подписчика, прежде чем он будет отключён как отстающий
+const subscriberBuffer = 256
+
+var (
+	// ErrEventsLost — событий после запрошенного номера уже нет в истории (или номер из будущего,
+	// например после перезапуска сервиса): нужно перечитать состояние и подписаться заново
+	ErrEventsLost = errors.New("events lost")
+	// ErrSlowSubscriber — подписчик не успевал забирать события и был отключён; можно продолжить с последнего номера
+	ErrSlowSubscriber = errors.New("subscriber too slow")
+	// ErrClosed — шина закрыта при остановке сервиса
+	ErrClosed = errors.New("event bus closed")
+)
+
+// Event — событие жизненного цикла заказа
+type Event struct {
+	Seq   uint64      `json:"seq"`   // сквозной номер события в шине, растёт на единицу
+	Type  string      `json:"type"`  // TypeOrder*
+	At    time.Time   `json:"at"`    // когда опубликовано
+	Order model.Order `json:"order"` // заказ после изменения
+}
+
+// Bus — шина событий заказов внутри процесса. Каждая смена статуса публикуется в неё,
+// а потоковые API (gRPC, SSE, WebSocket, вебхуки) на неё подписываются
+type Bus struct {
+	mu      sync.Mutex
+	seq     uint64
+	history []Event // последние события по возрастанию Seq
+	size    int
+	subs    map[*Subscription]struct{}
+	closed  bool
+}
+
+// NewBus создаёт шину, хранящую history последних событий
+func NewBus(history int) *Bus {
+	return &Bus{size: history, subs: make(map[*Subscription]struct{})}
+}
+
+// Publish присваивает событию номер и рассылает подписчикам. Подписчик с переполненным
+// каналом отключается с ErrSlowSubscriber, чтобы не задерживать остальных
+func (b *Bus) Publish(typ string, o *model.Order) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e := Event{Seq: b.seq, Type: typ, At: time.Now(), Order: *o}
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			copy(b.history, b.history[1:])
+			b.history = b.history[:b.size-1]
+		}
+		b.history = append(b.history, e)
+	}
+	for s := range b.subs {
+		if s.match != nil && !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			b.drop(s, ErrSlowSubscriber)
+		}
+	}
+	return e
+}
+
+// SubscribeBackground — как Subscribe, но Close шины такую подписку не закрывает: её держит фоновый
+// обработчик (вебхуки), которому нужны и события, опубликованные, пока серверы дорабатывают запросы при остановке.
+// Отписывается он сам через Subscription.Close
+func (b *Bus) SubscribeBackground(after uint64, match func(Event) bool) (*Subscription, uint64, error) {
+	return b.subscribe(after, match, true)
+}
+
+// Subscribe подписывает на события, для которых match возвращает true (nil — на все).
+// after > 0 — сначала придут пропущенные события с номерами больше after (ErrEventsLost, если их уже нет);
+// after == 0 — только новые. Возвращает номер последнего опубликованного события на момент подписки
+func (b *Bus) Subscribe(after uint64, match func(Event) bool) (*Subscription, uint64, error) {
+	return b.subscribe(after, match, false)
+}
+
+func (b *Bus) subscribe(after uint64, match func(Event) bool, background bool) (*Subscription, uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, 0, ErrClosed
+	}
+	var replay []Event
+	if after > 0 {
+		oldest := b.seq + 1
+		if len(b.history) > 0 {
+			oldest = b.history[0].Seq
+		}
+		if after > b.seq || after+1 < oldest {
+			return nil, 0, ErrEventsLost
+		}
+		for _, e := range b.history {
+			if e.Seq > after && (match == nil || match(e)) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	s := &Subscription{bus: b, match: match, background: background, ch: make(chan Event, len(replay)+subscriberBuffer)}
+	for _, e := range replay {
+		s.ch <- e
+	}
+	b.subs[s] = struct{}{}
+	return s, b.seq, nil
+}
+
+// Close отключает подписчиков с ErrClosed (кроме фоновых); новые подписки больше не принимаются
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		if !s.background {
+			b.drop(s, ErrClosed)
+		}
+	}
+}
+
+// drop отключает подписчика; вызывается под mu
+func (b *Bus) drop(s *Subscription, err error) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	s.err = err
+	close(s.ch)
+}
+
+// Subscription — подписка на шину; события читаются из Events до закрытия канала
+type Subscription struct {
+	bus   *Bus
+	match func(Event) bool
+	ch    chan Event
+	err   error // почему шина закрыла канал; nil — подписку закрыл сам подписчик
+	// фоновая подписка переживает Close шины
+	background bool
+}
+
+// Events — канал событий; закрывается при отключении подписки
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Err объясняет, почему закрылся канал событий: ErrSlowSubscriber, ErrClosed или nil после Close
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Close отписывается от шины; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.drop(s, nil)
+}
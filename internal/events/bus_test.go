@@ -0,0 +1,256 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+	"order-ms/internal/service"
+)
+
+// drain забирает из подписки всё, что уже в канале
+func drain(sub *Subscription) []Event {
+	var out []Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return out
+			}
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestBusResume(t *testing.T) {
+	bus := NewBus(3)
+	a, b := model.NewOrder("alice"), model.NewOrder("bob")
+
+	live, last, err := bus.Subscribe(0, func(e Event) bool { return e.Order.Id == a.Id })
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), last)
+	defer live.Close()
+
+	bus.Publish(TypeOrderCreated, a) // 1
+	bus.Publish(TypeOrderCreated, b) // 2
+	a.Status = model.OrderConfirmed
+	bus.Publish(TypeForStatus(a.Status), a) // 3
+	a.Status = model.OrderDelivered
+	bus.Publish(TypeForStatus(a.Status), a) // 4, событие 1 вытеснено из истории
+
+	got := drain(live)
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{TypeOrderCreated, TypeOrderConfirmed, TypeOrderDelivered}, []string{got[0].Type, got[1].Type, got[2].Type})
+	assert.Equal(t, model.OrderConfirmed, got[1].Order.Status, "в событии снимок, а не ссылка на заказ")
+
+	tests := []struct {
+		name     string
+		after    uint64
+		expected []uint64
+		err      error
+	}{
+		{"resume replays missed events", 2, []uint64{3, 4}, nil},
+		{"oldest kept event is still available", 1, []uint64{2, 3, 4}, nil},
+		{"up to date", 4, nil, nil},
+		{"zero means new events only", 0, nil, nil},
+		{"seq from the future", 5, nil, ErrEventsLost},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sub, last, err := bus.Subscribe(tc.after, nil)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			defer sub.Close()
+			assert.Equal(t, uint64(4), last)
+			var seqs []uint64
+			for _, e := range drain(sub) {
+				seqs = append(seqs, e.Seq)
+			}
+			assert.Equal(t, tc.expected, seqs)
+		})
+	}
+
+	// история ограничена тремя событиями: после пятого события продолжить с первого уже нельзя
+	bus.Publish(TypeOrderCreated, b) // 5, вытесняет событие 2
+	_, _, err = bus.Subscribe(1, nil)
+	assert.ErrorIs(t, err, ErrEventsLost)
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	bus := NewBus(0)
+	o := model.NewOrder("alice")
+	sub, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		bus.Publish(TypeOrderCreated, o)
+	}
+	assert.Len(t, drain(sub), subscriberBuffer)
+	assert.ErrorIs(t, sub.Err(), ErrSlowSubscriber)
+
+	closed, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+	bus.Close()
+	_, ok := <-closed.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, closed.Err(), ErrClosed)
+	_, _, err = bus.Subscribe(0, nil)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestInstrumentRepository(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus(DefaultHistory)
+	repo := InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	sub, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	o := model.NewOrder("alice")
+	require.NoError(t, repo.Save(ctx, o))
+	ok, err := repo.ConfirmOrder(ctx, o.Id, 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.DeliverOrder(ctx, o.Id, 99) // конфликт версий — события нет
+	assert.Error(t, err)
+	assert.False(t, ok)
+	ok, err = repo.CancelOrder(ctx, o.Id, 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	got := drain(sub)
+	require.Len(t, got, 3)
+	assert.Equal(t, TypeOrderCreated, got[0].Type)
+	assert.Equal(t, TypeOrderConfirmed, got[1].Type)
+	assert.Equal(t, TypeOrderCancelled, got[2].Type)
+	assert.Equal(t, model.OrderCancelled, got[2].Order.Status)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{got[0].Seq, got[1].Seq, got[2].Seq})
+}
+
+// racingRepo сразу после подтверждения отменяет заказ, как параллельный запрос
+type racingRepo struct {
+	service.Repository
+}
+
+func (r racingRepo) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	ok, err := r.Repository.ConfirmOrder(ctx, id, expectedVersion)
+	if ok {
+		_, _ = r.Repository.CancelOrder(ctx, id, 0)
+	}
+	return ok, err
+}
+
+// тест: тип и снимок события берутся из самой смены статуса, а не из заказа, перечитанного после неё
+func TestInstrumentRepositoryFixedTypes(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus(DefaultHistory)
+	mem := memory.NewMemoryRepo(logging.Discard())
+	repo := InstrumentRepository(racingRepo{mem}, bus, logging.Discard())
+	sub, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	o := model.NewOrder("alice")
+	require.NoError(t, mem.Save(ctx, o))
+	ok, err := repo.ConfirmOrder(ctx, o.Id, 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// истекший заказ отменяется с причиной и публикуется один раз
+	expiring := model.NewOrder("bob")
+	require.NoError(t, mem.Save(ctx, expiring))
+	ok, err = repo.ExpireOrder(ctx, expiring.Id)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _ = repo.ExpireOrder(ctx, expiring.Id)
+	assert.False(t, ok)
+
+	got := drain(sub)
+	require.Len(t, got, 2)
+	assert.Equal(t, TypeOrderConfirmed, got[0].Type)
+	assert.Equal(t, model.OrderConfirmed, got[0].Order.Status)
+	assert.Equal(t, int64(2), got[0].Order.Version)
+	assert.Equal(t, TypeOrderCancelled, got[1].Type)
+	assert.Equal(t, model.CancelReasonExpired, got[1].Order.CancelReason)
+	assert.Equal(t, int64(2), got[1].Order.Version)
+}
+
+// staleRepo отдаёт заказ с устаревшей версией, как будто его всё время меняет кто-то другой
+type staleRepo struct {
+	service.Repository
+}
+
+func (r staleRepo) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, err := r.Repository.GetOrderByID(ctx, id)
+	if o == nil || err != nil {
+		return o, err
+	}
+	stale := *o
+	stale.Version--
+	return &stale, nil
+}
+
+// тест: параллельные смены статуса без версии не получают model.ErrVersionConflict,
+// даже если заказ не удаётся застать неизменным, и каждая успешная смена публикуется
+func TestInstrumentRepositoryUnconditionalTransitions(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus(DefaultHistory)
+	mem := memory.NewMemoryRepo(logging.Discard())
+	repo := InstrumentRepository(staleRepo{mem}, bus, logging.Discard())
+	sub, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	o := model.NewOrder("alice")
+	require.NoError(t, mem.Save(ctx, o))
+
+	const workers = 10
+	var wg sync.WaitGroup
+	var confirmed, cancelled atomic.Int32
+	errs := make(chan error, 2*workers)
+	for range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.ConfirmOrder(ctx, o.Id, 0)
+			if ok {
+				confirmed.Add(1)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			ok, err := repo.CancelOrder(ctx, o.Id, 0)
+			if ok {
+				cancelled.Add(1)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.LessOrEqual(t, confirmed.Load(), int32(1))
+	assert.Equal(t, int32(1), cancelled.Load())
+	got := drain(sub)
+	require.Len(t, got, int(confirmed.Load()+cancelled.Load()))
+	for _, e := range got {
+		if e.Type == TypeOrderCancelled {
+			assert.Equal(t, model.OrderCancelled, e.Order.Status)
+		}
+	}
+}
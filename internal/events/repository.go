@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+)
+
+// publishingRepo — обёртка над service.Repository, которая публикует в шину создание заказа
+// и каждую смену его статуса, откуда бы она ни пришла: REST, gRPC или фоновая задача.
+// Пакетная загрузка (SaveOrders) не публикуется: это перенос истории, а не новые заказы.
+type publishingRepo struct {
+	service.Repository
+	bus    *Bus
+	logger *slog.Logger
+}
+
+// InstrumentRepository оборачивает репозиторий публикацией событий заказов в bus
+func InstrumentRepository(repo service.Repository, bus *Bus, logger *slog.Logger) service.Repository {
+	return &publishingRepo{Repository: repo, bus: bus, logger: logger}
+}
+
+// сколько раз повторить смену статуса без заданной версии, если заказ изменился между чтением и записью;
+// последняя попытка идёт без проверки версии
+const transitionAttempts = 5
+
+// transition меняет статус заказа через change с проверкой версии и публикует событие typ со снимком
+// заказа после смены: статус to и версия на единицу больше проверенной. Заказ после смены не перечитывается,
+// поэтому тип и содержимое события не зависят от смен статуса, пришедших следом.
+// Если вызывающий не задал версию, проверяется прочитанная, а при конфликте заказ читается заново;
+// если заказ так и не удалось застать неизменным, смена выполняется без версии, как просил вызывающий,
+// и снимок события читается уже после неё
+func (r *publishingRepo) transition(ctx context.Context, id string, expectedVersion int64, typ string, to model.OrderStatus,
+	change func(ctx context.Context, id string, expectedVersion int64) (bool, error)) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		if expectedVersion == 0 && attempt == transitionAttempts {
+			return r.transitionUnchecked(ctx, id, typ, to, change)
+		}
+		o, err := r.Repository.GetOrderByID(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		if o == nil {
+			return change(ctx, id, expectedVersion) // заказа нет: ответ репозитория, событие не нужно
+		}
+		snapshot := *o // копия до смены: MemoryRepo отдаёт свой указатель
+		version := expectedVersion
+		if version == 0 {
+			version = snapshot.Version
+		}
+		ok, err := change(ctx, id, version)
+		if errors.Is(err, model.ErrVersionConflict) && expectedVersion == 0 {
+			continue
+		}
+		if ok && err == nil {
+			snapshot.Status = to
+			snapshot.Version = version + 1
+			r.bus.Publish(typ, &snapshot)
+		}
+		return ok, err
+	}
+}
+
+// transitionUnchecked меняет статус без проверки версии и публикует заказ, перечитанный после смены
+func (r *publishingRepo) transitionUnchecked(ctx context.Context, id string, typ string, to model.OrderStatus,
+	change func(ctx context.Context, id string, expectedVersion int64) (bool, error)) (bool, error) {
+	ok, err := change(ctx, id, 0)
+	if !ok || err != nil {
+		return ok, err
+	}
+	o, err := r.Repository.GetOrderByID(ctx, id)
+	if err != nil || o == nil {
+		r.logger.WarnContext(ctx, "cannot read order after status change, event skipped", "order_id", id, "err", err)
+		return true, nil
+	}
+	snapshot := *o
+	snapshot.Status = to
+	r.bus.Publish(typ, &snapshot)
+	return true, nil
+}
+
+func (r *publishingRepo) Save(ctx context.Context, s model.Storable) error {
+	err := r.Repository.Save(ctx, s)
+	if o, ok := s.(*model.Order); ok && err == nil {
+		r.bus.Publish(TypeOrderCreated, o)
+	}
+	return err
+}
+
+func (r *publishingRepo) SaveOrder(ctx context.Context, order *model.Order) error {
+	err := r.Repository.SaveOrder(ctx, order)
+	if err == nil {
+		r.bus.Publish(TypeOrderCreated, order)
+	}
+	return err
+}
+
+func (r *publishingRepo) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	return r.transition(ctx, id, expectedVersion, TypeOrderConfirmed, model.OrderConfirmed, r.Repository.ConfirmOrder)
+}
+
+func (r *publishingRepo) DeliverOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	return r.transition(ctx, id, expectedVersion, TypeOrderDelivered, model.OrderDelivered, r.Repository.DeliverOrder)
+}
+
+func (r *publishingRepo) CancelOrder(ctx context.Context, id string, expectedVersion int64) (bool, error) {
+	return r.transition(ctx, id, expectedVersion, TypeOrderCancelled, model.OrderCancelled, r.Repository.CancelOrder)
+}
+
+// ExpireOrder публикует снимок, прочитанный до отмены: репозиторий отменяет заказ, только пока он в статусе
+// "создан", поэтому событие всегда order.cancelled, даже если следом заказ изменится снова
+func (r *publishingRepo) ExpireOrder(ctx context.Context, id string) (bool, error) {
+	o, err := r.Repository.GetOrderByID(ctx, id)
+	if err != nil || o == nil {
+		return false, err
+	}
+	snapshot := *o
+	ok, err := r.Repository.ExpireOrder(ctx, id)
+	if ok && err == nil {
+		snapshot.Status = model.OrderCancelled
+		snapshot.CancelReason = model.CancelReasonExpired
+		snapshot.Version++
+		r.bus.Publish(TypeOrderCancelled, &snapshot)
+	}
+	return ok, err
+}
@@ -0,0 +1,296 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"order-ms/internal/auth"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/service"
+	pb "order-ms/pkg/proto"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ключ metadata, в котором передаётся request ID (аналог заголовка X-Request-ID в HTTP)
+const requestIDMetadataKey = "x-request-id"
+
+// requestIDInterceptor берёт request ID из входящей metadata (или генерирует новый),
+// кладёт его в контекст и возвращает клиенту в заголовке ответа
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := incomingRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id))
+		return handler(logging.WithRequestID(ctx, id), req)
+	}
+}
+
+// requestIDStreamInterceptor — то же для потоковых вызовов
+func requestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := incomingRequestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(requestIDMetadataKey, id))
+		return handler(srv, &serverStream{ServerStream: ss, ctx: logging.WithRequestID(ss.Context(), id)})
+	}
+}
+
+// incomingRequestID — request ID из входящей metadata или новый
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(requestIDMetadataKey); len(vals) > 0 && vals[0] != "" {
+			return vals[0]
+		}
+	}
+	return logging.NewRequestID()
+}
+
+// loggingInterceptor пишет одну запись в лог на каждый вызов
+func loggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, "grpc request", info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+// loggingStreamInterceptor пишет запись в лог, когда поток завершается
+func loggingStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, "grpc stream", info.FullMethod, err, start)
+		return err
+	}
+}
+
+// logCall пишет итог вызова; уровень зависит от кода ответа
+func logCall(ctx context.Context, logger *slog.Logger, msg, method string, err error, start time.Time) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.NotFound, codes.InvalidArgument, codes.FailedPrecondition:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	logger.Log(ctx, level, msg,
+		"method", method,
+		"code", code.String(),
+		"duration", time.Since(start),
+	)
+}
+
+// metricsInterceptor учитывает вызов в метриках Prometheus
+func metricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// metricsStreamInterceptor учитывает поток, когда он завершается; длительность — время жизни потока
+func metricsStreamInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// методы, доступные без токена (проверки здоровья)
+func isPublicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// principalFromMetadata проверяет API-ключ из metadata "x-api-key", bearer-токен из "authorization"
+// или, если нет ни того ни другого, сертификат клиента
+func principalFromMetadata(ctx context.Context, a *auth.Authenticator) (*auth.Principal, error) {
+	var apiKey, authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("x-api-key"); len(vals) > 0 {
+			apiKey = vals[0]
+		}
+		if vals := md.Get("authorization"); len(vals) > 0 {
+			authorization = vals[0]
+		}
+	}
+	p, err := a.Credentials(ctx, apiKey, authorization)
+	if errors.Is(err, auth.ErrNoCredentials) {
+		// без токена и ключа — сертификат клиента, если соединение mTLS
+		if pr, ok := peer.FromContext(ctx); ok {
+			if info, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+				p, err = a.ClientCertificate(info.State.VerifiedChains)
+			}
+		}
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return p, nil
+}
+
+// authInterceptor пропускает только вызовы с валидным токеном и кладёт вызывающего в контекст.
+// a == nil — аутентификация выключена (флаг -insecure-no-auth): вызывающим считается service.AnonymousAdmin
+func authInterceptor(a *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if a == nil {
+			return handler(auth.WithPrincipal(ctx, service.AnonymousAdmin()), req)
+		}
+		p, err := principalFromMetadata(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		return handler(auth.WithPrincipal(ctx, p), req)
+	}
+}
+
+// authStreamInterceptor — то же для потоковых вызовов
+func authStreamInterceptor(a *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if a == nil {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: auth.WithPrincipal(ss.Context(), service.AnonymousAdmin())})
+		}
+		p, err := principalFromMetadata(ss.Context(), a)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: auth.WithPrincipal(ss.Context(), p)})
+	}
+}
+
+// errShuttingDown — причина отмены контекста потока при остановке сервера
+var errShuttingDown = errors.New("server is shutting down")
+
+// shutdownStreamInterceptor отменяет контекст потока, когда отменён streams (в начале GracefulStop),
+// иначе GracefulStop ждал бы, пока клиенты сами закроют бесконечные потоки событий
+func shutdownStreamInterceptor(streams context.Context) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancelCause(ss.Context())
+		defer cancel(nil)
+		stop := context.AfterFunc(streams, func() { cancel(errShuttingDown) })
+		defer stop()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream подменяет контекст потока
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+// rateLimitGroup — группа лимитов для gRPC-метода (те же группы, что у REST-маршрутов)
+func rateLimitGroup(fullMethod string) string {
+	switch {
+	case strings.HasPrefix(fullMethod, "/"+pb.OrderService_ServiceDesc.ServiceName+"/"):
+		return "orders"
+	case strings.HasPrefix(fullMethod, "/"+pb.UserService_ServiceDesc.ServiceName+"/"):
+		return "users"
+	case strings.HasPrefix(fullMethod, "/"+pb.DeliveryService_ServiceDesc.ServiceName+"/"),
+		strings.HasPrefix(fullMethod, "/"+pb.WarehouseService_ServiceDesc.ServiceName+"/"):
+		return "logistics"
+	}
+	return ratelimit.DefaultGroup
+}
+
+// peerIP — IP клиента для анонимных вызовов
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// ipRateLimitInterceptor ограничивает частоту вызовов с одного IP до аутентификации (ratelimit.AuthGroup),
+// чтобы перебор токенов и API-ключей упирался в лимит. Ставится перед authInterceptor. l == nil — без лимитов
+func ipRateLimitInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := allowIP(ctx, l, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ipRateLimitStreamInterceptor — то же для потоковых вызовов
+func ipRateLimitStreamInterceptor(l *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allowIP(ss.Context(), l, info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// rateLimitInterceptor ограничивает частоту вызовов клиента; при превышении — ResourceExhausted
+// и заголовок retry-after в секундах. Ставится после authInterceptor. l == nil — без лимитов
+func rateLimitInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := allow(ctx, l, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreamInterceptor — то же для потоковых вызовов: лимит расходуется при открытии потока
+func rateLimitStreamInterceptor(l *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(ss.Context(), l, info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// allowIP проверяет лимит ratelimit.AuthGroup для IP вызывающего
+func allowIP(ctx context.Context, l *ratelimit.Limiter, method string, setHeader func(metadata.MD) error) error {
+	return take(ctx, l, method, ratelimit.AuthGroup, ratelimit.IPKey(peerIP(ctx)), setHeader)
+}
+
+// allow проверяет лимит вызова method для клиента из контекста
+func allow(ctx context.Context, l *ratelimit.Limiter, method string, setHeader func(metadata.MD) error) error {
+	return take(ctx, l, method, rateLimitGroup(method), ratelimit.ClientKey(ctx, peerIP(ctx)), setHeader)
+}
+
+// take забирает токен группы group из корзины key; при отказе отдаёт retry-after через setHeader
+func take(ctx context.Context, l *ratelimit.Limiter, method, group, key string, setHeader func(metadata.MD) error) error {
+	if l == nil || isPublicMethod(method) {
+		return nil
+	}
+	d := l.Allow(ctx, group, key)
+	if !d.Allowed {
+		_ = setHeader(metadata.Pairs("retry-after", ratelimit.RetryAfterSeconds(d.RetryAfter)))
+		return status.Error(codes.ResourceExhausted, "too many requests")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"order-ms/internal/service"
+	pb "order-ms/pkg/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Delivery service
+
+type DeliveryServer struct {
+	pb.UnimplementedDeliveryServiceServer
+	repo   service.Repository
+	logger *slog.Logger
+}
+
+// Конструктор, возвращающий новый сервер для DeliveryService
+func NewDeliveryServer(repo service.Repository, logger *slog.Logger) pb.DeliveryServiceServer {
+	return &DeliveryServer{repo: repo, logger: logger}
+}
+
+func (s *DeliveryServer) ListDeliveries(ctx context.Context, req *pb.ListDeliveriesRequest) (*pb.ListDeliveriesResponse, error) {
+	if err := authorize(ctx, service.ActionDeliveryList, ""); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.GetDeliveries(ctx)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get deliveries", err)
+	}
+	filter := service.DeliveryFilter{OrderID: req.GetOrderId(), UserID: req.GetUserId()}
+	out := &pb.ListDeliveriesResponse{}
+	for _, d := range filter.Apply(service.VisibleDeliveries(ctx, deliveries)) {
+		out.Deliveries = append(out.Deliveries, toProtoDelivery(d))
+	}
+	return out, nil
+}
+
+func (s *DeliveryServer) GetDelivery(ctx context.Context, req *pb.GetDeliveryRequest) (*pb.Delivery, error) {
+	if req == nil || req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	d, err := service.GetDelivery(ctx, s.repo, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get delivery", err)
+	}
+	if d == nil {
+		return nil, status.Error(codes.NotFound, "delivery not found")
+	}
+	if err := authorize(ctx, service.ActionDeliveryRead, d.UserId); err != nil {
+		return nil, err
+	}
+	return toProtoDelivery(d), nil
+}
+
+// Warehouse service
+
+type WarehouseServer struct {
+	pb.UnimplementedWarehouseServiceServer
+	repo   service.Repository
+	logger *slog.Logger
+}
+
+// Конструктор, возвращающий новый сервер для WarehouseService
+func NewWarehouseServer(repo service.Repository, logger *slog.Logger) pb.WarehouseServiceServer {
+	return &WarehouseServer{repo: repo, logger: logger}
+}
+
+func (s *WarehouseServer) ListWarehouses(ctx context.Context, req *pb.ListWarehousesRequest) (*pb.ListWarehousesResponse, error) {
+	if err := authorize(ctx, service.ActionWarehouseRead, ""); err != nil {
+		return nil, err
+	}
+	warehouses, err := s.repo.GetWarehouses(ctx)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get warehouses", err)
+	}
+	out := &pb.ListWarehousesResponse{}
+	for _, w := range (service.WarehouseFilter{OrderID: req.GetOrderId()}).Apply(warehouses) {
+		out.Warehouses = append(out.Warehouses, toProtoWarehouse(w))
+	}
+	return out, nil
+}
+
+func (s *WarehouseServer) GetWarehouse(ctx context.Context, req *pb.GetWarehouseRequest) (*pb.Warehouse, error) {
+	if req == nil || req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := authorize(ctx, service.ActionWarehouseRead, ""); err != nil {
+		return nil, err
+	}
+	w, err := service.GetWarehouse(ctx, s.repo, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get warehouse", err)
+	}
+	if w == nil {
+		return nil, status.Error(codes.NotFound, "warehouse not found")
+	}
+	return toProtoWarehouse(w), nil
+}
@@ -2,24 +2,81 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"google.golang.org/grpc"
+	"log/slog"
+	"time"
 
+	"order-ms/internal/auth"
+	"order-ms/internal/events"
+	"order-ms/internal/health"
+	"order-ms/internal/metrics"
 	"order-ms/internal/model"
-	"order-ms/internal/repository"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/service"
 	pb "order-ms/pkg/proto"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// NewGrpcServer создаёт gRPC сервер и регистрирует на нём User и Order сервисы
-func NewGrpcServer() *grpc.Server {
-	s := grpc.NewServer()
+// Server — gRPC сервер сервиса заказов; потоки событий завершаются в начале GracefulStop
+type Server struct {
+	*grpc.Server
+	stopStreams context.CancelFunc
+}
+
+// NewGrpcServer создаёт gRPC сервер и регистрирует на нём User, Order, Delivery и Warehouse сервисы, а также grpc.health.v1.
+// opts добавляются к стандартным опциям, например grpc.Creds для TLS
+func NewGrpcServer(repo service.Repository, bus *events.Bus, logger *slog.Logger, m *metrics.Metrics, checker *health.Checker, authn *auth.Authenticator, limiter *ratelimit.Limiter, opts ...grpc.ServerOption) *Server {
+	streams, stopStreams := context.WithCancel(context.Background())
+	s := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()), // спаны OpenTelemetry с контекстом из входящей metadata
+		grpc.ChainUnaryInterceptor(
+			requestIDInterceptor(),
+			loggingInterceptor(logger),
+			metricsInterceptor(m),
+			ipRateLimitInterceptor(limiter),
+			authInterceptor(authn),
+			rateLimitInterceptor(limiter),
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDStreamInterceptor(),
+			loggingStreamInterceptor(logger),
+			metricsStreamInterceptor(m),
+			ipRateLimitStreamInterceptor(limiter),
+			authStreamInterceptor(authn),
+			rateLimitStreamInterceptor(limiter),
+			shutdownStreamInterceptor(streams),
+		),
+	}, opts...)...)
+
+	pb.RegisterUserServiceServer(s, NewUserServer(repo, logger))
+	pb.RegisterOrderServiceServer(s, NewOrderServer(repo, bus, logger))
+	pb.RegisterDeliveryServiceServer(s, NewDeliveryServer(repo, logger))
+	pb.RegisterWarehouseServiceServer(s, NewWarehouseServer(repo, logger))
+	checker.RegisterGRPC(s)
+	return &Server{Server: s, stopStreams: stopStreams}
+}
 
-	pb.RegisterUserServiceServer(s, NewUserServer())
-	pb.RegisterOrderServiceServer(s, NewOrderServer())
-	return s
+// GracefulStop завершает потоки WatchOrder и WatchOrders и дожидается завершения текущих вызовов;
+// если ctx истёк раньше, рвёт соединения
+func GracefulStop(ctx context.Context, s *Server) {
+	s.stopStreams()
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+	}
 }
 
 // Helpers: функции, которые переводят внутренние модели пользователя и заказа (model.User/Order) в protobuf-сообщение (pb.User/Order), которое отправляется по gRPC
@@ -27,21 +84,146 @@ func toProtoUser(u *model.User) *pb.User {
 	if u == nil {
 		return nil
 	}
-	return &pb.User{
-		Id:   u.Id,
-		Name: u.Name,
+	out := &pb.User{
+		Id:        u.Id,
+		Name:      u.Name,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+		Version:   u.Version,
+	}
+	for _, a := range u.Addresses {
+		out.Addresses = append(out.Addresses, toProtoAddress(&a))
+	}
+	if u.ErasedAt != nil {
+		out.ErasedAt = timestamppb.New(*u.ErasedAt)
+	}
+	return out
+}
+
+func toProtoAddress(a *model.Address) *pb.Address {
+	if a == nil {
+		return nil
+	}
+	return &pb.Address{
+		Id:         a.Id,
+		Label:      a.Label,
+		Country:    a.Country,
+		City:       a.City,
+		Street:     a.Street,
+		PostalCode: a.PostalCode,
 	}
 }
 
+func fromProtoAddresses(in []*pb.Address) []model.Address {
+	var out []model.Address
+	for _, a := range in {
+		out = append(out, model.Address{
+			Id:         a.GetId(),
+			Label:      a.GetLabel(),
+			Country:    a.GetCountry(),
+			City:       a.GetCity(),
+			Street:     a.GetStreet(),
+			PostalCode: a.GetPostalCode(),
+		})
+	}
+	return out
+}
+
 func toProtoOrder(o *model.Order) *pb.Order {
 	if o == nil {
 		return nil
 	}
-	return &pb.Order{
-		Id:     o.Id,
-		UserId: o.UserID,
-		Status: pb.OrderStatus(int32(o.Status)),
+	out := &pb.Order{
+		Id:              o.Id,
+		UserId:          o.UserID,
+		Status:          pb.OrderStatus(int32(o.Status)),
+		ShippingAddress: toProtoAddress(o.ShippingAddress),
+		Version:         o.Version,
+		CreatedAt:       timestamppb.New(o.CreatedAt),
+		CancelReason:    o.CancelReason,
+	}
+	if o.DeletedAt != nil {
+		out.DeletedAt = timestamppb.New(*o.DeletedAt)
 	}
+	return out
+}
+
+func toProtoDelivery(d *model.Delivery) *pb.Delivery {
+	if d == nil {
+		return nil
+	}
+	return &pb.Delivery{
+		Id:      d.Id,
+		OrderId: d.OrderId,
+		UserId:  d.UserId,
+		Address: d.Address,
+		Status:  int32(d.Status),
+	}
+}
+
+func toProtoWarehouse(w *model.Warehouse) *pb.Warehouse {
+	if w == nil {
+		return nil
+	}
+	return &pb.Warehouse{
+		Id:      w.Id,
+		OrderId: w.OrderId,
+		Status:  int32(w.Status),
+	}
+}
+
+func toProtoExport(e *service.UserExport) *pb.UserExport {
+	out := &pb.UserExport{
+		ExportedAt: timestamppb.New(e.ExportedAt),
+		User:       toProtoUser(e.User),
+	}
+	for _, o := range e.Orders {
+		out.Orders = append(out.Orders, toProtoOrder(o))
+	}
+	for _, d := range e.Deliveries {
+		out.Deliveries = append(out.Deliveries, toProtoDelivery(d))
+	}
+	for _, a := range e.History {
+		out.History = append(out.History, &pb.AuditEntry{
+			Id:      a.Id,
+			At:      timestamppb.New(a.At),
+			Actor:   a.Actor,
+			Action:  a.Action,
+			Subject: a.Subject,
+			Details: a.Details,
+		})
+	}
+	return out
+}
+
+// userError переводит ошибку проверки или сохранения профиля в статус gRPC
+func userError(ctx context.Context, logger *slog.Logger, msg string, err error) error {
+	var verr *model.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return status.Error(codes.InvalidArgument, verr.Error())
+	case errors.Is(err, model.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, model.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return internalError(ctx, logger, msg, err)
+}
+
+// internalError логирует ошибку репозитория и возвращает клиенту codes.Internal без подробностей
+func internalError(ctx context.Context, logger *slog.Logger, msg string, err error) error {
+	logger.ErrorContext(ctx, msg, "err", err)
+	return status.Error(codes.Internal, msg)
+}
+
+// authorize проверяет права вызывающего по политике service и возвращает codes.PermissionDenied при отказе
+func authorize(ctx context.Context, action service.Action, owner string) error {
+	if err := service.Authorize(ctx, action, owner); err != nil {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return nil
 }
 
 // User service:
@@ -50,21 +232,33 @@ func toProtoOrder(o *model.Order) *pb.Order {
 // встраивается UnimplementedUserServiceServer, чтобы не реализовывать все методы сразу
 type UserServer struct {
 	pb.UnimplementedUserServiceServer
+	repo   service.Repository
+	logger *slog.Logger
 }
 
 // Конструктор, возвращающий новый сервер для UserService
-func NewUserServer() pb.UserServiceServer {
-	return &UserServer{}
+func NewUserServer(repo service.Repository, logger *slog.Logger) pb.UserServiceServer {
+	return &UserServer{repo: repo, logger: logger}
 }
 
 // Методы UserServer
 
 func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	if req == nil || req.GetName() == "" {
+	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "name is required")
 	}
+	if err := authorize(ctx, service.ActionUserCreate, ""); err != nil {
+		return nil, err
+	}
 	u := model.NewUser(req.GetName())
-	repository.SaveStorable(u)
+	u.Email, u.Phone, u.Addresses = req.GetEmail(), req.GetPhone(), fromProtoAddresses(req.GetAddresses())
+	u.Normalize()
+	if err := u.Validate(); err != nil {
+		return nil, userError(ctx, s.logger, "invalid user", err)
+	}
+	if err := s.repo.Save(ctx, u); err != nil {
+		return nil, userError(ctx, s.logger, "cannot save user", err)
+	}
 	return &pb.CreateUserResponse{User: toProtoUser(u)}, nil
 }
 
@@ -72,7 +266,13 @@ func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.U
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	u := repository.GetUserByID(req.GetId())
+	if err := authorize(ctx, service.ActionUserRead, req.GetId()); err != nil {
+		return nil, err
+	}
+	u, err := s.repo.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get user", err)
+	}
 	if u == nil {
 		return nil, status.Error(codes.NotFound, "user not found")
 	}
@@ -80,7 +280,13 @@ func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.U
 }
 
 func (s *UserServer) ListUsers(ctx context.Context, _ *emptypb.Empty) (*pb.ListUsersResponse, error) {
-	users := repository.GetUsers()
+	if err := authorize(ctx, service.ActionUserList, ""); err != nil {
+		return nil, err
+	}
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get users", err)
+	}
 	out := &pb.ListUsersResponse{}
 	for _, u := range users {
 		out.Users = append(out.Users, toProtoUser(u))
@@ -89,14 +295,43 @@ func (s *UserServer) ListUsers(ctx context.Context, _ *emptypb.Empty) (*pb.ListU
 }
 
 func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
-	if req == nil || req.GetId() == "" || req.GetName() == "" {
-		return nil, status.Error(codes.InvalidArgument, "id and name required")
+	if req == nil || req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := authorize(ctx, service.ActionUserUpdate, req.GetId()); err != nil {
+		return nil, err
+	}
+	updated := &model.User{
+		Id:        req.GetId(),
+		Name:      req.GetName(),
+		Email:     req.GetEmail(),
+		Phone:     req.GetPhone(),
+		Addresses: fromProtoAddresses(req.GetAddresses()),
+	}
+	updated.Normalize()
+	if err := updated.Validate(); err != nil {
+		return nil, userError(ctx, s.logger, "invalid user", err)
+	}
+	current, err := s.repo.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get user", err)
+	}
+	if current == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	updated.CreatedAt, updated.UpdatedAt = current.CreatedAt, time.Now()
+	expected := req.GetExpectedVersion()
+	if expected == 0 {
+		expected = current.Version // защищаемся хотя бы от изменений между чтением и записью
+	}
+
+	ok, err := s.repo.UpdateUser(ctx, updated, expected)
+	if err != nil {
+		return nil, userError(ctx, s.logger, "cannot update user", err)
 	}
-	ok := repository.UpdateUserName(req.GetId(), req.GetName())
 	if !ok {
 		return nil, status.Error(codes.NotFound, "user not found")
 	}
-	updated := repository.GetUserByID(req.GetId())
 	return toProtoUser(updated), nil
 }
 
@@ -104,22 +339,67 @@ func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest)
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	ok := repository.DeleteUser(req.GetId())
+	if err := authorize(ctx, service.ActionUserDelete, req.GetId()); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.DeleteUser(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot delete user", err)
+	}
 	if !ok {
 		return nil, status.Error(codes.NotFound, "user not found")
 	}
 	return &emptypb.Empty{}, nil
 }
 
+// ExportUser выгружает все данные пользователя: профиль, заказы, доставки и историю из журнала аудита
+func (s *UserServer) ExportUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserExport, error) {
+	if req == nil || req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := authorize(ctx, service.ActionUserExport, req.GetId()); err != nil {
+		return nil, err
+	}
+	export, err := service.ExportUser(ctx, s.repo, s.logger, req.GetId())
+	if errors.Is(err, service.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot export user", err)
+	}
+	return toProtoExport(export), nil
+}
+
+// EraseUser обезличивает пользователя; заказы остаются для учёта
+func (s *UserServer) EraseUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if req == nil || req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := authorize(ctx, service.ActionUserErase, req.GetId()); err != nil {
+		return nil, err
+	}
+	u, err := service.EraseUser(ctx, s.repo, req.GetId())
+	if errors.Is(err, service.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot erase user", err)
+	}
+	return toProtoUser(u), nil
+}
+
 // Order service
 
 type OrderServer struct {
 	pb.UnimplementedOrderServiceServer
+	repo   service.Repository
+	bus    *events.Bus // откуда WatchOrder/WatchOrders берут смены статусов
+	logger *slog.Logger
 }
 
 // Конструктор, возвращающий новый сервер для OrderService
-func NewOrderServer() pb.OrderServiceServer {
-	return &OrderServer{}
+func NewOrderServer(repo service.Repository, bus *events.Bus, logger *slog.Logger) pb.OrderServiceServer {
+	return &OrderServer{repo: repo, bus: bus, logger: logger}
 }
 
 // Методы OrderServer
@@ -128,15 +408,34 @@ func (s *OrderServer) CreateOrder(ctx context.Context, req *pb.CreateOrderReques
 	if req == nil || req.GetUserId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "user_id is required")
 	}
+	if err := authorize(ctx, service.ActionOrderCreate, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	address, err := service.ShippingAddress(ctx, s.repo, req.GetUserId(), req.GetAddressId())
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAddressNotFound):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, internalError(ctx, s.logger, "cannot get shipping address", err)
+	}
 	o := model.NewOrder(req.GetUserId())
-	repository.SaveStorable(o)
+	o.ShippingAddress = address
+	if err := s.repo.Save(ctx, o); err != nil {
+		return nil, internalError(ctx, s.logger, "cannot save order", err)
+	}
 	return &pb.CreateOrderResponse{Order: toProtoOrder(o)}, nil
 }
 
-func (s *OrderServer) ListOrders(ctx context.Context, _ *emptypb.Empty) (*pb.ListOrdersResponse, error) {
-	ords := repository.GetOrders()
+func (s *OrderServer) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	if err := authorize(ctx, service.ActionOrderList, ""); err != nil {
+		return nil, err
+	}
+	ords, err := s.repo.GetOrders(ctx)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get orders", err)
+	}
 	out := &pb.ListOrdersResponse{}
-	for _, o := range ords {
+	for _, o := range orderFilter(req).Apply(service.VisibleOrders(ctx, ords)) {
 		out.Orders = append(out.Orders, toProtoOrder(o))
 	}
 	return out, nil
@@ -146,10 +445,16 @@ func (s *OrderServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*p
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	o := repository.GetOrderByID(req.GetId())
+	o, err := s.repo.GetOrderByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get order", err)
+	}
 	if o == nil {
 		return nil, status.Error(codes.NotFound, "order not found")
 	}
+	if err := authorize(ctx, service.ActionOrderRead, o.UserID); err != nil {
+		return nil, err
+	}
 	return toProtoOrder(o), nil
 }
 
@@ -157,52 +462,117 @@ func (s *OrderServer) DeleteOrder(ctx context.Context, req *pb.DeleteOrderReques
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	ok := repository.DeleteOrder(req.GetId())
+	if _, err := s.authorizeOrder(ctx, service.ActionOrderDelete, req.GetId()); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.DeleteOrder(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot delete order", err)
+	}
 	if !ok {
 		return nil, status.Error(codes.NotFound, "order not found")
 	}
 	return &emptypb.Empty{}, nil
 }
 
-func (s *OrderServer) ConfirmOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
-	if req == nil || req.GetId() == "" {
-		return nil, status.Error(codes.InvalidArgument, "id is required")
+// authorizeOrder загружает заказ, чтобы узнать его владельца, и проверяет права вызывающего
+func (s *OrderServer) authorizeOrder(ctx context.Context, action service.Action, id string) (*model.Order, error) {
+	o, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get order", err)
 	}
-	o := repository.GetOrderByID(req.GetId())
 	if o == nil {
 		return nil, status.Error(codes.NotFound, "order not found")
 	}
-	ok := repository.ConfirmOrder(req.GetId())
+	if err := authorize(ctx, action, o.UserID); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// orderFilter переводит фильтры запроса списка в service.OrderFilter
+func orderFilter(req *pb.ListOrdersRequest) service.OrderFilter {
+	f := service.OrderFilter{UserID: req.GetUserId()}
+	if req != nil && req.Status != nil {
+		st := model.OrderStatus(req.GetStatus())
+		f.Status = &st
+	}
+	if req.GetCreatedAfter() != nil {
+		f.CreatedAfter = req.GetCreatedAfter().AsTime()
+	}
+	if req.GetCreatedBefore() != nil {
+		f.CreatedBefore = req.GetCreatedBefore().AsTime()
+	}
+	return f
+}
+
+// changeStatus — общая логика Confirm/Deliver: проверяем, что заказ есть и действие разрешено,
+// меняем статус (с проверкой версии, если она задана) и возвращаем обновлённый заказ
+func (s *OrderServer) changeStatus(ctx context.Context, req *pb.OrderActionRequest, action service.Action, change func(context.Context, string, int64) (bool, error), failMsg string) (*pb.Order, error) {
+	id := req.GetId()
+	if _, err := s.authorizeOrder(ctx, action, id); err != nil {
+		return nil, err
+	}
+	ok, err := change(ctx, id, req.GetExpectedVersion())
+	if errors.Is(err, model.ErrVersionConflict) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(ctx, s.logger, failMsg, err)
+	}
 	if !ok {
-		return nil, status.Error(codes.FailedPrecondition, "cannot confirm order")
+		return nil, status.Error(codes.FailedPrecondition, failMsg)
+	}
+	updated, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot get order", err)
 	}
-	updated := repository.GetOrderByID(req.GetId())
 	return toProtoOrder(updated), nil
 }
 
-func (s *OrderServer) DeliverOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+func (s *OrderServer) ConfirmOrder(ctx context.Context, req *pb.OrderActionRequest) (*pb.Order, error) {
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	o := repository.GetOrderByID(req.GetId())
-	if o == nil {
-		return nil, status.Error(codes.NotFound, "order not found")
-	}
-	ok := repository.DeliveredOrder(req.GetId())
-	if !ok {
-		return nil, status.Error(codes.FailedPrecondition, "cannot deliver order")
+	return s.changeStatus(ctx, req, service.ActionOrderConfirm, s.repo.ConfirmOrder, "cannot confirm order")
+}
+
+func (s *OrderServer) DeliverOrder(ctx context.Context, req *pb.OrderActionRequest) (*pb.Order, error) {
+	if req == nil || req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	updated := repository.GetOrderByID(req.GetId())
-	return toProtoOrder(updated), nil
+	return s.changeStatus(ctx, req, service.ActionOrderDeliver, s.repo.DeliverOrder, "cannot deliver order")
 }
 
-func (s *OrderServer) CancelOrder(ctx context.Context, req *pb.GetOrderRequest) (*emptypb.Empty, error) {
+func (s *OrderServer) CancelOrder(ctx context.Context, req *pb.OrderActionRequest) (*emptypb.Empty, error) {
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
-	ok := repository.CancelOrder(req.GetId())
+	if _, err := s.authorizeOrder(ctx, service.ActionOrderCancel, req.GetId()); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.CancelOrder(ctx, req.GetId(), req.GetExpectedVersion())
+	if errors.Is(err, model.ErrVersionConflict) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(ctx, s.logger, "cannot cancel order", err)
+	}
 	if !ok {
 		return nil, status.Error(codes.FailedPrecondition, "cannot cancel order")
 	}
 	return &emptypb.Empty{}, nil
 }
+
+// UpdateOrderStatus переводит заказ в запрошенный статус теми же правилами и с теми же правами,
+// что ConfirmOrder, DeliverOrder и CancelOrder
+func (s *OrderServer) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.Order, error) {
+	if req == nil || req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	action, change, err := service.StatusChange(s.repo, model.OrderStatus(req.GetStatus()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.changeStatus(ctx, &pb.OrderActionRequest{Id: req.GetId(), ExpectedVersion: req.GetExpectedVersion()}, action, change, "cannot change order status")
+}
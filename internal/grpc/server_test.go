@@ -0,0 +1,447 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"order-ms/internal/auth"
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/events"
+	"order-ms/internal/health"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/model"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/repository/memory"
+	pb "order-ms/pkg/proto"
+)
+
+// тест ролей в gRPC: те же правила, что и в REST, отказ — PermissionDenied
+func TestOrderServerAuthorization(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	srv := NewOrderServer(repo, events.NewBus(events.DefaultHistory), logging.Discard())
+
+	own := model.NewOrder("alice")
+	foreign := model.NewOrder("bob")
+	_ = repo.Save(context.Background(), own)
+	_ = repo.Save(context.Background(), foreign)
+
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected codes.Code
+	}{
+		{"customer reads own order", func() error {
+			_, err := srv.GetOrder(as("alice", "customer"), &pb.GetOrderRequest{Id: own.Id})
+			return err
+		}, codes.OK},
+		{"customer cannot read foreign order", func() error {
+			_, err := srv.GetOrder(as("alice", "customer"), &pb.GetOrderRequest{Id: foreign.Id})
+			return err
+		}, codes.PermissionDenied},
+		{"customer cannot confirm", func() error {
+			_, err := srv.ConfirmOrder(as("alice", "customer"), &pb.OrderActionRequest{Id: own.Id})
+			return err
+		}, codes.PermissionDenied},
+		{"warehouse confirms", func() error {
+			_, err := srv.ConfirmOrder(as("w-1", "warehouse"), &pb.OrderActionRequest{Id: own.Id})
+			return err
+		}, codes.OK},
+		{"courier delivers", func() error {
+			_, err := srv.DeliverOrder(as("c-1", "courier"), &pb.OrderActionRequest{Id: own.Id})
+			return err
+		}, codes.OK},
+		{"customer cannot cancel foreign order", func() error {
+			_, err := srv.CancelOrder(as("alice", "customer"), &pb.OrderActionRequest{Id: foreign.Id})
+			return err
+		}, codes.PermissionDenied},
+		{"admin deletes", func() error {
+			_, err := srv.DeleteOrder(as("root", "admin"), &pb.DeleteOrderRequest{Id: foreign.Id})
+			return err
+		}, codes.OK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, status.Code(tc.call()))
+		})
+	}
+
+	// в списке покупатель видит только свои заказы
+	list, err := srv.ListOrders(as("alice", "customer"), nil)
+	assert.NoError(t, err)
+	for _, o := range list.GetOrders() {
+		assert.Equal(t, "alice", o.GetUserId())
+	}
+}
+
+// тест expected_version: устаревшая версия — Aborted, актуальная — изменение и новая версия в ответе
+func TestExpectedVersion(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	users := NewUserServer(repo, logging.Discard())
+	orders := NewOrderServer(repo, events.NewBus(events.DefaultHistory), logging.Discard())
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "root", Roles: []string{"admin"}})
+
+	created, err := users.CreateUser(ctx, &pb.CreateUserRequest{Name: "Оля", Email: "olya@example.com"})
+	assert.NoError(t, err)
+	id := created.GetUser().GetId()
+	assert.Equal(t, int64(1), created.GetUser().GetVersion())
+
+	updated, err := users.UpdateUser(ctx, &pb.UpdateUserRequest{Id: id, Name: "Ольга", Email: "olya@example.com", ExpectedVersion: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.GetVersion())
+
+	_, err = users.UpdateUser(ctx, &pb.UpdateUserRequest{Id: id, Name: "Оля", Email: "olya@example.com", ExpectedVersion: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	order := model.NewOrder(id)
+	_ = repo.Save(ctx, order)
+	_, err = orders.ConfirmOrder(ctx, &pb.OrderActionRequest{Id: order.Id, ExpectedVersion: 2})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	confirmed, err := orders.ConfirmOrder(ctx, &pb.OrderActionRequest{Id: order.Id, ExpectedVersion: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), confirmed.GetVersion())
+}
+
+// тест выгрузки и обезличивания: покупатель выгружает свои данные, обезличивает только admin
+// тест порядка проверок: права проверяются до содержимого запроса, как в REST
+func TestUserServerAuthorizeBeforeValidate(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	users := NewUserServer(repo, logging.Discard())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+	user := model.NewUser("Оля")
+	_ = repo.Save(context.Background(), user)
+	invalidEmail := "not-an-email"
+
+	_, err := users.CreateUser(as("bob", "customer"), &pb.CreateUserRequest{Name: "", Email: invalidEmail})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = users.CreateUser(as("root", "admin"), &pb.CreateUserRequest{Name: "", Email: invalidEmail})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = users.UpdateUser(as("bob", "customer"), &pb.UpdateUserRequest{Id: user.Id, Email: invalidEmail})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = users.UpdateUser(as(user.Id, "customer"), &pb.UpdateUserRequest{Id: user.Id, Email: invalidEmail})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestExportEraseUser(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	users := NewUserServer(repo, logging.Discard())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+
+	user := model.NewUser("Оля")
+	user.Email = "olya@example.com"
+	user.Addresses = []model.Address{{Id: "home", Country: "RU", City: "Москва", Street: "Тверская, 1"}}
+	_ = repo.Save(context.Background(), user)
+	order := model.NewOrder(user.Id)
+	order.ShippingAddress = &user.Addresses[0]
+	_ = repo.Save(context.Background(), order)
+
+	export, err := users.ExportUser(as(user.Id, "customer"), &pb.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "olya@example.com", export.GetUser().GetEmail())
+	if assert.Len(t, export.GetOrders(), 1) {
+		assert.Equal(t, order.Id, export.GetOrders()[0].GetId())
+	}
+	_, err = users.ExportUser(as("bob", "customer"), &pb.GetUserRequest{Id: user.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = users.EraseUser(as(user.Id, "customer"), &pb.GetUserRequest{Id: user.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	erased, err := users.EraseUser(as("root", "admin"), &pb.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, model.ErasedName, erased.GetName())
+	assert.Empty(t, erased.GetEmail())
+	assert.Empty(t, erased.GetAddresses())
+	assert.NotNil(t, erased.GetErasedAt())
+
+	export, err = users.ExportUser(as("root", "admin"), &pb.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+	assert.Nil(t, export.GetOrders()[0].GetShippingAddress()) // заказ остался, адрес стёрт
+	var actions []string
+	for _, e := range export.GetHistory() {
+		actions = append(actions, e.GetAction())
+	}
+	assert.Equal(t, []string{"user:export"}, actions) // без обёртки аудита в журнале только выгрузка
+
+	_, err = users.EraseUser(as("root", "admin"), &pb.GetUserRequest{Id: "User-missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// watchStream — серверный поток для тестов: отправленные события складываются в канал
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.OrderEvent
+}
+
+func (s *watchStream) Context() context.Context { return s.ctx }
+
+func (s *watchStream) Send(e *pb.OrderEvent) error {
+	s.sent <- e
+	return nil
+}
+
+func (s *watchStream) next(t *testing.T) *pb.OrderEvent {
+	t.Helper()
+	select {
+	case e := <-s.sent:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+		return nil
+	}
+}
+
+// тест WatchOrder/WatchOrders: снимок, живые события, продолжение с номера и видимость чужих заказов
+func TestWatchOrder(t *testing.T) {
+	bus := events.NewBus(events.DefaultHistory)
+	repo := events.InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	srv := NewOrderServer(repo, bus, logging.Discard())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+
+	order := model.NewOrder("alice")
+	require.NoError(t, repo.Save(context.Background(), order)) // событие 1
+
+	ctx, cancel := context.WithCancel(as("alice", "customer"))
+	stream := &watchStream{ctx: ctx, sent: make(chan *pb.OrderEvent, 10)}
+	done := make(chan error, 1)
+	go func() { done <- srv.WatchOrder(&pb.WatchOrderRequest{Id: order.Id}, stream) }()
+
+	snapshot := stream.next(t)
+	assert.Equal(t, "order.snapshot", snapshot.GetType())
+	assert.Equal(t, uint64(1), snapshot.GetSeq())
+	assert.Equal(t, pb.OrderStatus_ORDER_CREATED, snapshot.GetOrder().GetStatus())
+
+	_, err := srv.ConfirmOrder(as("w-1", "warehouse"), &pb.OrderActionRequest{Id: order.Id})
+	require.NoError(t, err)
+	confirmed := stream.next(t)
+	assert.Equal(t, "order.confirmed", confirmed.GetType())
+	assert.Equal(t, uint64(2), confirmed.GetSeq())
+	assert.Equal(t, pb.OrderStatus_ORDER_CONFIRMED, confirmed.GetOrder().GetStatus())
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+
+	// переподключение после события 1: пропущенное подтверждение приходит без снимка
+	ctx, cancel = context.WithCancel(as("alice", "customer"))
+	stream = &watchStream{ctx: ctx, sent: make(chan *pb.OrderEvent, 10)}
+	go func() { done <- srv.WatchOrder(&pb.WatchOrderRequest{Id: order.Id, AfterSeq: 1}, stream) }()
+	assert.Equal(t, uint64(2), stream.next(t).GetSeq())
+	cancel()
+	<-done
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		req      *pb.WatchOrderRequest
+		expected codes.Code
+	}{
+		{"foreign order", as("bob", "customer"), &pb.WatchOrderRequest{Id: order.Id}, codes.PermissionDenied},
+		{"unknown order", as("root", "admin"), &pb.WatchOrderRequest{Id: "nope"}, codes.NotFound},
+		{"seq from the future", as("alice", "customer"), &pb.WatchOrderRequest{Id: order.Id, AfterSeq: 100}, codes.OutOfRange},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := srv.WatchOrder(tc.req, &watchStream{ctx: tc.ctx, sent: make(chan *pb.OrderEvent, 10)})
+			assert.Equal(t, tc.expected, status.Code(err))
+		})
+	}
+
+	// WatchOrders: покупатель видит только события своих заказов
+	require.NoError(t, repo.Save(context.Background(), model.NewOrder("bob"))) // событие 3
+	ctx, cancel = context.WithCancel(as("bob", "customer"))
+	defer cancel()
+	stream = &watchStream{ctx: ctx, sent: make(chan *pb.OrderEvent, 10)}
+	go func() { done <- srv.WatchOrders(&pb.WatchOrdersRequest{AfterSeq: 1}, stream) }()
+	e := stream.next(t)
+	assert.Equal(t, uint64(3), e.GetSeq())
+	assert.Equal(t, "bob", e.GetOrder().GetUserId())
+}
+
+// тест UpdateOrderStatus: те же переходы и права, что у Confirm/Deliver/Cancel
+func TestUpdateOrderStatus(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	srv := NewOrderServer(repo, events.NewBus(events.DefaultHistory), logging.Discard())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+	order := model.NewOrder("alice")
+	_ = repo.Save(context.Background(), order)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		status     pb.OrderStatus
+		version    int64
+		expected   codes.Code
+		wantStatus pb.OrderStatus
+	}{
+		{"back to created", as("root", "admin"), pb.OrderStatus_ORDER_CREATED, 0, codes.InvalidArgument, 0},
+		{"customer cannot confirm", as("alice", "customer"), pb.OrderStatus_ORDER_CONFIRMED, 0, codes.PermissionDenied, 0},
+		{"deliver before confirm", as("c-1", "courier"), pb.OrderStatus_ORDER_DELIVERED, 0, codes.FailedPrecondition, 0},
+		{"stale version", as("w-1", "warehouse"), pb.OrderStatus_ORDER_CONFIRMED, 5, codes.Aborted, 0},
+		{"warehouse confirms", as("w-1", "warehouse"), pb.OrderStatus_ORDER_CONFIRMED, 1, codes.OK, pb.OrderStatus_ORDER_CONFIRMED},
+		{"owner cancels", as("alice", "customer"), pb.OrderStatus_ORDER_CANCELLED, 0, codes.OK, pb.OrderStatus_ORDER_CANCELLED},
+		{"confirm cancelled", as("root", "admin"), pb.OrderStatus_ORDER_CONFIRMED, 0, codes.FailedPrecondition, 0},
+		{"deliver cancelled", as("root", "admin"), pb.OrderStatus_ORDER_DELIVERED, 0, codes.FailedPrecondition, 0},
+		{"cancel cancelled", as("root", "admin"), pb.OrderStatus_ORDER_CANCELLED, 0, codes.FailedPrecondition, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := srv.UpdateOrderStatus(tc.ctx, &pb.UpdateOrderStatusRequest{Id: order.Id, Status: tc.status, ExpectedVersion: tc.version})
+			assert.Equal(t, tc.expected, status.Code(err))
+			if err == nil {
+				assert.Equal(t, tc.wantStatus, got.GetStatus())
+				assert.Equal(t, order.CreatedAt.Unix(), got.GetCreatedAt().AsTime().Unix())
+			}
+		})
+	}
+}
+
+// тест доставок и складов: покупатель видит только свои доставки, склады ему недоступны
+func TestDeliveryWarehouseServers(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	deliveries := NewDeliveryServer(repo, logging.Discard())
+	warehouses := NewWarehouseServer(repo, logging.Discard())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+	}
+	own := model.NewDelivery("Order-1", "alice", "Москва", 0)
+	foreign := model.NewDelivery("Order-2", "bob", "Казань", 0)
+	stock := model.NewWarehouse("Order-1", 1)
+	for _, s := range []model.Storable{own, foreign, stock} {
+		require.NoError(t, repo.Save(context.Background(), s))
+	}
+
+	list, err := deliveries.ListDeliveries(as("alice", "customer"), &pb.ListDeliveriesRequest{})
+	require.NoError(t, err)
+	if assert.Len(t, list.GetDeliveries(), 1) {
+		assert.Equal(t, own.Id, list.GetDeliveries()[0].GetId())
+	}
+	list, err = deliveries.ListDeliveries(as("c-1", "courier"), &pb.ListDeliveriesRequest{OrderId: "Order-2"})
+	require.NoError(t, err)
+	assert.Len(t, list.GetDeliveries(), 1)
+
+	_, err = deliveries.GetDelivery(as("alice", "customer"), &pb.GetDeliveryRequest{Id: foreign.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = deliveries.GetDelivery(as("c-1", "courier"), &pb.GetDeliveryRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = warehouses.ListWarehouses(as("alice", "customer"), &pb.ListWarehousesRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	w, err := warehouses.GetWarehouse(as("w-1", "warehouse"), &pb.GetWarehouseRequest{Id: stock.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "Order-1", w.GetOrderId())
+}
+
+// тест цепочки потоковых перехватчиков: request ID возвращается в заголовке, лимит частоты действует
+func TestStreamInterceptors(t *testing.T) {
+	bus := events.NewBus(events.DefaultHistory)
+	repo := events.InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	order := model.NewOrder("alice")
+	require.NoError(t, repo.Save(context.Background(), order))
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"orders": {Rate: 0.001, Burst: 1}}, logging.Discard())
+	srv := NewGrpcServer(repo, bus, logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), nil, limiter)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	c := pb.NewOrderServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1"))
+	defer cancel()
+	stream, err := c.WatchOrder(ctx, &pb.WatchOrderRequest{Id: order.Id})
+	require.NoError(t, err)
+	snapshot, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "order.snapshot", snapshot.GetType())
+	header, err := stream.Header()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	// второй поток сверх лимита группы orders
+	stream, err = c.WatchOrder(context.Background(), &pb.WatchOrderRequest{Id: order.Id})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// тест лимита по IP до аутентификации: перебор неверных ключей и токенов получает ResourceExhausted
+func TestRateLimitBeforeAuth(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{ratelimit.AuthGroup: {Rate: 0.001, Burst: 3}}, logging.Discard())
+	srv := NewGrpcServer(memory.NewMemoryRepo(logging.Discard()), events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), limiter)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	c := pb.NewOrderServiceClient(conn)
+	call := func(key, value string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), key, value)
+		_, err := c.ListOrders(ctx, &pb.ListOrdersRequest{})
+		return err
+	}
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("x-api-key", "guess-1")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("x-api-key", "guess-2")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("authorization", "Bearer garbage")))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call("x-api-key", "guess-3")))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call("authorization", authtest.Bearer(t, "alice", "admin"))))
+}
+
+// тест GracefulStop с открытым потоком WatchOrder: поток завершается с UNAVAILABLE, остановка не ждёт таймаута
+func TestGracefulStopEndsStreams(t *testing.T) {
+	bus := events.NewBus(events.DefaultHistory)
+	repo := events.InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	order := model.NewOrder("alice")
+	require.NoError(t, repo.Save(context.Background(), order))
+
+	srv := NewGrpcServer(repo, bus, logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), nil, nil)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	stream, err := pb.NewOrderServiceClient(conn).WatchOrder(context.Background(), &pb.WatchOrderRequest{Id: order.Id})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	GracefulStop(ctx, srv)
+	assert.NoError(t, ctx.Err())
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"order-ms/internal/events"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	pb "order-ms/pkg/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoEvent(e events.Event) *pb.OrderEvent {
+	return &pb.OrderEvent{
+		Seq:   e.Seq,
+		Type:  e.Type,
+		At:    timestamppb.New(e.At),
+		Order: toProtoOrder(&e.Order),
+	}
+}
+
+// watchError переводит ошибки шины событий в коды gRPC. На UNAVAILABLE клиент переподключается
+// с after_seq последнего полученного события, на OUT_OF_RANGE — перечитывает состояние заново
+func watchError(err error) error {
+	switch {
+	case errors.Is(err, events.ErrEventsLost):
+		return status.Error(codes.OutOfRange, "events after after_seq are no longer available, re-read state and watch again")
+	case errors.Is(err, events.ErrSlowSubscriber):
+		return status.Error(codes.Unavailable, "subscriber fell behind, reconnect with after_seq")
+	case errors.Is(err, events.ErrClosed):
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// forward отправляет события подписки в поток, пока клиент не отключится, сервер не начнёт остановку или шина не закроет подписку
+func forward(ctx context.Context, sub *events.Subscription, send func(*pb.OrderEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), errShuttingDown) {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			return status.FromContextError(ctx.Err()).Err()
+		case e, ok := <-sub.Events():
+			if !ok {
+				return watchError(sub.Err())
+			}
+			if err := send(toProtoEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WatchOrder присылает текущее состояние заказа (событие order.snapshot с номером последнего события шины),
+// а затем каждую смену его статуса. С after_seq > 0 вместо снимка присылаются пропущенные события
+func (s *OrderServer) WatchOrder(req *pb.WatchOrderRequest, stream pb.OrderService_WatchOrderServer) error {
+	ctx := stream.Context()
+	if req == nil || req.GetId() == "" {
+		return status.Error(codes.InvalidArgument, "id is required")
+	}
+	id := req.GetId()
+	if _, err := s.authorizeOrder(ctx, service.ActionOrderRead, id); err != nil {
+		return err
+	}
+	sub, last, err := s.bus.Subscribe(req.GetAfterSeq(), func(e events.Event) bool { return e.Order.Id == id })
+	if err != nil {
+		return watchError(err)
+	}
+	defer sub.Close()
+
+	if req.GetAfterSeq() == 0 {
+		// снимок читаем уже после подписки, чтобы не потерять смену статуса между чтением и подпиской;
+		// такое событие придёт следом и повторит снимок — клиенту достаточно сравнить version
+		o, err := s.repo.GetOrderByID(ctx, id)
+		if err != nil {
+			return internalError(ctx, s.logger, "cannot get order", err)
+		}
+		if o == nil {
+			return status.Error(codes.NotFound, "order not found")
+		}
+		snapshot := events.Event{Seq: last, Type: events.TypeOrderSnapshot, At: time.Now(), Order: *o}
+		if err := stream.Send(toProtoEvent(snapshot)); err != nil {
+			return err
+		}
+	}
+	return forward(ctx, sub, stream.Send)
+}
+
+// WatchOrders присылает смены статусов заказов по фильтру. Покупатель видит только свои заказы
+func (s *OrderServer) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderService_WatchOrdersServer) error {
+	ctx := stream.Context()
+	if err := authorize(ctx, service.ActionOrderList, ""); err != nil {
+		return err
+	}
+	var statuses []model.OrderStatus
+	for _, st := range req.GetStatuses() {
+		statuses = append(statuses, model.OrderStatus(st))
+	}
+	match := func(e events.Event) bool {
+		if req.GetUserId() != "" && e.Order.UserID != req.GetUserId() {
+			return false
+		}
+		if len(statuses) > 0 && !slices.Contains(statuses, e.Order.Status) {
+			return false
+		}
+		return service.Authorize(ctx, service.ActionOrderRead, e.Order.UserID) == nil
+	}
+	sub, _, err := s.bus.Subscribe(req.GetAfterSeq(), match)
+	if err != nil {
+		return watchError(err)
+	}
+	defer sub.Close()
+	return forward(ctx, sub, stream.Send)
+}
@@ -0,0 +1,160 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// статусы в ответе /readyz
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// сколько ждём ответа одной зависимости
+const checkTimeout = 2 * time.Second
+
+// Check проверяет одну зависимость (обычно Ping); nil — зависимость доступна
+type Check func(ctx context.Context) error
+
+// DependencyStatus — результат проверки одной зависимости. Текст ошибки /readyz не отдаёт:
+// ручка открыта без аутентификации, а в ошибке бывают адреса и имена хостов. Он пишется в лог
+type DependencyStatus struct {
+	Status string `json:"status"`
+}
+
+// Report — ответ /readyz
+type Report struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+// Ready — готов ли сервис принимать трафик
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker собирает проверки зависимостей активного бэкенда и отдаёт
+// их результат в HTTP (/readyz) и в стандартный grpc.health.v1
+type Checker struct {
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+	grpc         *grpchealth.Server
+	logger       *slog.Logger
+}
+
+func NewChecker(logger *slog.Logger) *Checker {
+	return &Checker{
+		checks: make(map[string]Check),
+		grpc:   grpchealth.NewServer(),
+		logger: logger,
+	}
+}
+
+// Add регистрирует проверку зависимости под именем (postgres, mongo, redis)
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Check опрашивает все зависимости параллельно
+func (c *Checker) Check(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, Checks: map[string]DependencyStatus{}}
+	}
+
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	results := make([]DependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			if err := checks[i](ctx); err != nil {
+				c.logger.WarnContext(ctx, "dependency check failed", "dependency", names[i], "err", err)
+				results[i] = DependencyStatus{Status: StatusUnavailable}
+				return
+			}
+			results[i] = DependencyStatus{Status: StatusOK}
+		}(i)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]DependencyStatus, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// RegisterGRPC регистрирует сервис grpc.health.v1 на gRPC-сервере
+func (c *Checker) RegisterGRPC(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, c.grpc)
+}
+
+// Watch периодически проверяет зависимости и обновляет статус grpc.health.v1
+// (пустое имя сервиса — общий статус сервера). Работает до отмены ctx.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		report := c.Check(ctx)
+		if c.shuttingDown.Load() {
+			return
+		}
+		status := healthpb.HealthCheckResponse_SERVING
+		if !report.Ready() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != last {
+			c.logger.Info("readiness changed", "status", report.Status, "checks", report.Checks)
+			last = status
+		}
+		c.grpc.SetServingStatus("", status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown переводит сервис в «не готов»: /readyz отвечает 503, gRPC — NOT_SERVING.
+// Вызывается в начале graceful shutdown, чтобы балансировщик перестал слать трафик.
+func (c *Checker) Shutdown() {
+	if c.shuttingDown.Swap(true) {
+		return
+	}
+	c.grpc.Shutdown()
+	c.logger.Info("readiness disabled for shutdown")
+}
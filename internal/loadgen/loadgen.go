@@ -0,0 +1,342 @@
+// Package loadgen создаёт синтетическую нагрузку на сервис заказов: смесь создания, подтверждения,
+// доставки, отмены и списка заказов с заданной частотой, через REST или gRPC (см. Target).
+// Нагрузка открытая: запросы отправляются по расписанию, не дожидаясь ответов на предыдущие,
+// поэтому медленный сервис не снижает частоту, а копит запросы в работе
+package loadgen
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op — вид запроса в смеси
+type Op string
+
+const (
+	OpCreate  Op = "create"  // новый заказ случайного пользователя
+	OpConfirm Op = "confirm" // подтверждение созданного заказа
+	OpDeliver Op = "deliver" // доставка подтверждённого заказа
+	OpCancel  Op = "cancel"  // отмена созданного или подтверждённого заказа
+	OpList    Op = "list"    // список заказов случайного пользователя
+)
+
+// Ops — все виды запросов в порядке вывода отчёта
+var Ops = []Op{OpCreate, OpConfirm, OpDeliver, OpCancel, OpList}
+
+// DefaultMix — смесь по умолчанию
+const DefaultMix = "create=30,confirm=20,deliver=15,cancel=5,list=30"
+
+// Mix — относительные веса видов запросов
+type Mix map[Op]int
+
+// ParseMix разбирает смесь вида "create=30,list=70"; виды без веса не отправляются
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for part := range strings.SplitSeq(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix entry %q: want op=weight", part)
+		}
+		op := Op(strings.TrimSpace(name))
+		if !slices.Contains(Ops, op) {
+			return nil, fmt.Errorf("unknown op %q", op)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("op %s: weight must be a non-negative integer", op)
+		}
+		mix[op] = n
+	}
+	total := 0
+	for _, n := range mix {
+		total += n
+	}
+	if total == 0 {
+		return nil, errors.New("mix has no ops with a positive weight")
+	}
+	return mix, nil
+}
+
+// String возвращает смесь в том же виде, что принимает ParseMix
+func (m Mix) String() string {
+	var parts []string
+	for _, op := range Ops {
+		if m[op] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Target — сервис под нагрузкой. Методы безопасны для вызова из нескольких горутин
+type Target interface {
+	Users(ctx context.Context) ([]string, error)
+	CreateOrder(ctx context.Context, userID string) (string, error)
+	ConfirmOrder(ctx context.Context, id string) error
+	DeliverOrder(ctx context.Context, id string) error
+	CancelOrder(ctx context.Context, id string) error
+	ListOrders(ctx context.Context, userID string) error
+}
+
+// Options — параметры прогона
+type Options struct {
+	RPS         float64       // запросов в секунду
+	Duration    time.Duration // длительность прогона
+	Concurrency int           // предел запросов в работе; сверх него запрос пропускается и считается в Dropped
+	Timeout     time.Duration // таймаут одного запроса
+	Mix         Mix
+	Seed        uint64   // зерно выбора запросов и пользователей
+	Users       []string // ID пользователей для create и list; пусто — все из Target.Users
+}
+
+const (
+	DefaultConcurrency = 64
+	DefaultTimeout     = 5 * time.Second
+)
+
+// Stats — итог по одному виду запросов
+type Stats struct {
+	Op       Op
+	Requests int
+	Errors   int
+	// Causes — число ошибок по причине: код gRPC, "HTTP 409", "timeout"
+	Causes             map[string]int
+	P50, P90, P99, Max time.Duration
+	latencies          []time.Duration
+}
+
+// ErrorRate — доля ошибок от 0 до 1
+func (s Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Report — итог прогона
+type Report struct {
+	Elapsed time.Duration // сколько длилась отправка, без ожидания последних ответов
+	Ops     []Stats       // только виды, по которым были запросы, в порядке Ops
+	Total   Stats
+	Dropped int // запросы, пропущенные из-за предела Concurrency
+}
+
+// RPS — фактическая частота отправленных запросов
+func (r *Report) RPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Total.Requests) / r.Elapsed.Seconds()
+}
+
+// run — состояние прогона: заказы, созданные генератором, ждут в очередях следующей смены статуса
+type run struct {
+	target  Target
+	opts    Options
+	mu      sync.Mutex
+	created []string
+	confirm []string
+	stats   map[Op]*Stats
+}
+
+// Run отправляет запросы с частотой opts.RPS, пока не истечёт opts.Duration или ctx, и дожидается ответов.
+// Подтверждаются, доставляются и отменяются только заказы, созданные этим прогоном; пока подходящих нет,
+// вместо такого запроса отправляется create. Ошибка возвращается, только если прогон не удалось начать
+func Run(ctx context.Context, target Target, opts Options) (*Report, error) {
+	if opts.RPS <= 0 || opts.Duration <= 0 {
+		return nil, errors.New("rps and duration must be positive")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Mix == nil {
+		opts.Mix, _ = ParseMix(DefaultMix)
+	}
+	users := opts.Users
+	if len(users) == 0 {
+		var err error
+		if users, err = target.Users(ctx); err != nil {
+			return nil, fmt.Errorf("list users: %w", err)
+		}
+		if len(users) == 0 {
+			return nil, errors.New("no users to create orders for: run `order-ms seed` first")
+		}
+	}
+
+	r := &run{target: target, opts: opts, stats: map[Op]*Stats{}}
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	weights := make([]int, len(Ops))
+	total := 0
+	for i, op := range Ops {
+		weights[i] = max(opts.Mix[op], 0)
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, errors.New("mix has no ops with a positive weight")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+	interval := time.Duration(float64(time.Second) / opts.RPS)
+	ticker := time.NewTicker(max(interval, time.Microsecond))
+	defer ticker.Stop()
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	report := &Report{}
+	started := time.Now()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+		// выбор запроса — в этой горутине, чтобы последовательность зависела только от зерна
+		op := Ops[weighted(rng, weights)]
+		user := users[rng.IntN(len(users))]
+		select {
+		case slots <- struct{}{}:
+		default:
+			report.Dropped++
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			r.do(op, user)
+		}()
+	}
+	report.Elapsed = time.Since(started)
+	wg.Wait()
+
+	report.Total = Stats{Op: "total", Causes: map[string]int{}}
+	for _, op := range Ops {
+		s := r.stats[op]
+		if s == nil {
+			continue
+		}
+		report.Total.Requests += s.Requests
+		report.Total.Errors += s.Errors
+		for cause, n := range s.Causes {
+			report.Total.Causes[cause] += n
+		}
+		report.Total.latencies = append(report.Total.latencies, s.latencies...)
+		s.percentiles()
+		report.Ops = append(report.Ops, *s)
+	}
+	report.Total.percentiles()
+	return report, nil
+}
+
+// do отправляет один запрос вида op, подставляя create, если подходящего заказа нет.
+// Таймаут отсчитывается от своего контекста: запросы в работе дожидаются и после окончания прогона
+func (r *run) do(op Op, user string) {
+	id := ""
+	switch op {
+	case OpConfirm:
+		id = r.take(&r.created)
+	case OpDeliver:
+		id = r.take(&r.confirm)
+	case OpCancel:
+		if id = r.take(&r.created); id == "" {
+			id = r.take(&r.confirm)
+		}
+	}
+	if op != OpCreate && op != OpList && id == "" {
+		op = OpCreate
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	var err error
+	switch op {
+	case OpCreate:
+		id, err = r.target.CreateOrder(ctx, user)
+	case OpConfirm:
+		err = r.target.ConfirmOrder(ctx, id)
+	case OpDeliver:
+		err = r.target.DeliverOrder(ctx, id)
+	case OpCancel:
+		err = r.target.CancelOrder(ctx, id)
+	case OpList:
+		err = r.target.ListOrders(ctx, user)
+	}
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats[op]
+	if s == nil {
+		s = &Stats{Op: op, Causes: map[string]int{}}
+		r.stats[op] = s
+	}
+	s.Requests++
+	s.latencies = append(s.latencies, elapsed)
+	if err != nil {
+		s.Errors++
+		s.Causes[Cause(err)]++
+		return
+	}
+	switch op {
+	case OpCreate:
+		r.created = append(r.created, id)
+	case OpConfirm:
+		r.confirm = append(r.confirm, id)
+	}
+}
+
+// take забирает из очереди самый старый заказ; "" — очередь пуста
+func (r *run) take(queue *[]string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(*queue) == 0 {
+		return ""
+	}
+	id := (*queue)[0]
+	*queue = (*queue)[1:]
+	return id
+}
+
+// percentiles считает перцентили методом ближайшего ранга
+func (s *Stats) percentiles() {
+	if len(s.latencies) == 0 {
+		return
+	}
+	slices.SortFunc(s.latencies, cmp.Compare)
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(s.latencies)))) - 1
+		return s.latencies[max(i, 0)]
+	}
+	s.P50, s.P90, s.P99 = rank(0.50), rank(0.90), rank(0.99)
+	s.Max = s.latencies[len(s.latencies)-1]
+}
+
+// weighted возвращает индекс веса с вероятностью, пропорциональной весу
+func weighted(rng *rand.Rand, weights []int) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	n := rng.IntN(total)
+	for i, w := range weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(weights) - 1
+}
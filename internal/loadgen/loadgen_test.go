@@ -0,0 +1,176 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/events"
+	grpcServer "order-ms/internal/grpc"
+	"order-ms/internal/health"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+	"order-ms/internal/web"
+	"order-ms/pkg/client"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix(" create=3, list=1,cancel=0")
+	require.NoError(t, err)
+	assert.Equal(t, Mix{OpCreate: 3, OpList: 1, OpCancel: 0}, mix)
+	assert.Equal(t, "create=3,list=1", mix.String())
+
+	for _, s := range []string{"create", "ship=1", "list=-1", "list=x", "create=0"} {
+		_, err := ParseMix(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// fakeTarget проверяет переходы статусов, как сервис, и отвечает 429 на список
+type fakeTarget struct {
+	mu     sync.Mutex
+	n      int
+	orders map[string]model.OrderStatus
+}
+
+func (f *fakeTarget) Users(context.Context) ([]string, error) { return []string{"alice", "bob"}, nil }
+
+func (f *fakeTarget) CreateOrder(context.Context, string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+	id := fmt.Sprintf("Order-%d", f.n)
+	f.orders[id] = model.OrderCreated
+	return id, nil
+}
+
+func (f *fakeTarget) change(id string, from []model.OrderStatus, to model.OrderStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, st := range from {
+		if f.orders[id] == st {
+			f.orders[id] = to
+			return nil
+		}
+	}
+	return &StatusError{Code: http.StatusConflict}
+}
+
+func (f *fakeTarget) ConfirmOrder(_ context.Context, id string) error {
+	return f.change(id, []model.OrderStatus{model.OrderCreated}, model.OrderConfirmed)
+}
+
+func (f *fakeTarget) DeliverOrder(_ context.Context, id string) error {
+	return f.change(id, []model.OrderStatus{model.OrderConfirmed}, model.OrderDelivered)
+}
+
+func (f *fakeTarget) CancelOrder(_ context.Context, id string) error {
+	return f.change(id, []model.OrderStatus{model.OrderCreated, model.OrderConfirmed}, model.OrderCancelled)
+}
+
+func (f *fakeTarget) ListOrders(context.Context, string) error {
+	return &StatusError{Code: http.StatusTooManyRequests}
+}
+
+// тест прогона: заказы проходят статусы по порядку, ошибки считаются по видам и причинам
+func TestRun(t *testing.T) {
+	target := &fakeTarget{orders: map[string]model.OrderStatus{}}
+	mix, err := ParseMix("create=3,confirm=3,deliver=2,cancel=1,list=1")
+	require.NoError(t, err)
+	report, err := Run(context.Background(), target, Options{RPS: 1000, Duration: 300 * time.Millisecond, Mix: mix})
+	require.NoError(t, err)
+
+	require.NotZero(t, report.Total.Requests)
+	assert.Equal(t, len(target.orders), report.Ops[0].Requests)
+	byOp := map[Op]Stats{}
+	for _, s := range report.Ops {
+		byOp[s.Op] = s
+		assert.LessOrEqual(t, s.P50, s.P90)
+		assert.LessOrEqual(t, s.P90, s.P99)
+		assert.LessOrEqual(t, s.P99, s.Max)
+	}
+	for _, op := range []Op{OpCreate, OpConfirm, OpDeliver, OpCancel} {
+		assert.NotZero(t, byOp[op].Requests, op)
+		assert.Zero(t, byOp[op].Errors, op)
+	}
+	list := byOp[OpList]
+	assert.Equal(t, list.Requests, list.Errors)
+	assert.Equal(t, map[string]int{"HTTP 429": list.Errors}, report.Total.Causes)
+	assert.InDelta(t, 1.0, list.ErrorRate(), 0)
+	assert.Greater(t, report.RPS(), 0.0)
+
+	// без пользователей прогон не начинается
+	_, err = Run(context.Background(), emptyTarget{target}, Options{RPS: 1, Duration: time.Second})
+	assert.ErrorContains(t, err, "no users")
+}
+
+type emptyTarget struct{ *fakeTarget }
+
+func (emptyTarget) Users(context.Context) ([]string, error) { return nil, nil }
+
+// тест целей против настоящих серверов: все запросы смеси проходят без ошибок
+func TestTargets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := memory.NewMemoryRepo(logging.Discard())
+	require.NoError(t, repo.Save(context.Background(), &model.User{Id: "alice", Name: "Alice", Email: "alice@example.com"}))
+	authn := authtest.NewHS256(t)
+	admin := authtest.Token(t, "root", "admin")
+
+	grpcSrv := grpcServer.NewGrpcServer(repo, events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authn, nil)
+	grpcLis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = grpcSrv.Serve(grpcLis) }()
+	t.Cleanup(grpcSrv.Stop)
+	c, err := client.New(grpcLis.Addr().String(), client.WithBearerToken(admin), client.WithRetry(client.RetryPolicy{MaxAttempts: 1}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	// web.Server слушает сам, поэтому берём свободный порт заранее
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+	webSrv, err := web.NewServer(addr, repo, events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authn, nil)
+	require.NoError(t, err)
+	go func() { _ = webSrv.Start() }()
+	t.Cleanup(func() { _ = webSrv.Shutdown(context.Background()) })
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	targets := map[string]Target{
+		"grpc": GRPC{Client: c},
+		"rest": REST{BaseURL: "http://" + addr, Token: admin},
+	}
+	for name, target := range targets {
+		t.Run(name, func(t *testing.T) {
+			report, err := Run(context.Background(), target, Options{RPS: 200, Duration: 300 * time.Millisecond})
+			require.NoError(t, err)
+			require.NotZero(t, report.Total.Requests)
+			assert.Zero(t, report.Total.Errors, report.Total.Causes)
+			assert.Len(t, report.Ops, len(Ops))
+		})
+	}
+
+	// причины ошибок — код ответа REST и код gRPC
+	_, err = REST{BaseURL: "http://" + addr}.CreateOrder(context.Background(), "alice")
+	assert.Equal(t, "HTTP 401", Cause(err))
+	assert.Equal(t, "NotFound", Cause(GRPC{Client: c}.ConfirmOrder(context.Background(), "nope")))
+}
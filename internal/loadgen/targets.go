@@ -0,0 +1,180 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"order-ms/pkg/client"
+	pb "order-ms/pkg/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPC — цель через gRPC-клиента. Клиента лучше создать без повторов (client.WithRetry с MaxAttempts 1),
+// иначе задержки включают повторы, а ошибки перегрузки скрываются
+type GRPC struct {
+	Client *client.Client
+}
+
+func (g GRPC) Users(ctx context.Context) ([]string, error) {
+	users, err := g.Client.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.GetId())
+	}
+	return ids, nil
+}
+
+func (g GRPC) CreateOrder(ctx context.Context, userID string) (string, error) {
+	o, err := g.Client.CreateOrder(ctx, &pb.CreateOrderRequest{UserId: userID})
+	return o.GetId(), err
+}
+
+func (g GRPC) ConfirmOrder(ctx context.Context, id string) error {
+	_, err := g.Client.ConfirmOrder(ctx, id, 0)
+	return err
+}
+
+func (g GRPC) DeliverOrder(ctx context.Context, id string) error {
+	_, err := g.Client.DeliverOrder(ctx, id, 0)
+	return err
+}
+
+func (g GRPC) CancelOrder(ctx context.Context, id string) error {
+	return g.Client.CancelOrder(ctx, id, 0)
+}
+
+func (g GRPC) ListOrders(ctx context.Context, userID string) error {
+	_, err := g.Client.ListOrders(ctx, &pb.ListOrdersRequest{UserId: userID})
+	return err
+}
+
+// REST — цель через REST API по адресу BaseURL (например "http://localhost:8080")
+type REST struct {
+	BaseURL string
+	Client  *http.Client // nil — http.DefaultClient
+	Token   string       // bearer-токен
+	APIKey  string       // API-ключ вместо токена
+}
+
+// StatusError — ответ REST API с кодом 4xx или 5xx
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Message)
+}
+
+func (r REST) Users(ctx context.Context) ([]string, error) {
+	var users []struct {
+		ID string `json:"id"`
+	}
+	if err := r.do(ctx, http.MethodGet, "/api/users", nil, &users); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids, nil
+}
+
+func (r REST) CreateOrder(ctx context.Context, userID string) (string, error) {
+	var order struct {
+		ID string `json:"id"`
+	}
+	err := r.do(ctx, http.MethodPost, "/api/orders", map[string]string{"user_id": userID}, &order)
+	return order.ID, err
+}
+
+func (r REST) ConfirmOrder(ctx context.Context, id string) error {
+	return r.do(ctx, http.MethodPost, "/api/orders/"+url.PathEscape(id)+"/confirm", nil, nil)
+}
+
+func (r REST) DeliverOrder(ctx context.Context, id string) error {
+	return r.do(ctx, http.MethodPost, "/api/orders/"+url.PathEscape(id)+"/deliver", nil, nil)
+}
+
+func (r REST) CancelOrder(ctx context.Context, id string) error {
+	return r.do(ctx, http.MethodPost, "/api/orders/"+url.PathEscape(id)+"/cancel", nil, nil)
+}
+
+func (r REST) ListOrders(ctx context.Context, userID string) error {
+	return r.do(ctx, http.MethodGet, "/api/orders?user_id="+url.QueryEscape(userID), nil, nil)
+}
+
+// do отправляет запрос и читает ответ целиком, чтобы соединение вернулось в пул; out == nil — тело не нужно
+func (r REST) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.APIKey != "" {
+		req.Header.Set("X-API-Key", r.APIKey)
+	} else if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	httpClient := r.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return &StatusError{Code: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Cause — причина ошибки для отчёта: "HTTP 409", код gRPC ("Aborted"), "timeout" или "transport"
+func Cause(err error) string {
+	var httpErr *StatusError
+	var rpcErr *client.Error
+	switch {
+	case errors.As(err, &httpErr):
+		return fmt.Sprintf("HTTP %d", httpErr.Code)
+	case errors.As(err, &rpcErr):
+		return rpcErr.Code.String()
+	case errors.Is(err, context.DeadlineExceeded), status.Code(err) == codes.DeadlineExceeded:
+		return "timeout"
+	}
+	return "transport"
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+// тест: request_id из контекста попадает в JSON-запись лога
+func TestRequestIDInLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{name: "with request id", requestID: "abc123"},
+		{name: "without request id", requestID: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, slog.LevelInfo).With("component", "test")
+
+			ctx := context.Background()
+			if tc.requestID != "" {
+				ctx = WithRequestID(ctx, tc.requestID)
+			}
+			logger.InfoContext(ctx, "hello")
+
+			var entry map[string]any
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "hello", entry["msg"])
+			assert.Equal(t, "test", entry["component"])
+			if tc.requestID != "" {
+				assert.Equal(t, tc.requestID, entry["request_id"])
+			} else {
+				assert.NotContains(t, entry, "request_id")
+			}
+		})
+	}
+}
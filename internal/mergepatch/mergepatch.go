@@ -0,0 +1,48 @@
+// Package mergepatch реализует JSON Merge Patch (RFC 7396): объект патча рекурсивно
+// накладывается на документ, null удаляет поле, всё остальное (включая массивы) заменяется целиком
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ContentType — MIME-тип тела запроса с merge patch
+const ContentType = "application/merge-patch+json"
+
+// ErrInvalidPatch — тело патча не является JSON
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Apply накладывает patch на JSON-документ doc и возвращает результат
+func Apply(doc, patch []byte) ([]byte, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, ErrInvalidPatch
+	}
+	var d any
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(d, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch // не объект — заменяет цель целиком
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
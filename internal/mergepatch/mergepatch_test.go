@@ -0,0 +1,39 @@
+package mergepatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// примеры из приложения A RFC 7396
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace field", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add field", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove field", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"remove one of two", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaces", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"array of objects replaced", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"non-object patch", `{"a":"foo"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"nested null kept out", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Apply([]byte(tc.doc), []byte(tc.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(got))
+		})
+	}
+
+	_, err := Apply([]byte(`{}`), []byte(`{broken`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// storeCollector считает заказы по статусам, пользователей, доставки и склады в момент опроса /metrics
+// (раньше эти числа раз в минуту печатал Service.Logger). Числа считает БД (Repository.Count), записи не читаются
+type storeCollector struct {
+	repo   service.Repository
+	logger *slog.Logger
+
+	orders     *prometheus.Desc
+	users      *prometheus.Desc
+	deliveries *prometheus.Desc
+	warehouses *prometheus.Desc
+}
+
+// сколько ждём ответа БД при опросе
+const collectTimeout = 5 * time.Second
+
+// RegisterStoreCollector регистрирует коллектор содержимого БД
+func (m *Metrics) RegisterStoreCollector(repo service.Repository, logger *slog.Logger) {
+	m.Registry.MustRegister(&storeCollector{
+		repo:       repo,
+		logger:     logger,
+		orders:     prometheus.NewDesc(namespace+"_orders", "Orders in the store by status.", []string{"status"}, nil),
+		users:      prometheus.NewDesc(namespace+"_users", "Users in the store.", nil, nil),
+		deliveries: prometheus.NewDesc(namespace+"_deliveries", "Deliveries in the store.", nil, nil),
+		warehouses: prometheus.NewDesc(namespace+"_warehouses", "Warehouse records in the store.", nil, nil),
+	})
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.orders
+	ch <- c.users
+	ch <- c.deliveries
+	ch <- c.warehouses
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.repo.Count(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "cannot collect store counts", "err", err)
+		return
+	}
+	for _, st := range []model.OrderStatus{model.OrderCreated, model.OrderConfirmed, model.OrderDelivered, model.OrderCancelled} {
+		ch <- prometheus.MustNewConstMetric(c.orders, prometheus.GaugeValue, float64(counts.Orders[st]), st.String())
+	}
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(counts.Users))
+	ch <- prometheus.MustNewConstMetric(c.deliveries, prometheus.GaugeValue, float64(counts.Deliveries))
+	ch <- prometheus.MustNewConstMetric(c.warehouses, prometheus.GaugeValue, float64(counts.Warehouses))
+}
+
+// RegisterRedisPool публикует статистику пула соединений Redis (попадания/промахи, таймауты, соединения)
+func (m *Metrics) RegisterRedisPool(client *redis.Client) {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: namespace, Subsystem: "redis_pool", Name: name, Help: help}
+	}
+	stat := func(value func(*redis.PoolStats) uint32) func() float64 {
+		return func() float64 { return float64(value(client.PoolStats())) }
+	}
+	m.Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("hits_total", "Times a free connection was found in the pool.")),
+			stat(func(s *redis.PoolStats) uint32 { return s.Hits })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("misses_total", "Times a free connection was not found in the pool.")),
+			stat(func(s *redis.PoolStats) uint32 { return s.Misses })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("timeouts_total", "Times a wait timeout occurred.")),
+			stat(func(s *redis.PoolStats) uint32 { return s.Timeouts })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("total_conns", "Connections in the pool.")),
+			stat(func(s *redis.PoolStats) uint32 { return s.TotalConns })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("idle_conns", "Idle connections in the pool.")),
+			stat(func(s *redis.PoolStats) uint32 { return s.IdleConns })),
+	)
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// общий префикс всех метрик сервиса
+const namespace = "orderms"
+
+// Metrics — набор метрик сервиса и реестр, из которого их отдаёт /metrics
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	repoDuration *prometheus.HistogramVec
+	transitions  *prometheus.CounterVec
+}
+
+// New создаёт метрики и регистрирует их в собственном реестре (вместе со стандартными метриками Go и процесса)
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "gRPC call latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Repository operation latency by operation and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation", "result"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_status_transitions_total",
+			Help:      "Order status transitions.",
+		}, []string{"from", "to"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.grpcRequests, m.grpcDuration,
+		m.repoDuration,
+		m.transitions,
+	)
+	return m
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// ObserveHTTP учитывает один HTTP-запрос
+func (m *Metrics) ObserveHTTP(route, method string, code int, d time.Duration) {
+	c := strconv.Itoa(code)
+	m.httpRequests.WithLabelValues(route, method, c).Inc()
+	m.httpDuration.WithLabelValues(route, method, c).Observe(d.Seconds())
+}
+
+// ObserveGRPC учитывает один gRPC-вызов
+func (m *Metrics) ObserveGRPC(method, code string, d time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(d.Seconds())
+}
+
+// ObserveRepo учитывает одну операцию репозитория
+func (m *Metrics) ObserveRepo(operation string, err error, d time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.repoDuration.WithLabelValues(operation, result).Observe(d.Seconds())
+}
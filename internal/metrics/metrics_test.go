@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+)
+
+// тест: переходы статусов считаются, а коллектор отдаёт заказы по статусам
+func TestTransitionsAndStoreCollector(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	mem := memory.NewMemoryRepo(logging.Discard())
+	repo := InstrumentRepository(mem, m)
+	m.RegisterStoreCollector(mem, logging.Discard())
+
+	confirmed := model.NewOrder("User-1")
+	cancelled := model.NewOrder("User-2")
+	_ = mem.Save(ctx, confirmed)
+	_ = mem.Save(ctx, cancelled)
+
+	ok, err := repo.ConfirmOrder(ctx, confirmed.Id, 0)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	ok, _ = repo.ConfirmOrder(ctx, confirmed.Id, 0) // повторное подтверждение не считается
+	assert.False(t, ok)
+	ok, _ = repo.CancelOrder(ctx, cancelled.Id, 0)
+	assert.True(t, ok)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.transitions.WithLabelValues("created", "confirmed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.transitions.WithLabelValues("any", "cancelled")))
+
+	expected := `
+# HELP orderms_orders Orders in the store by status.
+# TYPE orderms_orders gauge
+orderms_orders{status="cancelled"} 1
+orderms_orders{status="confirmed"} 1
+orderms_orders{status="created"} 0
+orderms_orders{status="delivered"} 0
+# HELP orderms_users Users in the store.
+# TYPE orderms_users gauge
+orderms_users 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "orderms_orders", "orderms_users"))
+}
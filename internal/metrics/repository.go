@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"context"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"time"
+)
+
+// instrumentedRepo — обёртка над service.Repository, которая замеряет время операций
+// и считает переходы статусов заказов
+type instrumentedRepo struct {
+	next service.Repository
+	m    *Metrics
+}
+
+// InstrumentRepository оборачивает репозиторий метриками
+func InstrumentRepository(repo service.Repository, m *Metrics) service.Repository {
+	return &instrumentedRepo{next: repo, m: m}
+}
+
+// observe замеряет одну операцию
+func (r *instrumentedRepo) observe(operation string, start time.Time, err error) {
+	r.m.ObserveRepo(operation, err, time.Since(start))
+}
+
+// метка from для отмены: исходный статус репозиторий не возвращает
+const anyStatus = "any"
+
+// transition учитывает успешную смену статуса
+func (r *instrumentedRepo) transition(ok bool, err error, from, to model.OrderStatus) {
+	if ok && err == nil {
+		r.m.transitions.WithLabelValues(from.String(), to.String()).Inc()
+	}
+}
+
+func (r *instrumentedRepo) Save(ctx context.Context, s model.Storable) (err error) {
+	defer func(start time.Time) { r.observe("save", start, err) }(time.Now())
+	return r.next.Save(ctx, s)
+}
+
+func (r *instrumentedRepo) SaveOrder(ctx context.Context, order *model.Order) (err error) {
+	defer func(start time.Time) { r.observe("save_order", start, err) }(time.Now())
+	return r.next.SaveOrder(ctx, order)
+}
+
+func (r *instrumentedRepo) SaveOrders(ctx context.Context, orders []*model.Order) (err error) {
+	defer func(start time.Time) { r.observe("save_orders", start, err) }(time.Now())
+	return r.next.SaveOrders(ctx, orders)
+}
+
+func (r *instrumentedRepo) GetOrders(ctx context.Context) (orders []*model.Order, err error) {
+	defer func(start time.Time) { r.observe("get_orders", start, err) }(time.Now())
+	return r.next.GetOrders(ctx)
+}
+
+func (r *instrumentedRepo) GetOrderByID(ctx context.Context, id string) (order *model.Order, err error) {
+	defer func(start time.Time) { r.observe("get_order_by_id", start, err) }(time.Now())
+	return r.next.GetOrderByID(ctx, id)
+}
+
+func (r *instrumentedRepo) GetUnconfirmedOrders(ctx context.Context, createdBefore time.Time) (orders []*model.Order, err error) {
+	defer func(start time.Time) { r.observe("get_unconfirmed_orders", start, err) }(time.Now())
+	return r.next.GetUnconfirmedOrders(ctx, createdBefore)
+}
+
+func (r *instrumentedRepo) Count(ctx context.Context) (counts *model.StoreCounts, err error) {
+	defer func(start time.Time) { r.observe("count", start, err) }(time.Now())
+	return r.next.Count(ctx)
+}
+
+func (r *instrumentedRepo) Scan(ctx context.Context, kind, after string, limit int) (page []model.Storable, err error) {
+	defer func(start time.Time) { r.observe("scan", start, err) }(time.Now())
+	return r.next.Scan(ctx, kind, after, limit)
+}
+
+func (r *instrumentedRepo) DeleteOrder(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("delete_order", start, err) }(time.Now())
+	return r.next.DeleteOrder(ctx, id)
+}
+
+func (r *instrumentedRepo) GetDeletedOrders(ctx context.Context) (orders []*model.Order, err error) {
+	defer func(start time.Time) { r.observe("get_deleted_orders", start, err) }(time.Now())
+	return r.next.GetDeletedOrders(ctx)
+}
+
+func (r *instrumentedRepo) RestoreOrder(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("restore_order", start, err) }(time.Now())
+	return r.next.RestoreOrder(ctx, id)
+}
+
+func (r *instrumentedRepo) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("confirm_order", start, err) }(time.Now())
+	ok, err = r.next.ConfirmOrder(ctx, id, expectedVersion)
+	r.transition(ok, err, model.OrderCreated, model.OrderConfirmed)
+	return ok, err
+}
+
+func (r *instrumentedRepo) DeliverOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("deliver_order", start, err) }(time.Now())
+	ok, err = r.next.DeliverOrder(ctx, id, expectedVersion)
+	r.transition(ok, err, model.OrderConfirmed, model.OrderDelivered)
+	return ok, err
+}
+
+func (r *instrumentedRepo) CancelOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("cancel_order", start, err) }(time.Now())
+	ok, err = r.next.CancelOrder(ctx, id, expectedVersion)
+	if ok && err == nil {
+		// отменить можно и созданный, и подтверждённый заказ; ради метки статус заранее не читаем
+		r.m.transitions.WithLabelValues(anyStatus, model.OrderCancelled.String()).Inc()
+	}
+	return ok, err
+}
+
+func (r *instrumentedRepo) ExpireOrder(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("expire_order", start, err) }(time.Now())
+	ok, err = r.next.ExpireOrder(ctx, id)
+	r.transition(ok, err, model.OrderCreated, model.OrderCancelled)
+	return ok, err
+}
+
+func (r *instrumentedRepo) SaveUser(ctx context.Context, user *model.User) (err error) {
+	defer func(start time.Time) { r.observe("save_user", start, err) }(time.Now())
+	return r.next.SaveUser(ctx, user)
+}
+
+func (r *instrumentedRepo) GetUsers(ctx context.Context) (users []*model.User, err error) {
+	defer func(start time.Time) { r.observe("get_users", start, err) }(time.Now())
+	return r.next.GetUsers(ctx)
+}
+
+func (r *instrumentedRepo) GetUserByID(ctx context.Context, id string) (user *model.User, err error) {
+	defer func(start time.Time) { r.observe("get_user_by_id", start, err) }(time.Now())
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *instrumentedRepo) UpdateUser(ctx context.Context, u *model.User, expectedVersion int64) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("update_user", start, err) }(time.Now())
+	return r.next.UpdateUser(ctx, u, expectedVersion)
+}
+
+func (r *instrumentedRepo) DeleteUser(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("delete_user", start, err) }(time.Now())
+	return r.next.DeleteUser(ctx, id)
+}
+
+func (r *instrumentedRepo) GetDeletedUsers(ctx context.Context) (users []*model.User, err error) {
+	defer func(start time.Time) { r.observe("get_deleted_users", start, err) }(time.Now())
+	return r.next.GetDeletedUsers(ctx)
+}
+
+func (r *instrumentedRepo) RestoreUser(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("restore_user", start, err) }(time.Now())
+	return r.next.RestoreUser(ctx, id)
+}
+
+func (r *instrumentedRepo) EraseUser(ctx context.Context, id string, at time.Time) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("erase_user", start, err) }(time.Now())
+	return r.next.EraseUser(ctx, id, at)
+}
+
+func (r *instrumentedRepo) PurgeDeleted(ctx context.Context, before time.Time) (n int, err error) {
+	defer func(start time.Time) { r.observe("purge_deleted", start, err) }(time.Now())
+	return r.next.PurgeDeleted(ctx, before)
+}
+
+func (r *instrumentedRepo) SaveAuditEntry(ctx context.Context, e *model.AuditEntry) (err error) {
+	defer func(start time.Time) { r.observe("save_audit_entry", start, err) }(time.Now())
+	return r.next.SaveAuditEntry(ctx, e)
+}
+
+func (r *instrumentedRepo) GetAuditEntries(ctx context.Context, subjects []string) (entries []*model.AuditEntry, err error) {
+	defer func(start time.Time) { r.observe("get_audit_entries", start, err) }(time.Now())
+	return r.next.GetAuditEntries(ctx, subjects)
+}
+
+func (r *instrumentedRepo) GetDeliveries(ctx context.Context) (deliveries []*model.Delivery, err error) {
+	defer func(start time.Time) { r.observe("get_deliveries", start, err) }(time.Now())
+	return r.next.GetDeliveries(ctx)
+}
+
+func (r *instrumentedRepo) GetWarehouses(ctx context.Context) (warehouses []*model.Warehouse, err error) {
+	defer func(start time.Time) { r.observe("get_warehouses", start, err) }(time.Now())
+	return r.next.GetWarehouses(ctx)
+}
+
+func (r *instrumentedRepo) SaveAPIKey(ctx context.Context, key *model.APIKey) (err error) {
+	defer func(start time.Time) { r.observe("save_api_key", start, err) }(time.Now())
+	return r.next.SaveAPIKey(ctx, key)
+}
+
+func (r *instrumentedRepo) GetAPIKeys(ctx context.Context) (keys []*model.APIKey, err error) {
+	defer func(start time.Time) { r.observe("get_api_keys", start, err) }(time.Now())
+	return r.next.GetAPIKeys(ctx)
+}
+
+func (r *instrumentedRepo) GetAPIKeyByID(ctx context.Context, id string) (key *model.APIKey, err error) {
+	defer func(start time.Time) { r.observe("get_api_key_by_id", start, err) }(time.Now())
+	return r.next.GetAPIKeyByID(ctx, id)
+}
+
+func (r *instrumentedRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) (err error) {
+	defer func(start time.Time) { r.observe("touch_api_key", start, err) }(time.Now())
+	return r.next.TouchAPIKey(ctx, id, usedAt)
+}
+
+func (r *instrumentedRepo) SaveWebhook(ctx context.Context, w *model.Webhook) (err error) {
+	defer func(start time.Time) { r.observe("save_webhook", start, err) }(time.Now())
+	return r.next.SaveWebhook(ctx, w)
+}
+
+func (r *instrumentedRepo) GetWebhooks(ctx context.Context) (webhooks []*model.Webhook, err error) {
+	defer func(start time.Time) { r.observe("get_webhooks", start, err) }(time.Now())
+	return r.next.GetWebhooks(ctx)
+}
+
+func (r *instrumentedRepo) GetWebhookByID(ctx context.Context, id string) (w *model.Webhook, err error) {
+	defer func(start time.Time) { r.observe("get_webhook_by_id", start, err) }(time.Now())
+	return r.next.GetWebhookByID(ctx, id)
+}
+
+func (r *instrumentedRepo) DeleteWebhook(ctx context.Context, id string) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("delete_webhook", start, err) }(time.Now())
+	return r.next.DeleteWebhook(ctx, id)
+}
+
+func (r *instrumentedRepo) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (err error) {
+	defer func(start time.Time) { r.observe("save_webhook_delivery", start, err) }(time.Now())
+	return r.next.SaveWebhookDelivery(ctx, d)
+}
+
+func (r *instrumentedRepo) GetWebhookDeliveries(ctx context.Context, webhookID, status string) (deliveries []*model.WebhookDelivery, err error) {
+	defer func(start time.Time) { r.observe("get_webhook_deliveries", start, err) }(time.Now())
+	return r.next.GetWebhookDeliveries(ctx, webhookID, status)
+}
+
+func (r *instrumentedRepo) GetWebhookDeliveryByID(ctx context.Context, id string) (d *model.WebhookDelivery, err error) {
+	defer func(start time.Time) { r.observe("get_webhook_delivery_by_id", start, err) }(time.Now())
+	return r.next.GetWebhookDeliveryByID(ctx, id)
+}
+
+func (r *instrumentedRepo) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (ok bool, err error) {
+	defer func(start time.Time) { r.observe("acquire_lease", start, err) }(time.Now())
+	return r.next.AcquireLease(ctx, name, owner, ttl)
+}
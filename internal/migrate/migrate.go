@@ -0,0 +1,383 @@
+// Package migrate переносит данные из одного хранилища в другое через service.Repository:
+// пользователей, заказы, доставки, склады, API-ключи, вебхуки и журнал аудита с исходными ID и датами.
+//
+// Записи читаются страницами (service.Repository.Scan), поэтому коллекции целиком в память не загружаются.
+// Записи, которые уже есть в приёмнике, не перезаписываются, поэтому прерванный перенос можно
+// просто запустить снова. В конце числа записей и контрольные суммы источника и приёмника сверяются
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+)
+
+// DefaultBatchSize — записей в странице чтения и заказов в одной пакетной вставке по умолчанию
+const DefaultBatchSize = 500
+
+// Options — настройки переноса
+type Options struct {
+	DryRun    bool // только сравнить источник с приёмником, ничего не записывать
+	BatchSize int  // записей в странице чтения и заказов в одной пакетной вставке; 0 — DefaultBatchSize
+}
+
+// Result — итог по одному типу записей
+type Result struct {
+	Kind       string   `json:"kind"`       // users, orders, deliveries, ...
+	Source     int      `json:"source"`     // записей в источнике
+	Existing   int      `json:"existing"`   // уже были в приёмнике и пропущены
+	Copied     int      `json:"copied"`     // записаны сейчас; в dry-run — будут записаны
+	Failed     int      `json:"failed"`     // не записались
+	Errors     []string `json:"errors"`     // "ID: ошибка" по незаписанным
+	Target     int      `json:"target"`     // записей источника, найденных в приёмнике после переноса
+	Mismatched []string `json:"mismatched"` // ID, которые в приёмнике отличаются от источника
+	// контрольные суммы записей источника и их копий в приёмнике
+	SourceChecksum string `json:"source_checksum"`
+	TargetChecksum string `json:"target_checksum"`
+}
+
+// Verified — все записи источника есть в приёмнике и совпадают с ним
+func (r Result) Verified() bool {
+	return r.Failed == 0 && len(r.Mismatched) == 0 && r.Target == r.Source && r.SourceChecksum == r.TargetChecksum
+}
+
+// Report — итог переноса по всем типам записей
+type Report struct {
+	DryRun  bool     `json:"dry_run"`
+	Results []Result `json:"results"`
+}
+
+// OK — перенос завершён и сверен; для dry-run — нет записей, которые перенос не смог бы исправить
+// (в приёмнике уже есть запись с тем же ID, но другим содержимым)
+func (r *Report) OK() bool {
+	for _, res := range r.Results {
+		if r.DryRun && len(res.Mismatched) > 0 || !r.DryRun && !res.Verified() {
+			return false
+		}
+	}
+	return true
+}
+
+// Run переносит записи from → to. Типы идут по порядку зависимостей: пользователи раньше заказов,
+// журнал аудита последним. Ошибка — если не удалось прочитать источник или приёмник;
+// ошибки записи отдельных записей попадают в Result.Errors, и перенос продолжается
+func Run(ctx context.Context, from, to service.Repository, opts Options, logger *slog.Logger) (*Report, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	report := &Report{DryRun: opts.DryRun}
+	for _, c := range collections() {
+		res, err := c.migrate(ctx, from, to, opts)
+		if err != nil {
+			return report, err
+		}
+		logger.InfoContext(ctx, "migrated", "kind", res.Kind, "source", res.Source, "existing", res.Existing,
+			"copied", res.Copied, "failed", res.Failed, "dry_run", opts.DryRun)
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+// migrator — перенос одного типа записей
+type migrator interface {
+	migrate(ctx context.Context, from, to service.Repository, opts Options) (Result, error)
+}
+
+// collection описывает, как прочитать и записать записи типа T
+type collection[T model.Storable] struct {
+	kind string // название в отчёте
+	typ  string // вид записей для service.Repository.Scan
+	id   func(T) string
+	// поля повторяют сигнатуры методов service.Repository, чтобы подходили выражения вида service.Repository.SaveUser
+	save func(repo service.Repository, ctx context.Context, item T) error
+	// пакетная запись; nil — по одной
+	saveBatch func(repo service.Repository, ctx context.Context, items []T) error
+}
+
+// типы записей в порядке переноса
+var (
+	users = collection[*model.User]{
+		kind: "users",
+		typ:  "user",
+		id:   func(u *model.User) string { return u.Id },
+		save: service.Repository.SaveUser,
+	}
+	orders = collection[*model.Order]{
+		kind:      "orders",
+		typ:       "order",
+		id:        func(o *model.Order) string { return o.Id },
+		save:      service.Repository.SaveOrder,
+		saveBatch: service.Repository.SaveOrders,
+	}
+	deliveries = collection[*model.Delivery]{
+		kind: "deliveries",
+		typ:  "delivery",
+		id:   func(d *model.Delivery) string { return strconv.FormatInt(d.Id, 10) },
+		save: func(repo service.Repository, ctx context.Context, d *model.Delivery) error { return repo.Save(ctx, d) },
+	}
+	warehouses = collection[*model.Warehouse]{
+		kind: "warehouses",
+		typ:  "warehouse",
+		id:   func(w *model.Warehouse) string { return strconv.FormatInt(w.Id, 10) },
+		save: func(repo service.Repository, ctx context.Context, w *model.Warehouse) error { return repo.Save(ctx, w) },
+	}
+	apiKeys = collection[*model.APIKey]{
+		kind: "api_keys",
+		typ:  "api_key",
+		id:   func(k *model.APIKey) string { return k.Id },
+		save: service.Repository.SaveAPIKey,
+	}
+	webhooks = collection[*model.Webhook]{
+		kind: "webhooks",
+		typ:  "webhook",
+		id:   func(w *model.Webhook) string { return w.Id },
+		save: service.Repository.SaveWebhook,
+	}
+	webhookDeliveries = collection[*model.WebhookDelivery]{
+		kind: "webhook_deliveries",
+		typ:  "webhook_delivery",
+		id:   func(d *model.WebhookDelivery) string { return d.Id },
+		save: service.Repository.SaveWebhookDelivery,
+	}
+	audit = collection[*model.AuditEntry]{
+		kind: "audit",
+		typ:  "audit_entry",
+		id:   func(e *model.AuditEntry) string { return e.Id },
+		save: service.Repository.SaveAuditEntry,
+	}
+)
+
+func collections() []migrator {
+	return []migrator{users, orders, deliveries, warehouses, apiKeys, webhooks, webhookDeliveries, audit}
+}
+
+// reader читает записи одного хранилища страницами по возрастанию ID (model.CompareIDs)
+type reader[T model.Storable] struct {
+	c     collection[T]
+	repo  service.Repository
+	limit int
+	after string // ID последней прочитанной записи
+	done  bool   // страниц больше нет
+	buf   []T    // прочитанные, но ещё не просмотренные find записи
+}
+
+func (c collection[T]) reader(repo service.Repository, limit int) *reader[T] {
+	return &reader[T]{c: c, repo: repo, limit: limit}
+}
+
+// next возвращает следующую страницу; пустая — записи кончились
+func (r *reader[T]) next(ctx context.Context) ([]T, error) {
+	if r.done {
+		return nil, nil
+	}
+	items, err := r.repo.Scan(ctx, r.c.typ, r.after, r.limit)
+	if err != nil {
+		return nil, err
+	}
+	page := make([]T, 0, len(items))
+	for _, item := range items {
+		v, ok := item.(T)
+		if !ok {
+			return nil, fmt.Errorf("scan %s: unexpected %T", r.c.typ, item)
+		}
+		page = append(page, v)
+	}
+	r.done = len(page) < r.limit
+	if len(page) > 0 {
+		r.after = r.c.id(page[len(page)-1])
+	}
+	return page, nil
+}
+
+// find ищет запись с ID id. ID должны запрашиваться по возрастанию: записи с меньшими ID пропускаются.
+// Записи, добавленные в хранилище с ID меньше уже прочитанных, reader не видит
+func (r *reader[T]) find(ctx context.Context, id string) (T, bool, error) {
+	var zero T
+	for {
+		for len(r.buf) > 0 && model.CompareIDs(r.c.typ, r.c.id(r.buf[0]), id) < 0 {
+			r.buf = r.buf[1:]
+		}
+		if len(r.buf) > 0 {
+			if r.c.id(r.buf[0]) == id {
+				return r.buf[0], true, nil
+			}
+			return zero, false, nil
+		}
+		page, err := r.next(ctx)
+		if err != nil || len(page) == 0 {
+			return zero, false, err
+		}
+		r.buf = page
+	}
+}
+
+// migrate копирует записи, которых нет в приёмнике, страницами источника по opts.BatchSize,
+// сверяя каждую страницу с приёмником, который читается в том же порядке. В памяти — не больше
+// страницы каждого хранилища
+func (c collection[T]) migrate(ctx context.Context, from, to service.Repository, opts Options) (Result, error) {
+	res := Result{Kind: c.kind}
+	source, target := c.reader(from, opts.BatchSize), c.reader(to, opts.BatchSize)
+	for {
+		after := source.after
+		page, err := source.next(ctx)
+		if err != nil {
+			return res, fmt.Errorf("%s: read source: %w", c.kind, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		res.Source += len(page)
+
+		var pending []T
+		for _, item := range page {
+			_, ok, err := target.find(ctx, c.id(item))
+			if err != nil {
+				return res, fmt.Errorf("%s: read target: %w", c.kind, err)
+			}
+			if ok {
+				res.Existing++
+				continue
+			}
+			pending = append(pending, item)
+		}
+		if opts.DryRun {
+			res.Copied += len(pending)
+		} else if err := c.write(ctx, to, pending, after, opts.BatchSize, &res); err != nil {
+			return res, err
+		}
+	}
+
+	if err := c.verify(ctx, from, to, opts.BatchSize, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// write записывает pending одним пакетом или по одной. Если пакет не записался, часть его могла
+// остаться в приёмнике (Mongo), поэтому приёмник перечитывается с after — места страницы источника,
+// из которой взяты pending, — и оставшиеся записи дописываются по одной
+func (c collection[T]) write(ctx context.Context, to service.Repository, pending []T, after string, batchSize int, res *Result) error {
+	saveEach := func(items []T) {
+		for _, item := range items {
+			if err := c.save(to, ctx, item); err != nil {
+				res.Failed++
+				res.Errors = append(res.Errors, c.id(item)+": "+err.Error())
+				continue
+			}
+			res.Copied++
+		}
+	}
+	if c.saveBatch == nil || len(pending) == 0 {
+		saveEach(pending)
+		return nil
+	}
+	if err := c.saveBatch(to, ctx, pending); err == nil {
+		res.Copied += len(pending)
+		return nil
+	}
+	target := c.reader(to, batchSize)
+	target.after = after
+	var rest []T
+	for _, item := range pending {
+		_, ok, err := target.find(ctx, c.id(item))
+		if err != nil {
+			return fmt.Errorf("%s: read target: %w", c.kind, err)
+		}
+		if ok {
+			res.Copied++
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	saveEach(rest)
+	return nil
+}
+
+// verify ещё раз читает источник и приёмник страницами и сверяет их: сколько записей источника нашлось
+// в приёмнике, какие отличаются, и контрольные суммы
+func (c collection[T]) verify(ctx context.Context, from, to service.Repository, pageSize int, res *Result) error {
+	sourceSum, targetSum := sha256.New(), sha256.New()
+	source, target := c.reader(from, pageSize), c.reader(to, pageSize)
+	for {
+		page, err := source.next(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: read source: %w", c.kind, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, item := range page {
+			id := c.id(item)
+			digest := fingerprint(item)
+			fmt.Fprintf(sourceSum, "%s\x00%s\n", id, digest)
+			copied, ok, err := target.find(ctx, id)
+			if err != nil {
+				return fmt.Errorf("%s: read target: %w", c.kind, err)
+			}
+			if !ok {
+				continue
+			}
+			res.Target++
+			copiedDigest := fingerprint(copied)
+			fmt.Fprintf(targetSum, "%s\x00%s\n", id, copiedDigest)
+			if copiedDigest != digest {
+				res.Mismatched = append(res.Mismatched, id)
+			}
+		}
+	}
+	res.SourceChecksum = hex.EncodeToString(sourceSum.Sum(nil))
+	res.TargetChecksum = hex.EncodeToString(targetSum.Sum(nil))
+	return nil
+}
+
+// fingerprint — хеш записи в JSON без различий, которые вносят сами хранилища: время приводится к UTC
+// с точностью до миллисекунды (Mongo хранит миллисекунды, Postgres — микросекунды), null и пустые
+// списки не различаются
+func fingerprint(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return "error: " + err.Error()
+	}
+	b, _ = json.Marshal(normalize(generic)) // ключи map выводятся по порядку
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalize(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, item := range x {
+			if n := normalize(item); n == nil {
+				delete(x, k)
+			} else {
+				x[k] = n
+			}
+		}
+		return x
+	case []any:
+		if len(x) == 0 {
+			return nil
+		}
+		for i, item := range x {
+			x[i] = normalize(item)
+		}
+		return x
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, x); err == nil {
+			return t.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)
+		}
+	}
+	return v
+}
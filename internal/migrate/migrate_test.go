@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+	"order-ms/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// source наполняет хранилище записями всех типов, включая мягко удалённые
+func source(t *testing.T) (*memory.MemoryRepo, *model.Order) {
+	t.Helper()
+	ctx := context.Background()
+	repo := memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
+
+	alice := model.NewUser("Alice")
+	alice.Id, alice.Email = "alice", "alice@example.com"
+	alice.CreatedAt = time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	bob := model.NewUser("Bob")
+	bob.Id = "bob"
+	require.NoError(t, repo.SaveUser(ctx, alice))
+	require.NoError(t, repo.SaveUser(ctx, bob))
+	_, err := repo.DeleteUser(ctx, "bob")
+	require.NoError(t, err)
+
+	order := model.NewOrder("alice")
+	order.CreatedAt = time.Date(2024, 3, 2, 11, 30, 0, 0, time.UTC)
+	order.Version = 3
+	orders := []*model.Order{order, model.NewOrder("alice"), model.NewOrder("bob")}
+	require.NoError(t, repo.SaveOrders(ctx, orders))
+	_, err = repo.DeleteOrder(ctx, orders[2].Id)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Save(ctx, model.NewDelivery(order.Id, "alice", "Main st. 1", 0)))
+	require.NoError(t, repo.Save(ctx, model.NewWarehouse(order.Id, 1)))
+	require.NoError(t, repo.SaveAPIKey(ctx, model.NewAPIKey("warehouse-gw", "warehouse", nil, nil)))
+	hook := model.NewWebhook("https://example.com/hook", []string{"order.confirmed"}, "secret", "admin")
+	require.NoError(t, repo.SaveWebhook(ctx, hook))
+	require.NoError(t, repo.SaveWebhookDelivery(ctx, model.NewWebhookDelivery(hook.Id, "order.confirmed", order.Id, []byte(`{}`))))
+	require.NoError(t, repo.SaveAuditEntry(ctx, model.NewAuditEntry("admin", "order:create", order.Id, "")))
+	require.NoError(t, repo.SaveAuditEntry(ctx, model.NewAuditEntry("admin", "user:delete", "bob", "")))
+	return repo, order
+}
+
+func results(r *Report) map[string]Result {
+	m := map[string]Result{}
+	for _, res := range r.Results {
+		m[res.Kind] = res
+	}
+	return m
+}
+
+// тест переноса: dry-run ничего не пишет, повторный запуск докопирует недостающее, итог сверяется
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	from, order := source(t)
+	to := memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
+
+	report, err := Run(ctx, from, to, Options{DryRun: true}, logging.Discard())
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 3, results(report)["orders"].Copied)
+	orders, _ := to.GetOrders(ctx)
+	assert.Empty(t, orders, "dry run must not write")
+
+	// прошлый запуск успел перенести одного пользователя
+	alice, _ := from.GetUserByID(ctx, "alice")
+	require.NoError(t, to.SaveUser(ctx, alice))
+
+	// страницы по одной записи: источник и приёмник сверяются постранично
+	report, err = Run(ctx, from, to, Options{BatchSize: 1}, logging.Discard())
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	byKind := results(report)
+	expected := map[string]int{"users": 2, "orders": 3, "deliveries": 1, "warehouses": 1, "api_keys": 1, "webhooks": 1, "webhook_deliveries": 1, "audit": 2}
+	for kind, n := range expected {
+		res := byKind[kind]
+		assert.True(t, res.Verified(), kind)
+		assert.Equal(t, n, res.Source, kind)
+		assert.Equal(t, n, res.Target, kind)
+		assert.Equal(t, res.SourceChecksum, res.TargetChecksum, kind)
+	}
+	assert.Equal(t, 1, byKind["users"].Existing)
+	assert.Equal(t, 1, byKind["users"].Copied)
+
+	// ID, даты, версии и отметки удаления сохранены
+	copied, err := to.GetOrderByID(ctx, order.Id)
+	require.NoError(t, err)
+	assert.Equal(t, order.CreatedAt, copied.CreatedAt)
+	assert.Equal(t, int64(3), copied.Version)
+	deleted, _ := to.GetDeletedUsers(ctx)
+	if assert.Len(t, deleted, 1) {
+		assert.Equal(t, "bob", deleted[0].Id)
+	}
+
+	// повтор ничего не копирует
+	report, err = Run(ctx, from, to, Options{}, logging.Discard())
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Zero(t, results(report)["orders"].Copied)
+
+	// запись с тем же ID, но другим содержимым не перезаписывается и видна как расхождение
+	other := memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
+	changed := *order
+	changed.Status = model.OrderDelivered
+	require.NoError(t, other.SaveOrder(ctx, &changed))
+	report, err = Run(ctx, from, other, Options{DryRun: true}, logging.Discard())
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{order.Id}, results(report)["orders"].Mismatched)
+	assert.Equal(t, 2, results(report)["orders"].Copied)
+}
+
+// partialOrders записывает из пакета только первый заказ и возвращает ошибку, как InsertMany в Mongo
+type partialOrders struct {
+	service.Repository
+}
+
+func (r partialOrders) SaveOrders(ctx context.Context, orders []*model.Order) error {
+	if err := r.Repository.SaveOrder(ctx, orders[0]); err != nil {
+		return err
+	}
+	return errors.New("connection reset")
+}
+
+// тест сбоя пакетной записи: оставшиеся заказы дописываются по одной, без дублей
+func TestRunBatchFailure(t *testing.T) {
+	ctx := context.Background()
+	from, _ := source(t)
+	target := memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
+
+	report, err := Run(ctx, from, partialOrders{target}, Options{BatchSize: 2}, logging.Discard())
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	orders, _ := target.GetOrders(ctx)
+	deleted, _ := target.GetDeletedOrders(ctx)
+	assert.Len(t, orders, 2)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, 3, results(report)["orders"].Copied)
+}
+
+// тест сравнения записей: время сравнивается в UTC с точностью до миллисекунды, null и пустой список равны
+func TestFingerprint(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	a := &model.User{Id: "u", CreatedAt: at}
+	b := &model.User{Id: "u", CreatedAt: at.Truncate(time.Microsecond).In(time.FixedZone("MSK", 3*3600)), Addresses: []model.Address{}}
+	assert.Equal(t, fingerprint(a), fingerprint(b))
+	b.Name = "Bob"
+	assert.NotEqual(t, fingerprint(a), fingerprint(b))
+}
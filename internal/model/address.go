@@ -0,0 +1,40 @@
+package model
+
+import "strings"
+
+// Address — адрес доставки. У пользователя хранится список адресов,
+// а заказ получает копию выбранного адреса на момент создания
+
+type Address struct {
+	Id         string `json:"id"`              // ID адреса в профиле пользователя
+	Label      string `json:"label,omitempty"` // Подпись: "дом", "работа"
+	Country    string `json:"country"`         // Страна
+	City       string `json:"city"`            // Город
+	Street     string `json:"street"`          // Улица, дом, квартира
+	PostalCode string `json:"postal_code"`     // Почтовый индекс
+}
+
+func (a *Address) normalize() {
+	a.Id = strings.TrimSpace(a.Id)
+	a.Label = strings.TrimSpace(a.Label)
+	a.Country = strings.TrimSpace(a.Country)
+	a.City = strings.TrimSpace(a.City)
+	a.Street = strings.TrimSpace(a.Street)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+}
+
+// Validate проверяет, что заполнены все обязательные поля адреса
+
+func (a Address) Validate() *ValidationError {
+	switch {
+	case a.Country == "":
+		return &ValidationError{Field: "country", Reason: "is required"}
+	case a.City == "":
+		return &ValidationError{Field: "city", Reason: "is required"}
+	case a.Street == "":
+		return &ValidationError{Field: "street", Reason: "is required"}
+	case a.PostalCode == "":
+		return &ValidationError{Field: "postal_code", Reason: "is required"}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// APIKey — ключ для межсервисных вызовов (склад, служба доставки).
+// Сам ключ не хранится, только его SHA-256 хеш.
+
+type APIKey struct {
+	Id         string     `json:"id"`                     // Публичная часть ключа, по ней ищем запись
+	Name       string     `json:"name"`                   // Для кого выпущен ключ
+	Role       string     `json:"role"`                   // Роль вызывающего (warehouse, courier, admin)
+	Scopes     []string   `json:"scopes,omitempty"`       // Разрешённые действия; пусто — все действия роли
+	Hash       string     `json:"hash,omitempty"`         // Хеш секретной части ключа
+	CreatedAt  time.Time  `json:"created_at"`             // Когда выпущен
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`   // Когда последний раз перевыпущен секрет
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // Когда перестаёт действовать (nil — бессрочный)
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Когда последний раз использовался
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`   // Когда отозван
+}
+
+// NewAPIKey создаёт запись ключа без секрета; хеш заполняет тот, кто генерирует секрет
+
+func NewAPIKey(name, role string, scopes []string, expiresAt *time.Time) *APIKey {
+	return &APIKey{
+		Id:        generateAPIKeyID(),
+		Name:      name,
+		Role:      role,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func generateAPIKeyID() string {
+	return fmt.Sprintf("Key-%d", time.Now().UnixNano())
+}
+
+// Active — ключ не отозван и не истёк на момент now
+
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Redacted возвращает копию без хеша — для ответов API
+
+func (k *APIKey) Redacted() *APIKey {
+	c := *k
+	c.Hash = ""
+	return &c
+}
+
+// реализация интерфейса Storable
+
+func (k *APIKey) GetType() string {
+	return "api_key"
+}
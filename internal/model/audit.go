@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditEntry — запись журнала аудита: кто, когда и что сделал с заказом или пользователем.
+// Личных данных в записи нет, поэтому журнал переживает обезличивание пользователя.
+
+type AuditEntry struct {
+	Id      string    `json:"id"`                // Уникальный номер записи
+	At      time.Time `json:"at"`                // Когда выполнено действие
+	Actor   string    `json:"actor"`             // Кто выполнил: subject вызывающего или "system" для фоновых задач
+	Action  string    `json:"action"`            // Действие, например order:confirm
+	Subject string    `json:"subject"`           // ID заказа или пользователя
+	Details string    `json:"details,omitempty"` // Подробности, например новый статус заказа
+}
+
+// NewAuditEntry создаёт запись журнала с текущим временем
+
+func NewAuditEntry(actor, action, subject, details string) *AuditEntry {
+	return &AuditEntry{
+		Id:      generateAuditID(),
+		At:      time.Now(),
+		Actor:   actor,
+		Action:  action,
+		Subject: subject,
+		Details: details,
+	}
+}
+
+// реализация интерфейса Storable
+
+func (e *AuditEntry) GetType() string {
+	return "audit_entry"
+}
+
+func generateAuditID() string {
+	return fmt.Sprintf("Audit-%d", time.Now().UnixNano())
+}
@@ -0,0 +1,9 @@
+package model
+
+// StoreCounts — сколько записей в хранилище, без мягко удалённых (для метрик)
+type StoreCounts struct {
+	Orders     map[OrderStatus]int // по статусам; статусы без заказов могут отсутствовать
+	Users      int
+	Deliveries int
+	Warehouses int
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -16,13 +17,55 @@ const (
 	OrderCancelled             // Заказ отменен
 )
 
+// String возвращает название статуса (для логов и метрик)
+
+func (s OrderStatus) String() string {
+	switch s {
+	case OrderCreated:
+		return "created"
+	case OrderConfirmed:
+		return "confirmed"
+	case OrderDelivered:
+		return "delivered"
+	case OrderCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ParseOrderStatus разбирает статус по названию ("confirmed") или номеру ("1")
+
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	for st := OrderCreated; st <= OrderCancelled; st++ {
+		if s == st.String() {
+			return st, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= int(OrderCreated) && n <= int(OrderCancelled) {
+		return OrderStatus(n), nil
+	}
+	return 0, fmt.Errorf("unknown order status %q", s)
+}
+
+// причины отмены заказа
+
+const (
+	CancelReasonExpired = "expired" // заказ не был подтвержден складом вовремя
+)
+
 // структура для объекта Заказ
 
 type Order struct {
-	Id        string      `json:"id"`         // Уникальный номер заказа
-	UserID    string      `json:"user_id"`    // Кто сделал заказ
-	Status    OrderStatus `json:"status"`     // Статус заказа (0-3)
-	CreatedAt time.Time   `json:"created_at"` // Когда заказ создан
+	Id           string      `json:"id"`                      // Уникальный номер заказа
+	UserID       string      `json:"user_id"`                 // Кто сделал заказ
+	Status       OrderStatus `json:"status"`                  // Статус заказа (0-3)
+	CreatedAt    time.Time   `json:"created_at"`              // Когда заказ создан
+	CancelReason string      `json:"cancel_reason,omitempty"` // Причина отмены (если заказ отменен)
+	// Копия адреса пользователя на момент заказа: последующие правки профиля её не меняют
+	ShippingAddress *Address   `json:"shipping_address,omitempty"`
+	Version         int64      `json:"version"`              // Растёт при каждой смене статуса, используется для ETag/If-Match
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // Когда запись мягко удалена (nil — активна)
 }
 
 // NewOrder создаёт новый заказ с уникальным ID, привязанный к пользователю userID.
@@ -34,6 +77,7 @@ func NewOrder(newUserId string) *Order {
 		UserID:    newUserId,
 		Status:    OrderStatus(0),
 		CreatedAt: time.Now(),
+		Version:   1,
 	}
 }
 
@@ -1,5 +1,21 @@
 package model
 
+import (
+	"cmp"
+	"strconv"
+)
+
 type Storable interface {
 	GetType() string
 }
+
+// CompareIDs сравнивает ID записей вида kind (GetType) в порядке Repository.Scan:
+// числовые ID доставок и складов — как числа, остальные — побайтно
+func CompareIDs(kind, a, b string) int {
+	if kind == "delivery" || kind == "warehouse" {
+		x, _ := strconv.ParseInt(a, 10, 64)
+		y, _ := strconv.ParseInt(b, 10, 64)
+		return cmp.Compare(x, y)
+	}
+	return cmp.Compare(a, b)
+}
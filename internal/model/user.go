@@ -2,20 +2,35 @@ package model
 
 import (
 	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
 )
 
 type User struct {
-	Id   string `json:"id"`   // Уникальный номер пользователя
-	Name string `json:"name"` // Имя пользователя
+	Id        string     `json:"id"`                   // Уникальный номер пользователя
+	Name      string     `json:"name"`                 // Имя пользователя
+	Email     string     `json:"email,omitempty"`      // Email (уникальный, в нижнем регистре)
+	Phone     string     `json:"phone,omitempty"`      // Телефон в формате +79991234567
+	Addresses []Address  `json:"addresses,omitempty"`  // Сохранённые адреса доставки
+	CreatedAt time.Time  `json:"created_at"`           // Когда пользователь создан
+	UpdatedAt time.Time  `json:"updated_at"`           // Когда профиль последний раз менялся
+	Version   int64      `json:"version"`              // Растёт при каждом изменении, используется для ETag/If-Match
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Когда запись мягко удалена (nil — активна)
+	ErasedAt  *time.Time `json:"erased_at,omitempty"`  // Когда личные данные обезличены по запросу пользователя
 }
 
 // NewUser создаёт нового пользователя с заданным id и именем.
 
 func NewUser(newName string) *User {
+	now := time.Now()
 	return &User{
-		Id:   generateUserID(),
-		Name: newName,
+		Id:        generateUserID(),
+		Name:      newName,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
 	}
 }
 
@@ -23,6 +38,79 @@ func generateUserID() string {
 	return fmt.Sprintf("User-%d", time.Now().UnixNano())
 }
 
+// допустимый телефон: необязательный "+" и 7–15 цифр (E.164)
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// Normalize приводит профиль к каноническому виду перед валидацией и сохранением:
+// обрезает пробелы, переводит email в нижний регистр и выдаёт ID новым адресам
+
+func (u *User) Normalize() {
+	u.Name = strings.TrimSpace(u.Name)
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.Phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(u.Phone))
+	for i := range u.Addresses {
+		u.Addresses[i].normalize()
+		if u.Addresses[i].Id == "" {
+			u.Addresses[i].Id = fmt.Sprintf("Address-%d-%d", time.Now().UnixNano(), i)
+		}
+	}
+}
+
+// Validate проверяет профиль: имя и email обязательны, телефон и адреса — если заданы
+
+func (u *User) Validate() error {
+	if u.Name == "" {
+		return &ValidationError{Field: "name", Reason: "is required"}
+	}
+	if u.Email == "" {
+		return &ValidationError{Field: "email", Reason: "is required"}
+	}
+	if a, err := mail.ParseAddress(u.Email); err != nil || a.Address != u.Email {
+		return &ValidationError{Field: "email", Reason: "is not a valid address"}
+	}
+	if u.Phone != "" && !phonePattern.MatchString(u.Phone) {
+		return &ValidationError{Field: "phone", Reason: "must contain 7-15 digits"}
+	}
+	seen := make(map[string]bool, len(u.Addresses))
+	for i, a := range u.Addresses {
+		if err := a.Validate(); err != nil {
+			err.Field = fmt.Sprintf("addresses[%d].%s", i, err.Field)
+			return err
+		}
+		if seen[a.Id] {
+			return &ValidationError{Field: fmt.Sprintf("addresses[%d].id", i), Reason: "is duplicated"}
+		}
+		seen[a.Id] = true
+	}
+	return nil
+}
+
+// ErasedName — имя, которое остаётся у пользователя после обезличивания
+
+const ErasedName = "erased user"
+
+// Erase обезличивает профиль: стирает имя, контакты и адреса, ID и даты остаются для учёта заказов
+
+func (u *User) Erase(at time.Time) {
+	u.Name = ErasedName
+	u.Email = ""
+	u.Phone = ""
+	u.Addresses = nil
+	u.UpdatedAt = at
+	u.ErasedAt = &at
+}
+
+// Address возвращает сохранённый адрес пользователя по ID
+
+func (u *User) Address(id string) (Address, bool) {
+	for _, a := range u.Addresses {
+		if a.Id == id {
+			return a, true
+		}
+	}
+	return Address{}, false
+}
+
 // реализация интерфейса Storable
 
 func (u *User) GetType() string {
@@ -0,0 +1,21 @@
+package model
+
+import "errors"
+
+// ValidationError — некорректные данные от клиента (HTTP 400, gRPC InvalidArgument)
+
+type ValidationError struct {
+	Field  string // поле, например "email" или "addresses[0].city"
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// ErrEmailTaken — email уже занят другим пользователем (HTTP 409, gRPC AlreadyExists)
+var ErrEmailTaken = errors.New("email already taken")
+
+// ErrVersionConflict — запись изменилась с момента чтения: версия не совпала с ожидаемой
+// (HTTP 412, gRPC Aborted)
+var ErrVersionConflict = errors.New("version conflict")
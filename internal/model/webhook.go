@@ -0,0 +1,172 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Webhook — подписка внешней системы на события заказов: на URL уходят POST-запросы,
+// подписанные HMAC-SHA256 секретом подписки
+
+type Webhook struct {
+	Id        string    `json:"id"`               // Уникальный номер подписки
+	URL       string    `json:"url"`              // Куда отправлять события
+	Events    []string  `json:"events"`           // Типы событий, например order.confirmed
+	Secret    string    `json:"secret,omitempty"` // Ключ подписи; в ответах API виден только при создании
+	CreatedBy string    `json:"created_by"`       // Кто создал подписку
+	CreatedAt time.Time `json:"created_at"`       // Когда создана
+}
+
+// NewWebhook создаёт подписку с текущим временем
+
+func NewWebhook(url string, events []string, secret, createdBy string) *Webhook {
+	return &Webhook{
+		Id:        generateWebhookID(),
+		URL:       url,
+		Events:    events,
+		Secret:    secret,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+func generateWebhookID() string {
+	return fmt.Sprintf("Webhook-%d", time.Now().UnixNano())
+}
+
+// Subscribed — подписка получает события этого типа
+
+func (w *Webhook) Subscribed(eventType string) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+// Redacted возвращает копию без секрета — для ответов API
+
+func (w *Webhook) Redacted() *Webhook {
+	c := *w
+	c.Secret = ""
+	return &c
+}
+
+// Clone возвращает копию, не делящую с оригиналом список событий
+
+func (w *Webhook) Clone() *Webhook {
+	c := *w
+	c.Events = slices.Clone(w.Events)
+	return &c
+}
+
+// реализация интерфейса Storable
+
+func (w *Webhook) GetType() string {
+	return "webhook"
+}
+
+// статусы доставки события подписчику
+const (
+	DeliveryPending   = "pending"   // ждёт первой или повторной попытки
+	DeliveryDelivered = "delivered" // подписчик ответил 2xx
+	DeliveryDead      = "dead"      // попытки кончились; доставка в списке недоставленных, её можно повторить вручную
+)
+
+// WebhookDelivery — доставка одного события одной подписке вместе с историей попыток
+
+type WebhookDelivery struct {
+	Id            string           `json:"id"`                           // Уникальный номер доставки, уходит подписчику для дедупликации
+	WebhookID     string           `json:"webhook_id"`                   // Подписка
+	Event         string           `json:"event"`                        // Тип события
+	OrderID       string           `json:"order_id"`                     // Заказ, о котором событие
+	Payload       json.RawMessage  `json:"payload" swaggertype:"object"` // Тело запроса ровно в том виде, в каком оно подписано
+	Status        string           `json:"status"`                       // pending, delivered или dead
+	Attempts      []WebhookAttempt `json:"attempts,omitempty"`           // Попытки по порядку
+	CreatedAt     time.Time        `json:"created_at"`                   // Когда событие поставлено в очередь
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"`    // Когда следующая попытка (только pending)
+	RequeuedAt    *time.Time       `json:"requeued_at,omitempty"`        // Когда недоставленное событие поставлено на повтор вручную
+	DeliveredAt   *time.Time       `json:"delivered_at,omitempty"`       // Когда подписчик принял событие
+}
+
+// реализация интерфейса Storable
+
+func (d *WebhookDelivery) GetType() string {
+	return "webhook_delivery"
+}
+
+// WebhookAttempt — одна попытка доставки
+
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"` // HTTP-код ответа; 0 — ответа не было
+	Error      string    `json:"error,omitempty"`       // Почему попытка не удалась
+}
+
+// NewWebhookDelivery ставит событие в очередь на немедленную отправку
+
+func NewWebhookDelivery(webhookID, event, orderID string, payload json.RawMessage) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		Id:            generateWebhookDeliveryID(),
+		WebhookID:     webhookID,
+		Event:         event,
+		OrderID:       orderID,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	}
+}
+
+func generateWebhookDeliveryID() string {
+	return fmt.Sprintf("Delivery-%d", time.Now().UnixNano())
+}
+
+// FailedAttempts — сколько попыток не удалось с постановки в очередь (или с последнего ручного повтора)
+
+func (d *WebhookDelivery) FailedAttempts() int {
+	n := 0
+	for _, a := range d.Attempts {
+		if d.RequeuedAt == nil || !a.At.Before(*d.RequeuedAt) {
+			n++
+		}
+	}
+	return n
+}
+
+// Clone возвращает копию, не делящую с оригиналом историю попыток
+
+func (d *WebhookDelivery) Clone() *WebhookDelivery {
+	c := *d
+	c.Attempts = slices.Clone(d.Attempts)
+	return &c
+}
+
+// EraseShippingAddress убирает адрес доставки из снимка заказа в теле запроса (поле order.shipping_address)
+// при обезличивании пользователя. false — адреса в теле нет, сохранять нечего
+
+func (d *WebhookDelivery) EraseShippingAddress() (bool, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(d.Payload, &payload); err != nil {
+		return false, err
+	}
+	raw, ok := payload["order"]
+	if !ok {
+		return false, nil
+	}
+	var order map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &order); err != nil || order == nil {
+		return false, err
+	}
+	if _, ok := order["shipping_address"]; !ok {
+		return false, nil
+	}
+	delete(order, "shipping_address")
+	var err error
+	if payload["order"], err = json.Marshal(order); err != nil {
+		return false, err
+	}
+	if d.Payload, err = json.Marshal(payload); err != nil {
+		return false, err
+	}
+	return true, nil
+}
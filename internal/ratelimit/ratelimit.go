@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"order-ms/internal/auth"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGroup — группа маршрутов, для которой нет своего лимита
+const DefaultGroup = "default"
+
+// AuthGroup — лимит запросов с одного IP до аутентификации. Он ограничивает и запросы с неверным
+// токеном или API-ключом, иначе перебор ключей не упирается ни в какой лимит
+const AuthGroup = "auth"
+
+// Limit — параметры token bucket: Rate токенов в секунду, не больше Burst подряд
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%g:%d", l.Rate, l.Burst)
+}
+
+// Store хранит корзины токенов; Take забирает один токен из корзины key.
+// Если токена нет, возвращает false и время, через которое он появится.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// Decision — результат проверки запроса
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// Limiter применяет лимиты по группам маршрутов к клиентам (API-ключ, пользователь или IP)
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+	logger *slog.Logger
+}
+
+// New создаёт лимитер; лимит группы DefaultGroup применяется ко всем группам без своего лимита
+func New(store Store, limits map[string]Limit, logger *slog.Logger) *Limiter {
+	return &Limiter{store: store, limits: limits, logger: logger}
+}
+
+// Allow решает, пропустить ли запрос клиента client в группу group.
+// Если хранилище недоступно, запрос пропускается: лимитер не должен ронять сервис вместе с Redis.
+func (l *Limiter) Allow(ctx context.Context, group, client string) Decision {
+	limit, ok := l.limits[group]
+	if !ok {
+		if limit, ok = l.limits[DefaultGroup]; !ok {
+			return Decision{Allowed: true}
+		}
+		group = DefaultGroup
+	}
+
+	allowed, retryAfter, err := l.store.Take(ctx, group+":"+client, limit, time.Now())
+	if err != nil {
+		l.logger.WarnContext(ctx, "rate limit store failed", "group", group, "err", err)
+		return Decision{Allowed: true}
+	}
+	return Decision{Allowed: allowed, RetryAfter: retryAfter}
+}
+
+// ClientKey определяет, кого ограничивать: API-ключ, пользователя из токена или, для анонимных запросов, IP
+func ClientKey(ctx context.Context, ip string) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		if id, isKey := strings.CutPrefix(p.Subject, "apikey:"); isKey {
+			return "key:" + id
+		}
+		return "user:" + p.Subject
+	}
+	return IPKey(ip)
+}
+
+// IPKey — ключ клиента по IP, для лимита AuthGroup до аутентификации
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// RetryAfterSeconds — значение заголовка Retry-After (целые секунды, не меньше 1)
+func RetryAfterSeconds(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.FormatInt(secs, 10)
+}
+
+// ParseLimits разбирает лимиты вида "default=20:40,orders=5:10" (группа=запросов в секунду:burst)
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		group, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want group=rate:burst", part)
+		}
+		rateStr, burstStr, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want group=rate:burst", part)
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("rate limit %q: bad rate", part)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("rate limit %q: bad burst", part)
+		}
+		limits[strings.TrimSpace(group)] = Limit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
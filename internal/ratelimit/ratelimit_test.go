@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-ms/internal/auth"
+	"order-ms/internal/logging"
+)
+
+// тест корзины: burst запросов подряд, затем ожидание пополнения
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3} // 2 запроса в секунду, 3 подряд
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := store.Take(context.Background(), "k", limit, now)
+		require.NoError(t, err)
+		assert.True(t, ok, "запрос %d в пределах burst", i+1)
+	}
+	ok, wait, _ := store.Take(context.Background(), "k", limit, now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// другой клиент ограничивается отдельно
+	ok, _, _ = store.Take(context.Background(), "other", limit, now)
+	assert.True(t, ok)
+
+	// через полсекунды появился один токен
+	ok, _, _ = store.Take(context.Background(), "k", limit, now.Add(500*time.Millisecond))
+	assert.True(t, ok)
+	ok, _, _ = store.Take(context.Background(), "k", limit, now.Add(500*time.Millisecond))
+	assert.False(t, ok)
+}
+
+func TestLimiterGroups(t *testing.T) {
+	l := New(NewMemoryStore(), map[string]Limit{
+		DefaultGroup: {Rate: 1, Burst: 1},
+		"orders":     {Rate: 1, Burst: 2},
+	}, logging.Discard())
+	ctx := context.Background()
+
+	assert.True(t, l.Allow(ctx, "orders", "ip:1").Allowed)
+	assert.True(t, l.Allow(ctx, "orders", "ip:1").Allowed)
+	d := l.Allow(ctx, "orders", "ip:1")
+	assert.False(t, d.Allowed)
+	assert.Positive(t, d.RetryAfter)
+
+	// группы без своего лимита делят лимит default
+	assert.True(t, l.Allow(ctx, "users", "ip:1").Allowed)
+	assert.False(t, l.Allow(ctx, "admin", "ip:1").Allowed)
+}
+
+func TestClientKey(t *testing.T) {
+	user := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice"})
+	key := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "apikey:Key-1"})
+
+	assert.Equal(t, "user:alice", ClientKey(user, "10.0.0.1"))
+	assert.Equal(t, "key:Key-1", ClientKey(key, "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", ClientKey(context.Background(), "10.0.0.1"))
+}
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]Limit
+		wantErr bool
+	}{
+		{in: "default=20:40, orders=0.5:5", want: map[string]Limit{"default": {20, 40}, "orders": {0.5, 5}}},
+		{in: "", want: map[string]Limit{}},
+		{in: "orders=5", wantErr: true},
+		{in: "orders=0:5", wantErr: true},
+		{in: "orders=5:0", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLimits(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, "1", RetryAfterSeconds(0))
+	assert.Equal(t, "1", RetryAfterSeconds(200*time.Millisecond))
+	assert.Equal(t, "3", RetryAfterSeconds(2100*time.Millisecond))
+}
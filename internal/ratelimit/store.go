@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryStore держит корзины в памяти процесса (лимиты действуют на одну реплику)
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	cleaned time.Time // когда последний раз чистили простаивающие корзины
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	refill time.Duration // за сколько пустая корзина наполняется целиком
+}
+
+// как часто выбрасывать полные корзины, чтобы map не рос бесконечно
+const cleanupInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.cleaned) > cleanupInterval {
+		s.cleanup(now)
+		s.cleaned = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(limit.Burst),
+			last:   now,
+			refill: time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second)),
+		}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// cleanup удаляет корзины, которые успели бы наполниться целиком: их состояние равно новому
+func (s *MemoryStore) cleanup(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.refill {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// RedisStore держит корзины в Redis, общем для всех реплик
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "ratelimit:"}
+}
+
+// скрипт token bucket: пополняем корзину за прошедшее время и забираем токен атомарно
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1]) -- токенов в миллисекунду
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])  -- миллисекунды
+local b = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, wait}`)
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	res, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Rate/1000, limit.Burst, now.UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
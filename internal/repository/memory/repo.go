@@ -1,11 +1,19 @@
 package memory
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"order-ms/internal/model"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Хранит данные в оперативке
@@ -19,84 +27,168 @@ type MemoryRepo struct {
 	muUsers      sync.Mutex
 	muDeliveries sync.Mutex
 	muWarehouses sync.Mutex
+
+	apiKeys   []*model.APIKey
+	muAPIKeys sync.Mutex
+
+	audit   []*model.AuditEntry // журнал аудита, только добавление
+	muAudit sync.Mutex
+
+	webhooks          []*model.Webhook
+	webhookDeliveries []*model.WebhookDelivery
+	muWebhooks        sync.Mutex // общий для подписок и доставок
+
+	leases   map[string]lease // аренды фоновых задач (в памяти достаточно одной реплики)
+	muLeases sync.Mutex
+
+	dir    string // каталог JSON-файлов с данными
+	logger *slog.Logger
 }
 
-// конструктор
-func NewMemoryRepo() *MemoryRepo {
-	return &MemoryRepo{}
+// аренда: кто держит и до какого момента
+type lease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// конструктор; данные сохраняются в каталог data
+func NewMemoryRepo(logger *slog.Logger) *MemoryRepo {
+	return NewMemoryRepoAt("data", logger)
+}
+
+// NewMemoryRepoAt — то же, но файлы данных лежат в dir
+func NewMemoryRepoAt(dir string, logger *slog.Logger) *MemoryRepo {
+	return &MemoryRepo{leases: make(map[string]lease), dir: dir, logger: logger.With("repo", "memory")}
+}
+
+// file — путь к файлу данных name
+func (r *MemoryRepo) file(name string) string {
+	return filepath.Join(r.dir, name)
 }
 
 //функция, принимает любой объект, реализующий интерфейс
 //проверяет конкретный тип и добавляет его в соответствующий слайс
 
-func (r *MemoryRepo) Save(s model.Storable) error {
+func (r *MemoryRepo) Save(ctx context.Context, s model.Storable) error {
 	switch v := s.(type) {
 	case *model.Order:
 		r.muOrders.Lock()
 		r.orders = append(r.orders, v)
 		r.muOrders.Unlock()
-		if err := r.SaveOrdersToFile("data/orders.json"); err != nil {
-			fmt.Println("Ошибка при сохранении orders", err)
+		if err := r.SaveOrdersToFile(r.file("orders.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save orders to file", "err", err)
 		}
 	case *model.User:
 		r.muUsers.Lock()
+		if r.emailTaken(v.Email, v.Id) {
+			r.muUsers.Unlock()
+			return model.ErrEmailTaken
+		}
 		r.users = append(r.users, v)
 		r.muUsers.Unlock()
-		if err := r.SaveUsersToFile("data/users.json"); err != nil {
-			fmt.Println("Ошибка при сохранении users", err)
+		if err := r.SaveUsersToFile(r.file("users.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save users to file", "err", err)
 		}
 	case *model.Delivery:
 		r.muDeliveries.Lock()
 		r.deliveries = append(r.deliveries, v)
 		r.muDeliveries.Unlock()
-		if err := r.SaveDeliveriesToFile("data/deliveries.json"); err != nil {
-			fmt.Println("Ошибка при сохранении deliveries", err)
+		if err := r.SaveDeliveriesToFile(r.file("deliveries.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save deliveries to file", "err", err)
 		}
 	case *model.Warehouse:
 		r.muWarehouses.Lock()
 		r.warehouses = append(r.warehouses, v)
 		r.muWarehouses.Unlock()
-		if err := r.SaveWarehousesToFile("data/warehouses.json"); err != nil {
-			fmt.Println("Ошибка при сохранении warehouses", err)
+		if err := r.SaveWarehousesToFile(r.file("warehouses.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save warehouses to file", "err", err)
 		}
 	default:
-		fmt.Println("Type: Undefined")
+		return fmt.Errorf("unsupported type %T", s)
 	}
 	return nil
 }
 
 // Обёртки для интерфейса service.Repository
-func (r *MemoryRepo) SaveOrder(order *model.Order) error {
-	r.Save(order)
-	return nil
+func (r *MemoryRepo) SaveOrder(ctx context.Context, order *model.Order) error {
+	return r.Save(ctx, order)
 }
 
-func (r *MemoryRepo) SaveUser(user *model.User) error {
-	r.Save(user)
+// SaveOrders добавляет заказы разом и пишет файл один раз на весь пакет
+func (r *MemoryRepo) SaveOrders(ctx context.Context, orders []*model.Order) error {
+	r.muOrders.Lock()
+	r.orders = append(r.orders, orders...)
+	r.muOrders.Unlock()
+	if err := r.SaveOrdersToFile(r.file("orders.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save orders to file", "err", err)
+	}
 	return nil
 }
 
+func (r *MemoryRepo) SaveUser(ctx context.Context, user *model.User) error {
+	return r.Save(ctx, user)
+}
+
 // методы получения копий слайсов
 
-func (r *MemoryRepo) GetOrders() ([]*model.Order, error) {
+// GetOrders и GetUsers возвращают только не удалённые записи, GetDeleted* — только удалённые
+
+func (r *MemoryRepo) GetOrders(_ context.Context) ([]*model.Order, error) {
+	return r.filterOrders(false), nil
+}
+
+func (r *MemoryRepo) GetDeletedOrders(_ context.Context) ([]*model.Order, error) {
+	return r.filterOrders(true), nil
+}
+
+func (r *MemoryRepo) GetUnconfirmedOrders(_ context.Context, createdBefore time.Time) ([]*model.Order, error) {
 	r.muOrders.Lock()
 	defer r.muOrders.Unlock()
 
-	copiedOrders := make([]*model.Order, len(r.orders))
-	copy(copiedOrders, r.orders)
-	return copiedOrders, nil
+	var orders []*model.Order
+	for _, o := range r.orders {
+		if o.DeletedAt == nil && o.Status == model.OrderCreated && o.CreatedAt.Before(createdBefore) {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
 }
 
-func (r *MemoryRepo) GetUsers() ([]*model.User, error) {
+func (r *MemoryRepo) filterOrders(deleted bool) []*model.Order {
+	r.muOrders.Lock()
+	defer r.muOrders.Unlock()
+
+	copiedOrders := make([]*model.Order, 0, len(r.orders))
+	for _, o := range r.orders {
+		if (o.DeletedAt != nil) == deleted {
+			copiedOrders = append(copiedOrders, o)
+		}
+	}
+	return copiedOrders
+}
+
+func (r *MemoryRepo) GetUsers(_ context.Context) ([]*model.User, error) {
+	return r.filterUsers(false), nil
+}
+
+func (r *MemoryRepo) GetDeletedUsers(_ context.Context) ([]*model.User, error) {
+	return r.filterUsers(true), nil
+}
+
+func (r *MemoryRepo) filterUsers(deleted bool) []*model.User {
 	r.muUsers.Lock()
 	defer r.muUsers.Unlock()
 
-	copiedUsers := make([]*model.User, len(r.users))
-	copy(copiedUsers, r.users)
-	return copiedUsers, nil
+	copiedUsers := make([]*model.User, 0, len(r.users))
+	for _, u := range r.users {
+		if (u.DeletedAt != nil) == deleted {
+			copiedUsers = append(copiedUsers, u)
+		}
+	}
+	return copiedUsers
 }
 
-func (r *MemoryRepo) GetDeliveries() ([]*model.Delivery, error) {
+func (r *MemoryRepo) GetDeliveries(_ context.Context) ([]*model.Delivery, error) {
 	r.muDeliveries.Lock()
 	defer r.muDeliveries.Unlock()
 
@@ -105,7 +197,7 @@ func (r *MemoryRepo) GetDeliveries() ([]*model.Delivery, error) {
 	return copiedDeliveries, nil
 }
 
-func (r *MemoryRepo) GetWarehouses() ([]*model.Warehouse, error) {
+func (r *MemoryRepo) GetWarehouses(_ context.Context) ([]*model.Warehouse, error) {
 	r.muWarehouses.Lock()
 	defer r.muWarehouses.Unlock()
 
@@ -114,11 +206,97 @@ func (r *MemoryRepo) GetWarehouses() ([]*model.Warehouse, error) {
 	return copiedWarehouses, nil
 }
 
+func (r *MemoryRepo) Count(_ context.Context) (*model.StoreCounts, error) {
+	counts := &model.StoreCounts{Orders: make(map[model.OrderStatus]int)}
+
+	r.muOrders.Lock()
+	for _, o := range r.orders {
+		if o.DeletedAt == nil {
+			counts.Orders[o.Status]++
+		}
+	}
+	r.muOrders.Unlock()
+
+	r.muUsers.Lock()
+	for _, u := range r.users {
+		if u.DeletedAt == nil {
+			counts.Users++
+		}
+	}
+	r.muUsers.Unlock()
+
+	r.muDeliveries.Lock()
+	counts.Deliveries = len(r.deliveries)
+	r.muDeliveries.Unlock()
+
+	r.muWarehouses.Lock()
+	counts.Warehouses = len(r.warehouses)
+	r.muWarehouses.Unlock()
+	return counts, nil
+}
+
+// Scan отбирает и сортирует страницу в памяти: MemoryRepo и так держит все записи целиком
+func (r *MemoryRepo) Scan(_ context.Context, kind, after string, limit int) ([]model.Storable, error) {
+	switch kind {
+	case "order":
+		r.muOrders.Lock()
+		defer r.muOrders.Unlock()
+		return scanPage(kind, r.orders, func(o *model.Order) string { return o.Id }, after, limit), nil
+	case "user":
+		r.muUsers.Lock()
+		defer r.muUsers.Unlock()
+		return scanPage(kind, r.users, func(u *model.User) string { return u.Id }, after, limit), nil
+	case "delivery":
+		r.muDeliveries.Lock()
+		defer r.muDeliveries.Unlock()
+		return scanPage(kind, r.deliveries, func(d *model.Delivery) string { return strconv.FormatInt(d.Id, 10) }, after, limit), nil
+	case "warehouse":
+		r.muWarehouses.Lock()
+		defer r.muWarehouses.Unlock()
+		return scanPage(kind, r.warehouses, func(w *model.Warehouse) string { return strconv.FormatInt(w.Id, 10) }, after, limit), nil
+	case "api_key":
+		r.muAPIKeys.Lock()
+		defer r.muAPIKeys.Unlock()
+		return scanPage(kind, r.apiKeys, func(k *model.APIKey) string { return k.Id }, after, limit), nil
+	case "audit_entry":
+		r.muAudit.Lock()
+		defer r.muAudit.Unlock()
+		return scanPage(kind, r.audit, func(e *model.AuditEntry) string { return e.Id }, after, limit), nil
+	case "webhook":
+		r.muWebhooks.Lock()
+		defer r.muWebhooks.Unlock()
+		return scanPage(kind, r.webhooks, func(w *model.Webhook) string { return w.Id }, after, limit), nil
+	case "webhook_delivery":
+		r.muWebhooks.Lock()
+		defer r.muWebhooks.Unlock()
+		return scanPage(kind, r.webhookDeliveries, func(d *model.WebhookDelivery) string { return d.Id }, after, limit), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", kind)
+	}
+}
+
+// scanPage — до limit записей items с ID больше after в порядке model.CompareIDs
+func scanPage[T model.Storable](kind string, items []T, id func(T) string, after string, limit int) []model.Storable {
+	var page []T
+	for _, item := range items {
+		if after == "" || model.CompareIDs(kind, id(item), after) > 0 {
+			page = append(page, item)
+		}
+	}
+	slices.SortFunc(page, func(a, b T) int { return model.CompareIDs(kind, id(a), id(b)) })
+	out := make([]model.Storable, 0, min(limit, len(page)))
+	for _, item := range page[:min(limit, len(page))] {
+		out = append(out, item)
+	}
+	return out
+}
+
 // функции сохранения слайса в json-файл
 
 func (r *MemoryRepo) SaveOrdersToFile(filepath string) error {
-	orders, _ := r.GetOrders()                        // получаем слайс заказов
-	data, err := json.MarshalIndent(orders, "", "  ") // сериализуем в json с отступами и префиксами
+	r.muOrders.Lock()
+	data, err := json.MarshalIndent(r.orders, "", "  ") // сериализуем в json все заказы, включая удалённые
+	r.muOrders.Unlock()
 	if err != nil {
 		return err
 	}
@@ -130,8 +308,9 @@ func (r *MemoryRepo) SaveOrdersToFile(filepath string) error {
 }
 
 func (r *MemoryRepo) SaveUsersToFile(filepath string) error {
-	users, _ := r.GetUsers()
-	data, err := json.MarshalIndent(users, "", "  ")
+	r.muUsers.Lock()
+	data, err := json.MarshalIndent(r.users, "", "  ")
+	r.muUsers.Unlock()
 	if err != nil {
 		return err
 	}
@@ -143,7 +322,7 @@ func (r *MemoryRepo) SaveUsersToFile(filepath string) error {
 }
 
 func (r *MemoryRepo) SaveDeliveriesToFile(filepath string) error {
-	deliveries, _ := r.GetDeliveries()
+	deliveries, _ := r.GetDeliveries(context.Background())
 	data, err := json.MarshalIndent(deliveries, "", "  ")
 	if err != nil {
 		return err
@@ -156,7 +335,7 @@ func (r *MemoryRepo) SaveDeliveriesToFile(filepath string) error {
 }
 
 func (r *MemoryRepo) SaveWarehousesToFile(filepath string) error {
-	warehouses, _ := r.GetWarehouses()
+	warehouses, _ := r.GetWarehouses(context.Background())
 	data, err := json.MarshalIndent(warehouses, "", "  ")
 	if err != nil {
 		return err
@@ -269,152 +448,671 @@ func (r *MemoryRepo) LoadWarehousesFromFile(filepath string) error {
 // функция сохранения данных в файлы
 
 func (r *MemoryRepo) SaveAllData() {
-	err := r.SaveOrdersToFile("data/orders.json")
+	err := r.SaveOrdersToFile(r.file("orders.json"))
+	if err != nil {
+		r.logger.Error("cannot save orders", "err", err)
+	}
+	err = r.SaveUsersToFile(r.file("users.json"))
 	if err != nil {
-		fmt.Println("Не удалось сохранить заказы:", err)
+		r.logger.Error("cannot save users", "err", err)
 	}
-	err = r.SaveUsersToFile("data/users.json")
+	err = r.SaveDeliveriesToFile(r.file("deliveries.json"))
 	if err != nil {
-		fmt.Println("Не удалось сохранить пользователей:", err)
+		r.logger.Error("cannot save deliveries", "err", err)
 	}
-	err = r.SaveDeliveriesToFile("data/deliveries.json")
+	err = r.SaveWarehousesToFile(r.file("warehouses.json"))
 	if err != nil {
-		fmt.Println("Не удалось сохранить доставки:", err)
+		r.logger.Error("cannot save warehouses", "err", err)
 	}
-	err = r.SaveWarehousesToFile("data/warehouses.json")
+	err = r.SaveAPIKeysToFile(r.file("api_keys.json"))
 	if err != nil {
-		fmt.Println("Не удалось сохранить склады:", err)
+		r.logger.Error("cannot save api keys", "err", err)
+	}
+	err = r.SaveAuditToFile(r.file("audit.json"))
+	if err != nil {
+		r.logger.Error("cannot save audit log", "err", err)
+	}
+	err = r.SaveWebhooksToFile(r.file("webhooks.json"), r.file("webhook_deliveries.json"))
+	if err != nil {
+		r.logger.Error("cannot save webhooks", "err", err)
 	}
 }
 
 // функция загрузки данных из файлов
 
 func (r *MemoryRepo) LoadAllData() {
-	err := r.LoadOrdersFromFile("data/orders.json")
+	err := r.LoadOrdersFromFile(r.file("orders.json"))
+	if err != nil {
+		r.logger.Warn("cannot load orders", "err", err)
+	}
+	err = r.LoadUsersFromFile(r.file("users.json"))
+	if err != nil {
+		r.logger.Warn("cannot load users", "err", err)
+	}
+	err = r.LoadDeliveriesFromFile(r.file("deliveries.json"))
+	if err != nil {
+		r.logger.Warn("cannot load deliveries", "err", err)
+	}
+	err = r.LoadWarehousesFromFile(r.file("warehouses.json"))
 	if err != nil {
-		fmt.Println("Не удалось загрузить заказы:", err)
+		r.logger.Warn("cannot load warehouses", "err", err)
 	}
-	err = r.LoadUsersFromFile("data/users.json")
+	err = r.LoadAPIKeysFromFile(r.file("api_keys.json"))
 	if err != nil {
-		fmt.Println("Не удалось загрузить пользователей:", err)
+		r.logger.Warn("cannot load api keys", "err", err)
 	}
-	err = r.LoadDeliveriesFromFile("data/deliveries.json")
+	err = r.LoadAuditFromFile(r.file("audit.json"))
 	if err != nil {
-		fmt.Println("Не удалось загрузить доставки:", err)
+		r.logger.Warn("cannot load audit log", "err", err)
 	}
-	err = r.LoadWarehousesFromFile("data/warehouses.json")
+	err = r.LoadWebhooksFromFile(r.file("webhooks.json"), r.file("webhook_deliveries.json"))
 	if err != nil {
-		fmt.Println("Не удалось загрузить склады:", err)
+		r.logger.Warn("cannot load webhooks", "err", err)
 	}
-	fmt.Println("Данные успешно загружены")
+	r.logger.Info("data loaded")
 }
 
 // метод, который ищет заказ по id
 
-func (r *MemoryRepo) GetOrderByID(id string) (*model.Order, error) {
+func (r *MemoryRepo) GetOrderByID(_ context.Context, id string) (*model.Order, error) {
 	r.muOrders.Lock()
 	defer r.muOrders.Unlock()
 	for _, order := range r.orders {
-		if order.Id == id {
+		if order.Id == id && order.DeletedAt == nil {
 			return order, nil
 		}
 	}
 	return nil, nil
 }
 
-// методы обновления статуса заказа
+// методы обновления статуса заказа. expectedVersion > 0 — заказ должен быть в этой версии,
+// иначе model.ErrVersionConflict; при успехе версия увеличивается
 
-func (r *MemoryRepo) ConfirmOrder(orderId string) (bool, error) {
-	r.muOrders.Lock()
-	defer r.muOrders.Unlock()
+func (r *MemoryRepo) ConfirmOrder(_ context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.changeOrderStatus(orderId, expectedVersion, func(o *model.Order) bool {
+		if o.Status != model.OrderCreated {
+			return false
+		}
+		o.Status = model.OrderConfirmed
+		return true
+	})
+}
 
-	for _, order := range r.orders {
-		if order.Id == orderId && order.Status == model.OrderCreated {
-			order.Status = model.OrderConfirmed
-			return true, nil
+func (r *MemoryRepo) DeliverOrder(_ context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.changeOrderStatus(orderId, expectedVersion, func(o *model.Order) bool {
+		if o.Status != model.OrderConfirmed {
+			return false
 		}
-	}
-	return false, nil
+		o.Status = model.OrderDelivered
+		return true
+	})
 }
 
-func (r *MemoryRepo) DeliverOrder(orderId string) (bool, error) {
-	r.muOrders.Lock()
-	defer r.muOrders.Unlock()
+func (r *MemoryRepo) CancelOrder(_ context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.changeOrderStatus(orderId, expectedVersion, func(o *model.Order) bool {
+		if o.Status != model.OrderCreated && o.Status != model.OrderConfirmed {
+			return false
+		}
+		o.Status = model.OrderCancelled
+		return true
+	})
+}
 
-	for _, order := range r.orders {
-		if order.Id == orderId && order.Status == model.OrderConfirmed {
-			order.Status = model.OrderDelivered
-			return true, nil
+// ExpireOrder отменяет заказ с причиной "expired", только если он всё ещё в статусе "создан"
+
+func (r *MemoryRepo) ExpireOrder(_ context.Context, orderId string) (bool, error) {
+	return r.changeOrderStatus(orderId, 0, func(o *model.Order) bool {
+		if o.Status != model.OrderCreated {
+			return false
 		}
-	}
-	return false, nil
+		o.Status = model.OrderCancelled
+		o.CancelReason = model.CancelReasonExpired
+		return true
+	})
 }
 
-func (r *MemoryRepo) CancelOrder(orderId string) (bool, error) {
+// changeOrderStatus применяет переход apply к заказу под блокировкой; apply возвращает false,
+// если переход из текущего статуса недопустим
+
+func (r *MemoryRepo) changeOrderStatus(orderId string, expectedVersion int64, apply func(*model.Order) bool) (bool, error) {
 	r.muOrders.Lock()
 	defer r.muOrders.Unlock()
 
 	for _, order := range r.orders {
-		if order.Id == orderId && (order.Status == model.OrderCreated || order.Status == model.OrderConfirmed) {
-			order.Status = model.OrderCancelled
-			return true, nil
+		if order.Id != orderId || order.DeletedAt != nil {
+			continue
+		}
+		if expectedVersion > 0 && order.Version != expectedVersion {
+			return false, model.ErrVersionConflict
 		}
+		if !apply(order) {
+			return false, nil
+		}
+		order.Version++
+		return true, nil
 	}
 	return false, nil
 }
 
-// метод удаления заказа
+// мягкое удаление заказа: запись остаётся с отметкой deleted_at и пропадает из выборок
 
-func (r *MemoryRepo) DeleteOrder(orderId string) (bool, error) {
+func (r *MemoryRepo) DeleteOrder(ctx context.Context, orderId string) (bool, error) {
+	return r.setOrderDeleted(ctx, orderId, true)
+}
+
+// RestoreOrder возвращает мягко удалённый заказ
+
+func (r *MemoryRepo) RestoreOrder(ctx context.Context, orderId string) (bool, error) {
+	return r.setOrderDeleted(ctx, orderId, false)
+}
+
+func (r *MemoryRepo) setOrderDeleted(ctx context.Context, orderId string, deleted bool) (bool, error) {
 	r.muOrders.Lock()
-	for i, order := range r.orders {
-		if order.Id == orderId {
-			r.orders = append(r.orders[:i], r.orders[i+1:]...)
-			r.muOrders.Unlock()
-			if err := r.SaveOrdersToFile("data/orders.json"); err != nil {
-				fmt.Println("Ошибка при сохранении заказов:", err)
+	var found bool
+	for _, order := range r.orders {
+		if order.Id == orderId && (order.DeletedAt != nil) != deleted {
+			order.DeletedAt = nil
+			if deleted {
+				now := time.Now()
+				order.DeletedAt = &now
 			}
-			return true, nil
+			order.Version++
+			found = true
+			break
 		}
 	}
-	return false, nil
+	r.muOrders.Unlock()
+	if !found {
+		return false, nil
+	}
+	if err := r.SaveOrdersToFile(r.file("orders.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save orders to file", "err", err)
+	}
+	return true, nil
 }
 
-func (r *MemoryRepo) GetUserByID(id string) (*model.User, error) {
+func (r *MemoryRepo) GetUserByID(_ context.Context, id string) (*model.User, error) {
 	r.muUsers.Lock()
 	defer r.muUsers.Unlock()
 	for _, user := range r.users {
-		if user.Id == id {
+		if user.Id == id && user.DeletedAt == nil {
 			return user, nil
 		}
 	}
 	return nil, nil
 }
 
-func (r *MemoryRepo) UpdateUserName(id, name string) (bool, error) {
+// UpdateUser заменяет профиль пользователя (всё, кроме ID и даты создания).
+// expectedVersion > 0 — сравнение с текущей версией (model.ErrVersionConflict при расхождении);
+// новая версия записывается в u.Version
+func (r *MemoryRepo) UpdateUser(ctx context.Context, u *model.User, expectedVersion int64) (bool, error) {
 	r.muUsers.Lock()
-	defer r.muUsers.Unlock()
+	var found bool
+	for _, user := range r.users {
+		if user.Id == u.Id && user.DeletedAt == nil {
+			if expectedVersion > 0 && user.Version != expectedVersion {
+				r.muUsers.Unlock()
+				return false, model.ErrVersionConflict
+			}
+			if r.emailTaken(u.Email, u.Id) {
+				r.muUsers.Unlock()
+				return false, model.ErrEmailTaken
+			}
+			user.Name = u.Name
+			user.Email = u.Email
+			user.Phone = u.Phone
+			user.Addresses = append([]model.Address(nil), u.Addresses...)
+			user.UpdatedAt = u.UpdatedAt
+			user.Version++
+			u.Version = user.Version
+			found = true
+			break
+		}
+	}
+	r.muUsers.Unlock()
+	if !found {
+		return false, nil // пользователь не найден
+	}
+	if err := r.SaveUsersToFile(r.file("users.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save users to file", "err", err)
+	}
+	return true, nil
+}
 
+// emailTaken — занят ли email другим пользователем (в том числе удалённым, чтобы его можно было
+// восстановить); вызывается под muUsers
+func (r *MemoryRepo) emailTaken(email, exceptID string) bool {
+	if email == "" {
+		return false
+	}
 	for _, user := range r.users {
-		if user.Id == id {
-			user.Name = name
-			return true, nil
+		if user.Id != exceptID && strings.EqualFold(user.Email, email) {
+			return true
 		}
 	}
-	return false, nil // пользователь не найден
+	return false
 }
 
-func (r *MemoryRepo) DeleteUser(id string) (bool, error) {
+// мягкое удаление пользователя
+
+func (r *MemoryRepo) DeleteUser(ctx context.Context, id string) (bool, error) {
+	return r.setUserDeleted(ctx, id, true)
+}
+
+// RestoreUser возвращает мягко удалённого пользователя
+
+func (r *MemoryRepo) RestoreUser(ctx context.Context, id string) (bool, error) {
+	return r.setUserDeleted(ctx, id, false)
+}
+
+func (r *MemoryRepo) setUserDeleted(ctx context.Context, id string, deleted bool) (bool, error) {
 	r.muUsers.Lock()
-	for i, user := range r.users {
-		if user.Id == id {
-			r.users = append(r.users[:i], r.users[i+1:]...)
-			r.muUsers.Unlock()
-			if err := r.SaveUsersToFile("data/users.json"); err != nil {
-				fmt.Println("Ошибка при сохранении пользователей:", err)
+	var found bool
+	for _, user := range r.users {
+		if user.Id == id && (user.DeletedAt != nil) != deleted {
+			user.DeletedAt = nil
+			if deleted {
+				now := time.Now()
+				user.DeletedAt = &now
 			}
-			return true, nil
+			user.Version++
+			found = true
+			break
 		}
 	}
-	return false, nil
+	r.muUsers.Unlock()
+	if !found {
+		return false, nil
+	}
+	if err := r.SaveUsersToFile(r.file("users.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save users to file", "err", err)
+	}
+	return true, nil
+}
+
+// EraseUser обезличивает пользователя и стирает адреса в его заказах, доставках и телах доставок вебхуков
+
+func (r *MemoryRepo) EraseUser(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.muUsers.Lock()
+	var found bool
+	for _, user := range r.users {
+		if user.Id == id && user.DeletedAt == nil {
+			user.Erase(at)
+			user.Version++
+			found = true
+			break
+		}
+	}
+	r.muUsers.Unlock()
+	if !found {
+		return false, nil
+	}
+
+	owned := make(map[string]bool)
+	r.muOrders.Lock()
+	for _, order := range r.orders {
+		if order.UserID == id {
+			order.ShippingAddress = nil
+			owned[order.Id] = true
+		}
+	}
+	r.muOrders.Unlock()
+
+	r.muDeliveries.Lock()
+	for _, delivery := range r.deliveries {
+		if delivery.UserId == id {
+			delivery.Address = ""
+		}
+	}
+	r.muDeliveries.Unlock()
+
+	// снимки заказов в телах доставок вебхуков
+	r.muWebhooks.Lock()
+	var scrubbed bool
+	var scrubErr error
+	for _, d := range r.webhookDeliveries {
+		if !owned[d.OrderID] {
+			continue
+		}
+		ok, err := d.EraseShippingAddress()
+		if err != nil {
+			scrubErr = errors.Join(scrubErr, fmt.Errorf("erase webhook delivery %s: %w", d.Id, err))
+		}
+		scrubbed = scrubbed || ok
+	}
+	r.muWebhooks.Unlock()
+	if scrubbed {
+		r.saveWebhooks(ctx)
+	}
+
+	if err := r.SaveUsersToFile(r.file("users.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save users to file", "err", err)
+	}
+	if err := r.SaveOrdersToFile(r.file("orders.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save orders to file", "err", err)
+	}
+	if err := r.SaveDeliveriesToFile(r.file("deliveries.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save deliveries to file", "err", err)
+	}
+	return scrubErr == nil, scrubErr
+}
+
+// PurgeDeleted окончательно удаляет пользователей и заказы, мягко удалённые раньше before
+
+func (r *MemoryRepo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	expired := func(deletedAt *time.Time) bool { return deletedAt != nil && deletedAt.Before(before) }
+
+	r.muOrders.Lock()
+	n := len(r.orders)
+	r.orders = slices.DeleteFunc(r.orders, func(o *model.Order) bool { return expired(o.DeletedAt) })
+	orders := n - len(r.orders)
+	// пользователи, у которых остались заказы, не удаляются, как и в Postgres и Mongo
+	owners := make(map[string]bool, len(r.orders))
+	for _, o := range r.orders {
+		owners[o.UserID] = true
+	}
+	r.muOrders.Unlock()
+
+	r.muUsers.Lock()
+	n = len(r.users)
+	r.users = slices.DeleteFunc(r.users, func(u *model.User) bool { return expired(u.DeletedAt) && !owners[u.Id] })
+	users := n - len(r.users)
+	r.muUsers.Unlock()
+
+	if orders > 0 {
+		if err := r.SaveOrdersToFile(r.file("orders.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save orders to file", "err", err)
+		}
+	}
+	if users > 0 {
+		if err := r.SaveUsersToFile(r.file("users.json")); err != nil {
+			r.logger.ErrorContext(ctx, "cannot save users to file", "err", err)
+		}
+	}
+	return orders + users, nil
+}
+
+// AcquireLease захватывает или продлевает аренду name для owner на время ttl.
+// Возвращает false, если аренду держит кто-то другой и она ещё не истекла.
+
+func (r *MemoryRepo) AcquireLease(_ context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	r.muLeases.Lock()
+	defer r.muLeases.Unlock()
+
+	now := time.Now()
+	if l, ok := r.leases[name]; ok && l.owner != owner && now.Before(l.expiresAt) {
+		return false, nil
+	}
+	r.leases[name] = lease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// API-ключи: в слайсе храним копии, чтобы вызывающий не менял запись в обход мьютекса
+
+func (r *MemoryRepo) SaveAPIKey(ctx context.Context, key *model.APIKey) error {
+	c := *key
+	r.muAPIKeys.Lock()
+	replaced := false
+	for i, k := range r.apiKeys {
+		if k.Id == key.Id {
+			r.apiKeys[i] = &c
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.apiKeys = append(r.apiKeys, &c)
+	}
+	r.muAPIKeys.Unlock()
+
+	if err := r.SaveAPIKeysToFile(r.file("api_keys.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save api keys to file", "err", err)
+	}
+	return nil
+}
+
+func (r *MemoryRepo) GetAPIKeys(_ context.Context) ([]*model.APIKey, error) {
+	r.muAPIKeys.Lock()
+	defer r.muAPIKeys.Unlock()
+
+	copiedKeys := make([]*model.APIKey, 0, len(r.apiKeys))
+	for _, k := range r.apiKeys {
+		c := *k
+		copiedKeys = append(copiedKeys, &c)
+	}
+	return copiedKeys, nil
+}
+
+func (r *MemoryRepo) GetAPIKeyByID(_ context.Context, id string) (*model.APIKey, error) {
+	r.muAPIKeys.Lock()
+	defer r.muAPIKeys.Unlock()
+	for _, k := range r.apiKeys {
+		if k.Id == id {
+			c := *k
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepo) TouchAPIKey(_ context.Context, id string, usedAt time.Time) error {
+	r.muAPIKeys.Lock()
+	defer r.muAPIKeys.Unlock()
+	for _, k := range r.apiKeys {
+		if k.Id == id {
+			k.LastUsedAt = &usedAt
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepo) SaveAPIKeysToFile(filepath string) error {
+	keys, _ := r.GetAPIKeys(context.Background())
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, data, 0600) // в файле хеши ключей, читать его должен только сервис
+}
+
+func (r *MemoryRepo) LoadAPIKeysFromFile(filepath string) error {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	var loadedKeys []*model.APIKey
+	if err := json.Unmarshal(data, &loadedKeys); err != nil {
+		return err
+	}
+
+	r.muAPIKeys.Lock()
+	r.apiKeys = loadedKeys
+	r.muAPIKeys.Unlock()
+	return nil
+}
+
+// журнал аудита: записи только добавляются, поэтому отдаём указатели без копирования
+
+func (r *MemoryRepo) SaveAuditEntry(ctx context.Context, e *model.AuditEntry) error {
+	r.muAudit.Lock()
+	r.audit = append(r.audit, e)
+	r.muAudit.Unlock()
+
+	if err := r.SaveAuditToFile(r.file("audit.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save audit log to file", "err", err)
+	}
+	return nil
+}
+
+func (r *MemoryRepo) GetAuditEntries(_ context.Context, subjects []string) ([]*model.AuditEntry, error) {
+	r.muAudit.Lock()
+	defer r.muAudit.Unlock()
+
+	entries := make([]*model.AuditEntry, 0)
+	for _, e := range r.audit {
+		if slices.Contains(subjects, e.Subject) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *MemoryRepo) SaveAuditToFile(filepath string) error {
+	r.muAudit.Lock()
+	data, err := json.MarshalIndent(r.audit, "", "  ")
+	r.muAudit.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, data, 0644)
+}
+
+func (r *MemoryRepo) LoadAuditFromFile(filepath string) error {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	var loadedEntries []*model.AuditEntry
+	if err := json.Unmarshal(data, &loadedEntries); err != nil {
+		return err
+	}
+
+	r.muAudit.Lock()
+	r.audit = loadedEntries
+	r.muAudit.Unlock()
+	return nil
+}
+
+// вебхуки: как и API-ключи, храним и отдаём копии
+
+func (r *MemoryRepo) SaveWebhook(ctx context.Context, w *model.Webhook) error {
+	c := w.Clone()
+	r.muWebhooks.Lock()
+	if i := slices.IndexFunc(r.webhooks, func(x *model.Webhook) bool { return x.Id == w.Id }); i >= 0 {
+		r.webhooks[i] = c
+	} else {
+		r.webhooks = append(r.webhooks, c)
+	}
+	r.muWebhooks.Unlock()
+	r.saveWebhooks(ctx)
+	return nil
+}
+
+func (r *MemoryRepo) GetWebhooks(_ context.Context) ([]*model.Webhook, error) {
+	r.muWebhooks.Lock()
+	defer r.muWebhooks.Unlock()
+	res := make([]*model.Webhook, 0, len(r.webhooks))
+	for _, w := range r.webhooks {
+		res = append(res, w.Clone())
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) GetWebhookByID(_ context.Context, id string) (*model.Webhook, error) {
+	r.muWebhooks.Lock()
+	defer r.muWebhooks.Unlock()
+	for _, w := range r.webhooks {
+		if w.Id == id {
+			return w.Clone(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	r.muWebhooks.Lock()
+	n := len(r.webhooks)
+	r.webhooks = slices.DeleteFunc(r.webhooks, func(w *model.Webhook) bool { return w.Id == id })
+	deleted := len(r.webhooks) < n
+	r.muWebhooks.Unlock()
+	if deleted {
+		r.saveWebhooks(ctx)
+	}
+	return deleted, nil
+}
+
+func (r *MemoryRepo) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	c := d.Clone()
+	r.muWebhooks.Lock()
+	if i := slices.IndexFunc(r.webhookDeliveries, func(x *model.WebhookDelivery) bool { return x.Id == d.Id }); i >= 0 {
+		r.webhookDeliveries[i] = c
+	} else {
+		r.webhookDeliveries = append(r.webhookDeliveries, c)
+	}
+	r.muWebhooks.Unlock()
+	r.saveWebhooks(ctx)
+	return nil
+}
+
+func (r *MemoryRepo) GetWebhookDeliveries(_ context.Context, webhookID, status string) ([]*model.WebhookDelivery, error) {
+	r.muWebhooks.Lock()
+	defer r.muWebhooks.Unlock()
+	res := make([]*model.WebhookDelivery, 0)
+	for _, d := range r.webhookDeliveries {
+		if (webhookID == "" || d.WebhookID == webhookID) && (status == "" || d.Status == status) {
+			res = append(res, d.Clone())
+		}
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) GetWebhookDeliveryByID(_ context.Context, id string) (*model.WebhookDelivery, error) {
+	r.muWebhooks.Lock()
+	defer r.muWebhooks.Unlock()
+	for _, d := range r.webhookDeliveries {
+		if d.Id == id {
+			return d.Clone(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepo) saveWebhooks(ctx context.Context) {
+	if err := r.SaveWebhooksToFile(r.file("webhooks.json"), r.file("webhook_deliveries.json")); err != nil {
+		r.logger.ErrorContext(ctx, "cannot save webhooks to file", "err", err)
+	}
+}
+
+func (r *MemoryRepo) SaveWebhooksToFile(webhooksPath, deliveriesPath string) error {
+	r.muWebhooks.Lock()
+	webhooks, err := json.MarshalIndent(r.webhooks, "", "  ")
+	if err != nil {
+		r.muWebhooks.Unlock()
+		return err
+	}
+	deliveries, err := json.MarshalIndent(r.webhookDeliveries, "", "  ")
+	r.muWebhooks.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(webhooksPath, webhooks, 0600); err != nil { // в файле секреты подписей
+		return err
+	}
+	return os.WriteFile(deliveriesPath, deliveries, 0644)
+}
+
+func (r *MemoryRepo) LoadWebhooksFromFile(webhooksPath, deliveriesPath string) error {
+	var webhooks []*model.Webhook
+	var deliveries []*model.WebhookDelivery
+	for _, f := range []struct {
+		path string
+		dst  any
+	}{{webhooksPath, &webhooks}, {deliveriesPath, &deliveries}} {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, f.dst); err != nil {
+			return err
+		}
+	}
+
+	r.muWebhooks.Lock()
+	r.webhooks = webhooks
+	r.webhookDeliveries = deliveries
+	r.muWebhooks.Unlock()
+	return nil
 }
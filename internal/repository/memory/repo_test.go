@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// тест аренды: вторая реплика не может захватить чужую действующую аренду
+func TestAcquireLease(t *testing.T) {
+	repo := NewMemoryRepo(logging.Discard())
+	ctx := context.Background()
+
+	ok, err := repo.AcquireLease(ctx, "job", "replica-1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, _ = repo.AcquireLease(ctx, "job", "replica-2", time.Minute)
+	assert.False(t, ok, "lease is held by replica-1")
+
+	ok, _ = repo.AcquireLease(ctx, "job", "replica-1", time.Minute)
+	assert.True(t, ok, "owner can renew its lease")
+
+	// истекшую аренду может забрать другая реплика
+	ok, _ = repo.AcquireLease(ctx, "job", "replica-1", -time.Second)
+	assert.True(t, ok)
+	ok, _ = repo.AcquireLease(ctx, "job", "replica-2", time.Minute)
+	assert.True(t, ok)
+}
+
+// тест постраничного чтения: удалённые записи включены, числовые ID сравниваются как числа
+func TestScan(t *testing.T) {
+	repo := NewMemoryRepoAt(t.TempDir(), logging.Discard())
+	ctx := context.Background()
+	for _, id := range []string{"b", "c", "a"} {
+		require.NoError(t, repo.SaveUser(ctx, &model.User{Id: id}))
+	}
+	_, err := repo.DeleteUser(ctx, "c")
+	require.NoError(t, err)
+	for _, id := range []int64{10, 9, 100} {
+		require.NoError(t, repo.Save(ctx, &model.Warehouse{Id: id}))
+	}
+
+	ids := func(page []model.Storable) []string {
+		var out []string
+		for _, s := range page {
+			switch v := s.(type) {
+			case *model.User:
+				out = append(out, v.Id)
+			case *model.Warehouse:
+				out = append(out, strconv.FormatInt(v.Id, 10))
+			}
+		}
+		return out
+	}
+	page, err := repo.Scan(ctx, "user", "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(page))
+	page, err = repo.Scan(ctx, "user", "b", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(page))
+	page, err = repo.Scan(ctx, "warehouse", "9", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10", "100"}, ids(page))
+
+	_, err = repo.Scan(ctx, "product", "", 10)
+	assert.Error(t, err)
+}
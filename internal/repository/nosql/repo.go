@@ -1,49 +1,110 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log/slog"
 	"order-ms/internal/model"
+	"order-ms/internal/tracing"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Repo реализует интерфейс Repository
-type Repo struct{}
+type Repo struct {
+	logger *slog.Logger
+}
 
 // Создаем "конструктор"
-func NewRepository() *Repo {
-	return &Repo{}
+func NewRepository(logger *slog.Logger) *Repo {
+	return &Repo{logger: logger.With("repo", "mongo")}
 }
 
-// LogEvent сохраняет событие с TTL в Redis
-func LogEvent(key, value string, ttl time.Duration) error {
-	err := RedisClient.Set(Ctx, key, value, ttl).Err()
-	if err != nil {
+// LogEvent сохраняет событие с TTL в Redis.
+// Рядом с событием кладётся traceparent текущего спана, чтобы потребитель мог продолжить трейс.
+func LogEvent(ctx context.Context, key, value string, ttl time.Duration) error {
+	pipe := RedisClient.TxPipeline()
+	pipe.Set(ctx, key, value, ttl)
+	if traceparent := tracing.Inject(ctx)["traceparent"]; traceparent != "" {
+		pipe.Set(ctx, key+":traceparent", traceparent, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("не удалось записать в Redis: %w", err)
 	}
 	return nil
 }
 
-func (r *Repo) Save(s model.Storable) error {
+// traceQuery записывает коллекцию и запрос Mongo в спан трейсинга (filter == nil — запрос без фильтра, например insert)
+func traceQuery(ctx context.Context, coll *mongo.Collection, op string, filter any) {
+	tracing.SetCollection(ctx, coll.Name())
+	statement := op
+	if filter != nil {
+		// в трейс попадают только имена полей и операторы: значения могут быть личными данными
+		if b, err := bson.MarshalExtJSON(bson.M{"q": redact(filter)}, false, false); err == nil {
+			statement += " " + strings.TrimSuffix(strings.TrimPrefix(string(b), `{"q":`), "}")
+		}
+	}
+	tracing.SetStatement(ctx, statement)
+}
+
+// redact заменяет значения в фильтре или конвейере на "?", сохраняя структуру; null остаётся как есть
+func redact(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bson.M:
+		out := make(bson.M, len(v))
+		for k, val := range v {
+			out[k] = redact(val)
+		}
+		return out
+	case bson.D:
+		out := make(bson.D, len(v))
+		for i, e := range v {
+			out[i] = bson.E{Key: e.Key, Value: redact(e.Value)}
+		}
+		return out
+	case mongo.Pipeline:
+		out := make(bson.A, len(v))
+		for i, stage := range v {
+			out[i] = redact(stage)
+		}
+		return out
+	case bson.A:
+		out := make(bson.A, len(v))
+		for i, e := range v {
+			out[i] = redact(e)
+		}
+		return out
+	default:
+		return "?"
+	}
+}
+
+func (r *Repo) Save(ctx context.Context, s model.Storable) error {
 	switch v := s.(type) {
 	case *model.Order:
-		return r.SaveOrder(v)
+		return r.SaveOrder(ctx, v)
 	case *model.User:
-		return r.SaveUser(v)
+		return r.SaveUser(ctx, v)
 	case *model.Delivery:
-		return r.SaveDelivery(v)
+		return r.SaveDelivery(ctx, v)
 	case *model.Warehouse:
-		return r.SaveWarehouse(v)
+		return r.SaveWarehouse(ctx, v)
 	default:
 		return fmt.Errorf("unsupported type")
 	}
 }
 
 // Сохраняем новый заказ в MongoDB
-func (r *Repo) SaveOrder(order *model.Order) error {
-	_, err := OrderCollection.InsertOne(Ctx, order)
+func (r *Repo) SaveOrder(ctx context.Context, order *model.Order) error {
+	traceQuery(ctx, OrderCollection, "insertOne", nil)
+	_, err := OrderCollection.InsertOne(ctx, order)
 	if err != nil {
 		return fmt.Errorf("не удалось сохранить заказ: %w", err)
 	}
@@ -51,23 +112,55 @@ func (r *Repo) SaveOrder(order *model.Order) error {
 	// логируем событие в Redis
 	key := fmt.Sprintf("order:%s:status", order.Id)
 	value := strconv.Itoa(int(order.Status)) // конвертируем OrderStatus в строку числа
-	if err := LogEvent(key, value, 24*time.Hour); err != nil {
-		fmt.Println("Ошибка логирования создания нового заказа в Redis:", err)
+	if err := LogEvent(ctx, key, value, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return nil
 }
 
-// получаем все заказы из MongoDB
-func (r *Repo) GetOrders() ([]*model.Order, error) {
-	cursor, err := OrderCollection.Find(Ctx, bson.M{})
+// Сохраняем пакет заказов одним InsertMany (без транзакции: при ошибке вставленные до неё заказы остаются);
+// события в Redis для импорта не пишем
+func (r *Repo) SaveOrders(ctx context.Context, orders []*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	docs := make([]any, len(orders))
+	for i, o := range orders {
+		docs[i] = o
+	}
+	traceQuery(ctx, OrderCollection, "insertMany", nil)
+	if _, err := OrderCollection.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("не удалось сохранить заказы: %w", err)
+	}
+	return nil
+}
+
+// получаем все не удалённые заказы из MongoDB
+func (r *Repo) GetOrders(ctx context.Context) ([]*model.Order, error) {
+	return r.findOrders(ctx, bson.M{"deletedat": nil}) // null или отсутствующее поле — заказ не удалён
+}
+
+// получаем мягко удалённые заказы
+func (r *Repo) GetDeletedOrders(ctx context.Context) ([]*model.Order, error) {
+	return r.findOrders(ctx, bson.M{"deletedat": bson.M{"$ne": nil}})
+}
+
+// получаем заказы, которые так и не были подтверждены складом до createdBefore
+func (r *Repo) GetUnconfirmedOrders(ctx context.Context, createdBefore time.Time) ([]*model.Order, error) {
+	return r.findOrders(ctx, bson.M{"status": model.OrderCreated, "createdat": bson.M{"$lt": createdBefore}, "deletedat": nil})
+}
+
+func (r *Repo) findOrders(ctx context.Context, filter bson.M) ([]*model.Order, error) {
+	traceQuery(ctx, OrderCollection, "find", filter)
+	cursor, err := OrderCollection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(Ctx)
+	defer cursor.Close(ctx)
 
 	var orders []*model.Order
-	for cursor.Next(Ctx) {
+	for cursor.Next(ctx) {
 		var order model.Order
 		if err := cursor.Decode(&order); err != nil {
 			return nil, err
@@ -79,9 +172,11 @@ func (r *Repo) GetOrders() ([]*model.Order, error) {
 }
 
 // получаем заказ по ID из MongoDB
-func (r *Repo) GetOrderByID(id string) (*model.Order, error) {
+func (r *Repo) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
 	var order model.Order
-	err := OrderCollection.FindOne(Ctx, bson.M{"id": id}).Decode(&order)
+	filter := bson.M{"id": id, "deletedat": nil}
+	traceQuery(ctx, OrderCollection, "findOne", filter)
+	err := OrderCollection.FindOne(ctx, filter).Decode(&order)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // заказ не найден
@@ -92,124 +187,217 @@ func (r *Repo) GetOrderByID(id string) (*model.Order, error) {
 }
 
 // подтверждаем заказ в MongoDB
-func (r *Repo) ConfirmOrder(orderId string) (bool, error) {
-	filter := bson.M{"id": orderId, "status": model.OrderCreated} // ищем только созданный заказ
-	update := bson.M{"$set": bson.M{"status": model.OrderConfirmed}}
+func (r *Repo) ConfirmOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
+	filter := bson.M{"id": orderId, "status": model.OrderCreated, "deletedat": nil} // ищем только созданный заказ
+	update := bson.M{"$set": bson.M{"status": model.OrderConfirmed}, "$inc": bson.M{"version": 1}}
+	withVersion(filter, expectedVersion)
 
-	result, err := OrderCollection.UpdateOne(Ctx, filter, update)
+	traceQuery(ctx, OrderCollection, "updateOne", filter)
+	result, err := OrderCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return false, fmt.Errorf("не удалось подтвердить заказ: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		// либо заказа нет, либо статус не Created
-		return false, nil
+		// либо заказа нет, либо статус не Created, либо версия другая
+		return false, versionConflict(ctx, OrderCollection, orderId, expectedVersion)
 	}
 
 	// логируем событие в Redis с TTL
 	key := fmt.Sprintf("order:%s:status", orderId)
 	value := strconv.Itoa(int(model.OrderConfirmed)) // конвертируем в строку
-	if err := RedisClient.Set(Ctx, key, value, 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования подтверждения заказа в Redis:", err)
+	if err := LogEvent(ctx, key, value, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return true, nil
 }
 
 // отмечаем заказ как доставленный в MongoDB
-func (r *Repo) DeliverOrder(orderId string) (bool, error) {
-	filter := bson.M{"id": orderId, "status": model.OrderConfirmed} // ищем только подтверждённый заказ
-	update := bson.M{"$set": bson.M{"status": model.OrderDelivered}}
+func (r *Repo) DeliverOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
+	filter := bson.M{"id": orderId, "status": model.OrderConfirmed, "deletedat": nil} // ищем только подтверждённый заказ
+	update := bson.M{"$set": bson.M{"status": model.OrderDelivered}, "$inc": bson.M{"version": 1}}
+	withVersion(filter, expectedVersion)
 
-	result, err := OrderCollection.UpdateOne(Ctx, filter, update)
+	traceQuery(ctx, OrderCollection, "updateOne", filter)
+	result, err := OrderCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return false, fmt.Errorf("не удалось отметить заказ как доставленный: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		// либо заказа нет, либо статус не Confirmed
-		return false, nil
+		// либо заказа нет, либо статус не Confirmed, либо версия другая
+		return false, versionConflict(ctx, OrderCollection, orderId, expectedVersion)
 	}
 
 	// логируем событие в Redis
 	key := fmt.Sprintf("order:%s:status", orderId)
 	value := strconv.Itoa(int(model.OrderDelivered))
-	if err := RedisClient.Set(Ctx, key, value, 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования заказа в статусе доставлен в Redis:", err)
+	if err := LogEvent(ctx, key, value, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return true, nil
 }
 
 // отменяем заказ в MongoDB
-func (r *Repo) CancelOrder(orderId string) (bool, error) {
+func (r *Repo) CancelOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
 	filter := bson.M{
-		"id":     orderId,
-		"status": bson.M{"$in": []int{int(model.OrderCreated), int(model.OrderConfirmed)}}, // можно отменять только созданные или подтверждённые
+		"id":        orderId,
+		"deletedat": nil,
+		"status":    bson.M{"$in": []int{int(model.OrderCreated), int(model.OrderConfirmed)}}, // можно отменять только созданные или подтверждённые
 	}
-	update := bson.M{"$set": bson.M{"status": model.OrderCancelled}}
+	update := bson.M{"$set": bson.M{"status": model.OrderCancelled}, "$inc": bson.M{"version": 1}}
+	withVersion(filter, expectedVersion)
 
-	result, err := OrderCollection.UpdateOne(Ctx, filter, update)
+	traceQuery(ctx, OrderCollection, "updateOne", filter)
+	result, err := OrderCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return false, fmt.Errorf("не удалось отменить заказ: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return false, nil
+		return false, versionConflict(ctx, OrderCollection, orderId, expectedVersion)
 	}
 
 	// логируем событие в Redis
 	key := fmt.Sprintf("order:%s:status", orderId)
 	value := strconv.Itoa(int(model.OrderCancelled))
-	if err := RedisClient.Set(Ctx, key, value, 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования отмены заказа в Redis:", err)
+	if err := LogEvent(ctx, key, value, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return true, nil
 }
 
-// удаляем заказ в MongoDB
-func (r *Repo) DeleteOrder(orderId string) (bool, error) {
-	res, err := OrderCollection.DeleteOne(Ctx, bson.M{"id": orderId})
+// withVersion добавляет в фильтр ожидаемую версию документа (0 — без проверки)
+func withVersion(filter bson.M, expectedVersion int64) {
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+}
+
+// versionConflict вызывается, когда обновление с фильтром по версии ничего не нашло:
+// если документ с таким id есть, но в другой версии — model.ErrVersionConflict, если нет — nil
+func versionConflict(ctx context.Context, coll *mongo.Collection, id string, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		return nil
+	}
+	filter := bson.M{"id": id, "deletedat": nil, "version": bson.M{"$ne": expectedVersion}}
+	traceQuery(ctx, coll, "countDocuments", filter)
+	n, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return model.ErrVersionConflict
+	}
+	return nil
+}
+
+// отменяем заказ, который так и не был подтвержден складом
+func (r *Repo) ExpireOrder(ctx context.Context, orderId string) (bool, error) {
+	filter := bson.M{"id": orderId, "status": model.OrderCreated, "deletedat": nil} // только если заказ всё ещё "создан"
+	update := bson.M{"$set": bson.M{"status": model.OrderCancelled, "cancelreason": model.CancelReasonExpired}, "$inc": bson.M{"version": 1}}
+
+	traceQuery(ctx, OrderCollection, "updateOne", filter)
+	result, err := OrderCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return false, fmt.Errorf("ошибка при удалении заказа: %w", err)
+		return false, fmt.Errorf("не удалось отменить просроченный заказ: %w", err)
 	}
-	if res.DeletedCount == 0 {
+
+	if result.MatchedCount == 0 {
 		return false, nil
 	}
+
+	// логируем событие в Redis
+	key := fmt.Sprintf("order:%s:status", orderId)
+	value := strconv.Itoa(int(model.OrderCancelled))
+	if err := LogEvent(ctx, key, value, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
+	}
+	key = fmt.Sprintf("order:%s:cancel_reason", orderId)
+	if err := LogEvent(ctx, key, model.CancelReasonExpired, 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
+	}
+
+	return true, nil
+}
+
+// мягко удаляем заказ в MongoDB: ставим deletedat, документ остаётся
+func (r *Repo) DeleteOrder(ctx context.Context, orderId string) (bool, error) {
+	ok, err := setDeleted(ctx, OrderCollection, orderId, true)
+	if err != nil || !ok {
+		return ok, err
+	}
 	key := fmt.Sprintf("order:%s:deleted", orderId)
-	if err := RedisClient.Set(Ctx, key, "true", 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования удаления заказа в Redis:", err)
+	if err := LogEvent(ctx, key, "true", 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 	return true, nil
 }
 
+// восстанавливаем мягко удалённый заказ
+func (r *Repo) RestoreOrder(ctx context.Context, orderId string) (bool, error) {
+	return setDeleted(ctx, OrderCollection, orderId, false)
+}
+
+// setDeleted ставит или снимает отметку deletedat у документа с id и увеличивает его версию
+func setDeleted(ctx context.Context, coll *mongo.Collection, id string, deleted bool) (bool, error) {
+	filter := bson.M{"id": id, "deletedat": nil}
+	update := bson.M{"$set": bson.M{"deletedat": time.Now()}, "$inc": bson.M{"version": 1}}
+	if !deleted {
+		filter["deletedat"] = bson.M{"$ne": nil}
+		update["$set"] = bson.M{"deletedat": nil}
+	}
+	traceQuery(ctx, coll, "updateOne", filter)
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("не удалось изменить отметку удаления: %w", err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
 // сохраняем нового пользователя в MongoDB
-func (r *Repo) SaveUser(user *model.User) error {
-	_, err := UserCollection.InsertOne(Ctx, user)
+func (r *Repo) SaveUser(ctx context.Context, user *model.User) error {
+	traceQuery(ctx, UserCollection, "insertOne", nil)
+	_, err := UserCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return model.ErrEmailTaken
+	}
 	if err != nil {
 		return fmt.Errorf("не удалось сохранить пользователя: %w", err)
 	}
 
 	// логируем событие в Redis
 	key := fmt.Sprintf("user:%s:created", user.Id)
-	if err := RedisClient.Set(Ctx, key, "true", 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования создания пользователя в Redis:", err)
+	if err := LogEvent(ctx, key, "true", 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return nil
 }
 
-// получаем всех пользователей
-func (r *Repo) GetUsers() ([]*model.User, error) {
-	cursor, err := UserCollection.Find(Ctx, bson.M{})
+// получаем всех не удалённых пользователей
+func (r *Repo) GetUsers(ctx context.Context) ([]*model.User, error) {
+	return r.findUsers(ctx, bson.M{"deletedat": nil})
+}
+
+// получаем мягко удалённых пользователей
+func (r *Repo) GetDeletedUsers(ctx context.Context) ([]*model.User, error) {
+	return r.findUsers(ctx, bson.M{"deletedat": bson.M{"$ne": nil}})
+}
+
+func (r *Repo) findUsers(ctx context.Context, filter bson.M) ([]*model.User, error) {
+	traceQuery(ctx, UserCollection, "find", filter)
+	cursor, err := UserCollection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(Ctx)
+	defer cursor.Close(ctx)
 
 	var users []*model.User
-	for cursor.Next(Ctx) {
+	for cursor.Next(ctx) {
 		var user model.User
 		if err := cursor.Decode(&user); err != nil {
 			return nil, err
@@ -221,9 +409,11 @@ func (r *Repo) GetUsers() ([]*model.User, error) {
 }
 
 // получаем пользователя по ID из MongoDB
-func (r *Repo) GetUserByID(id string) (*model.User, error) {
+func (r *Repo) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	var user model.User
-	err := UserCollection.FindOne(Ctx, bson.M{"id": id}).Decode(&user)
+	filter := bson.M{"id": id, "deletedat": nil}
+	traceQuery(ctx, UserCollection, "findOne", filter)
+	err := UserCollection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // заказ не найден
@@ -233,48 +423,181 @@ func (r *Repo) GetUserByID(id string) (*model.User, error) {
 	return &user, nil
 }
 
-// обновляем имя пользователя
-func (r *Repo) UpdateUserName(id, name string) (bool, error) {
-	filter := bson.M{"id": id}                     // ищем пользователя по id
-	update := bson.M{"$set": bson.M{"name": name}} // обновляем поле name
-	result, err := UserCollection.UpdateOne(Ctx, filter, update)
-	if err != nil {
-		return false, fmt.Errorf("не удалось обновить имя пользователя: %w", err)
+// заменяем профиль пользователя (всё, кроме id и даты создания); expectedVersion > 0 — compare-and-swap
+// по версии, новая версия записывается в u.Version
+func (r *Repo) UpdateUser(ctx context.Context, u *model.User, expectedVersion int64) (bool, error) {
+	filter := bson.M{"id": u.Id, "deletedat": nil} // ищем не удалённого пользователя по id
+	withVersion(filter, expectedVersion)
+	update := bson.M{
+		"$set": bson.M{
+			"name":      u.Name,
+			"email":     u.Email,
+			"phone":     u.Phone,
+			"addresses": u.Addresses,
+			"updatedat": u.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"version": 1})
+	traceQuery(ctx, UserCollection, "findOneAndUpdate", filter)
+	var updated struct{ Version int64 }
+	err := UserCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		// пользователь не найден или версия другая
+		return false, versionConflict(ctx, UserCollection, u.Id, expectedVersion)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return false, model.ErrEmailTaken
 	}
-
-	if result.MatchedCount == 0 {
-		// пользователь не найден
-		return false, nil
+	if err != nil {
+		return false, fmt.Errorf("не удалось обновить пользователя: %w", err)
 	}
+	u.Version = updated.Version
 
 	// логируем изменение в Redis
-	key := fmt.Sprintf("user:%s:name", id)
-	if err := RedisClient.Set(Ctx, key, name, 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования в Redis:", err)
+	key := fmt.Sprintf("user:%s:updated", u.Id)
+	if err := LogEvent(ctx, key, u.UpdatedAt.Format(time.RFC3339), 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
 	}
 
 	return true, nil
 }
 
-// удаляем пользователя в MongoDB
-func (r *Repo) DeleteUser(id string) (bool, error) {
-	res, err := UserCollection.DeleteOne(Ctx, bson.M{"id": id})
+// мягко удаляем пользователя в MongoDB
+func (r *Repo) DeleteUser(ctx context.Context, id string) (bool, error) {
+	ok, err := setDeleted(ctx, UserCollection, id, true)
+	if err != nil || !ok {
+		return ok, err
+	}
+	key := fmt.Sprintf("user:%s:deleted", id)
+	if err := LogEvent(ctx, key, "true", 24*time.Hour); err != nil {
+		r.logger.WarnContext(ctx, "redis log event failed", "key", key, "err", err)
+	}
+	return true, nil
+}
+
+// восстанавливаем мягко удалённого пользователя
+func (r *Repo) RestoreUser(ctx context.Context, id string) (bool, error) {
+	return setDeleted(ctx, UserCollection, id, false)
+}
+
+// обезличиваем пользователя и стираем адреса в его заказах, доставках и телах доставок вебхуков
+func (r *Repo) EraseUser(ctx context.Context, id string, at time.Time) (bool, error) {
+	filter := bson.M{"id": id, "deletedat": nil}
+	update := bson.M{
+		"$set": bson.M{
+			"name":      model.ErasedName,
+			"email":     "",
+			"phone":     "",
+			"addresses": nil,
+			"updatedat": at,
+			"erasedat":  at,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	traceQuery(ctx, UserCollection, "updateOne", filter)
+	res, err := UserCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return false, fmt.Errorf("ошибка при удалении пользователя: %w", err)
+		return false, fmt.Errorf("не удалось обезличить пользователя: %w", err)
 	}
-	if res.DeletedCount == 0 {
+	if res.MatchedCount == 0 {
 		return false, nil
 	}
-	key := fmt.Sprintf("user:%s:deleted", id)
-	if err := RedisClient.Set(Ctx, key, "true", 24*time.Hour).Err(); err != nil {
-		fmt.Println("Ошибка логирования в Redis:", err)
+
+	owned := bson.M{"userid": id}
+	traceQuery(ctx, OrderCollection, "updateMany", owned)
+	if _, err := OrderCollection.UpdateMany(ctx, owned, bson.M{"$set": bson.M{"shippingaddress": nil}}); err != nil {
+		return false, fmt.Errorf("не удалось стереть адреса в заказах: %w", err)
+	}
+	traceQuery(ctx, DeliveryCollection, "updateMany", owned)
+	if _, err := DeliveryCollection.UpdateMany(ctx, owned, bson.M{"$set": bson.M{"address": ""}}); err != nil {
+		return false, fmt.Errorf("не удалось стереть адреса в доставках: %w", err)
+	}
+	if err := eraseWebhookPayloads(ctx, owned); err != nil {
+		return false, fmt.Errorf("не удалось стереть адреса в доставках вебхуков: %w", err)
 	}
 	return true, nil
 }
 
+// стираем адреса из снимков заказов в телах доставок вебхуков: тело хранится как есть (binary),
+// поэтому правим его в коде, а не оператором обновления
+func eraseWebhookPayloads(ctx context.Context, owned bson.M) error {
+	traceQuery(ctx, OrderCollection, "distinct", owned)
+	orderIDs, err := OrderCollection.Distinct(ctx, "id", owned)
+	if err != nil || len(orderIDs) == 0 {
+		return err
+	}
+	filter := bson.M{"orderid": bson.M{"$in": orderIDs}}
+	traceQuery(ctx, WebhookDeliveryCollection, "find", filter)
+	cursor, err := WebhookDeliveryCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var d model.WebhookDelivery
+		if err := cursor.Decode(&d); err != nil {
+			return err
+		}
+		ok, err := d.EraseShippingAddress()
+		if err != nil {
+			return fmt.Errorf("доставка %s: %w", d.Id, err)
+		}
+		if !ok {
+			continue
+		}
+		byID := bson.M{"id": d.Id}
+		traceQuery(ctx, WebhookDeliveryCollection, "updateOne", byID)
+		if _, err := WebhookDeliveryCollection.UpdateOne(ctx, byID, bson.M{"$set": bson.M{"payload": d.Payload}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// окончательно удаляем заказы и пользователей, мягко удалённых раньше before
+func (r *Repo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	filter := bson.M{"deletedat": bson.M{"$lt": before}}
+	traceQuery(ctx, OrderCollection, "deleteMany", filter)
+	res, err := OrderCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось очистить %s: %w", OrderCollection.Name(), err)
+	}
+	total := res.DeletedCount
+
+	// пользователей, у которых остались заказы, не удаляем, как и в Postgres
+	traceQuery(ctx, UserCollection, "distinct", filter)
+	candidates, err := UserCollection.Distinct(ctx, "id", filter)
+	if err != nil {
+		return int(total), fmt.Errorf("не удалось найти удалённых пользователей: %w", err)
+	}
+	if len(candidates) == 0 {
+		return int(total), nil
+	}
+	owned := bson.M{"userid": bson.M{"$in": candidates}}
+	traceQuery(ctx, OrderCollection, "distinct", owned)
+	owners, err := OrderCollection.Distinct(ctx, "userid", owned)
+	if err != nil {
+		return int(total), fmt.Errorf("не удалось найти владельцев заказов: %w", err)
+	}
+	if owners == nil {
+		owners = []any{} // $nin не принимает null
+	}
+	filter = bson.M{"deletedat": bson.M{"$lt": before}, "id": bson.M{"$in": candidates, "$nin": owners}}
+	traceQuery(ctx, UserCollection, "deleteMany", filter)
+	res, err = UserCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return int(total), fmt.Errorf("не удалось очистить %s: %w", UserCollection.Name(), err)
+	}
+	return int(total + res.DeletedCount), nil
+}
+
 // сохраняем новую доставку в MongoDB
-func (r *Repo) SaveDelivery(delivery *model.Delivery) error {
-	_, err := DeliveryCollection.InsertOne(Ctx, delivery)
+func (r *Repo) SaveDelivery(ctx context.Context, delivery *model.Delivery) error {
+	traceQuery(ctx, DeliveryCollection, "insertOne", nil)
+	_, err := DeliveryCollection.InsertOne(ctx, delivery)
 	if err != nil {
 		return fmt.Errorf("не удалось сохранить доставку: %w", err)
 	}
@@ -282,15 +605,16 @@ func (r *Repo) SaveDelivery(delivery *model.Delivery) error {
 }
 
 // получаем все доставки
-func (r *Repo) GetDeliveries() ([]*model.Delivery, error) {
-	cursor, err := DeliveryCollection.Find(Ctx, bson.M{})
+func (r *Repo) GetDeliveries(ctx context.Context) ([]*model.Delivery, error) {
+	traceQuery(ctx, DeliveryCollection, "find", bson.M{})
+	cursor, err := DeliveryCollection.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(Ctx)
+	defer cursor.Close(ctx)
 
 	var deliveries []*model.Delivery
-	for cursor.Next(Ctx) {
+	for cursor.Next(ctx) {
 		var delivery model.Delivery
 		if err := cursor.Decode(&delivery); err != nil {
 			return nil, err
@@ -302,8 +626,9 @@ func (r *Repo) GetDeliveries() ([]*model.Delivery, error) {
 }
 
 // Сохраняем новый склад в MongoDB
-func (r *Repo) SaveWarehouse(warehouse *model.Warehouse) error {
-	_, err := WarehouseCollection.InsertOne(Ctx, warehouse)
+func (r *Repo) SaveWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	traceQuery(ctx, WarehouseCollection, "insertOne", nil)
+	_, err := WarehouseCollection.InsertOne(ctx, warehouse)
 	if err != nil {
 		return fmt.Errorf("не удалось сохранить склад: %w", err)
 	}
@@ -311,15 +636,16 @@ func (r *Repo) SaveWarehouse(warehouse *model.Warehouse) error {
 }
 
 // получаем все склады
-func (r *Repo) GetWarehouses() ([]*model.Warehouse, error) {
-	cursor, err := WarehouseCollection.Find(Ctx, bson.M{})
+func (r *Repo) GetWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	traceQuery(ctx, WarehouseCollection, "find", bson.M{})
+	cursor, err := WarehouseCollection.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(Ctx)
+	defer cursor.Close(ctx)
 
 	var warehouses []*model.Warehouse
-	for cursor.Next(Ctx) {
+	for cursor.Next(ctx) {
 		var warehouse model.Warehouse
 		if err := cursor.Decode(&warehouse); err != nil {
 			return nil, err
@@ -329,3 +655,322 @@ func (r *Repo) GetWarehouses() ([]*model.Warehouse, error) {
 
 	return warehouses, nil
 }
+
+// считаем записи: заказы группируем по статусу на стороне Mongo, остальное — countDocuments
+func (r *Repo) Count(ctx context.Context) (*model.StoreCounts, error) {
+	counts := &model.StoreCounts{Orders: make(map[model.OrderStatus]int)}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deletedat": nil}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "n": bson.M{"$sum": 1}}}},
+	}
+	traceQuery(ctx, OrderCollection, "aggregate", pipeline)
+	cursor, err := OrderCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось посчитать заказы: %w", err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var group struct {
+			Status model.OrderStatus `bson:"_id"`
+			N      int               `bson:"n"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, err
+		}
+		counts.Orders[group.Status] = group.N
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range []struct {
+		coll   *mongo.Collection
+		filter bson.M
+		n      *int
+	}{
+		{UserCollection, bson.M{"deletedat": nil}, &counts.Users},
+		{DeliveryCollection, bson.M{}, &counts.Deliveries},
+		{WarehouseCollection, bson.M{}, &counts.Warehouses},
+	} {
+		n, err := c.coll.CountDocuments(ctx, c.filter)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось посчитать %s: %w", c.coll.Name(), err)
+		}
+		*c.n = int(n)
+	}
+	return counts, nil
+}
+
+// Scan читает страницу по полю id с сортировкой по нему; строки Mongo сравнивает побайтно
+func (r *Repo) Scan(ctx context.Context, kind, after string, limit int) ([]model.Storable, error) {
+	var cursorAfter any
+	if after != "" {
+		cursorAfter = after
+	}
+	if after != "" && (kind == "delivery" || kind == "warehouse") {
+		id, err := strconv.ParseInt(after, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("scan %s after %q: %w", kind, after, err)
+		}
+		cursorAfter = id
+	}
+	switch kind {
+	case "order":
+		return scanCollection[model.Order](ctx, OrderCollection, cursorAfter, limit)
+	case "user":
+		return scanCollection[model.User](ctx, UserCollection, cursorAfter, limit)
+	case "delivery":
+		return scanCollection[model.Delivery](ctx, DeliveryCollection, cursorAfter, limit)
+	case "warehouse":
+		return scanCollection[model.Warehouse](ctx, WarehouseCollection, cursorAfter, limit)
+	case "api_key":
+		return scanCollection[model.APIKey](ctx, APIKeyCollection, cursorAfter, limit)
+	case "audit_entry":
+		return scanCollection[model.AuditEntry](ctx, AuditCollection, cursorAfter, limit)
+	case "webhook":
+		return scanCollection[model.Webhook](ctx, WebhookCollection, cursorAfter, limit)
+	case "webhook_delivery":
+		return scanCollection[model.WebhookDelivery](ctx, WebhookDeliveryCollection, cursorAfter, limit)
+	}
+	return nil, fmt.Errorf("unsupported kind %q", kind)
+}
+
+// scanCollection — до limit документов coll с id больше after (nil — с начала) по возрастанию id
+func scanCollection[T any, P interface {
+	*T
+	model.Storable
+}](ctx context.Context, coll *mongo.Collection, after any, limit int) ([]model.Storable, error) {
+	filter := bson.M{}
+	if after != nil {
+		filter["id"] = bson.M{"$gt": after}
+	}
+	traceQuery(ctx, coll, "find", filter)
+	cursor, err := coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var page []model.Storable
+	for cursor.Next(ctx) {
+		var v T
+		if err := cursor.Decode(&v); err != nil {
+			return nil, err
+		}
+		page = append(page, P(&v))
+	}
+	return page, cursor.Err()
+}
+
+// Сохраняем API-ключ (создаём или заменяем запись целиком)
+func (r *Repo) SaveAPIKey(ctx context.Context, key *model.APIKey) error {
+	filter := bson.M{"id": key.Id}
+	traceQuery(ctx, APIKeyCollection, "replaceOne", filter)
+	_, err := APIKeyCollection.ReplaceOne(ctx, filter, key, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить api-ключ: %w", err)
+	}
+	return nil
+}
+
+// получаем все API-ключи
+func (r *Repo) GetAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	traceQuery(ctx, APIKeyCollection, "find", bson.M{})
+	cursor, err := APIKeyCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*model.APIKey
+	for cursor.Next(ctx) {
+		var key model.APIKey
+		if err := cursor.Decode(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+
+	return keys, nil
+}
+
+// получаем API-ключ по id
+func (r *Repo) GetAPIKeyByID(ctx context.Context, id string) (*model.APIKey, error) {
+	var key model.APIKey
+	traceQuery(ctx, APIKeyCollection, "findOne", bson.M{"id": id})
+	err := APIKeyCollection.FindOne(ctx, bson.M{"id": id}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // ключ не найден
+		}
+		return nil, fmt.Errorf("не удалось получить api-ключ: %w", err)
+	}
+	return &key, nil
+}
+
+// отмечаем время последнего использования ключа
+func (r *Repo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	filter := bson.M{"id": id}
+	traceQuery(ctx, APIKeyCollection, "updateOne", filter)
+	if _, err := APIKeyCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastusedat": usedAt}}); err != nil {
+		return fmt.Errorf("не удалось обновить api-ключ: %w", err)
+	}
+	return nil
+}
+
+// добавляем запись в журнал аудита
+func (r *Repo) SaveAuditEntry(ctx context.Context, e *model.AuditEntry) error {
+	traceQuery(ctx, AuditCollection, "insertOne", nil)
+	if _, err := AuditCollection.InsertOne(ctx, e); err != nil {
+		return fmt.Errorf("не удалось записать в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+// получаем записи журнала аудита по ID заказов и пользователей в порядке времени
+func (r *Repo) GetAuditEntries(ctx context.Context, subjects []string) ([]*model.AuditEntry, error) {
+	filter := bson.M{"subject": bson.M{"$in": subjects}}
+	traceQuery(ctx, AuditCollection, "find", filter)
+	cursor, err := AuditCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]*model.AuditEntry, 0)
+	for cursor.Next(ctx) {
+		var e model.AuditEntry
+		if err := cursor.Decode(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, cursor.Err()
+}
+
+// Сохраняем подписку на вебхуки (создаём или заменяем запись целиком)
+func (r *Repo) SaveWebhook(ctx context.Context, w *model.Webhook) error {
+	filter := bson.M{"id": w.Id}
+	traceQuery(ctx, WebhookCollection, "replaceOne", filter)
+	if _, err := WebhookCollection.ReplaceOne(ctx, filter, w, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("не удалось сохранить подписку: %w", err)
+	}
+	return nil
+}
+
+// получаем все подписки в порядке создания
+func (r *Repo) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	traceQuery(ctx, WebhookCollection, "find", bson.M{})
+	cursor, err := WebhookCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := make([]*model.Webhook, 0)
+	for cursor.Next(ctx) {
+		var w model.Webhook
+		if err := cursor.Decode(&w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &w)
+	}
+	return webhooks, cursor.Err()
+}
+
+// получаем подписку по id
+func (r *Repo) GetWebhookByID(ctx context.Context, id string) (*model.Webhook, error) {
+	var w model.Webhook
+	traceQuery(ctx, WebhookCollection, "findOne", bson.M{"id": id})
+	err := WebhookCollection.FindOne(ctx, bson.M{"id": id}).Decode(&w)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // подписка не найдена
+		}
+		return nil, fmt.Errorf("не удалось получить подписку: %w", err)
+	}
+	return &w, nil
+}
+
+// удаляем подписку; доставки остаются в журнале
+func (r *Repo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{"id": id}
+	traceQuery(ctx, WebhookCollection, "deleteOne", filter)
+	res, err := WebhookCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("не удалось удалить подписку: %w", err)
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// Сохраняем доставку вебхука (создаём или заменяем запись целиком)
+func (r *Repo) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	filter := bson.M{"id": d.Id}
+	traceQuery(ctx, WebhookDeliveryCollection, "replaceOne", filter)
+	if _, err := WebhookDeliveryCollection.ReplaceOne(ctx, filter, d, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("не удалось сохранить доставку: %w", err)
+	}
+	return nil
+}
+
+// получаем доставки по подписке и статусу ("" — любые) в порядке создания
+func (r *Repo) GetWebhookDeliveries(ctx context.Context, webhookID, status string) ([]*model.WebhookDelivery, error) {
+	filter := bson.M{}
+	if webhookID != "" {
+		filter["webhookid"] = webhookID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	traceQuery(ctx, WebhookDeliveryCollection, "find", filter)
+	cursor, err := WebhookDeliveryCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for cursor.Next(ctx) {
+		var d model.WebhookDelivery
+		if err := cursor.Decode(&d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, cursor.Err()
+}
+
+// получаем доставку по id
+func (r *Repo) GetWebhookDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	traceQuery(ctx, WebhookDeliveryCollection, "findOne", bson.M{"id": id})
+	err := WebhookDeliveryCollection.FindOne(ctx, bson.M{"id": id}).Decode(&d)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // доставка не найдена
+		}
+		return nil, fmt.Errorf("не удалось получить доставку: %w", err)
+	}
+	return &d, nil
+}
+
+// скрипт аренды: продлеваем, если аренда уже наша, иначе пытаемся захватить через SET NX
+var leaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+// AcquireLease захватывает аренду в Redis, общем для всех реплик
+func (r *Repo) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("lease:%s", name)
+	n, err := leaseScript.Run(ctx, RedisClient, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("не удалось захватить аренду %s: %w", name, err)
+	}
+	return n == 1, nil
+}
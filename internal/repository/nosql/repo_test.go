@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// тест: в текст запроса для трейса попадают имена полей и операторы, но не значения
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter any
+		want   any
+	}{
+		{
+			name:   "filter",
+			filter: bson.M{"email": "alice@example.com", "deletedat": nil, "createdat": bson.M{"$lt": time.Now()}},
+			want:   bson.M{"email": "?", "deletedat": nil, "createdat": bson.M{"$lt": "?"}},
+		},
+		{
+			name:   "update",
+			filter: bson.D{{Key: "$set", Value: bson.M{"shippingaddress": bson.M{"street": "Tverskaya 1"}}}},
+			want:   bson.D{{Key: "$set", Value: bson.M{"shippingaddress": bson.M{"street": "?"}}}},
+		},
+		{
+			name:   "list of values",
+			filter: bson.M{"subject": bson.M{"$in": []string{"User-1", "Order-1"}}},
+			want:   bson.M{"subject": bson.M{"$in": "?"}},
+		},
+		{
+			name:   "pipeline",
+			filter: mongo.Pipeline{{{Key: "$match", Value: bson.M{"userid": "User-1"}}}},
+			want:   bson.A{bson.D{{Key: "$match", Value: bson.M{"userid": "?"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redact(tt.filter))
+		})
+	}
+}
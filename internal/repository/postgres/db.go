@@ -42,5 +42,152 @@ CREATE TABLE IF NOT EXISTS orders (
 		return fmt.Errorf("migrate orders: %w", err)
 	}
 
+	// причина отмены заказа
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancel_reason text NOT NULL DEFAULT '';`); err != nil {
+		return fmt.Errorf("migrate orders.cancel_reason: %w", err)
+	}
+
+	// профиль пользователя: email (уникальный, если задан), телефон, адреса и даты
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email      text        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS phone      text        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS addresses  jsonb       NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE email <> '';`); err != nil {
+		return fmt.Errorf("migrate users profile: %w", err)
+	}
+
+	// копия адреса доставки в заказе
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address jsonb;`); err != nil {
+		return fmt.Errorf("migrate orders.shipping_address: %w", err)
+	}
+
+	// версии для оптимистичных блокировок (ETag/If-Match)
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;`); err != nil {
+		return fmt.Errorf("migrate version columns: %w", err)
+	}
+
+	// мягкое удаление
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at timestamptz;`); err != nil {
+		return fmt.Errorf("migrate deleted_at columns: %w", err)
+	}
+
+	// поиск заказов, не подтверждённых вовремя (планировщик отмены)
+	if _, err := db.ExecContext(ctx, `
+CREATE INDEX IF NOT EXISTS orders_unconfirmed_idx ON orders (created_at) WHERE status = 0 AND deleted_at IS NULL;`); err != nil {
+		return fmt.Errorf("migrate orders_unconfirmed_idx: %w", err)
+	}
+
+	// отметка обезличивания пользователя
+	if _, err := db.ExecContext(ctx, `
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at timestamptz;`); err != nil {
+		return fmt.Errorf("migrate users.erased_at: %w", err)
+	}
+
+	// audit_log — журнал аудита, только добавление
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS audit_log (
+    id      text PRIMARY KEY,
+    at      timestamptz NOT NULL,
+    actor   text NOT NULL,
+    action  text NOT NULL,
+    subject text NOT NULL,
+    details text NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS audit_log_subject_idx ON audit_log (subject, at);`); err != nil {
+		return fmt.Errorf("migrate audit_log: %w", err)
+	}
+
+	// leases — аренды фоновых задач между репликами
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS leases (
+    name       text PRIMARY KEY,
+    owner      text NOT NULL,
+    expires_at timestamptz NOT NULL
+);`); err != nil {
+		return fmt.Errorf("migrate leases: %w", err)
+	}
+
+	// api_keys — ключи межсервисных вызовов (хранится только хеш секрета)
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           text PRIMARY KEY,
+    name         text NOT NULL,
+    role         text NOT NULL,
+    scopes       text NOT NULL DEFAULT '',
+    hash         text NOT NULL,
+    created_at   timestamptz NOT NULL,
+    rotated_at   timestamptz,
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz
+);`); err != nil {
+		return fmt.Errorf("migrate api_keys: %w", err)
+	}
+
+	// webhooks — подписки на события заказов; webhook_deliveries — очередь и журнал доставок
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         text PRIMARY KEY,
+    url        text NOT NULL,
+    events     text NOT NULL,
+    secret     text NOT NULL,
+    created_by text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              text PRIMARY KEY,
+    webhook_id      text NOT NULL,
+    event           text NOT NULL,
+    order_id        text NOT NULL,
+    payload         jsonb NOT NULL,
+    status          text NOT NULL,
+    attempts        jsonb NOT NULL DEFAULT '[]',
+    created_at      timestamptz NOT NULL,
+    next_attempt_at timestamptz,
+    requeued_at     timestamptz,
+    delivered_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_idx ON webhook_deliveries (status, next_attempt_at);`); err != nil {
+		return fmt.Errorf("migrate webhooks: %w", err)
+	}
+
+	// deliveries и warehouses — доставки и складские записи по заказам
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS deliveries (
+    id       bigint PRIMARY KEY,
+    order_id text NOT NULL,
+    user_id  text NOT NULL,
+    address  text NOT NULL DEFAULT '',
+    status   int  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS warehouses (
+    id       bigint PRIMARY KEY,
+    order_id text NOT NULL,
+    status   int  NOT NULL
+);`); err != nil {
+		return fmt.Errorf("migrate deliveries and warehouses: %w", err)
+	}
+
+	// Scan читает страницы в побайтном порядке текстовых ID (как Mongo и Go), а не в порядке сортировки базы
+	if _, err := db.ExecContext(ctx, `
+CREATE INDEX IF NOT EXISTS users_id_c_idx ON users (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS orders_id_c_idx ON orders (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS api_keys_id_c_idx ON api_keys (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS audit_log_id_c_idx ON audit_log (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS webhooks_id_c_idx ON webhooks (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS webhook_deliveries_id_c_idx ON webhook_deliveries (id COLLATE "C");`); err != nil {
+		return fmt.Errorf("migrate id indexes: %w", err)
+	}
+
 	return nil
 }
@@ -3,51 +3,134 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"order-ms/internal/model"
+	"order-ms/internal/tracing"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Repo struct {
-	db  *sql.DB
-	ctx context.Context
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewPostgresRepo(db *sql.DB, logger *slog.Logger) *Repo {
+	return &Repo{db: db, logger: logger.With("repo", "postgres")}
+}
+
+// exec, query и queryRow — обёртки над database/sql, которые записывают текст запроса в спан трейсинга
+
+func (r *Repo) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	tracing.SetStatement(ctx, query)
+	return r.db.ExecContext(ctx, query, args...)
+}
+
+func (r *Repo) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	tracing.SetStatement(ctx, query)
+	return r.db.QueryContext(ctx, query, args...)
 }
 
-func NewPostgresRepo(db *sql.DB) *Repo {
-	return &Repo{db: db, ctx: context.Background()}
+func (r *Repo) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	tracing.SetStatement(ctx, query)
+	return r.db.QueryRowContext(ctx, query, args...)
 }
 
 // Общий Save
-func (r *Repo) Save(s model.Storable) error {
+func (r *Repo) Save(ctx context.Context, s model.Storable) error {
 	switch v := s.(type) {
 	case *model.Order:
-		return r.SaveOrder(v)
+		return r.SaveOrder(ctx, v)
 	case *model.User:
-		return r.SaveUser(v)
+		return r.SaveUser(ctx, v)
 	case *model.Delivery:
-		// опционально: INSERT в deliveries, если нужно
-		return fmt.Errorf("Save Delivery: not implemented yet")
+		return r.SaveDelivery(ctx, v)
 	case *model.Warehouse:
-		// опционально: INSERT в warehouses, если нужно
-		return fmt.Errorf("Save Warehouse: not implemented yet")
+		return r.SaveWarehouse(ctx, v)
 	default:
 		return fmt.Errorf("unsupported type %T", s)
 	}
 }
 
 // Заказы
-func (r *Repo) SaveOrder(o *model.Order) error {
-	_, err := r.db.ExecContext(r.ctx,
-		`INSERT INTO orders (id, user_id, status, created_at)
-		 VALUES ($1, $2, $3, $4)`,
-		o.Id, o.UserID, int(o.Status), o.CreatedAt)
+const orderColumns = `id, user_id, status, created_at, cancel_reason, shipping_address, version, deleted_at`
+
+func (r *Repo) SaveOrder(ctx context.Context, o *model.Order) error {
+	return r.SaveOrders(ctx, []*model.Order{o})
+}
+
+// SaveOrders вставляет пакет заказов одним INSERT: либо все, либо ни одного
+func (r *Repo) SaveOrders(ctx context.Context, orders []*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	const columns = 8
+	var values strings.Builder
+	args := make([]any, 0, len(orders)*columns)
+	for i, o := range orders {
+		var address []byte // NULL, если адрес не выбран
+		if o.ShippingAddress != nil {
+			var err error
+			if address, err = json.Marshal(o.ShippingAddress); err != nil {
+				return err
+			}
+		}
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+		args = append(args, o.Id, o.UserID, int(o.Status), o.CreatedAt, o.CancelReason, address, o.Version, o.DeletedAt)
+	}
+	_, err := r.exec(ctx, `INSERT INTO orders (`+orderColumns+`) VALUES `+values.String(), args...)
 	return err
 }
 
-func (r *Repo) GetOrders() ([]*model.Order, error) {
-	rows, err := r.db.QueryContext(r.ctx,
-		`SELECT id, user_id, status, created_at
+// scanOrder читает строку orders (rows или row)
+func scanOrder(scan func(dest ...any) error) (*model.Order, error) {
+	var o model.Order
+	var st int
+	var address []byte
+	var deletedAt sql.NullTime
+	if err := scan(&o.Id, &o.UserID, &st, &o.CreatedAt, &o.CancelReason, &address, &o.Version, &deletedAt); err != nil {
+		return nil, err
+	}
+	o.Status = model.OrderStatus(st)
+	o.DeletedAt = nullTime(deletedAt)
+	if address != nil {
+		o.ShippingAddress = &model.Address{}
+		if err := json.Unmarshal(address, o.ShippingAddress); err != nil {
+			return nil, fmt.Errorf("order %s shipping_address: %w", o.Id, err)
+		}
+	}
+	return &o, nil
+}
+
+// GetOrders возвращает не удалённые заказы, GetDeletedOrders — мягко удалённые
+func (r *Repo) GetOrders(ctx context.Context) ([]*model.Order, error) {
+	return r.listOrders(ctx, `deleted_at IS NULL`)
+}
+
+func (r *Repo) GetDeletedOrders(ctx context.Context) ([]*model.Order, error) {
+	return r.listOrders(ctx, `deleted_at IS NOT NULL`)
+}
+
+func (r *Repo) GetUnconfirmedOrders(ctx context.Context, createdBefore time.Time) ([]*model.Order, error) {
+	return r.listOrders(ctx, `status = $1 AND created_at < $2 AND deleted_at IS NULL`, int(model.OrderCreated), createdBefore)
+}
+
+func (r *Repo) listOrders(ctx context.Context, where string, args ...any) ([]*model.Order, error) {
+	rows, err := r.query(ctx,
+		`SELECT `+orderColumns+`
 		   FROM orders
-		   ORDER BY created_at DESC`)
+		  WHERE `+where+`
+		   ORDER BY created_at DESC`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,46 +138,57 @@ func (r *Repo) GetOrders() ([]*model.Order, error) {
 
 	var out []*model.Order
 	for rows.Next() {
-		var o model.Order
-		var st int
-		if err := rows.Scan(&o.Id, &o.UserID, &st, &o.CreatedAt); err != nil {
+		o, err := scanOrder(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
-		o.Status = model.OrderStatus(st)
-		out = append(out, &o)
+		out = append(out, o)
 	}
 	return out, rows.Err()
 }
 
-func (r *Repo) GetOrderByID(id string) (*model.Order, error) {
-	var o model.Order
-	var st int
-	err := r.db.QueryRowContext(r.ctx,
-		`SELECT id, user_id, status, created_at FROM orders WHERE id=$1`, id).
-		Scan(&o.Id, &o.UserID, &st, &o.CreatedAt)
+func (r *Repo) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, err := scanOrder(r.queryRow(ctx, `SELECT `+orderColumns+` FROM orders WHERE id=$1 AND deleted_at IS NULL`, id).Scan)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	o.Status = model.OrderStatus(st)
-	return &o, nil
+	return o, nil
+}
+
+// DeleteOrder мягко удаляет заказ, RestoreOrder возвращает его
+func (r *Repo) DeleteOrder(ctx context.Context, id string) (bool, error) {
+	return r.execAffected(ctx,
+		`UPDATE orders SET deleted_at = now(), version = version + 1 WHERE id=$1 AND deleted_at IS NULL`, id)
+}
+
+func (r *Repo) RestoreOrder(ctx context.Context, id string) (bool, error) {
+	return r.execAffected(ctx,
+		`UPDATE orders SET deleted_at = NULL, version = version + 1 WHERE id=$1 AND deleted_at IS NOT NULL`, id)
 }
 
-func (r *Repo) DeleteOrder(id string) (bool, error) {
-	res, err := r.db.ExecContext(r.ctx, `DELETE FROM orders WHERE id=$1`, id)
+// execAffected выполняет команду и сообщает, изменила ли она хотя бы одну строку
+func (r *Repo) execAffected(ctx context.Context, query string, args ...any) (bool, error) {
+	res, err := r.exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
 		return false, err
 	}
-	n, _ := res.RowsAffected()
 	return n > 0, nil
 }
 
-func (r *Repo) updateOrderStatus(orderId string, status int) (bool, error) {
-	const cmd = `UPDATE orders SET status = $1 WHERE id = $2`
+// updateOrderStatus переводит заказ в status, только если он сейчас в одном из статусов from
+// (как в MemoryRepo и Mongo): недопустимый переход ничего не меняет и возвращает false
+func (r *Repo) updateOrderStatus(ctx context.Context, orderId string, status model.OrderStatus, from []int, expectedVersion int64) (bool, error) {
+	const cmd = `UPDATE orders SET status = $1, version = version + 1
+	              WHERE id = $2 AND status = ANY($4) AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3)`
 
-	res, err := r.db.ExecContext(r.ctx, cmd, status, orderId)
+	res, err := r.exec(ctx, cmd, int(status), orderId, expectedVersion, from)
 	if err != nil {
 		return false, err
 	}
@@ -103,32 +197,101 @@ func (r *Repo) updateOrderStatus(orderId string, status int) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if n == 0 {
+		return false, r.versionConflict(ctx, "orders", orderId, expectedVersion)
+	}
+	r.logger.DebugContext(ctx, "order status updated", "order_id", orderId, "status", status)
 
-	return n > 0, nil
+	return true, nil
 }
 
-func (r *Repo) ConfirmOrder(orderId string) (bool, error) {
-	return r.updateOrderStatus(orderId, 1)
+// versionConflict вызывается, когда UPDATE с проверкой версии не изменил ни одной строки:
+// если запись есть, но в другой версии — model.ErrVersionConflict; если записи нет или версия та же
+// (не подошло другое условие, например статус) — nil
+func (r *Repo) versionConflict(ctx context.Context, table, id string, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		return nil
+	}
+	var exists bool
+	err := r.queryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NULL AND version <> $2)`,
+		id, expectedVersion).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return model.ErrVersionConflict
+	}
+	return nil
 }
 
-func (r *Repo) DeliverOrder(orderId string) (bool, error) {
-	return r.updateOrderStatus(orderId, 2)
+func (r *Repo) ConfirmOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.updateOrderStatus(ctx, orderId, model.OrderConfirmed, []int{int(model.OrderCreated)}, expectedVersion)
 }
 
-func (r *Repo) CancelOrder(orderId string) (bool, error) {
-	return r.updateOrderStatus(orderId, 3)
+func (r *Repo) DeliverOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.updateOrderStatus(ctx, orderId, model.OrderDelivered, []int{int(model.OrderConfirmed)}, expectedVersion)
+}
+
+func (r *Repo) CancelOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error) {
+	return r.updateOrderStatus(ctx, orderId, model.OrderCancelled, []int{int(model.OrderCreated), int(model.OrderConfirmed)}, expectedVersion)
+}
+
+// ExpireOrder отменяет заказ с причиной "expired", только если он всё ещё в статусе "создан"
+func (r *Repo) ExpireOrder(ctx context.Context, orderId string) (bool, error) {
+	res, err := r.exec(ctx,
+		`UPDATE orders SET status = $1, cancel_reason = $2, version = version + 1 WHERE id = $3 AND status = $4 AND deleted_at IS NULL`,
+		int(model.OrderCancelled), model.CancelReasonExpired, orderId, int(model.OrderCreated))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
 }
 
 // Пользователи
-func (r *Repo) SaveUser(u *model.User) error {
-	_, err := r.db.ExecContext(r.ctx,
-		`INSERT INTO users (id, name) VALUES ($1,$2)`,
-		u.Id, u.Name)
-	return err
+const userColumns = `id, name, email, phone, addresses, created_at, updated_at, version, deleted_at, erased_at`
+
+func (r *Repo) SaveUser(ctx context.Context, u *model.User) error {
+	addresses, err := json.Marshal(addressesOrEmpty(u.Addresses))
+	if err != nil {
+		return err
+	}
+	_, err = r.exec(ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
+		u.Id, u.Name, u.Email, u.Phone, addresses, u.CreatedAt, u.UpdatedAt, u.Version, u.DeletedAt, u.ErasedAt)
+	return emailTakenErr(err)
 }
 
-func (r *Repo) GetUsers() ([]*model.User, error) {
-	rows, err := r.db.QueryContext(r.ctx, `SELECT id, name FROM users ORDER BY name`)
+// scanUser читает строку users (rows или row)
+func scanUser(scan func(dest ...any) error) (*model.User, error) {
+	var u model.User
+	var addresses []byte
+	var deletedAt, erasedAt sql.NullTime
+	if err := scan(&u.Id, &u.Name, &u.Email, &u.Phone, &addresses, &u.CreatedAt, &u.UpdatedAt, &u.Version, &deletedAt, &erasedAt); err != nil {
+		return nil, err
+	}
+	u.DeletedAt = nullTime(deletedAt)
+	u.ErasedAt = nullTime(erasedAt)
+	if err := json.Unmarshal(addresses, &u.Addresses); err != nil {
+		return nil, fmt.Errorf("user %s addresses: %w", u.Id, err)
+	}
+	return &u, nil
+}
+
+// GetUsers возвращает не удалённых пользователей, GetDeletedUsers — мягко удалённых
+func (r *Repo) GetUsers(ctx context.Context) ([]*model.User, error) {
+	return r.listUsers(ctx, `deleted_at IS NULL`)
+}
+
+func (r *Repo) GetDeletedUsers(ctx context.Context) ([]*model.User, error) {
+	return r.listUsers(ctx, `deleted_at IS NOT NULL`)
+}
+
+func (r *Repo) listUsers(ctx context.Context, where string) ([]*model.User, error) {
+	rows, err := r.query(ctx, `SELECT `+userColumns+` FROM users WHERE `+where+` ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -136,46 +299,556 @@ func (r *Repo) GetUsers() ([]*model.User, error) {
 
 	var out []*model.User
 	for rows.Next() {
-		var u model.User
-		if err := rows.Scan(&u.Id, &u.Name); err != nil {
+		u, err := scanUser(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, &u)
+		out = append(out, u)
 	}
 	return out, rows.Err()
 }
 
-func (r *Repo) GetUserByID(id string) (*model.User, error) {
-	var u model.User
-	err := r.db.QueryRowContext(r.ctx, `SELECT id, name FROM users WHERE id=$1`, id).
-		Scan(&u.Id, &u.Name)
+func (r *Repo) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	u, err := scanUser(r.queryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1 AND deleted_at IS NULL`, id).Scan)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	return u, nil
 }
 
-func (r *Repo) UpdateUserName(id, name string) (bool, error) {
-	res, err := r.db.ExecContext(r.ctx, `UPDATE users SET name=$1 WHERE id=$2`, name, id)
+// UpdateUser заменяет профиль пользователя (всё, кроме ID и даты создания).
+// expectedVersion > 0 — compare-and-swap по версии; новая версия записывается в u.Version
+func (r *Repo) UpdateUser(ctx context.Context, u *model.User, expectedVersion int64) (bool, error) {
+	addresses, err := json.Marshal(addressesOrEmpty(u.Addresses))
 	if err != nil {
 		return false, err
 	}
-	n, _ := res.RowsAffected()
-	return n > 0, nil
+	err = r.queryRow(ctx,
+		`UPDATE users SET name=$1, email=$2, phone=$3, addresses=$4, updated_at=$5, version=version+1
+		  WHERE id=$6 AND deleted_at IS NULL AND ($7::bigint = 0 OR version=$7)
+		  RETURNING version`,
+		u.Name, u.Email, u.Phone, addresses, u.UpdatedAt, u.Id, expectedVersion).Scan(&u.Version)
+	if err == sql.ErrNoRows {
+		return false, r.versionConflict(ctx, "users", u.Id, expectedVersion)
+	}
+	if err != nil {
+		return false, emailTakenErr(err)
+	}
+	return true, nil
+}
+
+// addressesOrEmpty — пустой список вместо nil, чтобы в jsonb не попадал null
+func addressesOrEmpty(a []model.Address) []model.Address {
+	if a == nil {
+		return []model.Address{}
+	}
+	return a
+}
+
+// код ошибки PostgreSQL unique_violation
+const uniqueViolation = "23505"
+
+// emailTakenErr превращает нарушение уникального индекса users_email_key в model.ErrEmailTaken
+func emailTakenErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "users_email_key" {
+		return model.ErrEmailTaken
+	}
+	return err
+}
+
+// DeleteUser мягко удаляет пользователя, RestoreUser возвращает его
+func (r *Repo) DeleteUser(ctx context.Context, id string) (bool, error) {
+	return r.execAffected(ctx,
+		`UPDATE users SET deleted_at = now(), version = version + 1 WHERE id=$1 AND deleted_at IS NULL`, id)
+}
+
+func (r *Repo) RestoreUser(ctx context.Context, id string) (bool, error) {
+	return r.execAffected(ctx,
+		`UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id=$1 AND deleted_at IS NOT NULL`, id)
 }
 
-func (r *Repo) DeleteUser(id string) (bool, error) {
-	res, err := r.db.ExecContext(r.ctx, `DELETE FROM users WHERE id=$1`, id)
+// EraseUser обезличивает пользователя и стирает адреса в его заказах, доставках и телах доставок вебхуков
+// одной транзакцией
+func (r *Repo) EraseUser(ctx context.Context, id string, at time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return false, err
 	}
-	n, _ := res.RowsAffected()
-	return n > 0, nil
+	defer tx.Rollback()
+
+	const eraseUser = `UPDATE users
+		SET name = $2, email = '', phone = '', addresses = '[]', updated_at = $3, erased_at = $3, version = version + 1
+		WHERE id=$1 AND deleted_at IS NULL`
+	tracing.SetStatement(ctx, eraseUser)
+	res, err := tx.ExecContext(ctx, eraseUser, id, model.ErasedName, at)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	const eraseOrders = `UPDATE orders SET shipping_address = NULL WHERE user_id=$1`
+	tracing.SetStatement(ctx, eraseOrders)
+	if _, err := tx.ExecContext(ctx, eraseOrders, id); err != nil {
+		return false, err
+	}
+
+	const eraseDeliveries = `UPDATE deliveries SET address = '' WHERE user_id=$1`
+	tracing.SetStatement(ctx, eraseDeliveries)
+	if _, err := tx.ExecContext(ctx, eraseDeliveries, id); err != nil {
+		return false, err
+	}
+
+	// снимки заказов в телах доставок вебхуков
+	const eraseWebhookPayloads = `UPDATE webhook_deliveries SET payload = payload #- '{order,shipping_address}'
+		WHERE order_id IN (SELECT id FROM orders WHERE user_id=$1) AND payload #> '{order,shipping_address}' IS NOT NULL`
+	tracing.SetStatement(ctx, eraseWebhookPayloads)
+	if _, err := tx.ExecContext(ctx, eraseWebhookPayloads, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// PurgeDeleted окончательно удаляет записи, мягко удалённые раньше before.
+// Заказы удаляются первыми; заказы удаляемых пользователей уйдут по ON DELETE CASCADE
+func (r *Repo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	var total int64
+	// сначала заказы, затем пользователи без оставшихся заказов: orders.user_id удалил бы их заказы каскадом
+	for _, purge := range []struct{ table, query string }{
+		{"orders", `DELETE FROM orders WHERE deleted_at < $1`},
+		{"users", `DELETE FROM users u WHERE deleted_at < $1 AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)`},
+	} {
+		res, err := r.exec(ctx, purge.query, before)
+		if err != nil {
+			return int(total), fmt.Errorf("purge %s: %w", purge.table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return int(total), err
+		}
+		total += n
+	}
+	return int(total), nil
 }
 
 // Доставки и склады (минимальные заглушки, чтобы удовлетворить интерфейсу)
-func (r *Repo) GetDeliveries() ([]*model.Delivery, error)  { return []*model.Delivery{}, nil }
-func (r *Repo) GetWarehouses() ([]*model.Warehouse, error) { return []*model.Warehouse{}, nil }
+// Доставки и склады
+
+func (r *Repo) SaveDelivery(ctx context.Context, d *model.Delivery) error {
+	_, err := r.exec(ctx, `INSERT INTO deliveries (id, order_id, user_id, address, status) VALUES ($1, $2, $3, $4, $5)`,
+		d.Id, d.OrderId, d.UserId, d.Address, int(d.Status))
+	return err
+}
+
+const deliveryColumns = `id, order_id, user_id, address, status`
+
+func scanDelivery(scan func(dest ...any) error) (*model.Delivery, error) {
+	var d model.Delivery
+	var st int
+	if err := scan(&d.Id, &d.OrderId, &d.UserId, &d.Address, &st); err != nil {
+		return nil, err
+	}
+	d.Status = model.DeliveryStatus(st)
+	return &d, nil
+}
+
+func (r *Repo) GetDeliveries(ctx context.Context) ([]*model.Delivery, error) {
+	rows, err := r.query(ctx, `SELECT `+deliveryColumns+` FROM deliveries ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := make([]*model.Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *Repo) SaveWarehouse(ctx context.Context, w *model.Warehouse) error {
+	_, err := r.exec(ctx, `INSERT INTO warehouses (id, order_id, status) VALUES ($1, $2, $3)`, w.Id, w.OrderId, int(w.Status))
+	return err
+}
+
+const warehouseColumns = `id, order_id, status`
+
+func scanWarehouse(scan func(dest ...any) error) (*model.Warehouse, error) {
+	var w model.Warehouse
+	var st int
+	if err := scan(&w.Id, &w.OrderId, &st); err != nil {
+		return nil, err
+	}
+	w.Status = model.WarehouseStatus(st)
+	return &w, nil
+}
+
+func (r *Repo) GetWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	rows, err := r.query(ctx, `SELECT `+warehouseColumns+` FROM warehouses ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	warehouses := make([]*model.Warehouse, 0)
+	for rows.Next() {
+		w, err := scanWarehouse(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+	return warehouses, rows.Err()
+}
+
+func (r *Repo) Count(ctx context.Context) (*model.StoreCounts, error) {
+	counts := &model.StoreCounts{Orders: make(map[model.OrderStatus]int)}
+	rows, err := r.query(ctx, `SELECT status, count(*) FROM orders WHERE deleted_at IS NULL GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var st, n int
+		if err := rows.Scan(&st, &n); err != nil {
+			return nil, err
+		}
+		counts.Orders[model.OrderStatus(st)] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = r.queryRow(ctx,
+		`SELECT (SELECT count(*) FROM users WHERE deleted_at IS NULL),
+		        (SELECT count(*) FROM deliveries),
+		        (SELECT count(*) FROM warehouses)`).
+		Scan(&counts.Users, &counts.Deliveries, &counts.Warehouses)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Scan читает страницу по ключу ID. Текстовые ID сравниваются с COLLATE "C", то есть побайтно,
+// как в Mongo и MemoryRepo; числовые ID доставок и складов — как числа
+func (r *Repo) Scan(ctx context.Context, kind, after string, limit int) ([]model.Storable, error) {
+	var table, columns string
+	var scan func(func(dest ...any) error) (model.Storable, error)
+	switch kind {
+	case "order":
+		table, columns, scan = "orders", orderColumns, storable(scanOrder)
+	case "user":
+		table, columns, scan = "users", userColumns, storable(scanUser)
+	case "delivery":
+		table, columns, scan = "deliveries", deliveryColumns, storable(scanDelivery)
+	case "warehouse":
+		table, columns, scan = "warehouses", warehouseColumns, storable(scanWarehouse)
+	case "api_key":
+		table, columns, scan = "api_keys", apiKeyColumns, storable(scanAPIKey)
+	case "audit_entry":
+		table, columns, scan = "audit_log", auditColumns, storable(scanAuditEntry)
+	case "webhook":
+		table, columns, scan = "webhooks", webhookColumns, storable(scanWebhook)
+	case "webhook_delivery":
+		table, columns, scan = "webhook_deliveries", webhookDeliveryColumns, storable(scanWebhookDelivery)
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", kind)
+	}
+	key := `id COLLATE "C"`
+	if kind == "delivery" || kind == "warehouse" {
+		key = `id`
+	}
+	args := []any{limit}
+	where := `true`
+	if after != "" {
+		where = key + ` > $2`
+		args = append(args, after)
+		if key == `id` {
+			id, err := strconv.ParseInt(after, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("scan %s after %q: %w", kind, after, err)
+			}
+			args[1] = id
+		}
+	}
+	rows, err := r.query(ctx, `SELECT `+columns+` FROM `+table+` WHERE `+where+` ORDER BY `+key+` LIMIT $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Storable
+	for rows.Next() {
+		s, err := scan(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// storable приводит функцию чтения строки к общему типу Scan
+func storable[T model.Storable](scan func(func(dest ...any) error) (T, error)) func(func(dest ...any) error) (model.Storable, error) {
+	return func(s func(dest ...any) error) (model.Storable, error) { return scan(s) }
+}
+
+// AcquireLease захватывает аренду, если она свободна, истекла или уже принадлежит owner
+func (r *Repo) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	res, err := r.exec(ctx,
+		`INSERT INTO leases (name, owner, expires_at)
+		 VALUES ($1, $2, now() + make_interval(secs => $3))
+		 ON CONFLICT (name) DO UPDATE
+		    SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		  WHERE leases.owner = EXCLUDED.owner OR leases.expires_at < now()`,
+		name, owner, ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// API-ключи; scopes хранятся строкой через запятую
+
+const apiKeyColumns = `id, name, role, scopes, hash, created_at, rotated_at, expires_at, last_used_at, revoked_at`
+
+func (r *Repo) SaveAPIKey(ctx context.Context, k *model.APIKey) error {
+	_, err := r.exec(ctx,
+		`INSERT INTO api_keys (`+apiKeyColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 ON CONFLICT (id) DO UPDATE SET
+		     name = EXCLUDED.name, role = EXCLUDED.role, scopes = EXCLUDED.scopes, hash = EXCLUDED.hash,
+		     rotated_at = EXCLUDED.rotated_at, expires_at = EXCLUDED.expires_at,
+		     last_used_at = EXCLUDED.last_used_at, revoked_at = EXCLUDED.revoked_at`,
+		k.Id, k.Name, k.Role, strings.Join(k.Scopes, ","), k.Hash, k.CreatedAt,
+		k.RotatedAt, k.ExpiresAt, k.LastUsedAt, k.RevokedAt)
+	return err
+}
+
+// scanAPIKey читает строку api_keys (rows или row)
+func scanAPIKey(scan func(dest ...any) error) (*model.APIKey, error) {
+	var k model.APIKey
+	var scopes string
+	var rotatedAt, expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := scan(&k.Id, &k.Name, &k.Role, &scopes, &k.Hash, &k.CreatedAt,
+		&rotatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	k.RotatedAt = nullTime(rotatedAt)
+	k.ExpiresAt = nullTime(expiresAt)
+	k.LastUsedAt = nullTime(lastUsedAt)
+	k.RevokedAt = nullTime(revokedAt)
+	return &k, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (r *Repo) GetAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	rows, err := r.query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, k)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) GetAPIKeyByID(ctx context.Context, id string) (*model.APIKey, error) {
+	k, err := scanAPIKey(r.queryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id=$1`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+func (r *Repo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.exec(ctx, `UPDATE api_keys SET last_used_at=$1 WHERE id=$2`, usedAt, id)
+	return err
+}
+
+// Журнал аудита
+
+const auditColumns = `id, at, actor, action, subject, details`
+
+func (r *Repo) SaveAuditEntry(ctx context.Context, e *model.AuditEntry) error {
+	_, err := r.exec(ctx,
+		`INSERT INTO audit_log (`+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.Id, e.At, e.Actor, e.Action, e.Subject, e.Details)
+	return err
+}
+
+func (r *Repo) GetAuditEntries(ctx context.Context, subjects []string) ([]*model.AuditEntry, error) {
+	rows, err := r.query(ctx, `SELECT `+auditColumns+` FROM audit_log WHERE subject = ANY($1) ORDER BY at, id`, subjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*model.AuditEntry, 0)
+	for rows.Next() {
+		e, err := scanAuditEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+func scanAuditEntry(scan func(dest ...any) error) (*model.AuditEntry, error) {
+	var e model.AuditEntry
+	if err := scan(&e.Id, &e.At, &e.Actor, &e.Action, &e.Subject, &e.Details); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Вебхуки; типы событий подписки хранятся строкой через запятую, попытки доставки — в jsonb
+
+const webhookColumns = `id, url, events, secret, created_by, created_at`
+
+func (r *Repo) SaveWebhook(ctx context.Context, w *model.Webhook) error {
+	_, err := r.exec(ctx,
+		`INSERT INTO webhooks (`+webhookColumns+`) VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (id) DO UPDATE SET url = EXCLUDED.url, events = EXCLUDED.events, secret = EXCLUDED.secret`,
+		w.Id, w.URL, strings.Join(w.Events, ","), w.Secret, w.CreatedBy, w.CreatedAt)
+	return err
+}
+
+// scanWebhook читает строку webhooks (rows или row)
+func scanWebhook(scan func(dest ...any) error) (*model.Webhook, error) {
+	var w model.Webhook
+	var events string
+	if err := scan(&w.Id, &w.URL, &events, &w.Secret, &w.CreatedBy, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	if events != "" {
+		w.Events = strings.Split(events, ",")
+	}
+	return &w, nil
+}
+
+func (r *Repo) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	rows, err := r.query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*model.Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) GetWebhookByID(ctx context.Context, id string) (*model.Webhook, error) {
+	w, err := scanWebhook(r.queryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return w, err
+}
+
+func (r *Repo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	return r.execAffected(ctx, `DELETE FROM webhooks WHERE id=$1`, id)
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event, order_id, payload, status, attempts, created_at, next_attempt_at, requeued_at, delivered_at`
+
+func (r *Repo) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	attempts := []byte("[]") // nil-слайс дал бы в jsonb null
+	if len(d.Attempts) > 0 {
+		var err error
+		if attempts, err = json.Marshal(d.Attempts); err != nil {
+			return err
+		}
+	}
+	_, err := r.exec(ctx,
+		`INSERT INTO webhook_deliveries (`+webhookDeliveryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, attempts = EXCLUDED.attempts,
+		     next_attempt_at = EXCLUDED.next_attempt_at, requeued_at = EXCLUDED.requeued_at, delivered_at = EXCLUDED.delivered_at`,
+		d.Id, d.WebhookID, d.Event, d.OrderID, []byte(d.Payload), d.Status, attempts, d.CreatedAt, d.NextAttemptAt, d.RequeuedAt, d.DeliveredAt)
+	return err
+}
+
+// scanWebhookDelivery читает строку webhook_deliveries (rows или row)
+func scanWebhookDelivery(scan func(dest ...any) error) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	var payload, attempts []byte
+	var nextAttemptAt, requeuedAt, deliveredAt sql.NullTime
+	if err := scan(&d.Id, &d.WebhookID, &d.Event, &d.OrderID, &payload, &d.Status, &attempts,
+		&d.CreatedAt, &nextAttemptAt, &requeuedAt, &deliveredAt); err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	if err := json.Unmarshal(attempts, &d.Attempts); err != nil {
+		return nil, fmt.Errorf("webhook delivery %s attempts: %w", d.Id, err)
+	}
+	d.NextAttemptAt = nullTime(nextAttemptAt)
+	d.RequeuedAt = nullTime(requeuedAt)
+	d.DeliveredAt = nullTime(deliveredAt)
+	return &d, nil
+}
+
+func (r *Repo) GetWebhookDeliveries(ctx context.Context, webhookID, status string) ([]*model.WebhookDelivery, error) {
+	rows, err := r.query(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		  WHERE ($1 = '' OR webhook_id = $1) AND ($2 = '' OR status = $2)
+		  ORDER BY created_at, id`, webhookID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) GetWebhookDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.queryRow(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id=$1`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
@@ -0,0 +1,326 @@
+// Package seed генерирует правдоподобные тестовые данные — пользователей с адресами, заказы во всех статусах,
+// доставки и складские записи — и записывает их в хранилище. Одно и то же зерно даёт одни и те же данные,
+// включая ID и даты, поэтому повторный запуск ничего не дублирует.
+//
+// Товаров в модели нет: заказ не хранит позиций, поэтому и генерировать их некуда
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+)
+
+const (
+	DefaultUsers  = 50
+	DefaultOrders = 500
+	DefaultDays   = 90
+)
+
+// DefaultStart — начало периода по умолчанию; фиксировано, чтобы данные не зависели от дня запуска
+var DefaultStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Options — параметры генерации
+type Options struct {
+	Seed   uint64
+	Users  int
+	Orders int       // всего заказов; распределяются по пользователям неравномерно, как в жизни
+	Start  time.Time // записи созданы в периоде [Start, Start+Days)
+	Days   int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Users <= 0 {
+		o.Users = DefaultUsers
+	}
+	if o.Orders < 0 {
+		o.Orders = 0
+	}
+	if o.Start.IsZero() {
+		o.Start = DefaultStart
+	}
+	if o.Days <= 0 {
+		o.Days = DefaultDays
+	}
+	o.Start = o.Start.UTC()
+	return o
+}
+
+// Dataset — сгенерированные записи
+type Dataset struct {
+	Users      []*model.User
+	Orders     []*model.Order
+	Deliveries []*model.Delivery
+	Warehouses []*model.Warehouse
+}
+
+// статусы доставки и складской записи: 0 — в работе, 1 — завершена
+const (
+	deliveryNew       model.DeliveryStatus  = 0
+	deliveryDone      model.DeliveryStatus  = 1
+	warehouseReserved model.WarehouseStatus = 0
+	warehouseShipped  model.WarehouseStatus = 1
+)
+
+// Generate строит набор данных. ID повторяют формат конструкторов модели (наносекунды создания),
+// но берутся из сгенерированных дат, а не из часов
+func Generate(opts Options) *Dataset {
+	opts = opts.withDefaults()
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	period := time.Duration(opts.Days) * 24 * time.Hour
+	ds := &Dataset{}
+
+	for i := range opts.Users {
+		created := opts.Start.Add(randDuration(rng, period/2)).Add(time.Duration(i))
+		ds.Users = append(ds.Users, newUser(rng, i, created))
+	}
+
+	// у немногих покупателей большая часть заказов: вес пользователя — квадрат случайного числа
+	weights := make([]float64, len(ds.Users))
+	total := 0.0
+	for i := range weights {
+		w := rng.Float64()
+		weights[i] = w * w
+		total += weights[i]
+	}
+	end := opts.Start.Add(period)
+	for i := range opts.Orders {
+		user := ds.Users[pick(rng, weights, total)]
+		// целые секунды после регистрации плюс номер заказа в наносекундах — ID не совпадут
+		after := time.Second + randDuration(rng, end.Sub(user.CreatedAt)-time.Second)
+		created := user.CreatedAt.Truncate(time.Second).Add(after).Add(time.Duration(i))
+		order, delivery, warehouse := newOrder(rng, user, created)
+		ds.Orders = append(ds.Orders, order)
+		if delivery != nil {
+			ds.Deliveries = append(ds.Deliveries, delivery)
+		}
+		if warehouse != nil {
+			ds.Warehouses = append(ds.Warehouses, warehouse)
+		}
+	}
+	slices.SortFunc(ds.Orders, func(a, b *model.Order) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return ds
+}
+
+var (
+	maleNames   = []string{"Ivan", "Dmitry", "Alexey", "Sergey", "Andrey", "Mikhail", "Nikolay", "Pavel", "Artem", "Maxim", "Egor", "Kirill"}
+	femaleNames = []string{"Anna", "Maria", "Elena", "Olga", "Natalia", "Tatiana", "Irina", "Ekaterina", "Svetlana", "Daria", "Polina", "Ksenia"}
+	surnames    = []string{"Ivanov", "Smirnov", "Kuznetsov", "Popov", "Vasiliev", "Petrov", "Sokolov", "Mikhailov", "Novikov", "Fedorov", "Morozov", "Volkov", "Lebedev", "Kozlov", "Egorov", "Pavlov"}
+	domains     = []string{"example.com", "example.org", "example.net"}
+
+	cities = []struct{ name, postal string }{
+		{"Москва", "101"}, {"Санкт-Петербург", "190"}, {"Новосибирск", "630"}, {"Екатеринбург", "620"},
+		{"Казань", "420"}, {"Нижний Новгород", "603"}, {"Самара", "443"}, {"Ростов-на-Дону", "344"},
+	}
+	streets = []string{"ул. Ленина", "ул. Гагарина", "пр. Мира", "ул. Советская", "ул. Садовая", "ул. Пушкина", "Набережная ул.", "ул. Лесная", "ул. Победы", "Молодёжная ул."}
+	labels  = []string{"дом", "работа", "дача"}
+)
+
+func newUser(rng *rand.Rand, i int, created time.Time) *model.User {
+	first, last := pickString(rng, maleNames), pickString(rng, surnames)
+	if rng.IntN(2) == 0 {
+		first, last = pickString(rng, femaleNames), last+"a"
+	}
+	u := &model.User{
+		Id:   fmt.Sprintf("User-%d", created.UnixNano()),
+		Name: first + " " + last,
+		// порядковый номер делает email уникальным при совпадении имён
+		Email:     fmt.Sprintf("%s.%s%d@%s", strings.ToLower(first), strings.ToLower(last), i+1, pickString(rng, domains)),
+		CreatedAt: created,
+		UpdatedAt: created,
+		Version:   1,
+	}
+	if rng.IntN(10) < 7 {
+		u.Phone = fmt.Sprintf("+79%09d", rng.IntN(1_000_000_000))
+	}
+	for n := range 1 + weighted(rng, 6, 3, 1) {
+		city := cities[rng.IntN(len(cities))]
+		u.Addresses = append(u.Addresses, model.Address{
+			Id:         fmt.Sprintf("addr-%d", n+1),
+			Label:      labels[n],
+			Country:    "Россия",
+			City:       city.name,
+			Street:     fmt.Sprintf("%s, д. %d, кв. %d", pickString(rng, streets), 1+rng.IntN(120), 1+rng.IntN(300)),
+			PostalCode: fmt.Sprintf("%s%03d", city.postal, rng.IntN(1000)),
+		})
+	}
+	return u
+}
+
+// newOrder создаёт заказ в случайном статусе; версия растёт с каждой сменой статуса, как в репозиториях.
+// Подтверждённому заказу соответствуют складская запись и доставка
+func newOrder(rng *rand.Rand, user *model.User, created time.Time) (*model.Order, *model.Delivery, *model.Warehouse) {
+	o := &model.Order{
+		Id:        fmt.Sprintf("Order-%d", created.UnixNano()),
+		UserID:    user.Id,
+		CreatedAt: created,
+		Version:   1,
+	}
+	if len(user.Addresses) > 0 {
+		address := user.Addresses[rng.IntN(len(user.Addresses))]
+		o.ShippingAddress = &address
+	}
+	// доля статусов: 15% создан, 20% подтверждён, 55% доставлен, 10% отменён
+	o.Status = []model.OrderStatus{model.OrderCreated, model.OrderConfirmed, model.OrderDelivered, model.OrderCancelled}[weighted(rng, 15, 20, 55, 10)]
+	switch o.Status {
+	case model.OrderConfirmed:
+		o.Version = 2
+	case model.OrderDelivered:
+		o.Version = 3
+	case model.OrderCancelled:
+		// часть заказов отменена планировщиком, не дождавшись подтверждения склада
+		if rng.IntN(2) == 0 {
+			o.CancelReason = model.CancelReasonExpired
+		}
+		o.Version = 2
+	}
+	if o.Status != model.OrderConfirmed && o.Status != model.OrderDelivered {
+		return o, nil, nil
+	}
+
+	d := &model.Delivery{Id: created.UnixNano(), OrderId: o.Id, UserId: user.Id, Status: deliveryNew}
+	if a := o.ShippingAddress; a != nil {
+		d.Address = fmt.Sprintf("%s, %s, %s", a.PostalCode, a.City, a.Street)
+	}
+	w := &model.Warehouse{Id: created.UnixNano(), OrderId: o.Id, Status: warehouseReserved}
+	if o.Status == model.OrderDelivered {
+		d.Status, w.Status = deliveryDone, warehouseShipped
+	}
+	return o, d, w
+}
+
+func randDuration(rng *rand.Rand, limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rng.Int64N(int64(limit))).Truncate(time.Second)
+}
+
+func pickString(rng *rand.Rand, list []string) string {
+	return list[rng.IntN(len(list))]
+}
+
+// weighted возвращает индекс веса с вероятностью, пропорциональной весу
+func weighted(rng *rand.Rand, weights ...int) int {
+	n := rng.IntN(sum(weights))
+	for i, w := range weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(weights) - 1
+}
+
+func sum(weights []int) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	return total
+}
+
+func pick(rng *rand.Rand, weights []float64, total float64) int {
+	x := rng.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	return len(weights) - 1
+}
+
+// Counts — сколько записей каждого типа записано и сколько уже было в хранилище
+type Counts struct {
+	Kind     string
+	Total    int
+	Existing int
+}
+
+// Write сохраняет набор в хранилище, пропуская записи, чьи ID там уже есть. Заказы пишутся пакетами по batchSize
+func Write(ctx context.Context, repo service.Repository, ds *Dataset, batchSize int) ([]Counts, error) {
+	if batchSize <= 0 {
+		batchSize = len(ds.Orders) + 1
+	}
+	counts := make([]Counts, 0, 4)
+
+	c := Counts{Kind: "users", Total: len(ds.Users)}
+	for _, u := range ds.Users {
+		existing, err := repo.GetUserByID(ctx, u.Id)
+		if err != nil {
+			return counts, fmt.Errorf("user %s: %w", u.Id, err)
+		}
+		if existing != nil {
+			c.Existing++
+			continue
+		}
+		if err := repo.SaveUser(ctx, u); err != nil {
+			return counts, fmt.Errorf("user %s: %w", u.Id, err)
+		}
+	}
+	counts = append(counts, c)
+
+	c = Counts{Kind: "orders", Total: len(ds.Orders)}
+	var pending []*model.Order
+	for _, o := range ds.Orders {
+		existing, err := repo.GetOrderByID(ctx, o.Id)
+		if err != nil {
+			return counts, fmt.Errorf("order %s: %w", o.Id, err)
+		}
+		if existing != nil {
+			c.Existing++
+			continue
+		}
+		pending = append(pending, o)
+	}
+	for batch := range slices.Chunk(pending, batchSize) {
+		if err := repo.SaveOrders(ctx, batch); err != nil {
+			return counts, fmt.Errorf("orders: %w", err)
+		}
+	}
+	counts = append(counts, c)
+
+	deliveries, err := repo.GetDeliveries(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("deliveries: %w", err)
+	}
+	c, err = saveNew(ctx, repo, "deliveries", ds.Deliveries, deliveries, func(d *model.Delivery) int64 { return d.Id })
+	counts = append(counts, c)
+	if err != nil {
+		return counts, err
+	}
+
+	warehouses, err := repo.GetWarehouses(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("warehouses: %w", err)
+	}
+	c, err = saveNew(ctx, repo, "warehouses", ds.Warehouses, warehouses, func(w *model.Warehouse) int64 { return w.Id })
+	counts = append(counts, c)
+	return counts, err
+}
+
+// saveNew сохраняет через Save записи, которых нет среди existing
+func saveNew[T model.Storable](ctx context.Context, repo service.Repository, kind string, items, existing []T, id func(T) int64) (Counts, error) {
+	c := Counts{Kind: kind, Total: len(items)}
+	seen := make(map[int64]bool, len(existing))
+	for _, item := range existing {
+		seen[id(item)] = true
+	}
+	for _, item := range items {
+		if seen[id(item)] {
+			c.Existing++
+			continue
+		}
+		if err := repo.Save(ctx, item); err != nil {
+			return c, fmt.Errorf("%s %d: %w", kind, id(item), err)
+		}
+	}
+	return c, nil
+}
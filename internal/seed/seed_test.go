@@ -0,0 +1,84 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест генерации: одно зерно — одни и те же данные, записи согласованы между собой
+func TestGenerate(t *testing.T) {
+	opts := Options{Seed: 42, Users: 20, Orders: 200}
+	ds := Generate(opts)
+	assert.Equal(t, ds, Generate(opts))
+	assert.NotEqual(t, ds.Orders[0].Id, Generate(Options{Seed: 43, Users: 20, Orders: 200}).Orders[0].Id)
+
+	require.Len(t, ds.Users, 20)
+	require.Len(t, ds.Orders, 200)
+	users := map[string]*model.User{}
+	emails := map[string]bool{}
+	for _, u := range ds.Users {
+		require.NoError(t, u.Validate(), u.Id)
+		assert.False(t, emails[u.Email], "duplicate email %s", u.Email)
+		emails[u.Email] = true
+		users[u.Id] = u
+	}
+
+	end := DefaultStart.Add(DefaultDays * 24 * time.Hour)
+	ids := map[string]bool{}
+	statuses := map[model.OrderStatus]int{}
+	versions := map[model.OrderStatus]int64{model.OrderCreated: 1, model.OrderConfirmed: 2, model.OrderDelivered: 3, model.OrderCancelled: 2}
+	for _, o := range ds.Orders {
+		assert.False(t, ids[o.Id], "duplicate order %s", o.Id)
+		ids[o.Id] = true
+		user := users[o.UserID]
+		require.NotNil(t, user, o.Id)
+		assert.False(t, o.CreatedAt.Before(user.CreatedAt), o.Id)
+		assert.True(t, o.CreatedAt.Before(end), o.Id)
+		assert.Equal(t, versions[o.Status], o.Version, o.Id)
+		statuses[o.Status]++
+	}
+	assert.Len(t, statuses, 4, "all statuses expected in 200 orders")
+
+	// доставка и складская запись есть ровно у подтверждённых и доставленных заказов
+	assert.Equal(t, statuses[model.OrderConfirmed]+statuses[model.OrderDelivered], len(ds.Deliveries))
+	assert.Len(t, ds.Warehouses, len(ds.Deliveries))
+	for _, d := range ds.Deliveries {
+		assert.True(t, ids[d.OrderId])
+		assert.NotEmpty(t, d.Address)
+	}
+}
+
+// тест записи: повторный запуск с тем же зерном ничего не дублирует
+func TestWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
+	ds := Generate(Options{Seed: 7, Users: 5, Orders: 30})
+
+	counts, err := Write(ctx, repo, ds, 8)
+	require.NoError(t, err)
+	for _, c := range counts {
+		assert.Zero(t, c.Existing, c.Kind)
+	}
+	orders, _ := repo.GetOrders(ctx)
+	assert.Len(t, orders, 30)
+
+	counts, err = Write(ctx, repo, Generate(Options{Seed: 7, Users: 5, Orders: 30}), 8)
+	require.NoError(t, err)
+	for _, c := range counts {
+		assert.Equal(t, c.Total, c.Existing, c.Kind)
+	}
+	users, _ := repo.GetUsers(ctx)
+	orders, _ = repo.GetOrders(ctx)
+	deliveries, _ := repo.GetDeliveries(ctx)
+	assert.Len(t, users, 5)
+	assert.Len(t, orders, 30)
+	assert.Len(t, deliveries, len(ds.Deliveries))
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-ms/internal/auth"
+	"order-ms/internal/model"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// префикс ключа: по нему ключ легко узнать в логах и сканерах секретов
+const apiKeyPrefix = "oms_"
+
+// не чаще, чем раз в apiKeyTouchInterval, пишем в БД время последнего использования ключа
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeyRevoked        = errors.New("api key revoked")
+)
+
+// роли, которые можно выдать ключу: ключи для сервисов, а не для покупателей
+var apiKeyRoles = []string{RoleWarehouse, RoleCourier, RoleAdmin}
+
+// APIKeys выпускает, перевыпускает и отзывает API-ключи и проверяет их при аутентификации
+type APIKeys struct {
+	repo   Repository
+	logger *slog.Logger
+}
+
+func NewAPIKeys(repo Repository, logger *slog.Logger) *APIKeys {
+	return &APIKeys{repo: repo, logger: logger}
+}
+
+// Issue выпускает ключ; секрет возвращается один раз и нигде не хранится.
+// ttl == 0 — бессрочный ключ
+func (k *APIKeys) Issue(ctx context.Context, name, role string, scopes []string, ttl time.Duration) (_ *model.APIKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "IssueAPIKey", attribute.String("api_key.role", role))
+	defer func() { endSpan(span, err) }()
+
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if !slices.Contains(apiKeyRoles, role) {
+		return nil, "", fmt.Errorf("%w: role must be one of %s", ErrInvalidAPIKeyRequest, strings.Join(apiKeyRoles, ", "))
+	}
+	for _, scope := range scopes {
+		if _, ok := policy[Action(scope)]; !ok {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+	}
+	if ttl < 0 {
+		return nil, "", fmt.Errorf("%w: ttl must not be negative", ErrInvalidAPIKeyRequest)
+	}
+
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+	key := model.NewAPIKey(name, role, scopes, expiresAt)
+	secret, err := newSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := k.repo.SaveAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	k.logger.InfoContext(ctx, "api key issued", "key_id", key.Id, "role", role)
+	return key.Redacted(), secret, nil
+}
+
+// List возвращает все ключи без хешей
+func (k *APIKeys) List(ctx context.Context) ([]*model.APIKey, error) {
+	keys, err := k.repo.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.APIKey, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, key.Redacted())
+	}
+	return res, nil
+}
+
+// Rotate выпускает новый секрет для ключа; старый сразу перестаёт действовать
+func (k *APIKeys) Rotate(ctx context.Context, id string) (_ *model.APIKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "RotateAPIKey", attribute.String("api_key.id", id))
+	defer func() { endSpan(span, err) }()
+
+	key, err := k.get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.RevokedAt != nil {
+		return nil, "", ErrAPIKeyRevoked
+	}
+	secret, err := newSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	key.RotatedAt = &now
+	if err := k.repo.SaveAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	k.logger.InfoContext(ctx, "api key rotated", "key_id", key.Id)
+	return key.Redacted(), secret, nil
+}
+
+// Revoke отзывает ключ; повторный отзыв ничего не меняет
+func (k *APIKeys) Revoke(ctx context.Context, id string) (_ *model.APIKey, err error) {
+	ctx, span := startSpan(ctx, "RevokeAPIKey", attribute.String("api_key.id", id))
+	defer func() { endSpan(span, err) }()
+
+	key, err := k.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := k.repo.SaveAPIKey(ctx, key); err != nil {
+			return nil, err
+		}
+		k.logger.InfoContext(ctx, "api key revoked", "key_id", key.Id)
+	}
+	return key.Redacted(), nil
+}
+
+// VerifyAPIKey проверяет ключ из заголовка X-API-Key (реализует auth.KeyVerifier)
+func (k *APIKeys) VerifyAPIKey(ctx context.Context, raw string) (_ *auth.Principal, err error) {
+	ctx, span := startSpan(ctx, "VerifyAPIKey")
+	defer func() { endSpan(span, err) }()
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, apiKeyPrefix), ".")
+	if !ok || !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, auth.ErrInvalidToken
+	}
+	key, err := k.repo.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key == nil || !key.Active(now) || subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.Hash)) != 1 {
+		return nil, auth.ErrInvalidToken
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := k.repo.TouchAPIKey(ctx, key.Id, now); err != nil {
+			k.logger.WarnContext(ctx, "cannot update api key last use", "key_id", key.Id, "err", err)
+		}
+	}
+	return &auth.Principal{Subject: "apikey:" + key.Id, Roles: []string{key.Role}, Scopes: key.Scopes}, nil
+}
+
+func (k *APIKeys) get(ctx context.Context, id string) (*model.APIKey, error) {
+	key, err := k.repo.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// newSecret генерирует секрет, записывает его хеш в key и возвращает ключ целиком: oms_<id>.<secret>
+func newSecret(key *model.APIKey) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	key.Hash = hashSecret(secret)
+	return apiKeyPrefix + key.Id + "." + secret, nil
+}
+
+// секрет — 32 случайных байта, поэтому медленный хеш (bcrypt) не нужен
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
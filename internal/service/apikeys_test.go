@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"order-ms/internal/auth"
+	"order-ms/internal/logging"
+	"order-ms/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест жизненного цикла ключа: выпуск, проверка, перевыпуск и отзыв
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := service.NewAPIKeys(newRepo(t), logging.Discard())
+
+	key, secret, err := keys.Issue(ctx, "wms", service.RoleWarehouse, []string{string(service.ActionOrderConfirm)}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, key.Hash, "hash is never returned")
+	assert.Contains(t, secret, key.Id)
+
+	p, err := keys.VerifyAPIKey(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "apikey:"+key.Id, p.Subject)
+	assert.Equal(t, []string{service.RoleWarehouse}, p.Roles)
+	assert.Equal(t, []string{string(service.ActionOrderConfirm)}, p.Scopes)
+
+	_, err = keys.VerifyAPIKey(ctx, secret+"x")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// после перевыпуска старый секрет не действует
+	_, rotated, err := keys.Rotate(ctx, key.Id)
+	require.NoError(t, err)
+	_, err = keys.VerifyAPIKey(ctx, secret)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = keys.VerifyAPIKey(ctx, rotated)
+	assert.NoError(t, err)
+
+	revoked, err := keys.Revoke(ctx, key.Id)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = keys.VerifyAPIKey(ctx, rotated)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, _, err = keys.Rotate(ctx, key.Id)
+	assert.ErrorIs(t, err, service.ErrAPIKeyRevoked)
+
+	list, err := keys.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Empty(t, list[0].Hash)
+
+	_, err = keys.Revoke(ctx, "nope")
+	assert.ErrorIs(t, err, service.ErrAPIKeyNotFound)
+}
+
+func TestIssueAPIKeyValidation(t *testing.T) {
+	keys := service.NewAPIKeys(newRepo(t), logging.Discard())
+	tests := []struct {
+		name   string
+		key    string
+		role   string
+		scopes []string
+		ttl    time.Duration
+	}{
+		{name: "no name", role: service.RoleAdmin},
+		{name: "customer role", key: "k", role: service.RoleCustomer},
+		{name: "unknown scope", key: "k", role: service.RoleAdmin, scopes: []string{"order:fly"}},
+		{name: "negative ttl", key: "k", role: service.RoleAdmin, ttl: -time.Hour},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := keys.Issue(context.Background(), tc.key, tc.role, tc.scopes, tc.ttl)
+			assert.ErrorIs(t, err, service.ErrInvalidAPIKeyRequest)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"order-ms/internal/auth"
+	"order-ms/internal/model"
+)
+
+// SystemActor — исполнитель в журнале аудита для фоновых задач и вызовов без аутентификации
+const SystemActor = "system"
+
+// Actor возвращает, от чьего имени выполняется действие: subject вызывающего из ctx или SystemActor
+func Actor(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+	return SystemActor
+}
+
+// Audit записывает действие в журнал аудита. Ошибка записи только логируется:
+// само действие к этому моменту уже выполнено
+func Audit(ctx context.Context, repo Repository, logger *slog.Logger, action Action, subject, details string) {
+	e := model.NewAuditEntry(Actor(ctx), string(action), subject, details)
+	if err := repo.SaveAuditEntry(ctx, e); err != nil {
+		logger.ErrorContext(ctx, "cannot write audit entry", "action", e.Action, "subject", subject, "err", err)
+		return
+	}
+	logger.InfoContext(ctx, "audit", "actor", e.Actor, "action", e.Action, "subject", subject)
+}
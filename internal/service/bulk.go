@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"order-ms/internal/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// форматы пакетной загрузки и выгрузки заказов
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// размер пакета вставки по умолчанию и предельный (8 параметров на заказ в одном INSERT Postgres)
+const (
+	DefaultImportBatch = 500
+	MaxImportBatch     = 1000
+)
+
+// ErrInvalidImport — входные данные нельзя разобрать целиком: неизвестный формат или нет нужных колонок (HTTP 400)
+var ErrInvalidImport = errors.New("invalid import")
+
+// колонки CSV при выгрузке; загрузка понимает те же колонки, version и незнакомые пропускает
+var csvColumns = []string{"id", "user_id", "address_id", "status", "created_at", "cancel_reason", "version"}
+
+// ImportOptions — параметры загрузки
+type ImportOptions struct {
+	Format    string // FormatCSV или FormatNDJSON
+	DryRun    bool   // только проверить строки, ничего не записывать
+	BatchSize int    // сколько заказов вставлять за раз; 0 — DefaultImportBatch
+}
+
+// ImportRowError — ошибка в строке входных данных
+type ImportRowError struct {
+	Line  int    `json:"line"` // номер строки во входных данных, в CSV считая заголовок
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportResult — итог загрузки
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`    // сколько строк прочитано
+	Imported int              `json:"imported"` // сколько заказов записано (в dry-run — сколько было бы записано)
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// importRow — строка загрузки; одинакова для CSV и NDJSON
+type importRow struct {
+	line         int
+	err          error // строку не удалось разобрать
+	id           string
+	userID       string
+	addressID    string
+	status       string
+	createdAt    string
+	cancelReason string
+}
+
+// ImportOrders загружает заказы из CSV или NDJSON. Каждая строка проверяется (пользователь существует,
+// адрес есть в его профиле, статус известен, ID не занят), подходящие заказы вставляются пакетами.
+// Ошибки отдельных строк попадают в ImportResult.Errors и не прерывают загрузку
+func ImportOrders(ctx context.Context, repo Repository, r io.Reader, opts ImportOptions) (res *ImportResult, err error) {
+	ctx, span := startSpan(ctx, "ImportOrders", attribute.String("import.format", opts.Format), attribute.Bool("import.dry_run", opts.DryRun))
+	defer func() {
+		if res != nil {
+			span.SetAttributes(attribute.Int("import.total", res.Total), attribute.Int("import.imported", res.Imported), attribute.Int("import.failed", res.Failed))
+		}
+		endSpan(span, err)
+	}()
+
+	next, err := rowReader(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultImportBatch
+	}
+	if size > MaxImportBatch {
+		return nil, fmt.Errorf("%w: batch size must not exceed %d", ErrInvalidImport, MaxImportBatch)
+	}
+
+	// занятые ID, включая мягко удалённые заказы
+	taken := make(map[string]bool)
+	for _, list := range []func(context.Context) ([]*model.Order, error){repo.GetOrders, repo.GetDeletedOrders} {
+		orders, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			taken[o.Id] = true
+		}
+	}
+
+	res = &ImportResult{DryRun: opts.DryRun, Errors: []ImportRowError{}}
+	fail := func(line int, id string, err error) {
+		res.Failed++
+		res.Errors = append(res.Errors, ImportRowError{Line: line, ID: id, Error: err.Error()})
+	}
+
+	var batch []*model.Order
+	var lines []int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if opts.DryRun {
+			res.Imported += len(batch)
+		} else if err := repo.SaveOrders(ctx, batch); err != nil {
+			for i, o := range batch {
+				fail(lines[i], o.Id, fmt.Errorf("batch insert failed: %w", err))
+			}
+		} else {
+			res.Imported += len(batch)
+		}
+		batch, lines = batch[:0], lines[:0]
+	}
+
+	users := make(map[string]*model.User) // nil — пользователя нет
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Total++
+		if row.err != nil {
+			fail(row.line, row.id, row.err)
+			continue
+		}
+
+		u, ok := users[row.userID]
+		if !ok && row.userID != "" {
+			if u, err = repo.GetUserByID(ctx, row.userID); err != nil {
+				return nil, err
+			}
+			users[row.userID] = u
+		}
+		order, err := row.order(u)
+		if err == nil && taken[order.Id] {
+			if row.id != "" {
+				err = errors.New("order id already exists")
+			} else {
+				order.Id = freeOrderID(taken, order.Id) // сгенерированный ID совпал на грубых часах
+			}
+		}
+		if err != nil {
+			fail(row.line, row.id, err)
+			continue
+		}
+		taken[order.Id] = true
+		batch = append(batch, order)
+		lines = append(lines, row.line)
+		if len(batch) == size {
+			flush()
+		}
+	}
+	flush()
+	return res, nil
+}
+
+// order проверяет строку и собирает из неё заказ; u — владелец заказа (nil, если не найден)
+func (row importRow) order(u *model.User) (*model.Order, error) {
+	if row.userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	if u == nil {
+		return nil, fmt.Errorf("user %s not found", row.userID)
+	}
+	o := model.NewOrder(u.Id)
+	if row.id != "" {
+		o.Id = row.id
+	}
+	if row.status != "" {
+		st, err := model.ParseOrderStatus(row.status)
+		if err != nil {
+			return nil, err
+		}
+		o.Status = st
+	}
+	if row.createdAt != "" {
+		t, err := time.Parse(time.RFC3339, row.createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("created_at must be RFC 3339: %w", err)
+		}
+		o.CreatedAt = t
+	}
+	if row.cancelReason != "" && o.Status != model.OrderCancelled {
+		return nil, errors.New("cancel_reason is only allowed for cancelled orders")
+	}
+	o.CancelReason = row.cancelReason
+	if row.addressID != "" {
+		a, ok := u.Address(row.addressID)
+		if !ok {
+			return nil, fmt.Errorf("user %s has no address %s", u.Id, row.addressID)
+		}
+		o.ShippingAddress = &a
+	}
+	return o, nil
+}
+
+// freeOrderID возвращает сгенерированный id, а если он занят — id с первым свободным суффиксом -1, -2, ...
+func freeOrderID(taken map[string]bool, id string) string {
+	free := id
+	for n := 1; taken[free]; n++ {
+		free = id + "-" + strconv.Itoa(n)
+	}
+	return free
+}
+
+// rowReader возвращает функцию, читающую строки по одной; io.EOF — строки кончились
+func rowReader(r io.Reader, format string) (func() (importRow, error), error) {
+	switch format {
+	case FormatCSV:
+		return csvRows(r)
+	case FormatNDJSON:
+		return ndjsonRows(r), nil
+	default:
+		return nil, fmt.Errorf("%w: format must be %s or %s", ErrInvalidImport, FormatCSV, FormatNDJSON)
+	}
+}
+
+// csvRows читает CSV с заголовком; обязательна только колонка user_id
+func csvRows(r io.Reader) (func() (importRow, error), error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // число полей проверяем сами, чтобы ошибка касалась только строки
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: empty input", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := index["user_id"]; !ok {
+		return nil, fmt.Errorf("%w: csv header must contain user_id", ErrInvalidImport)
+	}
+
+	return func() (importRow, error) {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return importRow{}, io.EOF
+		}
+		line, _ := cr.FieldPos(0)
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			return importRow{line: perr.StartLine, err: perr.Err}, nil
+		}
+		if err != nil {
+			return importRow{}, err
+		}
+		if len(record) != len(header) {
+			return importRow{line: line, err: fmt.Errorf("expected %d fields, got %d", len(header), len(record))}, nil
+		}
+		field := func(name string) string {
+			if i, ok := index[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		return importRow{
+			line:         line,
+			id:           field("id"),
+			userID:       field("user_id"),
+			addressID:    field("address_id"),
+			status:       field("status"),
+			createdAt:    field("created_at"),
+			cancelReason: field("cancel_reason"),
+		}, nil
+	}, nil
+}
+
+// ndjsonLine — строка NDJSON; понимает и выгрузку заказов (status числом, адрес объектом)
+type ndjsonLine struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	AddressID       string          `json:"address_id"`
+	Status          json.RawMessage `json:"status"`
+	CreatedAt       string          `json:"created_at"`
+	CancelReason    string          `json:"cancel_reason"`
+	ShippingAddress *struct {
+		Id string `json:"id"`
+	} `json:"shipping_address"`
+}
+
+// ndjsonRows читает по одному JSON-объекту в строке; пустые строки пропускаются
+func ndjsonRows(r io.Reader) func() (importRow, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	return func() (importRow, error) {
+		for sc.Scan() {
+			line++
+			text := strings.TrimSpace(sc.Text())
+			if text == "" {
+				continue
+			}
+			var l ndjsonLine
+			if err := json.Unmarshal([]byte(text), &l); err != nil {
+				return importRow{line: line, err: fmt.Errorf("invalid json: %w", err)}, nil
+			}
+			row := importRow{
+				line:         line,
+				id:           l.ID,
+				userID:       l.UserID,
+				addressID:    l.AddressID,
+				createdAt:    l.CreatedAt,
+				cancelReason: l.CancelReason,
+			}
+			if row.addressID == "" && l.ShippingAddress != nil {
+				row.addressID = l.ShippingAddress.Id
+			}
+			if len(l.Status) > 0 {
+				if err := json.Unmarshal(l.Status, &row.status); err != nil {
+					row.status = string(l.Status) // число, а не строка
+				}
+			}
+			return row, nil
+		}
+		if err := sc.Err(); err != nil {
+			return importRow{}, err
+		}
+		return importRow{}, io.EOF
+	}
+}
+
+// ExportOrders пишет заказы в w построчно, не собирая выгрузку в памяти.
+// CSV — с заголовком и колонками, которые понимает ImportOrders; NDJSON — по заказу в строке
+func ExportOrders(w io.Writer, orders []*model.Order, format string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvColumns); err != nil {
+			return err
+		}
+		for _, o := range orders {
+			var addressID string
+			if o.ShippingAddress != nil {
+				addressID = o.ShippingAddress.Id
+			}
+			record := []string{o.Id, o.UserID, addressID, o.Status.String(), o.CreatedAt.Format(time.RFC3339Nano), o.CancelReason, strconv.FormatInt(o.Version, 10)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatNDJSON:
+		enc := json.NewEncoder(w) // Encode дописывает перевод строки после каждого заказа
+		for _, o := range orders {
+			if err := enc.Encode(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: format must be %s or %s", ErrInvalidImport, FormatCSV, FormatNDJSON)
+	}
+}
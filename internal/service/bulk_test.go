@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест загрузки: плохие строки попадают в отчёт, остальные записываются пакетами
+func TestImportOrders(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+	u := model.NewUser("Alice")
+	u.Addresses = []model.Address{{Id: "home", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}}
+	require.NoError(t, repo.Save(ctx, u))
+
+	input := "id,user_id,address_id,status,created_at,cancel_reason\n" +
+		"Order-1," + u.Id + ",home,confirmed,2025-01-02T10:00:00Z,\n" +
+		"Order-2," + u.Id + ",,cancelled,2025-01-03T10:00:00Z,expired\n" +
+		"Order-3,nobody,,,,\n" +
+		"Order-4," + u.Id + ",work,,,\n" +
+		"Order-1," + u.Id + ",,,,\n" +
+		"Order-5," + u.Id + ",,created,yesterday,\n"
+
+	dry, err := service.ImportOrders(ctx, repo, strings.NewReader(input), service.ImportOptions{Format: service.FormatCSV, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 6, dry.Total)
+	assert.Equal(t, 2, dry.Imported)
+	assert.Equal(t, 4, dry.Failed)
+	orders, _ := repo.GetOrders(ctx)
+	assert.Empty(t, orders, "dry run writes nothing")
+
+	res, err := service.ImportOrders(ctx, repo, strings.NewReader(input), service.ImportOptions{Format: service.FormatCSV, BatchSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Imported)
+	lines := make([]int, 0, len(res.Errors))
+	for _, e := range res.Errors {
+		lines = append(lines, e.Line)
+	}
+	assert.Equal(t, []int{4, 5, 6, 7}, lines)
+
+	o, err := repo.GetOrderByID(ctx, "Order-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderConfirmed, o.Status)
+	require.NotNil(t, o.ShippingAddress)
+	assert.Equal(t, "Moscow", o.ShippingAddress.City)
+
+	// выгрузка в NDJSON загружается обратно; ID уже заняты
+	orders, _ = repo.GetOrders(ctx)
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportOrders(&buf, orders, service.FormatNDJSON))
+	again, err := service.ImportOrders(ctx, repo, &buf, service.ImportOptions{Format: service.FormatNDJSON})
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.Failed)
+	assert.Contains(t, again.Errors[0].Error, "already exists")
+
+	_, err = service.ImportOrders(ctx, repo, strings.NewReader("id\nOrder-9\n"), service.ImportOptions{Format: service.FormatCSV})
+	assert.ErrorIs(t, err, service.ErrInvalidImport)
+	_, err = service.ImportOrders(ctx, repo, strings.NewReader(""), service.ImportOptions{Format: "xml"})
+	assert.ErrorIs(t, err, service.ErrInvalidImport)
+}
+
+// тест: строки без id получают разные сгенерированные ID, даже если часы выдали одно и то же время
+func TestImportOrdersGeneratedIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+	u := model.NewUser("Bob")
+	require.NoError(t, repo.Save(ctx, u))
+
+	const rows = 300
+	input := "user_id\n" + strings.Repeat(u.Id+"\n", rows)
+	res, err := service.ImportOrders(ctx, repo, strings.NewReader(input), service.ImportOptions{Format: service.FormatCSV})
+	require.NoError(t, err)
+	assert.Equal(t, rows, res.Imported)
+	assert.Empty(t, res.Errors)
+
+	orders, err := repo.GetOrders(ctx)
+	require.NoError(t, err)
+	ids := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		ids[o.Id] = true
+	}
+	assert.Len(t, ids, rows)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"order-ms/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// имя аренды, под которой работает планировщик отмены просроченных заказов
+const expiryLeaseName = "order-expiry"
+
+// ExpiryConfig — настройки фоновой отмены неподтвержденных заказов
+type ExpiryConfig struct {
+	TTL      time.Duration // сколько заказ может ждать подтверждения складом
+	Interval time.Duration // как часто запускается проверка
+	Owner    string        // идентификатор реплики, которая держит аренду
+}
+
+// ExpireOrders периодически отменяет заказы, которые слишком долго находятся в статусе "создан".
+// Проверку выполняет только реплика, захватившая аренду в БД.
+func (s *Service) ExpireOrders(ctx context.Context, cfg ExpiryConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// аренда живет чуть дольше интервала, чтобы владелец успел её продлить
+			ok, err := s.repo.AcquireLease(ctx, expiryLeaseName, cfg.Owner, 2*cfg.Interval)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "cannot acquire expiry lease", "err", err)
+				continue
+			}
+			if !ok {
+				continue // задачу выполняет другая реплика
+			}
+			if _, err := s.ExpireOnce(ctx, time.Now(), cfg.TTL); err != nil {
+				s.logger.ErrorContext(ctx, "order expiry failed", "err", err)
+			}
+		}
+	}
+}
+
+// ExpireOnce отменяет все заказы, созданные раньше now-ttl и не подтвержденные до сих пор.
+// Возвращает ID отмененных заказов.
+func (s *Service) ExpireOnce(ctx context.Context, now time.Time, ttl time.Duration) (expired []string, err error) {
+	ctx, span := startSpan(ctx, "ExpireOnce", attribute.String("order.ttl", ttl.String()))
+	defer func() {
+		span.SetAttributes(attribute.Int("orders.expired", len(expired)))
+		endSpan(span, err)
+	}()
+
+	orders, err := s.repo.GetUnconfirmedOrders(ctx, now.Add(-ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range orders {
+		// репозиторий повторно проверяет статус, чтобы не отменить заказ, подтвержденный в этот момент
+		ok, err := s.repo.ExpireOrder(ctx, o.Id)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			s.logger.InfoContext(ctx, "order cancelled", "order_id", o.Id, "reason", model.CancelReasonExpired)
+			expired = append(expired, o.Id)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест фоновой отмены неподтвержденных заказов
+func TestExpireOnce(t *testing.T) {
+	now := time.Now()
+
+	// заказ с заданным статусом и возрастом
+	newOrder := func(status model.OrderStatus, age time.Duration) *model.Order {
+		o := model.NewOrder("User-1")
+		o.Status = status
+		o.CreatedAt = now.Add(-age)
+		return o
+	}
+	deleted := newOrder(model.OrderCreated, 2*time.Hour)
+	deleted.DeletedAt = &now
+
+	tests := []struct {
+		name        string
+		order       *model.Order
+		wantExpired bool
+		wantStatus  model.OrderStatus
+	}{
+		{
+			name:        "old created order",
+			order:       newOrder(model.OrderCreated, 2*time.Hour),
+			wantExpired: true,
+			wantStatus:  model.OrderCancelled,
+		},
+		{
+			name:       "fresh created order",
+			order:      newOrder(model.OrderCreated, time.Minute),
+			wantStatus: model.OrderCreated,
+		},
+		{
+			name:       "old confirmed order",
+			order:      newOrder(model.OrderConfirmed, 2*time.Hour),
+			wantStatus: model.OrderConfirmed,
+		},
+		{
+			name:       "old deleted order",
+			order:      deleted,
+			wantStatus: model.OrderCreated,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			require.NoError(t, repo.Save(ctx, tc.order))
+			svc := service.NewService(repo, logging.Discard())
+
+			expired, err := svc.ExpireOnce(ctx, now, time.Hour)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.wantStatus, tc.order.Status)
+			if tc.wantExpired {
+				assert.Equal(t, []string{tc.order.Id}, expired)
+				assert.Equal(t, model.CancelReasonExpired, tc.order.CancelReason)
+			} else {
+				assert.Empty(t, expired)
+				assert.Empty(t, tc.order.CancelReason)
+			}
+		})
+	}
+}
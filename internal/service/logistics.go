@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"order-ms/internal/model"
+)
+
+// DeliveryFilter — отбор доставок для списка; пустые поля не ограничивают выборку
+type DeliveryFilter struct {
+	OrderID string
+	UserID  string
+}
+
+// Apply оставляет в списке только подходящие доставки
+func (f DeliveryFilter) Apply(deliveries []*model.Delivery) []*model.Delivery {
+	out := make([]*model.Delivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		if (f.OrderID == "" || d.OrderId == f.OrderID) && (f.UserID == "" || d.UserId == f.UserID) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// WarehouseFilter — отбор складских записей для списка; пустой OrderID — все
+type WarehouseFilter struct {
+	OrderID string
+}
+
+// Apply оставляет в списке только подходящие складские записи
+func (f WarehouseFilter) Apply(warehouses []*model.Warehouse) []*model.Warehouse {
+	out := make([]*model.Warehouse, 0, len(warehouses))
+	for _, w := range warehouses {
+		if f.OrderID == "" || w.OrderId == f.OrderID {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// GetDelivery ищет доставку по ID; nil, если её нет. Отдельной выборки по ID в репозитории нет
+func GetDelivery(ctx context.Context, repo Repository, id int64) (*model.Delivery, error) {
+	deliveries, err := repo.GetDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deliveries {
+		if d.Id == id {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetWarehouse ищет складскую запись по ID; nil, если её нет
+func GetWarehouse(ctx context.Context, repo Repository, id int64) (*model.Warehouse, error) {
+	warehouses, err := repo.GetWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warehouses {
+		if w.Id == id {
+			return w, nil
+		}
+	}
+	return nil, nil
+}
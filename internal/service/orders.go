@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"order-ms/internal/model"
+	"time"
+)
+
+// ErrInvalidStatus — в этот статус заказ нельзя перевести явно (например, обратно в created)
+var ErrInvalidStatus = errors.New("order cannot be moved to this status")
+
+// StatusChange возвращает действие для проверки прав и метод репозитория, которым заказ переводится в статус to.
+// Допустимость перехода из текущего статуса (created → confirmed → delivered, created/confirmed → cancelled)
+// проверяет сам метод: false — заказа нет или он в неподходящем статусе
+func StatusChange(repo Repository, to model.OrderStatus) (Action, func(ctx context.Context, id string, expectedVersion int64) (bool, error), error) {
+	switch to {
+	case model.OrderConfirmed:
+		return ActionOrderConfirm, repo.ConfirmOrder, nil
+	case model.OrderDelivered:
+		return ActionOrderDeliver, repo.DeliverOrder, nil
+	case model.OrderCancelled:
+		return ActionOrderCancel, repo.CancelOrder, nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrInvalidStatus, to)
+}
+
+// OrderFilter — отбор заказов для списка и выгрузки; пустые поля не ограничивают выборку
+type OrderFilter struct {
+	UserID        string
+	Status        *model.OrderStatus
+	CreatedAfter  time.Time // включительно
+	CreatedBefore time.Time // не включительно
+}
+
+// Match проверяет, подходит ли заказ под фильтр
+func (f OrderFilter) Match(o *model.Order) bool {
+	switch {
+	case f.UserID != "" && o.UserID != f.UserID:
+		return false
+	case f.Status != nil && o.Status != *f.Status:
+		return false
+	case !f.CreatedAfter.IsZero() && o.CreatedAt.Before(f.CreatedAfter):
+		return false
+	case !f.CreatedBefore.IsZero() && !o.CreatedAt.Before(f.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+// Apply оставляет в списке только подходящие заказы
+func (f OrderFilter) Apply(orders []*model.Order) []*model.Order {
+	out := make([]*model.Order, 0, len(orders))
+	for _, o := range orders {
+		if f.Match(o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"order-ms/internal/auth"
+	"order-ms/internal/model"
+	"slices"
+)
+
+// роли вызывающих (claim "roles" в JWT)
+const (
+	RoleCustomer  = "customer"  // покупатель: работает только со своими заказами и своим профилем
+	RoleWarehouse = "warehouse" // склад: подтверждает заказы
+	RoleCourier   = "courier"   // курьер: отмечает доставку
+	RoleAdmin     = "admin"     // может всё
+)
+
+// Action — операция, которую проверяет политика
+type Action string
+
+const (
+	ActionOrderCreate      Action = "order:create"
+	ActionOrderRead        Action = "order:read"
+	ActionOrderList        Action = "order:list"
+	ActionOrderCancel      Action = "order:cancel"
+	ActionOrderConfirm     Action = "order:confirm"
+	ActionOrderDeliver     Action = "order:deliver"
+	ActionOrderDelete      Action = "order:delete"
+	ActionOrderRestore     Action = "order:restore" // просмотр и восстановление удалённых заказов
+	ActionOrderImport      Action = "order:import"  // пакетная загрузка заказов из старой системы
+	ActionDeliveryRead     Action = "delivery:read"
+	ActionDeliveryList     Action = "delivery:list"
+	ActionWarehouseRead    Action = "warehouse:read" // складские записи по заказам, и отдельная, и списком
+	ActionUserCreate       Action = "user:create"
+	ActionUserRead         Action = "user:read"
+	ActionUserList         Action = "user:list"
+	ActionUserUpdate       Action = "user:update"
+	ActionUserDelete       Action = "user:delete"
+	ActionUserRestore      Action = "user:restore" // просмотр и восстановление удалённых пользователей
+	ActionUserExport       Action = "user:export"  // выгрузка всех данных пользователя
+	ActionUserErase        Action = "user:erase"   // обезличивание пользователя
+	ActionAPIKeyManage     Action = "apikey:manage"
+	ActionWebhookCreate    Action = "webhook:create"
+	ActionWebhookRead      Action = "webhook:read" // подписки, журнал доставок и недоставленные события
+	ActionWebhookDelete    Action = "webhook:delete"
+	ActionWebhookRedeliver Action = "webhook:redeliver" // повторная отправка недоставленного события
+)
+
+// ErrForbidden — у вызывающего нет прав на операцию (HTTP 403, gRPC PermissionDenied)
+var ErrForbidden = errors.New("forbidden")
+
+// AnonymousSubject — вызывающий, когда аутентификация выключена
+const AnonymousSubject = "anonymous"
+
+// AnonymousAdmin — вызывающий, которого REST и gRPC подставляют в запрос при выключенной аутентификации
+// (флаг -insecure-no-auth): может всё, как admin. Без него запрос без вызывающего запрещён
+func AnonymousAdmin() *auth.Principal {
+	return &auth.Principal{Subject: AnonymousSubject, Roles: []string{RoleAdmin}}
+}
+
+// rule — кто может выполнить действие: любые ресурсы (any) или только свои (own)
+type rule struct {
+	any []string
+	own []string
+}
+
+// policy — единая таблица прав для REST и gRPC; admin проверяется отдельно и может всё
+var policy = map[Action]rule{
+	ActionOrderCreate:      {own: []string{RoleCustomer}},
+	ActionOrderRead:        {any: []string{RoleWarehouse, RoleCourier}, own: []string{RoleCustomer}},
+	ActionOrderList:        {any: []string{RoleWarehouse, RoleCourier, RoleCustomer}}, // покупателю список фильтруется VisibleOrders
+	ActionOrderCancel:      {own: []string{RoleCustomer}},
+	ActionOrderConfirm:     {any: []string{RoleWarehouse}},
+	ActionOrderDeliver:     {any: []string{RoleCourier}},
+	ActionOrderDelete:      {},
+	ActionOrderRestore:     {},
+	ActionOrderImport:      {},
+	ActionDeliveryRead:     {any: []string{RoleWarehouse, RoleCourier}, own: []string{RoleCustomer}},
+	ActionDeliveryList:     {any: []string{RoleWarehouse, RoleCourier, RoleCustomer}}, // покупателю список фильтруется VisibleDeliveries
+	ActionWarehouseRead:    {any: []string{RoleWarehouse, RoleCourier}},
+	ActionUserCreate:       {},
+	ActionUserRead:         {own: []string{RoleCustomer, RoleWarehouse, RoleCourier}},
+	ActionUserList:         {},
+	ActionUserUpdate:       {own: []string{RoleCustomer, RoleWarehouse, RoleCourier}},
+	ActionUserDelete:       {},
+	ActionUserRestore:      {},
+	ActionUserExport:       {own: []string{RoleCustomer, RoleWarehouse, RoleCourier}},
+	ActionUserErase:        {},
+	ActionAPIKeyManage:     {},
+	ActionWebhookCreate:    {},
+	ActionWebhookRead:      {},
+	ActionWebhookDelete:    {},
+	ActionWebhookRedeliver: {},
+}
+
+// Authorize проверяет, может ли вызывающий из ctx выполнить действие над ресурсом владельца owner
+// (ID пользователя заказа или ID самого пользователя; "" — владелец ещё неизвестен).
+// У API-ключа с ограниченной областью действие ещё должно входить в его scopes.
+// Без вызывающего в контексте действие запрещено, даже если аутентификация выключена (см. AnonymousAdmin).
+func Authorize(ctx context.Context, action Action, owner string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p == nil {
+		return ErrForbidden
+	}
+	if len(p.Scopes) > 0 && !slices.Contains(p.Scopes, string(action)) {
+		return ErrForbidden // ключ с ограниченной областью, даже если роль admin
+	}
+	if p.HasRole(RoleAdmin) {
+		return nil
+	}
+	r := policy[action]
+	for _, role := range r.any {
+		if p.HasRole(role) {
+			return nil
+		}
+	}
+	if owner != "" && owner == p.Subject {
+		for _, role := range r.own {
+			if p.HasRole(role) {
+				return nil
+			}
+		}
+	}
+	return ErrForbidden
+}
+
+// VisibleOrders оставляет в списке только заказы, которые вызывающий может читать
+func VisibleOrders(ctx context.Context, orders []*model.Order) []*model.Order {
+	if Authorize(ctx, ActionOrderRead, "") == nil {
+		return orders
+	}
+	visible := make([]*model.Order, 0, len(orders))
+	for _, o := range orders {
+		if Authorize(ctx, ActionOrderRead, o.UserID) == nil {
+			visible = append(visible, o)
+		}
+	}
+	return visible
+}
+
+// VisibleDeliveries оставляет в списке только доставки, которые вызывающий может читать
+func VisibleDeliveries(ctx context.Context, deliveries []*model.Delivery) []*model.Delivery {
+	if Authorize(ctx, ActionDeliveryRead, "") == nil {
+		return deliveries
+	}
+	visible := make([]*model.Delivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		if Authorize(ctx, ActionDeliveryRead, d.UserId) == nil {
+			visible = append(visible, d)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"order-ms/internal/auth"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// контекст с вызывающим subject и ролями roles
+func as(subject string, roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		action  service.Action
+		owner   string
+		allowed bool
+	}{
+		{name: "no principal", ctx: context.Background(), action: service.ActionOrderRead, owner: "alice"},
+		{name: "anonymous admin when auth is off", ctx: auth.WithPrincipal(context.Background(), service.AnonymousAdmin()), action: service.ActionUserErase, allowed: true},
+		{name: "admin can do anything", ctx: as("root", service.RoleAdmin), action: service.ActionUserErase, allowed: true},
+		{name: "customer reads own order", ctx: as("alice", service.RoleCustomer), action: service.ActionOrderRead, owner: "alice", allowed: true},
+		{name: "customer cannot read foreign order", ctx: as("alice", service.RoleCustomer), action: service.ActionOrderRead, owner: "bob"},
+		{name: "customer cannot read unknown owner", ctx: as("alice", service.RoleCustomer), action: service.ActionOrderRead},
+		{name: "customer cannot confirm", ctx: as("alice", service.RoleCustomer), action: service.ActionOrderConfirm, owner: "alice"},
+		{name: "warehouse confirms any order", ctx: as("wh", service.RoleWarehouse), action: service.ActionOrderConfirm, owner: "bob", allowed: true},
+		{name: "courier cannot confirm", ctx: as("c", service.RoleCourier), action: service.ActionOrderConfirm, owner: "bob"},
+		{name: "courier delivers", ctx: as("c", service.RoleCourier), action: service.ActionOrderDeliver, owner: "bob", allowed: true},
+		{name: "admin-only action", ctx: as("wh", service.RoleWarehouse), action: service.ActionAPIKeyManage},
+		{
+			name:   "scoped admin key outside its scopes",
+			ctx:    auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "apikey:1", Roles: []string{service.RoleAdmin}, Scopes: []string{string(service.ActionOrderRead)}}),
+			action: service.ActionOrderDelete,
+		},
+		{
+			name:    "scoped admin key within its scopes",
+			ctx:     auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "apikey:1", Roles: []string{service.RoleAdmin}, Scopes: []string{string(service.ActionOrderRead)}}),
+			action:  service.ActionOrderRead,
+			allowed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := service.Authorize(tc.ctx, tc.action, tc.owner)
+			if tc.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, service.ErrForbidden)
+			}
+		})
+	}
+}
+
+// покупатель видит в списке только свои заказы, склад — все
+func TestVisibleOrders(t *testing.T) {
+	mine, foreign := model.NewOrder("alice"), model.NewOrder("bob")
+	orders := []*model.Order{mine, foreign}
+
+	assert.Equal(t, []*model.Order{mine}, service.VisibleOrders(as("alice", service.RoleCustomer), orders))
+	assert.Equal(t, orders, service.VisibleOrders(as("wh", service.RoleWarehouse), orders))
+}
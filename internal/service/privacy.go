@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"order-ms/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UserExport — все данные пользователя, которые хранит сервис, по запросу самого пользователя
+type UserExport struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	User       *model.User         `json:"user"`
+	Orders     []*model.Order      `json:"orders"` // в том числе мягко удалённые
+	Deliveries []*model.Delivery   `json:"deliveries"`
+	History    []*model.AuditEntry `json:"history"` // журнал аудита по пользователю и его заказам, включая смены статусов
+}
+
+// ExportUser собирает выгрузку данных пользователя и записывает её в журнал аудита.
+// ErrUserNotFound, если пользователя нет
+func ExportUser(ctx context.Context, repo Repository, logger *slog.Logger, id string) (_ *UserExport, err error) {
+	ctx, span := startSpan(ctx, "ExportUser", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+
+	u, err := repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	export := &UserExport{
+		ExportedAt: time.Now(),
+		User:       u,
+		Orders:     []*model.Order{},
+		Deliveries: []*model.Delivery{},
+	}
+
+	active, err := repo.GetOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := repo.GetDeletedOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subjects := []string{id}
+	for _, o := range append(active, deleted...) {
+		if o.UserID == id {
+			export.Orders = append(export.Orders, o)
+			subjects = append(subjects, o.Id)
+		}
+	}
+
+	deliveries, err := repo.GetDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deliveries {
+		if d.UserId == id {
+			export.Deliveries = append(export.Deliveries, d)
+		}
+	}
+
+	if export.History, err = repo.GetAuditEntries(ctx, subjects); err != nil {
+		return nil, err
+	}
+	Audit(ctx, repo, logger, ActionUserExport, id, "")
+	return export, nil
+}
+
+// EraseUser обезличивает пользователя (сами заказы остаются для учёта) и возвращает профиль
+// после обезличивания. В журнал аудита запись добавляет обёртка репозитория. ErrUserNotFound, если пользователя нет
+func EraseUser(ctx context.Context, repo Repository, id string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "EraseUser", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+
+	ok, err := repo.EraseUser(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	u, err := repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
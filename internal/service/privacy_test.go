@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест выгрузки и обезличивания: выгрузка содержит и удалённые заказы, после обезличивания
+// в профиле и заказах не остаётся личных данных, а сами заказы сохраняются
+func TestExportEraseUser(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+	u := model.NewUser("Alice")
+	u.Email = "alice@example.com"
+	u.Addresses = []model.Address{{Id: "home", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}}
+	require.NoError(t, repo.Save(ctx, u))
+
+	active := model.NewOrder(u.Id)
+	active.ShippingAddress = &u.Addresses[0]
+	deleted := model.NewOrder(u.Id)
+	deleted.DeletedAt = &deleted.CreatedAt
+	foreign := model.NewOrder("User-2")
+	foreign.ShippingAddress = &model.Address{Id: "work", Country: "RU", City: "Kazan", Street: "Baumana 2"}
+	for _, o := range []*model.Order{active, deleted, foreign} {
+		require.NoError(t, repo.Save(ctx, o))
+	}
+	// снимки заказов в телах доставок вебхуков
+	deliveries := map[string]*model.WebhookDelivery{}
+	for _, o := range []*model.Order{active, foreign} {
+		payload, err := json.Marshal(map[string]any{"event": "order.created", "order": o})
+		require.NoError(t, err)
+		deliveries[o.Id] = model.NewWebhookDelivery("Webhook-1", "order.created", o.Id, payload)
+		require.NoError(t, repo.SaveWebhookDelivery(ctx, deliveries[o.Id]))
+	}
+
+	export, err := service.ExportUser(ctx, repo, logging.Discard(), u.Id)
+	require.NoError(t, err)
+	assert.Equal(t, u.Id, export.User.Id)
+	assert.ElementsMatch(t, []*model.Order{active, deleted}, export.Orders)
+	assert.Empty(t, export.Deliveries)
+
+	erased, err := service.EraseUser(ctx, repo, u.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasedName, erased.Name)
+	assert.Empty(t, erased.Email)
+	assert.Empty(t, erased.Addresses)
+	assert.NotNil(t, erased.ErasedAt)
+
+	o, err := repo.GetOrderByID(ctx, active.Id)
+	require.NoError(t, err)
+	assert.Nil(t, o.ShippingAddress)
+	d, err := repo.GetWebhookDeliveryByID(ctx, deliveries[active.Id].Id)
+	require.NoError(t, err)
+	assert.NotContains(t, string(d.Payload), "shipping_address")
+	assert.Contains(t, string(d.Payload), active.Id)
+	d, err = repo.GetWebhookDeliveryByID(ctx, deliveries[foreign.Id].Id)
+	require.NoError(t, err)
+	assert.Contains(t, string(d.Payload), "Baumana 2")
+
+	_, err = service.ExportUser(ctx, repo, logging.Discard(), "nope")
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+	_, err = service.EraseUser(ctx, repo, "nope")
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+}
@@ -1,28 +1,81 @@
 package service
 
-import "order-ms/internal/model"
+import (
+	"context"
+	"order-ms/internal/model"
+	"time"
+)
 
 type Repository interface {
 	// Общий Save
-	Save(s model.Storable) error
+	Save(ctx context.Context, s model.Storable) error
 
 	// Заказы
-	SaveOrder(order *model.Order) error
-	GetOrders() ([]*model.Order, error)
-	GetOrderByID(id string) (*model.Order, error)
-	DeleteOrder(id string) (bool, error)
-	ConfirmOrder(orderId string) (bool, error)
-	DeliverOrder(id string) (bool, error)
-	CancelOrder(id string) (bool, error)
+	SaveOrder(ctx context.Context, order *model.Order) error
+	// пакетная вставка одной операцией; в Postgres атомарна, в Mongo при ошибке остаются заказы до сбойного
+	SaveOrders(ctx context.Context, orders []*model.Order) error
+	GetOrders(ctx context.Context) ([]*model.Order, error)
+	GetOrderByID(ctx context.Context, id string) (*model.Order, error)
+	// не удалённые заказы в статусе "создан", созданные раньше createdBefore: кандидаты на отмену по таймауту
+	GetUnconfirmedOrders(ctx context.Context, createdBefore time.Time) ([]*model.Order, error)
+	DeleteOrder(ctx context.Context, id string) (bool, error) // мягкое удаление: отметка deleted_at, запись пропадает из выборок
+	GetDeletedOrders(ctx context.Context) ([]*model.Order, error)
+	RestoreOrder(ctx context.Context, id string) (bool, error)
+	// смена статуса: expectedVersion > 0 — compare-and-swap по версии (model.ErrVersionConflict), 0 — без проверки
+	ConfirmOrder(ctx context.Context, orderId string, expectedVersion int64) (bool, error)
+	DeliverOrder(ctx context.Context, id string, expectedVersion int64) (bool, error)
+	CancelOrder(ctx context.Context, id string, expectedVersion int64) (bool, error)
+	ExpireOrder(ctx context.Context, id string) (bool, error) // отмена заказа, который так и не был подтвержден
 
 	// Пользователи
-	SaveUser(user *model.User) error
-	GetUsers() ([]*model.User, error)
-	GetUserByID(id string) (*model.User, error)
-	UpdateUserName(id, name string) (bool, error)
-	DeleteUser(id string) (bool, error)
+	SaveUser(ctx context.Context, user *model.User) error
+	GetUsers(ctx context.Context) ([]*model.User, error)
+	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	// замена профиля с compare-and-swap по версии, как у заказов; новая версия записывается в user.Version.
+	// model.ErrEmailTaken, если email занят
+	UpdateUser(ctx context.Context, user *model.User, expectedVersion int64) (bool, error)
+	DeleteUser(ctx context.Context, id string) (bool, error) // мягкое удаление, как у заказов; email остаётся занятым
+	GetDeletedUsers(ctx context.Context) ([]*model.User, error)
+	RestoreUser(ctx context.Context, id string) (bool, error)
+	// обезличивание по запросу пользователя: профиль очищается (model.User.Erase), адреса стираются
+	// в его заказах, доставках и снимках заказов в телах доставок вебхуков, сами заказы остаются для учёта; false — пользователя нет
+	EraseUser(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// окончательное удаление пользователей и заказов, мягко удалённых раньше before; возвращает число записей.
+	// Пользователь, у которого остались заказы (в том числе удалённые позже before), не удаляется:
+	// его заказы не должны пропасть вместе с ним
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
 
 	// доставки и склады
-	GetDeliveries() ([]*model.Delivery, error)
-	GetWarehouses() ([]*model.Warehouse, error)
+	GetDeliveries(ctx context.Context) ([]*model.Delivery, error)
+	GetWarehouses(ctx context.Context) ([]*model.Warehouse, error)
+	// число записей по видам одним запросом, без чтения самих записей
+	Count(ctx context.Context) (*model.StoreCounts, error)
+	// постраничное чтение для переноса данных: до limit записей вида kind (model.Storable.GetType),
+	// включая мягко удалённые, с ID больше after ("" — с начала) в порядке model.CompareIDs
+	Scan(ctx context.Context, kind, after string, limit int) ([]model.Storable, error)
+
+	// API-ключи для межсервисных вызовов; SaveAPIKey создаёт или заменяет запись целиком
+	SaveAPIKey(ctx context.Context, key *model.APIKey) error
+	GetAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id string) (*model.APIKey, error)
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error // отметка последнего использования
+
+	// журнал аудита: записи добавляются и не меняются; GetAuditEntries возвращает записи
+	// по ID заказов и пользователей в порядке времени
+	SaveAuditEntry(ctx context.Context, e *model.AuditEntry) error
+	GetAuditEntries(ctx context.Context, subjects []string) ([]*model.AuditEntry, error)
+
+	// подписки на вебхуки и их доставки. SaveWebhook и SaveWebhookDelivery создают или заменяют запись целиком;
+	// GetWebhookDeliveries отбирает по подписке и статусу ("" — любые) и возвращает в порядке создания
+	SaveWebhook(ctx context.Context, w *model.Webhook) error
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhookByID(ctx context.Context, id string) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error) // доставки остаются в журнале
+	SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookID, status string) ([]*model.WebhookDelivery, error)
+	GetWebhookDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error)
+
+	// аренда (lease) для фоновых задач, чтобы при нескольких репликах задачу выполняла только одна
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
 }
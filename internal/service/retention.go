@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// имя аренды, под которой работает очистка мягко удалённых записей
+const purgeLeaseName = "purge-deleted"
+
+// RetentionConfig — настройки окончательного удаления мягко удалённых пользователей и заказов
+type RetentionConfig struct {
+	Retention time.Duration // сколько удалённая запись хранится и может быть восстановлена
+	Interval  time.Duration // как часто запускается очистка
+	Owner     string        // идентификатор реплики, которая держит аренду
+}
+
+// PurgeDeleted периодически окончательно удаляет записи, мягко удалённые раньше чем Retention назад.
+// Очистку выполняет только реплика, захватившая аренду в БД.
+func (s *Service) PurgeDeleted(ctx context.Context, cfg RetentionConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := s.repo.AcquireLease(ctx, purgeLeaseName, cfg.Owner, 2*cfg.Interval)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "cannot acquire purge lease", "err", err)
+				continue
+			}
+			if !ok {
+				continue // задачу выполняет другая реплика
+			}
+			if _, err := s.PurgeOnce(ctx, time.Now(), cfg.Retention); err != nil {
+				s.logger.ErrorContext(ctx, "purge of deleted records failed", "err", err)
+			}
+		}
+	}
+}
+
+// PurgeOnce окончательно удаляет записи, мягко удалённые раньше now-retention. Возвращает их число.
+func (s *Service) PurgeOnce(ctx context.Context, now time.Time, retention time.Duration) (purged int, err error) {
+	ctx, span := startSpan(ctx, "PurgeOnce", attribute.String("retention", retention.String()))
+	defer func() {
+		span.SetAttributes(attribute.Int("records.purged", purged))
+		endSpan(span, err)
+	}()
+
+	purged, err = s.repo.PurgeDeleted(ctx, now.Add(-retention))
+	if purged > 0 {
+		s.logger.InfoContext(ctx, "deleted records purged", "count", purged, "retention", retention)
+	}
+	return purged, err
+}
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест окончательной очистки: удаляются только записи старше срока хранения
+func TestPurgeOnce(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		deletedAgo time.Duration // 0 — запись не удалена
+		wantPurged bool
+	}{
+		{name: "deleted long ago", deletedAgo: 48 * time.Hour, wantPurged: true},
+		{name: "deleted recently", deletedAgo: time.Hour},
+		{name: "not deleted"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			order := model.NewOrder("User-1")
+			if tc.deletedAgo > 0 {
+				deletedAt := now.Add(-tc.deletedAgo)
+				order.DeletedAt = &deletedAt
+			}
+			require.NoError(t, repo.Save(ctx, order))
+			svc := service.NewService(repo, logging.Discard())
+
+			purged, err := svc.PurgeOnce(ctx, now, 24*time.Hour)
+			assert.NoError(t, err)
+
+			active, _ := repo.GetOrders(ctx)
+			deleted, _ := repo.GetDeletedOrders(ctx)
+			if tc.wantPurged {
+				assert.Equal(t, 1, purged)
+				assert.Empty(t, append(active, deleted...))
+			} else {
+				assert.Zero(t, purged)
+				assert.Len(t, append(active, deleted...), 1)
+			}
+		})
+	}
+}
+
+// тест: удалённый пользователь, у которого остались заказы, не удаляется вместе с ними
+func TestPurgeOnceKeepsUsersWithOrders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	longAgo, recently := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	repo := newRepo(t)
+
+	withOrder, withDeletedOrder, alone := model.NewUser("Alice"), model.NewUser("Bob"), model.NewUser("Carol")
+	for _, u := range []*model.User{withOrder, withDeletedOrder, alone} {
+		u.DeletedAt = &longAgo
+		require.NoError(t, repo.Save(ctx, u))
+	}
+	active := model.NewOrder(withOrder.Id)
+	deletedRecently := model.NewOrder(withDeletedOrder.Id)
+	deletedRecently.DeletedAt = &recently
+	for _, o := range []*model.Order{active, deletedRecently} {
+		require.NoError(t, repo.Save(ctx, o))
+	}
+
+	purged, err := service.NewService(repo, logging.Discard()).PurgeOnce(ctx, now, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	users, _ := repo.GetDeletedUsers(ctx)
+	assert.ElementsMatch(t, []*model.User{withOrder, withDeletedOrder}, users)
+	o, _ := repo.GetOrderByID(ctx, active.Id)
+	assert.NotNil(t, o)
+}
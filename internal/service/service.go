@@ -2,47 +2,45 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"order-ms/internal/model"
-	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Service — обертка вокруг репозитория
 type Service struct {
-	repo Repository
+	repo   Repository
+	logger *slog.Logger
 }
 
 // NewService создаёт новый экземпляр Service
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, logger *slog.Logger) *Service {
+	return &Service{repo: repo, logger: logger}
 }
 
-// Logger выводит информацию о текущем состоянии базы
-func (s *Service) Logger(ctx context.Context) {
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			orders, _ := s.repo.GetOrders()
-			fmt.Printf("Orders in DB: %d\n", len(orders))
-
-			users, _ := s.repo.GetUsers()
-			fmt.Printf("Users in DB: %d\n", len(users))
-
-			deliveries, _ := s.repo.GetDeliveries()
-			fmt.Printf("Deliveries in DB: %d\n", len(deliveries))
+// Save сохраняет объект через репозиторий
+func (s *Service) Save(ctx context.Context, sObj model.Storable) (err error) {
+	ctx, span := startSpan(ctx, "Save", attribute.String("entity", sObj.GetType()))
+	defer func() { endSpan(span, err) }()
+	return s.repo.Save(ctx, sObj)
+}
 
-			warehouses, _ := s.repo.GetWarehouses()
-			fmt.Printf("Warehouses in DB: %d\n", len(warehouses))
-		}
-	}
+// startSpan открывает спан операции сервиса. Спаны есть у операций из нескольких обращений
+// к репозиторию; у тонких обёрток (ShippingAddress, GetDelivery, StatusChange) — только спаны репозитория.
+// Пакет tracing импортирует service, поэтому трейсер берём напрямую из otel
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("order-ms").Start(ctx, "service."+name, trace.WithAttributes(attrs...))
 }
 
-// Save сохраняет объект через репозиторий
-func (s *Service) Save(sObj model.Storable) error {
-	return s.repo.Save(sObj)
+// endSpan отмечает ошибку операции и закрывает спан
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
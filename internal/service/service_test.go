@@ -1,51 +1,45 @@
 package service_test
 
 import (
+	"context"
+	"order-ms/internal/logging"
 	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
 	"order-ms/internal/service"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
-// MockRepo — простая реализация интерфейса service.Repository для тестов
+// MockRepo — реализация service.Repository для тестов: запоминает сохранённые объекты,
+// остальные методы не нужны и не реализованы (вызов паникует)
 type MockRepo struct {
-	Saved      []model.Storable
-	Orders     []model.Order
-	Users      []model.User
-	Deliveries []model.Delivery
-	Warehouses []model.Warehouse
+	service.Repository
+	Saved []model.Storable
 }
 
-// методы, возвращают заранее подготовленные данные/ сохраняет их в память
-
-func (m *MockRepo) Save(s model.Storable) error {
+func (m *MockRepo) Save(_ context.Context, s model.Storable) error {
 	m.Saved = append(m.Saved, s)
 	return nil
 }
 
-func (m *MockRepo) GetOrders() []model.Order {
-	return m.Orders
-}
-
-func (m *MockRepo) GetUsers() []model.User {
-	return m.Users
-}
-
-func (m *MockRepo) GetDeliveries() []model.Delivery {
-	return m.Deliveries
-}
-
-func (m *MockRepo) GetWarehouses() []model.Warehouse {
-	return m.Warehouses
+// newRepo — репозиторий в памяти, файлы данных которого пишутся во временный каталог теста
+func newRepo(t *testing.T) *memory.MemoryRepo {
+	return memory.NewMemoryRepoAt(t.TempDir(), logging.Discard())
 }
 
 // Тест
 
-func TestProcessDataChan(t *testing.T) {
+func TestSave(t *testing.T) {
 
 	// срез структур - таблица тестов
 	tests := []struct {
 		name     string           // имя кейса
-		inputs   []model.Storable // набор объектов, которые кладем в канал
+		inputs   []model.Storable // набор объектов, которые сохраняем
 		expected int              // кол-во сохраненных объектов, которое ожидаем увидеть
 	}{
 		// первый сценарий
@@ -69,21 +63,35 @@ func TestProcessDataChan(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			//поднимаем новый мок-репозиторий
 			mock := &MockRepo{}
-			// создаем буферизированный канал на len(tc.inputs) элементов
-			dataChan := make(chan model.Storable, len(tc.inputs))
+			svc := service.NewService(mock, logging.Discard())
 
-			// отправляем все входные объекты из таблицы в канал
 			for _, s := range tc.inputs {
-				dataChan <- s
+				assert.NoError(t, svc.Save(context.Background(), s)) // вызов тестируемой функции
 			}
-			close(dataChan)
-
-			service.ProcessDataChan(dataChan, mock) // вызов тестируемой функции
 
 			// проверяем сколько объектов сохранил мок
-			if len(mock.Saved) != tc.expected {
-				t.Errorf("Expected %d saved objects, got %d", tc.expected, len(mock.Saved))
-			}
+			assert.Equal(t, tc.inputs, mock.Saved)
+			assert.Len(t, mock.Saved, tc.expected)
 		})
 	}
 }
+
+// тест: операции сервиса открывают свои спаны, ошибка отмечается в спане
+func TestServiceSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx := context.Background()
+	repo := newRepo(t)
+	require.NoError(t, service.NewService(repo, logging.Discard()).Save(ctx, model.NewUser("Иван")))
+	_, err := service.EraseUser(ctx, repo, "nope")
+	require.ErrorIs(t, err, service.ErrUserNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "service.Save", spans[0].Name)
+	assert.Equal(t, "service.EraseUser", spans[1].Name)
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"order-ms/internal/model"
+)
+
+// ErrAddressNotFound — у пользователя нет адреса с таким ID (HTTP 400, gRPC InvalidArgument)
+var ErrAddressNotFound = errors.New("address not found")
+
+// ErrUserNotFound — заказ создаётся для несуществующего пользователя
+var ErrUserNotFound = errors.New("user not found")
+
+// ShippingAddress возвращает копию сохранённого адреса пользователя для нового заказа.
+// Пустой addressID — заказ без адреса (nil, nil)
+func ShippingAddress(ctx context.Context, repo Repository, userID, addressID string) (*model.Address, error) {
+	if addressID == "" {
+		return nil, nil
+	}
+	u, err := repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	a, ok := u.Address(addressID)
+	if !ok {
+		return nil, ErrAddressNotFound
+	}
+	return &a, nil
+}
@@ -0,0 +1,280 @@
+package tracing
+
+import (
+	"context"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedRepo — обёртка над service.Repository, открывающая спан на каждую операцию.
+// Текст запроса к БД дописывают в этот спан сами репозитории через SetStatement.
+type tracedRepo struct {
+	next     service.Repository
+	dbSystem attribute.KeyValue
+}
+
+// InstrumentRepository оборачивает репозиторий трейсингом; dbSystem — "postgresql", "mongodb" или "memory"
+func InstrumentRepository(repo service.Repository, dbSystem string) service.Repository {
+	return &tracedRepo{next: repo, dbSystem: semconv.DBSystemNameKey.String(dbSystem)}
+}
+
+func (r *tracedRepo) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, r.dbSystem, semconv.DBOperationName(operation))
+	return Tracer().Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// атрибуты с ID сущности, чтобы спан можно было найти по заказу или пользователю
+func orderID(id string) attribute.KeyValue   { return attribute.String("order.id", id) }
+func userID(id string) attribute.KeyValue    { return attribute.String("user.id", id) }
+func apiKeyID(id string) attribute.KeyValue  { return attribute.String("api_key.id", id) }
+func webhookID(id string) attribute.KeyValue { return attribute.String("webhook.id", id) }
+
+func (r *tracedRepo) Save(ctx context.Context, s model.Storable) (err error) {
+	ctx, span := r.start(ctx, "Save", attribute.String("entity", s.GetType()))
+	defer func() { End(span, err) }()
+	return r.next.Save(ctx, s)
+}
+
+func (r *tracedRepo) SaveOrder(ctx context.Context, order *model.Order) (err error) {
+	ctx, span := r.start(ctx, "SaveOrder", orderID(order.Id))
+	defer func() { End(span, err) }()
+	return r.next.SaveOrder(ctx, order)
+}
+
+func (r *tracedRepo) SaveOrders(ctx context.Context, orders []*model.Order) (err error) {
+	ctx, span := r.start(ctx, "SaveOrders", attribute.Int("batch.size", len(orders)))
+	defer func() { End(span, err) }()
+	return r.next.SaveOrders(ctx, orders)
+}
+
+func (r *tracedRepo) GetOrders(ctx context.Context) (orders []*model.Order, err error) {
+	ctx, span := r.start(ctx, "GetOrders")
+	defer func() { End(span, err) }()
+	return r.next.GetOrders(ctx)
+}
+
+func (r *tracedRepo) GetOrderByID(ctx context.Context, id string) (order *model.Order, err error) {
+	ctx, span := r.start(ctx, "GetOrderByID", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.GetOrderByID(ctx, id)
+}
+
+func (r *tracedRepo) GetUnconfirmedOrders(ctx context.Context, createdBefore time.Time) (orders []*model.Order, err error) {
+	ctx, span := r.start(ctx, "GetUnconfirmedOrders")
+	defer func() { End(span, err) }()
+	return r.next.GetUnconfirmedOrders(ctx, createdBefore)
+}
+
+func (r *tracedRepo) Count(ctx context.Context) (counts *model.StoreCounts, err error) {
+	ctx, span := r.start(ctx, "Count")
+	defer func() { End(span, err) }()
+	return r.next.Count(ctx)
+}
+
+func (r *tracedRepo) Scan(ctx context.Context, kind, after string, limit int) (page []model.Storable, err error) {
+	ctx, span := r.start(ctx, "Scan", attribute.String("entity", kind), attribute.Int("page.limit", limit))
+	defer func() { End(span, err) }()
+	return r.next.Scan(ctx, kind, after, limit)
+}
+
+func (r *tracedRepo) DeleteOrder(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "DeleteOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.DeleteOrder(ctx, id)
+}
+
+func (r *tracedRepo) GetDeletedOrders(ctx context.Context) (orders []*model.Order, err error) {
+	ctx, span := r.start(ctx, "GetDeletedOrders")
+	defer func() { End(span, err) }()
+	return r.next.GetDeletedOrders(ctx)
+}
+
+func (r *tracedRepo) RestoreOrder(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "RestoreOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.RestoreOrder(ctx, id)
+}
+
+func (r *tracedRepo) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	ctx, span := r.start(ctx, "ConfirmOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.ConfirmOrder(ctx, id, expectedVersion)
+}
+
+func (r *tracedRepo) DeliverOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	ctx, span := r.start(ctx, "DeliverOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.DeliverOrder(ctx, id, expectedVersion)
+}
+
+func (r *tracedRepo) CancelOrder(ctx context.Context, id string, expectedVersion int64) (ok bool, err error) {
+	ctx, span := r.start(ctx, "CancelOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.CancelOrder(ctx, id, expectedVersion)
+}
+
+func (r *tracedRepo) ExpireOrder(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "ExpireOrder", orderID(id))
+	defer func() { End(span, err) }()
+	return r.next.ExpireOrder(ctx, id)
+}
+
+func (r *tracedRepo) SaveUser(ctx context.Context, user *model.User) (err error) {
+	ctx, span := r.start(ctx, "SaveUser", userID(user.Id))
+	defer func() { End(span, err) }()
+	return r.next.SaveUser(ctx, user)
+}
+
+func (r *tracedRepo) GetUsers(ctx context.Context) (users []*model.User, err error) {
+	ctx, span := r.start(ctx, "GetUsers")
+	defer func() { End(span, err) }()
+	return r.next.GetUsers(ctx)
+}
+
+func (r *tracedRepo) GetUserByID(ctx context.Context, id string) (user *model.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByID", userID(id))
+	defer func() { End(span, err) }()
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *tracedRepo) UpdateUser(ctx context.Context, u *model.User, expectedVersion int64) (ok bool, err error) {
+	ctx, span := r.start(ctx, "UpdateUser", userID(u.Id))
+	defer func() { End(span, err) }()
+	return r.next.UpdateUser(ctx, u, expectedVersion)
+}
+
+func (r *tracedRepo) DeleteUser(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "DeleteUser", userID(id))
+	defer func() { End(span, err) }()
+	return r.next.DeleteUser(ctx, id)
+}
+
+func (r *tracedRepo) GetDeletedUsers(ctx context.Context) (users []*model.User, err error) {
+	ctx, span := r.start(ctx, "GetDeletedUsers")
+	defer func() { End(span, err) }()
+	return r.next.GetDeletedUsers(ctx)
+}
+
+func (r *tracedRepo) RestoreUser(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "RestoreUser", userID(id))
+	defer func() { End(span, err) }()
+	return r.next.RestoreUser(ctx, id)
+}
+
+func (r *tracedRepo) EraseUser(ctx context.Context, id string, at time.Time) (ok bool, err error) {
+	ctx, span := r.start(ctx, "EraseUser", userID(id))
+	defer func() { End(span, err) }()
+	return r.next.EraseUser(ctx, id, at)
+}
+
+func (r *tracedRepo) PurgeDeleted(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, span := r.start(ctx, "PurgeDeleted", attribute.String("purge.before", before.Format(time.RFC3339)))
+	defer func() {
+		span.SetAttributes(attribute.Int("purge.count", n))
+		End(span, err)
+	}()
+	return r.next.PurgeDeleted(ctx, before)
+}
+
+func (r *tracedRepo) SaveAuditEntry(ctx context.Context, e *model.AuditEntry) (err error) {
+	ctx, span := r.start(ctx, "SaveAuditEntry", attribute.String("audit.action", e.Action))
+	defer func() { End(span, err) }()
+	return r.next.SaveAuditEntry(ctx, e)
+}
+
+func (r *tracedRepo) GetAuditEntries(ctx context.Context, subjects []string) (entries []*model.AuditEntry, err error) {
+	ctx, span := r.start(ctx, "GetAuditEntries", attribute.Int("audit.subjects", len(subjects)))
+	defer func() { End(span, err) }()
+	return r.next.GetAuditEntries(ctx, subjects)
+}
+
+func (r *tracedRepo) GetDeliveries(ctx context.Context) (deliveries []*model.Delivery, err error) {
+	ctx, span := r.start(ctx, "GetDeliveries")
+	defer func() { End(span, err) }()
+	return r.next.GetDeliveries(ctx)
+}
+
+func (r *tracedRepo) GetWarehouses(ctx context.Context) (warehouses []*model.Warehouse, err error) {
+	ctx, span := r.start(ctx, "GetWarehouses")
+	defer func() { End(span, err) }()
+	return r.next.GetWarehouses(ctx)
+}
+
+func (r *tracedRepo) SaveAPIKey(ctx context.Context, key *model.APIKey) (err error) {
+	ctx, span := r.start(ctx, "SaveAPIKey", apiKeyID(key.Id))
+	defer func() { End(span, err) }()
+	return r.next.SaveAPIKey(ctx, key)
+}
+
+func (r *tracedRepo) GetAPIKeys(ctx context.Context) (keys []*model.APIKey, err error) {
+	ctx, span := r.start(ctx, "GetAPIKeys")
+	defer func() { End(span, err) }()
+	return r.next.GetAPIKeys(ctx)
+}
+
+func (r *tracedRepo) GetAPIKeyByID(ctx context.Context, id string) (key *model.APIKey, err error) {
+	ctx, span := r.start(ctx, "GetAPIKeyByID", apiKeyID(id))
+	defer func() { End(span, err) }()
+	return r.next.GetAPIKeyByID(ctx, id)
+}
+
+func (r *tracedRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) (err error) {
+	ctx, span := r.start(ctx, "TouchAPIKey", apiKeyID(id))
+	defer func() { End(span, err) }()
+	return r.next.TouchAPIKey(ctx, id, usedAt)
+}
+
+func (r *tracedRepo) SaveWebhook(ctx context.Context, w *model.Webhook) (err error) {
+	ctx, span := r.start(ctx, "SaveWebhook", webhookID(w.Id))
+	defer func() { End(span, err) }()
+	return r.next.SaveWebhook(ctx, w)
+}
+
+func (r *tracedRepo) GetWebhooks(ctx context.Context) (webhooks []*model.Webhook, err error) {
+	ctx, span := r.start(ctx, "GetWebhooks")
+	defer func() { End(span, err) }()
+	return r.next.GetWebhooks(ctx)
+}
+
+func (r *tracedRepo) GetWebhookByID(ctx context.Context, id string) (w *model.Webhook, err error) {
+	ctx, span := r.start(ctx, "GetWebhookByID", webhookID(id))
+	defer func() { End(span, err) }()
+	return r.next.GetWebhookByID(ctx, id)
+}
+
+func (r *tracedRepo) DeleteWebhook(ctx context.Context, id string) (ok bool, err error) {
+	ctx, span := r.start(ctx, "DeleteWebhook", webhookID(id))
+	defer func() { End(span, err) }()
+	return r.next.DeleteWebhook(ctx, id)
+}
+
+func (r *tracedRepo) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (err error) {
+	ctx, span := r.start(ctx, "SaveWebhookDelivery", webhookID(d.WebhookID), attribute.String("webhook.delivery.id", d.Id))
+	defer func() { End(span, err) }()
+	return r.next.SaveWebhookDelivery(ctx, d)
+}
+
+func (r *tracedRepo) GetWebhookDeliveries(ctx context.Context, id, status string) (deliveries []*model.WebhookDelivery, err error) {
+	ctx, span := r.start(ctx, "GetWebhookDeliveries", webhookID(id), attribute.String("webhook.delivery.status", status))
+	defer func() { End(span, err) }()
+	return r.next.GetWebhookDeliveries(ctx, id, status)
+}
+
+func (r *tracedRepo) GetWebhookDeliveryByID(ctx context.Context, id string) (d *model.WebhookDelivery, err error) {
+	ctx, span := r.start(ctx, "GetWebhookDeliveryByID", attribute.String("webhook.delivery.id", id))
+	defer func() { End(span, err) }()
+	return r.next.GetWebhookDeliveryByID(ctx, id)
+}
+
+func (r *tracedRepo) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (ok bool, err error) {
+	ctx, span := r.start(ctx, "AcquireLease", attribute.String("lease.name", name))
+	defer func() { End(span, err) }()
+	return r.next.AcquireLease(ctx, name, owner, ttl)
+}
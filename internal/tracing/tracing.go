@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// имя инструментария, под которым сервис создаёт свои спаны
+const tracerName = "order-ms"
+
+// Config — куда отправлять трейсы
+type Config struct {
+	ServiceName  string    // имя сервиса в трейсах
+	OTLPEndpoint string    // адрес OTLP/gRPC коллектора (host:port); пусто — OTLP не используется
+	Insecure     bool      // подключаться к коллектору без TLS
+	Stdout       io.Writer // если задан, спаны дополнительно пишутся сюда в виде JSON (для отладки)
+}
+
+// Setup настраивает глобальный TracerProvider и W3C-пропагацию контекста.
+// Возвращает функцию, которая сбрасывает накопленные спаны и останавливает экспортёры.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var opts []sdktrace.TracerProviderOption
+
+	if cfg.OTLPEndpoint != "" {
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	if cfg.Stdout != nil {
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(cfg.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+
+	tp := NewProvider(cfg.ServiceName, opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+// NewProvider создаёт TracerProvider с ресурсом сервиса. В тестах сюда передают
+// sdktrace.WithSyncer(tracetest.NewInMemoryExporter()).
+func NewProvider(serviceName string, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		res = resource.Default()
+	}
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+}
+
+// Tracer возвращает трейсер сервиса из глобального провайдера
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start открывает дочерний спан (короткая запись для service и repository)
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End закрывает спан, отмечая ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetStatement добавляет текст запроса к БД в текущий спан (его открывает обёртка репозитория)
+func SetStatement(ctx context.Context, statement string) {
+	trace.SpanFromContext(ctx).SetAttributes(semconv.DBQueryText(statement))
+}
+
+// Inject сериализует контекст трейса (traceparent/tracestate) для исходящего сообщения
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract восстанавливает контекст трейса из входящего сообщения
+func Extract(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// SetCollection добавляет имя коллекции/таблицы в текущий спан
+func SetCollection(ctx context.Context, name string) {
+	trace.SpanFromContext(ctx).SetAttributes(semconv.DBCollectionName(name))
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+)
+
+// тест: спаны репозитория вложены в родительский спан и несут атрибуты БД и ID заказа
+func TestInstrumentRepository(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := NewProvider("order-ms-test", sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	mem := memory.NewMemoryRepo(logging.Discard())
+	repo := InstrumentRepository(mem, "memory")
+
+	order := model.NewOrder("User-1")
+	ctx, parent := Start(context.Background(), "test")
+	require.NoError(t, repo.SaveOrder(ctx, order))
+	found, err := repo.GetOrderByID(ctx, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	tests := []struct {
+		name    string
+		orderID string
+	}{
+		{"repository.SaveOrder", order.Id},
+		{"repository.GetOrderByID", "missing"},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		assert.Equal(t, tt.name, span.Name)
+		assert.Equal(t, spans[2].SpanContext.SpanID(), span.Parent.SpanID())
+		assert.Contains(t, span.Attributes, attribute.String("db.system.name", "memory"))
+		assert.Contains(t, span.Attributes, attribute.String("order.id", tt.orderID))
+		assert.Equal(t, "Unset", span.Status.Code.String())
+	}
+}
+
+// тест: контекст трейса переживает Inject/Extract (так он передаётся через Redis)
+func TestInjectExtract(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	shutdown, err := Setup(context.Background(), Config{ServiceName: "order-ms-test"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	ctx, span := Start(context.Background(), "producer")
+	defer span.End()
+
+	headers := Inject(ctx)
+	assert.NotEmpty(t, headers["traceparent"])
+
+	restored := Extract(context.Background(), headers)
+	_, child := Start(restored, "consumer")
+	defer child.End()
+	assert.Equal(t, span.SpanContext().TraceID(), child.SpanContext().TraceID())
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"time"
+)
+
+type createAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Role      string   `json:"role"`                 // warehouse, courier или admin
+	Scopes    []string `json:"scopes,omitempty"`     // например ["order:read", "order:confirm"]
+	ExpiresIn string   `json:"expires_in,omitempty"` // срок действия, например "720h"; пусто — бессрочный
+}
+
+// ответ на выпуск и перевыпуск ключа: секрет показывается только здесь
+type apiKeySecretResponse struct {
+	Key    *model.APIKey `json:"key"`
+	Secret string        `json:"secret"`
+}
+
+// apiKeyError переводит ошибку сервиса ключей в HTTP-ответ
+func (s *Server) apiKeyError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAPIKeyRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	case errors.Is(err, service.ErrAPIKeyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": "API key is revoked"})
+	default:
+		s.logger.ErrorContext(c.Request.Context(), msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot manage API keys"})
+	}
+}
+
+// handleAPIKeyCreate выпускает API-ключ
+// @Summary Выпустить API-ключ
+// @Description Выпускает ключ для сервиса с ролью и необязательной областью действий; секрет возвращается один раз
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param key body createAPIKeyRequest true "Параметры ключа"
+// @Success 201 {object} apiKeySecretResponse "Ключ и секрет"
+// @Failure 400 {object} object "Неверный JSON, роль, область или срок"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/keys [post]
+func (s *Server) handleAPIKeyCreate(c *gin.Context) {
+	if !s.authorize(c, service.ActionAPIKeyManage, "") {
+		return
+	}
+	var req createAPIKeyRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires_in"})
+			return
+		}
+		ttl = d
+	}
+
+	key, secret, err := s.keys.Issue(c.Request.Context(), req.Name, req.Role, req.Scopes, ttl)
+	if err != nil {
+		s.apiKeyError(c, "cannot issue api key", err)
+		return
+	}
+	c.JSON(http.StatusCreated, apiKeySecretResponse{Key: key, Secret: secret})
+}
+
+// handleAPIKeyList возвращает все API-ключи
+// @Summary Список API-ключей
+// @Description Возвращает все ключи (без секретов) с временем последнего использования и сроком действия
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} model.APIKey "Ключи"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/keys [get]
+func (s *Server) handleAPIKeyList(c *gin.Context) {
+	if !s.authorize(c, service.ActionAPIKeyManage, "") {
+		return
+	}
+	keys, err := s.keys.List(c.Request.Context())
+	if err != nil {
+		s.apiKeyError(c, "cannot list api keys", err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// handleAPIKeyRotate перевыпускает секрет ключа
+// @Summary Перевыпустить API-ключ
+// @Description Генерирует новый секрет; старый сразу перестаёт действовать
+// @Tags Admin
+// @Produce json
+// @Param id path string true "ID ключа"
+// @Success 200 {object} apiKeySecretResponse "Ключ и новый секрет"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 404 {object} object "Ключ не найден"
+// @Failure 409 {object} object "Ключ отозван"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/keys/{id}/rotate [post]
+func (s *Server) handleAPIKeyRotate(c *gin.Context) {
+	if !s.authorize(c, service.ActionAPIKeyManage, "") {
+		return
+	}
+	key, secret, err := s.keys.Rotate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.apiKeyError(c, "cannot rotate api key", err)
+		return
+	}
+	c.JSON(http.StatusOK, apiKeySecretResponse{Key: key, Secret: secret})
+}
+
+// handleAPIKeyRevoke отзывает ключ
+// @Summary Отозвать API-ключ
+// @Description Ключ перестаёт приниматься; запись остаётся в списке с revoked_at
+// @Tags Admin
+// @Produce json
+// @Param id path string true "ID ключа"
+// @Success 200 {object} model.APIKey "Отозванный ключ"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 404 {object} object "Ключ не найден"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/keys/{id} [delete]
+func (s *Server) handleAPIKeyRevoke(c *gin.Context) {
+	if !s.authorize(c, service.ActionAPIKeyManage, "") {
+		return
+	}
+	key, err := s.keys.Revoke(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.apiKeyError(c, "cannot revoke api key", err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,192 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"strconv"
+	"time"
+)
+
+// предельный размер тела пакетной загрузки и время на загрузку или выгрузку
+// (общие ReadTimeout/WriteTimeout сервера рассчитаны на обычные запросы)
+const (
+	maxImportSize = 64 << 20
+	bulkTimeout   = 10 * time.Minute
+)
+
+// extendDeadlines продлевает таймауты соединения для пакетной операции
+func extendDeadlines(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(bulkTimeout)
+	_ = rc.SetReadDeadline(deadline) // в тестах ResponseRecorder их не поддерживает, это не ошибка
+	_ = rc.SetWriteDeadline(deadline)
+}
+
+// parseOrderFilter разбирает фильтры списка и выгрузки заказов; отвечает 400 и возвращает false при ошибке
+func parseOrderFilter(c *gin.Context) (service.OrderFilter, bool) {
+	f := service.OrderFilter{UserID: c.Query("user_id")}
+	if s := c.Query("status"); s != "" {
+		st, err := model.ParseOrderStatus(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "field": "status"})
+			return f, false
+		}
+		f.Status = &st
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"created_after", &f.CreatedAfter}, {"created_before", &f.CreatedBefore}} {
+		if s := c.Query(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Time must be RFC 3339", "field": p.name})
+				return f, false
+			}
+			*p.dst = t
+		}
+	}
+	return f, true
+}
+
+// importFormat берёт формат из параметра format, иначе из Content-Type
+func importFormat(c *gin.Context) string {
+	if f := c.Query("format"); f != "" {
+		return f
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case "text/csv":
+		return service.FormatCSV
+	case "application/x-ndjson":
+		return service.FormatNDJSON
+	}
+	return ""
+}
+
+// handleOrderImport загружает заказы пакетом
+// @Summary Пакетная загрузка заказов
+// @Description Загружает заказы из CSV (с заголовком: id, user_id, address_id, status, created_at, cancel_reason) или NDJSON с теми же полями.
+// @Description Обязателен только user_id. Каждая строка проверяется отдельно, ошибки возвращаются с номером строки;
+// @Description подходящие заказы вставляются пакетами по batch_size. С dry_run=true ничего не записывается
+// @Tags Orders
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv или ndjson; по умолчанию по Content-Type"
+// @Param dry_run query bool false "Только проверить строки"
+// @Param batch_size query int false "Размер пакета вставки (по умолчанию 500, не больше 1000)"
+// @Success 200 {object} service.ImportResult "Итог загрузки с ошибками по строкам"
+// @Failure 400 {object} object "Неизвестный формат, нет колонки user_id или неверный batch_size"
+// @Failure 413 {object} object "Слишком большое тело запроса"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/orders/import [post]
+func (s *Server) handleOrderImport(c *gin.Context) {
+	if !s.authorize(c, service.ActionOrderImport, "") {
+		return
+	}
+	opts := service.ImportOptions{Format: importFormat(c), DryRun: c.Query("dry_run") == "true"}
+	if v := c.Query("batch_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "batch_size must be a positive integer"})
+			return
+		}
+		opts.BatchSize = n
+	}
+
+	extendDeadlines(c)
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	res, err := service.ImportOrders(c.Request.Context(), s.repo, body, opts)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import is too large"})
+		return
+	case errors.Is(err, service.ErrInvalidImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		s.logger.ErrorContext(c.Request.Context(), "cannot import orders", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot import orders"})
+		return
+	}
+	s.logger.InfoContext(c.Request.Context(), "orders imported",
+		"total", res.Total, "imported", res.Imported, "failed", res.Failed, "dry_run", res.DryRun)
+	c.JSON(http.StatusOK, res)
+}
+
+// handleOrderExport выгружает заказы потоком
+// @Summary Выгрузка заказов
+// @Description Выгружает заказы потоком в NDJSON (по заказу в строке) или CSV с колонками, которые понимает загрузка.
+// @Description Фильтры те же, что у списка заказов; покупатель получает только свои заказы
+// @Tags Orders
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param format query string false "ndjson (по умолчанию) или csv"
+// @Param user_id query string false "ID пользователя"
+// @Param status query string false "Статус: created, confirmed, delivered, cancelled или номер"
+// @Param created_after query string false "Созданы не раньше (RFC 3339)"
+// @Param created_before query string false "Созданы раньше (RFC 3339)"
+// @Success 200 {string} string "Заказы в выбранном формате"
+// @Failure 400 {object} object "Неизвестный формат или неверный фильтр"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/orders/export [get]
+func (s *Server) handleOrderExport(c *gin.Context) {
+	format := c.DefaultQuery("format", service.FormatNDJSON)
+	contentType := map[string]string{service.FormatNDJSON: "application/x-ndjson", service.FormatCSV: "text/csv; charset=utf-8"}[format]
+	if contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be ndjson or csv"})
+		return
+	}
+	filter, ok := parseOrderFilter(c)
+	if !ok {
+		return
+	}
+	if !s.authorize(c, service.ActionOrderList, "") {
+		return
+	}
+	orders, err := s.repo.GetOrders(c.Request.Context())
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get orders", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get orders"})
+		return
+	}
+	orders = filter.Apply(service.VisibleOrders(c.Request.Context(), orders))
+
+	extendDeadlines(c)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="orders.`+format+`"`)
+	c.Status(http.StatusOK)
+	if err := service.ExportOrders(&flushWriter{w: c.Writer}, orders, format); err != nil {
+		// заголовки уже отправлены, остаётся только оборвать поток
+		s.logger.ErrorContext(c.Request.Context(), "cannot export orders", "err", err)
+	}
+}
+
+// flushWriter отправляет выгрузку клиенту каждые exportFlushBytes, не дожидаясь конца
+type flushWriter struct {
+	w       gin.ResponseWriter
+	pending int
+}
+
+const exportFlushBytes = 32 << 10
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if fw.pending += n; err == nil && fw.pending >= exportFlushBytes {
+		fw.w.Flush()
+		fw.pending = 0
+	}
+	return n, err
+}
@@ -0,0 +1,316 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"order-ms/internal/events"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// как часто в простаивающий поток отправляется пульс, чтобы прокси и балансировщики не закрывали соединение
+const defaultHeartbeat = 15 * time.Second
+
+// служебные типы сообщений потока (помимо events.TypeOrder*)
+const (
+	typeHeartbeat = "heartbeat"
+	// продолжить с Last-Event-ID нельзя, события вытеснены из истории: клиенту нужно перечитать список заказов
+	typeResync = "resync"
+)
+
+// streamMessage — сообщение потока событий SSE и WebSocket
+type streamMessage struct {
+	Seq   uint64       `json:"seq,omitempty"` // номер события; передаётся в Last-Event-ID при переподключении
+	Type  string       `json:"type"`
+	At    time.Time    `json:"at"`
+	Order *model.Order `json:"order,omitempty"` // заказ после изменения
+}
+
+func toStreamMessage(e events.Event) streamMessage {
+	return streamMessage{Seq: e.Seq, Type: e.Type, At: e.At, Order: &e.Order}
+}
+
+// eventStream — на что подписан клиент: один заказ или все заказы пользователя
+type eventStream struct {
+	orderID string
+	userID  string
+}
+
+// lastEventID читает номер последнего полученного события из заголовка Last-Event-ID
+// (EventSource передаёт его сам при переподключении) или из параметра last_event_id
+func lastEventID(c *gin.Context) (uint64, bool) {
+	s := c.GetHeader("Last-Event-ID")
+	if s == "" {
+		s = c.Query("last_event_id")
+	}
+	if s == "" {
+		return 0, true
+	}
+	after, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+		return 0, false
+	}
+	return after, true
+}
+
+// startStream проверяет права на поток и подписывается на шину после события after. Вместе с подпиской возвращает
+// сообщения, которые уходят клиенту первыми: снимок заказа, если это не продолжение, или resync, если продолжить нельзя.
+// При ошибке отвечает клиенту сам и возвращает false
+func (s *Server) startStream(c *gin.Context, st eventStream, after uint64) (*events.Subscription, []streamMessage, bool) {
+	ctx := c.Request.Context()
+	var match func(events.Event) bool
+	if st.orderID != "" {
+		if !s.authorizeOrder(c, service.ActionOrderRead, st.orderID) {
+			return nil, nil, false
+		}
+		match = func(e events.Event) bool { return e.Order.Id == st.orderID }
+	} else {
+		user, err := s.repo.GetUserByID(ctx, st.userID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "cannot get user", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get user"})
+			return nil, nil, false
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, nil, false
+		}
+		if !s.authorize(c, service.ActionOrderRead, st.userID) {
+			return nil, nil, false
+		}
+		match = func(e events.Event) bool { return e.Order.UserID == st.userID }
+	}
+
+	sub, last, err := s.bus.Subscribe(after, match)
+	resync := errors.Is(err, events.ErrEventsLost)
+	if resync {
+		sub, last, err = s.bus.Subscribe(0, match)
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+		return nil, nil, false
+	}
+
+	var first []streamMessage
+	switch {
+	case st.orderID != "" && (after == 0 || resync):
+		// снимок читаем после подписки, чтобы не потерять смену статуса между чтением и подпиской
+		o, err := s.repo.GetOrderByID(ctx, st.orderID)
+		if err != nil || o == nil {
+			sub.Close()
+			s.logger.ErrorContext(ctx, "cannot get order", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get order"})
+			return nil, nil, false
+		}
+		first = append(first, streamMessage{Seq: last, Type: events.TypeOrderSnapshot, At: time.Now(), Order: o})
+	case resync:
+		first = append(first, streamMessage{Seq: last, Type: typeResync, At: time.Now()})
+	}
+	return sub, first, true
+}
+
+// pump отправляет клиенту первые сообщения, затем события подписки, а в паузах — пульс.
+// Возвращается, когда клиент отключился, сервер останавливается, шина закрыла подписку или отправка не удалась
+func (s *Server) pump(ctx context.Context, sub *events.Subscription, first []streamMessage, send func(streamMessage) error, heartbeat func() error) error {
+	for _, m := range first {
+		if err := send(m); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.streams.Done():
+			return events.ErrClosed
+		case e, ok := <-sub.Events():
+			if !ok {
+				return sub.Err()
+			}
+			if err := send(toStreamMessage(e)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// serveSSE отдаёт поток событий в формате text/event-stream
+func (s *Server) serveSSE(c *gin.Context, sub *events.Subscription, first []streamMessage) {
+	defer sub.Close()
+	// поток живёт дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx не должен копить ответ
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	send := func(m streamMessage) error {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if m.Seq > 0 {
+			fmt.Fprintf(c.Writer, "id: %d\n", m.Seq)
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", m.Type, data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	heartbeat := func() error {
+		// комментарий SSE: EventSource его не показывает, но соединение не простаивает
+		if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	err := s.pump(c.Request.Context(), sub, first, send, heartbeat)
+	s.logger.DebugContext(c.Request.Context(), "event stream closed", "reason", err)
+}
+
+// handleOrderEvents отдаёт смены статуса заказа через Server-Sent Events
+// @Summary События заказа (SSE)
+// @Description Поток text/event-stream: сначала order.snapshot с текущим состоянием, затем order.confirmed, order.delivered, order.cancelled.
+// @Description У каждого события есть id; при переподключении EventSource присылает Last-Event-ID и получает пропущенные события.
+// @Description Если они уже вытеснены из истории, вместо них приходит новый order.snapshot. В паузах приходит комментарий-пульс.
+// @Description Браузер не может задать заголовок Authorization для EventSource, поэтому токен можно передать в access_token
+// @Tags Orders
+// @Produce text/event-stream
+// @Param id path string true "ID заказа"
+// @Param Last-Event-ID header string false "Номер последнего полученного события"
+// @Param last_event_id query string false "То же, что Last-Event-ID"
+// @Param access_token query string false "Bearer-токен, если нельзя передать заголовок"
+// @Success 200 {object} streamMessage "Поток событий; data каждого события — это сообщение"
+// @Failure 400 {object} object "Неверный Last-Event-ID"
+// @Failure 404 {object} object "Заказ не найден"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Failure 503 {object} object "Сервис останавливается"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/orders/{id}/events [get]
+func (s *Server) handleOrderEvents(c *gin.Context) {
+	after, ok := lastEventID(c)
+	if !ok {
+		return
+	}
+	sub, first, ok := s.startStream(c, eventStream{orderID: c.Param("id")}, after)
+	if !ok {
+		return
+	}
+	s.serveSSE(c, sub, first)
+}
+
+// handleUserOrderEvents отдаёт смены статусов всех заказов пользователя через Server-Sent Events
+// @Summary События заказов пользователя (SSE)
+// @Description Поток text/event-stream с order.created, order.confirmed, order.delivered и order.cancelled по всем заказам пользователя.
+// @Description Продолжение по Last-Event-ID как у /api/orders/{id}/events; если пропущенные события уже вытеснены из истории,
+// @Description приходит resync — список заказов нужно перечитать
+// @Tags Users
+// @Produce text/event-stream
+// @Param id path string true "ID пользователя"
+// @Param Last-Event-ID header string false "Номер последнего полученного события"
+// @Param last_event_id query string false "То же, что Last-Event-ID"
+// @Param access_token query string false "Bearer-токен, если нельзя передать заголовок"
+// @Success 200 {object} streamMessage "Поток событий; data каждого события — это сообщение"
+// @Failure 400 {object} object "Неверный Last-Event-ID"
+// @Failure 404 {object} object "Пользователь не найден"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Failure 503 {object} object "Сервис останавливается"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/users/{id}/orders/events [get]
+func (s *Server) handleUserOrderEvents(c *gin.Context) {
+	after, ok := lastEventID(c)
+	if !ok {
+		return
+	}
+	sub, first, ok := s.startStream(c, eventStream{userID: c.Param("id")}, after)
+	if !ok {
+		return
+	}
+	s.serveSSE(c, sub, first)
+}
+
+// handleOrderEventsWS отдаёт те же события через WebSocket
+// @Summary События заказов (WebSocket)
+// @Description WebSocket с JSON-сообщениями того же вида, что data в SSE, и сообщениями heartbeat в паузах.
+// @Description Задаётся ровно один из order_id (один заказ, первым приходит order.snapshot) и user_id (все заказы пользователя).
+// @Description Для продолжения после переподключения передайте seq последнего сообщения в last_event_id. Сообщения клиента игнорируются
+// @Tags Orders
+// @Param order_id query string false "ID заказа"
+// @Param user_id query string false "ID пользователя"
+// @Param last_event_id query string false "Номер последнего полученного события"
+// @Param access_token query string false "Bearer-токен, если нельзя передать заголовок"
+// @Success 101 {object} streamMessage "Переход на WebSocket"
+// @Failure 400 {object} object "Не задан или задан и order_id, и user_id; неверный last_event_id"
+// @Failure 404 {object} object "Заказ или пользователь не найден"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Failure 503 {object} object "Сервис останавливается"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/orders/ws [get]
+func (s *Server) handleOrderEventsWS(c *gin.Context) {
+	st := eventStream{orderID: c.Query("order_id"), userID: c.Query("user_id")}
+	if (st.orderID == "") == (st.userID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of order_id and user_id is required"})
+		return
+	}
+	after, ok := lastEventID(c)
+	if !ok {
+		return
+	}
+	// права проверяем до рукопожатия, чтобы ответить обычным 403/404
+	sub, first, ok := s.startStream(c, st, after)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	ws := websocket.Server{
+		// Origin не проверяем: доступ даёт токен или API-ключ, а не cookie браузера
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			_ = conn.SetDeadline(time.Time{}) // соединение живёт дольше таймаутов сервера
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			// клиенту писать нечего, но только чтение замечает, что он закрыл соединение
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				cancel()
+			}()
+			send := func(m streamMessage) error { return websocket.JSON.Send(conn, m) }
+			heartbeat := func() error { return send(streamMessage{Type: typeHeartbeat, At: time.Now()}) }
+			err := s.pump(ctx, sub, first, send, heartbeat)
+			s.logger.DebugContext(ctx, "websocket stream closed", "reason", err)
+		},
+	}
+	ws.ServeHTTP(c.Writer, c.Request)
+}
+
+// isStreamRequest — запрос от EventSource или WebSocket, которые в браузере не могут задать заголовок Authorization
+func isStreamRequest(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket") || strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
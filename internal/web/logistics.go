@@ -0,0 +1,95 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order-ms/internal/service"
+	"strconv"
+)
+
+// pathID читает числовой ID доставки или складской записи из пути; отвечает 400, если он не число
+func pathID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a non-zero integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// handleDeliveryList возвращает доставки
+// REST-маршрут задан в api.proto: DeliveryService.ListDeliveries
+func (s *Server) handleDeliveryList(c *gin.Context) {
+	if !s.authorize(c, service.ActionDeliveryList, "") {
+		return
+	}
+	deliveries, err := s.repo.GetDeliveries(c.Request.Context())
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get deliveries", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get deliveries"})
+		return
+	}
+	filter := service.DeliveryFilter{OrderID: c.Query("order_id"), UserID: c.Query("user_id")}
+	c.JSON(http.StatusOK, filter.Apply(service.VisibleDeliveries(c.Request.Context(), deliveries)))
+}
+
+// handleDeliveryGet возвращает доставку по ID
+// REST-маршрут задан в api.proto: DeliveryService.GetDelivery
+func (s *Server) handleDeliveryGet(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	d, err := service.GetDelivery(c.Request.Context(), s.repo, id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get delivery", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get delivery"})
+		return
+	}
+	if d == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if !s.authorize(c, service.ActionDeliveryRead, d.UserId) {
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// handleWarehouseList возвращает складские записи
+// REST-маршрут задан в api.proto: WarehouseService.ListWarehouses
+func (s *Server) handleWarehouseList(c *gin.Context) {
+	if !s.authorize(c, service.ActionWarehouseRead, "") {
+		return
+	}
+	warehouses, err := s.repo.GetWarehouses(c.Request.Context())
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get warehouses", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get warehouses"})
+		return
+	}
+	c.JSON(http.StatusOK, service.WarehouseFilter{OrderID: c.Query("order_id")}.Apply(warehouses))
+}
+
+// handleWarehouseGet возвращает складскую запись по ID
+// REST-маршрут задан в api.proto: WarehouseService.GetWarehouse
+func (s *Server) handleWarehouseGet(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	if !s.authorize(c, service.ActionWarehouseRead, "") {
+		return
+	}
+	w, err := service.GetWarehouse(c.Request.Context(), s.repo, id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get warehouse", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get warehouse"})
+		return
+	}
+	if w == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
@@ -0,0 +1,168 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"order-ms/internal/auth"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/service"
+	"order-ms/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// заголовок, в котором клиент может передать свой request ID и в котором мы его возвращаем
+const requestIDHeader = "X-Request-ID"
+
+// requestID берёт request ID из заголовка (или генерирует новый) и кладёт его в контекст запроса
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			id = logging.NewRequestID()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// accessLog пишет одну запись в лог на каждый обработанный запрос
+func accessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// httpMetrics учитывает запрос в метриках Prometheus (по шаблону маршрута, а не по конкретному пути)
+func httpMetrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // чтобы несуществующие пути не раздували число серий
+		}
+		m.ObserveHTTP(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// tracingMiddleware открывает серверный спан на каждый запрос, продолжая трейс клиента из заголовка traceparent
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+			))
+		defer span.End()
+		if id := logging.RequestID(ctx); id != "" {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+}
+
+// заголовок, в котором сервисы (склад, доставка) передают API-ключ
+const apiKeyHeader = "X-API-Key"
+
+// authenticate пропускает только запросы с валидным API-ключом или bearer-токеном и кладёт вызывающего в контекст.
+// a == nil — аутентификация выключена (флаг -insecure-no-auth): вызывающим считается service.AnonymousAdmin
+func authenticate(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), service.AnonymousAdmin()))
+			c.Next()
+			return
+		}
+		authorization := c.GetHeader("Authorization")
+		if authorization == "" && isStreamRequest(c) && c.Query("access_token") != "" {
+			// EventSource и WebSocket в браузере не задают заголовки, токен приходит в параметре (в лог путь пишется без него)
+			authorization = "Bearer " + c.Query("access_token")
+		}
+		p, err := a.Credentials(c.Request.Context(), c.GetHeader(apiKeyHeader), authorization)
+		if errors.Is(err, auth.ErrNoCredentials) && c.Request.TLS != nil {
+			// без токена и ключа — сертификат клиента, если соединение mTLS
+			p, err = a.ClientCertificate(c.Request.TLS.VerifiedChains)
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="order-ms"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// rateLimitIP ограничивает частоту запросов с одного IP до аутентификации (ratelimit.AuthGroup):
+// перебор токенов и API-ключей получает 429, не доходя до проверки ключа в хранилище. l == nil — без лимитов
+func rateLimitIP(l *ratelimit.Limiter) gin.HandlerFunc {
+	return limitBy(l, ratelimit.AuthGroup, func(c *gin.Context) string { return ratelimit.IPKey(c.ClientIP()) })
+}
+
+// rateLimit ограничивает частоту запросов клиента к группе маршрутов; при превышении отвечает 429 с Retry-After.
+// Ставится после authenticate, чтобы считать по API-ключу или пользователю, а не по IP. l == nil — без лимитов
+func rateLimit(l *ratelimit.Limiter, group string) gin.HandlerFunc {
+	return limitBy(l, group, func(c *gin.Context) string { return ratelimit.ClientKey(c.Request.Context(), c.ClientIP()) })
+}
+
+// limitBy забирает токен группы group из корзины клиента key(c)
+func limitBy(l *ratelimit.Limiter, group string, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+		d := l.Allow(c.Request.Context(), group, key(c))
+		if !d.Allowed {
+			c.Header("Retry-After", ratelimit.RetryAfterSeconds(d.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
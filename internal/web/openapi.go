@@ -0,0 +1,145 @@
+package web
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	pb "order-ms/pkg/proto"
+
+	"github.com/swaggo/swag"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// openAPIInstance — имя спецификации, которую отдаёт /swagger
+const openAPIInstance = "order-ms"
+
+// openAPIDoc собирает документацию /swagger из двух источников: маршруты RPC описаны в api.proto
+// (pb.OpenAPI), остальные ручки — в swag-аннотациях обработчиков (пакет docs, инстанс "swagger").
+// Собирается при первом запросе, когда пакет docs уже зарегистрирован
+type openAPIDoc struct {
+	once sync.Once
+	doc  string
+}
+
+func init() {
+	swag.Register(openAPIInstance, &openAPIDoc{})
+}
+
+func (d *openAPIDoc) ReadDoc() string {
+	d.once.Do(func() {
+		var base string
+		if s := swag.GetSwagger(swag.Name); s != nil {
+			base = s.ReadDoc()
+		}
+		doc, err := mergeOpenAPI(base, pb.OpenAPI)
+		if err != nil {
+			doc = string(pb.OpenAPI)
+		}
+		d.doc = doc
+	})
+	return d.doc
+}
+
+// mergeOpenAPI добавляет к спецификации swag пути, схемы и теги из спецификации api.proto.
+// protoc-gen-openapiv2 всегда описывает ответ 200, поэтому у операций с 201 или 204 он убирается;
+// общие для файла требования безопасности переносятся в каждую операцию, как их пишет swag, а поля доставок
+// и складов называются так же, как в ответах REST
+func mergeOpenAPI(base string, protoDoc []byte) (string, error) {
+	var proto map[string]any
+	if err := json.Unmarshal(protoDoc, &proto); err != nil {
+		return "", err
+	}
+	out := map[string]any{}
+	if base != "" {
+		if err := json.Unmarshal([]byte(base), &out); err != nil {
+			return "", err
+		}
+	} else {
+		for _, k := range []string{"swagger", "info", "securityDefinitions"} {
+			out[k] = proto[k]
+		}
+	}
+
+	paths := object(out, "paths")
+	for path, item := range proto["paths"].(map[string]any) {
+		ops, _ := paths[path].(map[string]any)
+		if ops == nil {
+			ops = map[string]any{}
+			paths[path] = ops
+		}
+		for method, op := range item.(map[string]any) {
+			fixOperation(op.(map[string]any), proto["security"])
+			ops[method] = op
+		}
+	}
+	definitions := object(out, "definitions")
+	for name, schema := range object(proto, "definitions") {
+		definitions[name] = schema
+	}
+	renameJSONFields(definitions)
+	tags, _ := out["tags"].([]any)
+	out["tags"] = append(tags, proto["tags"].([]any)...)
+
+	doc, err := json.Marshal(out)
+	return string(doc), err
+}
+
+// legacyJSONMessages — сообщения, которые REST отдаёт с прежними именами полей из json_name в api.proto,
+// а не с именами полей proto, как остальные
+var legacyJSONMessages = []protoreflect.FullName{"proto.Delivery", "proto.Warehouse"}
+
+// renameJSONFields переименовывает поля схем legacyJSONMessages по json_name:
+// protoc-gen-openapiv2 с json_names_for_fields=false пишет имена полей proto
+func renameJSONFields(definitions map[string]any) {
+	for _, name := range legacyJSONMessages {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+		if err != nil {
+			continue
+		}
+		m := d.(protoreflect.MessageDescriptor)
+		schema, _ := definitions[string(m.Name())].(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		fields := m.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			f := fields.Get(i)
+			if p, ok := props[string(f.Name())]; ok && f.JSONName() != string(f.Name()) {
+				delete(props, string(f.Name()))
+				props[f.JSONName()] = p
+			}
+		}
+	}
+}
+
+// fixOperation приводит операцию из api.proto к тому, как ручки отвечают на самом деле
+func fixOperation(op map[string]any, security any) {
+	responses := op["responses"].(map[string]any)
+	for code := range responses {
+		if code != "200" && strings.HasPrefix(code, "2") {
+			delete(responses, "200")
+			break
+		}
+	}
+	if _, ok := op["security"]; !ok && security != nil {
+		op["security"] = security
+	}
+	// в REST числовые ID передаются числом, а не строкой, как в JSON-отображении protobuf
+	params, _ := op["parameters"].([]any)
+	for _, p := range params {
+		p := p.(map[string]any)
+		if p["type"] == "string" && (p["format"] == "int64" || p["format"] == "uint64") {
+			p["type"] = "integer"
+		}
+	}
+}
+
+// object возвращает вложенный JSON-объект key, создавая его при необходимости
+func object(m map[string]any, key string) map[string]any {
+	o, _ := m[key].(map[string]any)
+	if o == nil {
+		o = map[string]any{}
+		m[key] = o
+	}
+	return o
+}
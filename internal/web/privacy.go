@@ -0,0 +1,101 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order-ms/internal/service"
+)
+
+// handleUserExport выгружает все данные пользователя
+// REST-маршрут задан в api.proto: UserService.ExportUser
+func (s *Server) handleUserExport(c *gin.Context) {
+	id := c.Param("id")
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or zip"})
+		return
+	}
+	if !s.authorize(c, service.ActionUserExport, id) {
+		return
+	}
+	export, err := service.ExportUser(c.Request.Context(), s.repo, s.logger, id)
+	if errors.Is(err, service.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot export user", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot export user"})
+		return
+	}
+
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, id))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+	archive, err := exportZip(export)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot build export archive", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot export user"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, id))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// exportZip раскладывает выгрузку по отдельным JSON-файлам архива
+func exportZip(export *service.UserExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"user.json", export.User},
+		{"orders.json", export.Orders},
+		{"deliveries.json", export.Deliveries},
+		{"history.json", export.History},
+	}
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handleUserErase обезличивает пользователя
+// REST-маршрут задан в api.proto: UserService.EraseUser
+func (s *Server) handleUserErase(c *gin.Context) {
+	id := c.Param("id")
+	if !s.authorize(c, service.ActionUserErase, id) {
+		return
+	}
+	user, err := service.EraseUser(c.Request.Context(), s.repo, id)
+	if errors.Is(err, service.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot erase user", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot erase user"})
+		return
+	}
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, user)
+}
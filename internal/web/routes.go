@@ -0,0 +1,114 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"order-ms/internal/ratelimit"
+	pb "order-ms/pkg/proto"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoRoute — REST-маршрут RPC, заданный аннотацией google.api.http в api.proto
+type protoRoute struct {
+	RPC    protoreflect.FullName // например proto.OrderService.ConfirmOrder
+	Method string
+	Path   string // в синтаксисе gin: /api/orders/:id/confirm
+}
+
+// параметр пути из шаблона google.api.http: {id} → :id
+var pathParam = regexp.MustCompile(`\{([^}=]+)\}`)
+
+// protoRoutes читает REST-маршруты из аннотаций api.proto. RPC без аннотации (потоки Watch*) пропускаются
+func protoRoutes() []protoRoute {
+	var routes []protoRoute
+	services := pb.File_pkg_proto_api_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			m := methods.Get(j)
+			rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if rule == nil {
+				continue
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				method, path := httpRule(r)
+				routes = append(routes, protoRoute{RPC: m.FullName(), Method: method, Path: pathParam.ReplaceAllString(path, ":$1")})
+			}
+		}
+	}
+	return routes
+}
+
+// httpRule возвращает HTTP-метод и шаблон пути правила
+func httpRule(r *annotations.HttpRule) (string, string) {
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// группы лимитов частоты по сервисам api.proto — те же, что у gRPC-сервера
+var serviceRateLimitGroups = map[string]string{
+	pb.UserService_ServiceDesc.ServiceName:      "users",
+	pb.OrderService_ServiceDesc.ServiceName:     "orders",
+	pb.DeliveryService_ServiceDesc.ServiceName:  "logistics",
+	pb.WarehouseService_ServiceDesc.ServiceName: "logistics",
+}
+
+// rpcHandlers связывает RPC из api.proto с обработчиками gin
+func (s *Server) rpcHandlers() map[protoreflect.FullName]gin.HandlerFunc {
+	return map[protoreflect.FullName]gin.HandlerFunc{
+		"proto.UserService.CreateUser": s.handleUserCreate,
+		"proto.UserService.GetUser":    s.handleUserGetByID,
+		"proto.UserService.ListUsers":  s.handleUserList,
+		"proto.UserService.UpdateUser": s.handleUserUpdateByID,
+		"proto.UserService.DeleteUser": s.handleUserDeleteByID,
+		"proto.UserService.ExportUser": s.handleUserExport,
+		"proto.UserService.EraseUser":  s.handleUserErase,
+
+		"proto.OrderService.CreateOrder":       s.handleOrderCreate,
+		"proto.OrderService.ListOrders":        s.handleOrderList,
+		"proto.OrderService.GetOrder":          s.handleOrderGetByID,
+		"proto.OrderService.DeleteOrder":       s.handleOrderDeleteByID,
+		"proto.OrderService.ConfirmOrder":      s.handleOrderConfirm,
+		"proto.OrderService.DeliverOrder":      s.handleOrderDelivery,
+		"proto.OrderService.CancelOrder":       s.handleOrderCancel,
+		"proto.OrderService.UpdateOrderStatus": s.handleOrderStatus,
+
+		"proto.DeliveryService.ListDeliveries": s.handleDeliveryList,
+		"proto.DeliveryService.GetDelivery":    s.handleDeliveryGet,
+
+		"proto.WarehouseService.ListWarehouses": s.handleWarehouseList,
+		"proto.WarehouseService.GetWarehouse":   s.handleWarehouseGet,
+	}
+}
+
+// registerProtoRoutes регистрирует REST-маршруты из api.proto с лимитом частоты группы их сервиса.
+// Ошибка, если у аннотированного RPC нет обработчика в handlers
+func registerProtoRoutes(r gin.IRoutes, handlers map[protoreflect.FullName]gin.HandlerFunc, limiter *ratelimit.Limiter) error {
+	for _, route := range protoRoutes() {
+		h, ok := handlers[route.RPC]
+		if !ok {
+			return fmt.Errorf("no handler for %s (%s %s)", route.RPC, route.Method, route.Path)
+		}
+		r.Handle(route.Method, route.Path, rateLimit(limiter, serviceRateLimitGroups[string(route.RPC.Parent())]), h)
+	}
+	return nil
+}
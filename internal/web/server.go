@@ -1,13 +1,29 @@
 package web
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"order-ms/internal/auth"
+	"order-ms/internal/events"
+	"order-ms/internal/health"
+	"order-ms/internal/mergepatch"
+	"order-ms/internal/metrics"
 	"order-ms/internal/model"
+	"order-ms/internal/ratelimit"
 	"order-ms/internal/service"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,25 +31,74 @@ type Server struct {
 	address    string       // Адрес, по которому будет слушать сервер
 	httpServer *http.Server // Указатель на стандартный http-сервер
 	repo       service.Repository
+	bus        *events.Bus   // источник событий для SSE и WebSocket
+	heartbeat  time.Duration // интервал пульса в потоках событий
+	// streams отменяется в начале Shutdown: потоки SSE и WebSocket завершаются, не дожидаясь закрытия шины
+	streams     context.Context
+	stopStreams context.CancelFunc
+	logger      *slog.Logger
+	health      *health.Checker
+	keys        *service.APIKeys
 }
 
 // Структура для парсинга, какие поля ожидаем в json-запросе
 type createOrderRequest struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	AddressID string `json:"address_id,omitempty"` // ID сохранённого адреса пользователя, копия попадёт в заказ
+}
+
+type updateOrderStatusRequest struct {
+	Status          orderStatusParam `json:"status"`                     // confirmed, delivered, cancelled или номер статуса
+	ExpectedVersion int64            `json:"expected_version,omitempty"` // то же, что If-Match
+}
+
+// orderStatusParam — статус в теле запроса: название ("confirmed") или номер, строкой или числом,
+// как его присылает клиент, сгенерированный по api.proto
+type orderStatusParam string
+
+func (p *orderStatusParam) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*p = orderStatusParam(strings.ToLower(strings.TrimPrefix(s, "ORDER_"))) // ORDER_CONFIRMED — имя из enum OrderStatus
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*p = orderStatusParam(strconv.Itoa(n))
+	return nil
 }
 
 type createUserRequest struct {
-	Name string `json:"name"`
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	Phone     string          `json:"phone,omitempty"`
+	Addresses []model.Address `json:"addresses,omitempty"` // ID адресов можно не задавать
 }
 
+// профиль заменяется целиком: не переданные телефон и адреса удаляются
 type updateUserRequest struct {
-	Name string `json:"name"`
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	Phone     string          `json:"phone,omitempty"`
+	Addresses []model.Address `json:"addresses,omitempty"`
 }
 
-// создание нового сервера
+// тело PUT: профиль и, как в UpdateUserRequest из api.proto, необязательная ожидаемая версия
+type replaceUserRequest struct {
+	updateUserRequest
+	ExpectedVersion int64 `json:"expected_version,omitempty"` // то же, что If-Match
+}
+
+// создание нового сервера; ошибка, если у RPC с REST-маршрутом в api.proto нет обработчика
 
-func NewServer(address string, repo service.Repository) *Server {
+func NewServer(address string, repo service.Repository, bus *events.Bus, logger *slog.Logger, m *metrics.Metrics, checker *health.Checker, authn *auth.Authenticator, limiter *ratelimit.Limiter) (*Server, error) {
 	router := gin.New()
+	// X-Forwarded-For принимается только от прокси из TrustProxies: иначе клиент сам выбирал бы себе IP
+	// и обходил лимит ratelimit.AuthGroup
+	_ = router.SetTrustedProxies(nil)
+	router.Use(requestID(), tracingMiddleware(), accessLog(logger), httpMetrics(m), gin.Recovery())
 
 	s := &Server{
 		address: address,
@@ -44,44 +109,142 @@ func NewServer(address string, repo service.Repository) *Server {
 			WriteTimeout: 10 * time.Second, // сколько времени дается серверу на отправку ответа клиенту
 			IdleTimeout:  60 * time.Second, // время ожидания между запросами, если клиент держит соединение открытым
 		},
-		repo: repo,
-	}
+		repo:      repo,
+		bus:       bus,
+		heartbeat: defaultHeartbeat,
+		logger:    logger,
+		health:    checker,
+		keys:      service.NewAPIKeys(repo, logger),
+	}
+	s.streams, s.stopStreams = context.WithCancel(context.Background())
 	//регистрируем эндпоинты (маршруты) в gin, по которым будут обрабатываться запросы
-	router.POST("/api/orders", s.handleOrderCreate) // связь url с методом-обработчиком
-	router.GET("/api/orders", s.handleOrderList)
-	router.GET("/api/orders/:id", s.handleOrderGetByID)
-	router.DELETE("/api/orders/:id", s.handleOrderDeleteByID)
-	router.POST("/api/orders/confirm/:id", s.handleOrderConfirm)
-	router.POST("/api/orders/delivery/:id", s.handleOrderDelivery)
-	router.POST("/api/orders/cancel/:id", s.handleOrderCancel)
+	// без токена доступны только документация и проверки здоровья
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName(openAPIInstance)))
+	router.GET("/healthz", s.handleHealthz)
+	router.GET("/readyz", s.handleReadyz)
+
+	// остальное — только с токеном или API-ключом и с лимитом частоты: по IP до аутентификации
+	// и по клиенту для каждой группы маршрутов
+	private := router.Group("/", rateLimitIP(limiter), authenticate(authn))
+	private.GET("/metrics", rateLimit(limiter, "metrics"), gin.WrapH(m.Handler()))
+
+	// маршруты RPC из api.proto — по их аннотациям google.api.http
+	if err := registerProtoRoutes(private, s.rpcHandlers(), limiter); err != nil {
+		return nil, err
+	}
+
+	api := private.Group("/api")
+	orders := api.Group("/orders", rateLimit(limiter, "orders"))
+	orders.POST("/import", s.handleOrderImport)
+	orders.GET("/export", s.handleOrderExport)
+	orders.GET("/ws", s.handleOrderEventsWS)
+	orders.GET("/:id/events", s.handleOrderEvents)
+	// прежние пути смены статуса оставлены для совместимости с уже написанными клиентами
+	orders.POST("/confirm/:id", s.handleOrderConfirm)
+	orders.POST("/delivery/:id", s.handleOrderDelivery)
+	orders.POST("/cancel/:id", s.handleOrderCancel)
+
+	users := api.Group("/users", rateLimit(limiter, "users"))
+	users.PATCH("/:id", s.handleUserPatchByID)
+	users.GET("/:id/orders/events", s.handleUserOrderEvents)
+
+	hooks := api.Group("/webhooks", rateLimit(limiter, "admin"))
+	hooks.POST("", s.handleWebhookCreate)
+	hooks.GET("", s.handleWebhookList)
+	hooks.GET("/dead-letters", s.handleWebhookDeadLetters)
+	hooks.POST("/deliveries/:id/retry", s.handleWebhookRedeliver)
+	hooks.GET("/:id", s.handleWebhookGet)
+	hooks.DELETE("/:id", s.handleWebhookDelete)
+	hooks.GET("/:id/deliveries", s.handleWebhookDeliveries)
+
+	admin := api.Group("/admin", rateLimit(limiter, "admin"))
+	admin.POST("/keys", s.handleAPIKeyCreate)
+	admin.GET("/keys", s.handleAPIKeyList)
+	admin.POST("/keys/:id/rotate", s.handleAPIKeyRotate)
+	admin.DELETE("/keys/:id", s.handleAPIKeyRevoke)
+	admin.GET("/deleted/orders", s.handleDeletedOrderList)
+	admin.POST("/orders/:id/restore", s.handleOrderRestore)
+	admin.GET("/deleted/users", s.handleDeletedUserList)
+	admin.POST("/users/:id/restore", s.handleUserRestore)
+
+	return s, nil
+}
 
-	router.POST("/api/users", s.handleUserCreate)
-	router.GET("/api/users", s.handleUserList)
-	router.GET("/api/users/:id", s.handleUserGetByID)
-	router.PUT("/api/users/:id", s.handleUserUpdateByID)
-	router.DELETE("/api/users/:id", s.handleUserDeleteByID)
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+// TrustProxies задаёт адреса прокси (IP или CIDR), которым можно верить в X-Forwarded-For; вызывается до Start
+func (s *Server) TrustProxies(proxies []string) error {
+	return s.httpServer.Handler.(*gin.Engine).SetTrustedProxies(proxies)
+}
 
-	return s
+// UseTLS включает HTTPS; вызывается до Start. Сертификаты берутся из cfg (GetCertificate), а не из файлов
+func (s *Server) UseTLS(cfg *tls.Config) {
+	s.httpServer.TLSConfig = cfg
 }
 
-// метод запуска http-сервера
+// метод запуска http-сервера; после Shutdown возвращает nil
 func (s *Server) Start() error {
-	log.Printf("Server starting on %s\n", s.address)
-	return s.httpServer.ListenAndServe() // запускает сервер и блокирует при ошибке
+	lis, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Serve обслуживает запросы на уже открытом lis (в тестах — на свободном порту 127.0.0.1:0);
+// блокирует до остановки или ошибки, после Shutdown возвращает nil
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Info("http server starting", "address", lis.Addr().String(), "tls", s.httpServer.TLSConfig != nil)
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		err = s.httpServer.ServeTLS(lis, "", "")
+	} else {
+		err = s.httpServer.Serve(lis)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown перестаёт принимать новые соединения, завершает потоки событий и ждёт, пока завершатся текущие запросы.
+// Если ctx истёк раньше, оставшиеся соединения закрываются принудительно.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Info("http server shutting down")
+	s.stopStreams()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.httpServer.Close()
+		return err
+	}
+	s.logger.Info("http server stopped")
+	return nil
+}
+
+// authorize проверяет права вызывающего по политике service; при отказе отвечает 403
+func (s *Server) authorize(c *gin.Context, action service.Action, owner string) bool {
+	if err := service.Authorize(c.Request.Context(), action, owner); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return false
+	}
+	return true
+}
+
+// authorizeOrder загружает заказ, чтобы узнать его владельца, и проверяет права.
+// Отвечает 404, если заказа нет, и 403, если действие запрещено
+func (s *Server) authorizeOrder(c *gin.Context, action service.Action, id string) bool {
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get order", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get order"})
+		return false
+	}
+	if order == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return false
+	}
+	return s.authorize(c, action, order.UserID)
 }
 
 // handleOrderCreate создает новый заказ
-// @Summary Создать заказ
-// @Description Создает новый заказ пользователя с переданным userID
-// @Tags Orders
-// @Accept json
-// @Produce json
-// @Param user body createOrderRequest true "User ID"
-// @Success 201 {object} model.Order "Созданный заказ"
-// @Failure 400 {object} object "Неверный JSON или не указан user ID"
-// @Failure 500 {object} object "Ошибка кодирования ответа"
-// @Router /api/orders [post]
+// REST-маршрут задан в api.proto: OrderService.CreateOrder
 func (s *Server) handleOrderCreate(c *gin.Context) {
 	// распарсим user_id в структуру
 	var req createOrderRequest
@@ -95,9 +258,26 @@ func (s *Server) handleOrderCreate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
+	// покупатель может создать заказ только на себя
+	if !s.authorize(c, service.ActionOrderCreate, req.UserID) {
+		return
+	}
+	// копируем выбранный адрес из профиля, чтобы его последующие правки не меняли заказ
+	address, err := service.ShippingAddress(c.Request.Context(), s.repo, req.UserID, req.AddressID)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAddressNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		s.logger.ErrorContext(c.Request.Context(), "cannot get shipping address", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot save order"})
+		return
+	}
 	// создаем заказ и сохраняем
 	order := model.NewOrder(req.UserID)
-	if err := s.repo.Save(order); err != nil {
+	order.ShippingAddress = address
+	if err := s.repo.Save(c.Request.Context(), order); err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot save order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot save order"})
 		return
 	}
@@ -106,36 +286,28 @@ func (s *Server) handleOrderCreate(c *gin.Context) {
 }
 
 // handleOrderList формирует список всех заказов
-// @Summary Получить список заказов
-// @Description Возвращает все созданные заказы
-// @Tags Orders
-// @Accept json
-// @Produce json
-// @Success 200 {array} model.Order "Список заказов"
-// @Failure 500 {object} object "Ошибка кодирования ответа"
-// @Router /api/orders [get]
+// REST-маршрут задан в api.proto: OrderService.ListOrders
 func (s *Server) handleOrderList(c *gin.Context) {
+	filter, ok := parseOrderFilter(c)
+	if !ok {
+		return
+	}
 	// получаем список всех заказов
-	orders, err := s.repo.GetOrders()
+	orders, err := s.repo.GetOrders(c.Request.Context())
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get orders", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get orders"})
 		return
 	}
-	// отправляем клиенту json-массив заказов
-	c.JSON(http.StatusOK, orders)
+	if !s.authorize(c, service.ActionOrderList, "") {
+		return
+	}
+	// отправляем клиенту json-массив заказов (покупатель видит только свои)
+	c.JSON(http.StatusOK, filter.Apply(service.VisibleOrders(c.Request.Context(), orders)))
 }
 
 // handleOrderGetByID получает заказ по его ID
-// @Summary Получить заказ по ID
-// @Description Возвращает заказ с указанным ID
-// @Tags Orders
-// @Accept json
-// @Produce json
-// @Param id path string true "ID заказа"
-// @Success 200 {object} model.Order "Найденный заказ"
-// @Failure 400 {object} object "Некорректный ID"
-// @Failure 404 {object} object "Заказ не найден"
-// @Router /api/orders/{id} [get]
+// REST-маршрут задан в api.proto: OrderService.GetOrder
 func (s *Server) handleOrderGetByID(c *gin.Context) {
 	// получаем id из пути /api/orders/{id}
 	id := c.Param("id")
@@ -144,8 +316,9 @@ func (s *Server) handleOrderGetByID(c *gin.Context) {
 		return
 	}
 	// ищем заказ
-	order, err := s.repo.GetOrderByID(id)
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get order"})
 		return
 	}
@@ -154,18 +327,15 @@ func (s *Server) handleOrderGetByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
+	if !s.authorize(c, service.ActionOrderRead, order.UserID) {
+		return
+	}
+	setETag(c, order.Version)
 	c.JSON(http.StatusOK, order)
 }
 
 // handleOrderDeleteByID удаляет заказ по его ID
-// @Summary Удалить заказ
-// @Description Удаляет заказ с указанным ID
-// @Tags Orders
-// @Param id path string true "ID заказа"
-// @Success 204 "Заказ успешно удален"
-// @Failure 400 {object} object "Некорректный ID"
-// @Failure 404 {object} object "Заказ не найден"
-// @Router /api/orders/{id} [delete]
+// REST-маршрут задан в api.proto: OrderService.DeleteOrder
 func (s *Server) handleOrderDeleteByID(c *gin.Context) {
 	// получаем id из пути /api/orders/{id}
 	id := c.Param("id")
@@ -173,8 +343,12 @@ func (s *Server) handleOrderDeleteByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order ID"})
 		return
 	}
-	ok, err := s.repo.DeleteOrder(id)
+	if !s.authorizeOrder(c, service.ActionOrderDelete, id) {
+		return
+	}
+	ok, err := s.repo.DeleteOrder(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot delete order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot delete order"})
 		return
 	}
@@ -186,15 +360,21 @@ func (s *Server) handleOrderDeleteByID(c *gin.Context) {
 }
 
 // handleOrderConfirm подтверждает заказ складом и переводит его в статус "подтвержден" (1)
-// @Summary Подтверждение заказа
-// @Description Подтверждает заказ, если он находится в статусе "создан" (0)
+// REST-маршрут задан в api.proto: OrderService.ConfirmOrder
+// Старый путь описан здесь, пока им пользуются клиенты:
+// @Summary Подтверждение заказа (устаревший путь)
+// @Description То же, что POST /api/orders/{id}/confirm
 // @Tags Orders
-// @Accept json
 // @Produce json
 // @Param id path string true "ID заказа"
-// @Success 204 "No Content - успешное подтверждение"
-// @Failure 400 {object} object "Некорректный запрос или статус заказа не позволяет подтверждение"
+// @Param If-Match header string false "ETag заказа: смена статуса, только если заказ не менялся"
+// @Success 200 {object} model.Order "Подтверждённый заказ"
 // @Failure 404 {object} object "Заказ не найден"
+// @Failure 409 {object} object "Заказ не в статусе \"создан\""
+// @Failure 412 {object} object "Заказ изменился с момента чтения"
+// @Deprecated
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/orders/confirm/{id} [post]
 func (s *Server) handleOrderConfirm(c *gin.Context) {
 	id := c.Param("id")
@@ -203,9 +383,21 @@ func (s *Server) handleOrderConfirm(c *gin.Context) {
 		return
 	}
 
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	if !s.authorizeOrder(c, service.ActionOrderConfirm, id) {
+		return
+	}
 	// подтверждаем заказ через репозиторий
-	ok, err := s.repo.ConfirmOrder(id)
+	ok, err := s.repo.ConfirmOrder(c.Request.Context(), id, expected)
+	if errors.Is(err, model.ErrVersionConflict) {
+		versionConflict(c)
+		return
+	}
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to confirm order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm order"})
 		return
 	}
@@ -216,8 +408,9 @@ func (s *Server) handleOrderConfirm(c *gin.Context) {
 	}
 
 	// берём обновлённый заказ
-	order, err := s.repo.GetOrderByID(id)
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to fetch order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
 		return
 	}
@@ -226,20 +419,26 @@ func (s *Server) handleOrderConfirm(c *gin.Context) {
 		return
 	}
 
+	setETag(c, order.Version)
 	c.JSON(http.StatusOK, order)
 }
 
 // handleOrderDelivery переводит заказ в статус "доставлен" (2)
-// @Summary Отметить заказ как доставленный
-// @Description Переводит заказ в статус "доставлен", если он в статусе "подтвержден"
+// REST-маршрут задан в api.proto: OrderService.DeliverOrder
+// Старый путь описан здесь, пока им пользуются клиенты:
+// @Summary Отметить заказ доставленным (устаревший путь)
+// @Description То же, что POST /api/orders/{id}/deliver
 // @Tags Orders
-// @Accept json
 // @Produce json
 // @Param id path string true "ID заказа"
-// @Success 204 "No Content - заказ успешно доставлен"
-// @Failure 400 {object} object "Некорректный запрос или заказ в нужном статусе"
+// @Param If-Match header string false "ETag заказа: смена статуса, только если заказ не менялся"
+// @Success 200 {object} model.Order "Доставленный заказ"
 // @Failure 404 {object} object "Заказ не найден"
-// @Failure 405 {object} object "Метод не поддерживается"
+// @Failure 409 {object} object "Заказ не в статусе \"подтверждён\""
+// @Failure 412 {object} object "Заказ изменился с момента чтения"
+// @Deprecated
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/orders/delivery/{id} [post]
 func (s *Server) handleOrderDelivery(c *gin.Context) {
 	id := c.Param("id")
@@ -247,9 +446,21 @@ func (s *Server) handleOrderDelivery(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order ID"})
 		return
 	}
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	if !s.authorizeOrder(c, service.ActionOrderDeliver, id) {
+		return
+	}
 	// помечаем заказ как доставленный
-	ok, err := s.repo.DeliverOrder(id)
+	ok, err := s.repo.DeliverOrder(c.Request.Context(), id, expected)
+	if errors.Is(err, model.ErrVersionConflict) {
+		versionConflict(c)
+		return
+	}
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to mark order as delivered", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark order as delivered"})
 		return
 	}
@@ -259,8 +470,9 @@ func (s *Server) handleOrderDelivery(c *gin.Context) {
 	}
 
 	// достаём обновлённый заказ
-	order, err := s.repo.GetOrderByID(id)
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to fetch updated order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated order"})
 		return
 	}
@@ -269,20 +481,26 @@ func (s *Server) handleOrderDelivery(c *gin.Context) {
 		return
 	}
 
+	setETag(c, order.Version)
 	c.JSON(http.StatusOK, order)
 }
 
 // handleOrderCancel отменяет заказ
-// @Summary Отмена заказа
-// @Description Отменяет заказ, если он в статусе "создан" или "подтвержден"
+// REST-маршрут задан в api.proto: OrderService.CancelOrder
+// Старый путь описан здесь, пока им пользуются клиенты:
+// @Summary Отмена заказа (устаревший путь)
+// @Description То же, что POST /api/orders/{id}/cancel
 // @Tags Orders
-// @Accept json
 // @Produce json
 // @Param id path string true "ID заказа"
-// @Success 204 "No Content - заказ успешно отменен"
-// @Failure 400 {object} object "Некорректный статус заказа для отмены"
+// @Param If-Match header string false "ETag заказа: смена статуса, только если заказ не менялся"
+// @Success 204 "Заказ отменён"
 // @Failure 404 {object} object "Заказ не найден"
-// @Failure 405 {object} object "Метод не поддерживается"
+// @Failure 409 {object} object "Заказ уже доставлен или отменён"
+// @Failure 412 {object} object "Заказ изменился с момента чтения"
+// @Deprecated
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/orders/cancel/{id} [post]
 func (s *Server) handleOrderCancel(c *gin.Context) {
 	id := c.Param("id")
@@ -290,8 +508,20 @@ func (s *Server) handleOrderCancel(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order ID"})
 		return
 	}
-	ok, err := s.repo.CancelOrder(id)
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	if !s.authorizeOrder(c, service.ActionOrderCancel, id) {
+		return
+	}
+	ok, err := s.repo.CancelOrder(c.Request.Context(), id, expected)
+	if errors.Is(err, model.ErrVersionConflict) {
+		versionConflict(c)
+		return
+	}
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to cancel order", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
 		return
 	}
@@ -304,31 +534,84 @@ func (s *Server) handleOrderCancel(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// handleOrderStatus переводит заказ в указанный статус
+// REST-маршрут задан в api.proto: OrderService.UpdateOrderStatus
+func (s *Server) handleOrderStatus(c *gin.Context) {
+	id := c.Param("id")
+	var req updateOrderStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	to, err := model.ParseOrderStatus(string(req.Status))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action, change, err := service.StatusChange(s.repo, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	if expected == 0 {
+		expected = req.ExpectedVersion
+	}
+	if !s.authorizeOrder(c, action, id) {
+		return
+	}
+	ok, err = change(c.Request.Context(), id, expected)
+	if errors.Is(err, model.ErrVersionConflict) {
+		versionConflict(c)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to change order status", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change order status"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order cannot move from its current status to " + to.String()})
+		return
+	}
+
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to fetch updated order", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated order"})
+		return
+	}
+	if order == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found after status change"})
+		return
+	}
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
 // handleUserCreate создает нового пользователя
-// @Summary Создать пользователя
-// @Description Создает нового пользователя с переданным именем
-// @Tags Users
-// @Accept json
-// @Produce json
-// @Param user body createUserRequest true "Имя пользователя"
-// @Success 200 {object} model.User "Созданный пользователь"
-// @Failure 400 {object} object "Неверный JSON или не указано имя"
-// @Failure 500 {object} object "Ошибка кодирования ответа"
-// @Router /api/users [post]
+// REST-маршрут задан в api.proto: UserService.CreateUser
 func (s *Server) handleUserCreate(c *gin.Context) {
 	var req createUserRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+	// создаем пользователя и проверяем профиль
+	user := model.NewUser(req.Name)
+	user.Email, user.Phone, user.Addresses = req.Email, req.Phone, req.Addresses
+	user.Normalize()
+	if !validateUser(c, user) {
 		return
 	}
-	// создаем пользователя и сохраняем
-	user := model.NewUser(req.Name)
-	if err := s.repo.Save(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot save user"})
+	if !s.authorize(c, service.ActionUserCreate, "") {
+		return
+	}
+	if err := s.repo.Save(c.Request.Context(), user); err != nil {
+		s.userSaveError(c, err, "Cannot save user")
 		return
 	}
 	// возвращаем результат клиенту
@@ -336,17 +619,14 @@ func (s *Server) handleUserCreate(c *gin.Context) {
 }
 
 // handleUserList формирует список всех пользователей
-// @Summary Получить список пользователей
-// @Description Возвращает всех зарегистрированных пользователей
-// @Tags Users
-// @Accept json
-// @Produce json
-// @Success 200 {array} model.User "Список пользователей"
-// @Failure 500 {object} object "Ошибка кодирования ответа"
-// @Router /api/users [get]
+// REST-маршрут задан в api.proto: UserService.ListUsers
 func (s *Server) handleUserList(c *gin.Context) {
-	users, err := s.repo.GetUsers()
+	if !s.authorize(c, service.ActionUserList, "") {
+		return
+	}
+	users, err := s.repo.GetUsers(c.Request.Context())
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get users", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get users"})
 		return
 	}
@@ -354,17 +634,7 @@ func (s *Server) handleUserList(c *gin.Context) {
 }
 
 // handleUserGetByID ищет пользователя по ID
-// @Summary Получить пользователя по ID
-// @Description Возвращает пользователя по указанному ID
-// @Tags Users
-// @Accept json
-// @Produce json
-// @Param id path string true "ID пользователя"
-// @Success 200 {object} model.User "Пользователь найден"
-// @Failure 400 {object} object "Отсутствует или некорректный ID"
-// @Failure 404 {object} object "Пользователь не найден"
-// @Failure 500 {object} object "Ошибка кодирования ответа"
-// @Router /api/users/{id} [get]
+// REST-маршрут задан в api.proto: UserService.GetUser
 func (s *Server) handleUserGetByID(c *gin.Context) {
 	// Извлекаем id из URL (/api/users/{id})
 	id := c.Param("id")
@@ -372,9 +642,13 @@ func (s *Server) handleUserGetByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user ID"})
 		return
 	}
+	if !s.authorize(c, service.ActionUserRead, id) {
+		return
+	}
 	// ищем пользователя по id
-	user, err := s.repo.GetUserByID(id)
+	user, err := s.repo.GetUserByID(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to get user", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
 		return
 	}
@@ -383,21 +657,12 @@ func (s *Server) handleUserGetByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, user)
 }
 
-// handleUserUpdateByID обновляет имя пользователя по ID
-// @Summary Обновить имя пользователя по ID
-// @Description Обновляет имя пользователя по указанному ID
-// @Tags Users
-// @Accept json
-// @Produce json
-// @Param id path string true "ID пользователя"
-// @Param user body updateUserRequest true "Новое имя пользователя"
-// @Success 204 "Успешное обновление без тела ответа"
-// @Failure 400 {object} object "Неверный JSON или некорректные данные"
-// @Failure 404 {object} object "Пользователь не найден"
-// @Router /api/users/{id} [put]
+// handleUserUpdateByID заменяет профиль пользователя по ID
+// REST-маршрут задан в api.proto: UserService.UpdateUser
 func (s *Server) handleUserUpdateByID(c *gin.Context) {
 	// Извлекаем id из URL (/api/users/{id})
 	id := c.Param("id")
@@ -405,44 +670,208 @@ func (s *Server) handleUserUpdateByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user ID"})
 		return
 	}
-	var req updateUserRequest
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	var req replaceUserRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
+	if expected == 0 {
+		expected = req.ExpectedVersion
+	}
 
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+	updated := req.user(id)
+	if !validateUser(c, updated) {
 		return
 	}
-	ok, err := s.repo.UpdateUserName(id, req.Name)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	if !s.authorize(c, service.ActionUserUpdate, id) {
 		return
 	}
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	current, found := s.getUser(c, id)
+	if !found {
 		return
 	}
-
-	updatedUser, err := s.repo.GetUserByID(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated user"})
-		return
+	if expected == 0 {
+		expected = current.Version // без If-Match защищаемся хотя бы от изменений между чтением и записью
 	}
-	c.JSON(http.StatusOK, updatedUser)
+	s.userUpdated(c, updated, s.updateUser(c.Request.Context(), current, updated, expected))
 }
 
-// handleUserDeleteByID удаляет пользователя по ID
-// @Summary Удалить пользователя по ID
-// @Description Удаляет пользователя по указанному ID
+// максимальное число попыток PATCH без If-Match, если профиль меняется параллельно
+const patchAttempts = 3
+
+// handleUserPatchByID частично обновляет профиль пользователя по ID (JSON Merge Patch, RFC 7396)
+// @Summary Частично обновить профиль пользователя
+// @Description Накладывает JSON Merge Patch на профиль: переданные поля заменяются, null удаляет телефон
+// @Description или адреса, массив addresses заменяется целиком. С If-Match патч применяется только к этой версии
 // @Tags Users
 // @Accept json
+// @Accept application/merge-patch+json
 // @Produce json
 // @Param id path string true "ID пользователя"
-// @Success 204 "Пользователь успешно удалён"
+// @Param If-Match header string false "ETag профиля, полученный при чтении"
+// @Param patch body updateUserRequest true "Изменяемые поля профиля"
+// @Success 200 {object} model.User "Обновлённый пользователь"
+// @Header 200 {string} ETag "Новая версия профиля"
+// @Failure 400 {object} object "Неверный JSON, неизвестное поле или некорректные данные"
 // @Failure 404 {object} object "Пользователь не найден"
-// @Router /api/users/{id} [delete]
+// @Failure 409 {object} object "Email уже занят"
+// @Failure 412 {object} object "Профиль изменился с момента чтения (If-Match)"
+// @Failure 415 {object} object "Тело не application/merge-patch+json или application/json"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/users/{id} [patch]
+func (s *Server) handleUserPatchByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user ID"})
+		return
+	}
+	if ct := c.ContentType(); ct != mergepatch.ContentType && ct != gin.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Use Content-Type " + mergepatch.ContentType})
+		return
+	}
+	expected, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read body"})
+		return
+	}
+	if !s.authorize(c, service.ActionUserUpdate, id) {
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, found := s.getUser(c, id)
+		if !found {
+			return
+		}
+		updated, err := patchUser(current, id, patch)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validateUser(c, updated) {
+			return
+		}
+		version := expected
+		if version == 0 {
+			version = current.Version
+		}
+		err = s.updateUser(c.Request.Context(), current, updated, version)
+		// без If-Match клиенту версия не важна: при гонке перечитываем профиль и накладываем патч заново
+		if errors.Is(err, model.ErrVersionConflict) && expected == 0 && attempt < patchAttempts {
+			continue
+		}
+		s.userUpdated(c, updated, err)
+		return
+	}
+}
+
+// patchUser накладывает merge patch на редактируемые поля профиля current
+func patchUser(current *model.User, id string, patch []byte) (*model.User, error) {
+	doc, err := json.Marshal(updateUserRequest{
+		Name:      current.Name,
+		Email:     current.Email,
+		Phone:     current.Phone,
+		Addresses: current.Addresses,
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	var req updateUserRequest
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields() // id, version и даты менять нельзя
+	if err := dec.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	return req.user(id), nil
+}
+
+// user собирает из запроса нормализованный профиль пользователя id
+func (req updateUserRequest) user(id string) *model.User {
+	u := &model.User{Id: id, Name: req.Name, Email: req.Email, Phone: req.Phone, Addresses: req.Addresses}
+	u.Normalize()
+	return u
+}
+
+// getUser загружает пользователя; отвечает 404 или 500 и возвращает false, если его нет
+func (s *Server) getUser(c *gin.Context, id string) (*model.User, bool) {
+	user, err := s.repo.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to get user", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// updateUser сохраняет updated вместо current, если профиль всё ещё в версии expected
+func (s *Server) updateUser(ctx context.Context, current, updated *model.User, expected int64) error {
+	updated.CreatedAt, updated.UpdatedAt = current.CreatedAt, time.Now()
+	ok, err := s.repo.UpdateUser(ctx, updated, expected)
+	if err == nil && !ok {
+		return errUserGone
+	}
+	return err
+}
+
+// errUserGone — пользователя удалили между чтением и записью
+var errUserGone = errors.New("user not found")
+
+// userUpdated отвечает на результат updateUser: 200 с новым профилем и ETag или ошибку
+func (s *Server) userUpdated(c *gin.Context, updated *model.User, err error) {
+	switch {
+	case err == nil:
+		setETag(c, updated.Version)
+		c.JSON(http.StatusOK, updated)
+	case errors.Is(err, model.ErrVersionConflict):
+		versionConflict(c)
+	case errors.Is(err, errUserGone):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		s.userSaveError(c, err, "Failed to update user")
+	}
+}
+
+// validateUser проверяет профиль и отвечает 400 с именем поля, если он некорректен
+func validateUser(c *gin.Context, u *model.User) bool {
+	var verr *model.ValidationError
+	if err := u.Validate(); errors.As(err, &verr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": verr.Error(), "field": verr.Field})
+		return false
+	}
+	return true
+}
+
+// userSaveError отвечает на ошибку сохранения пользователя: 409 для занятого email, иначе 500
+func (s *Server) userSaveError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, model.ErrEmailTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	s.logger.ErrorContext(c.Request.Context(), "cannot save user", "err", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+}
+
+// handleUserDeleteByID удаляет пользователя по ID
+// REST-маршрут задан в api.proto: UserService.DeleteUser
 func (s *Server) handleUserDeleteByID(c *gin.Context) {
 	// Извлекаем id из URL (/api/users/{id})
 	id := c.Param("id")
@@ -450,8 +879,12 @@ func (s *Server) handleUserDeleteByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing user ID"})
 		return
 	}
-	ok, err := s.repo.DeleteUser(id)
+	if !s.authorize(c, service.ActionUserDelete, id) {
+		return
+	}
+	ok, err := s.repo.DeleteUser(c.Request.Context(), id)
 	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to delete user", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
@@ -463,3 +896,31 @@ func (s *Server) handleUserDeleteByID(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// handleHealthz отвечает, что процесс жив
+// @Summary Liveness
+// @Description Всегда 200, пока процесс отвечает на запросы; зависимости не проверяются
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object "Процесс жив"
+// @Router /healthz [get]
+func (s *Server) handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// handleReadyz проверяет зависимости активного бэкенда
+// @Summary Readiness
+// @Description Пингует Postgres или Mongo и Redis; 503, если хоть одна зависимость недоступна или идёт остановка сервиса
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Report "Сервис готов"
+// @Failure 503 {object} health.Report "Сервис не готов"
+// @Router /readyz [get]
+func (s *Server) handleReadyz(c *gin.Context) {
+	report := s.health.Check(c.Request.Context())
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
@@ -1,18 +1,46 @@
 package web
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"io"
+	"maps"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"order-ms/internal/audit"
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/certs"
+	"order-ms/internal/certs/certstest"
+	"order-ms/internal/events"
+	"order-ms/internal/health"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
 	"order-ms/internal/model"
-	"order-ms/internal/repository"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/repository/memory"
+	"order-ms/internal/service"
+	pb "order-ms/pkg/proto"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
+// общий in-memory репозиторий для тестов ручек
+var repository = memory.NewMemoryRepo(logging.Discard())
+
 // тест ручки GET для получения заказов
 func TestGetOrders(t *testing.T) {
 	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
@@ -39,13 +67,15 @@ func TestGetOrders(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			// создаем сервер
-			s := NewServer(":8080")
+			s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+			require.NoError(t, err)
 
 			// получаем роутер
 			r := s.httpServer.Handler.(*gin.Engine)
 
 			// создаем запрос
 			req, _ := http.NewRequest("GET", "/api/orders", nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			// выполняем запрос
@@ -56,7 +86,7 @@ func TestGetOrders(t *testing.T) {
 
 			// проверяем тело ответа
 			var got []model.Order
-			err := json.Unmarshal(w.Body.Bytes(), &got)
+			err = json.Unmarshal(w.Body.Bytes(), &got)
 			assert.NoError(t, err)
 			assert.GreaterOrEqual(t, len(got), tc.expectedMin)
 		})
@@ -99,13 +129,15 @@ func TestGetOrderByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			// создаем сервер
-			s := NewServer(":8080")
+			s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+			require.NoError(t, err)
 
 			// получаем роутер
 			r := s.httpServer.Handler.(*gin.Engine)
 
 			// создаем запрос
 			req, _ := http.NewRequest("GET", "/api/orders/"+tc.orderID, nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			// выполняем запрос
@@ -128,15 +160,22 @@ func TestGetOrderByID(t *testing.T) {
 func TestCreateOrder(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	s := NewServer(":8080")
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
+	// пользователь с сохранённым адресом доставки
+	home := model.Address{Id: "home", Country: "RU", City: "Москва", Street: "Тверская, 1", PostalCode: "125009"}
+	repository.Save(context.Background(), &model.User{Id: "User-withAddress", Name: "Адрес", Addresses: []model.Address{home}})
+
 	tests := []struct {
 		name        string
 		body        string
 		wantStatus  int
 		wantUserID  string
 		wantCreated bool
+		wantAddress *model.Address
 	}{
 		{
 			name:        "valid order",
@@ -145,6 +184,19 @@ func TestCreateOrder(t *testing.T) {
 			wantUserID:  "User-testOne",
 			wantCreated: true,
 		},
+		{
+			name:        "order with saved address",
+			body:        `{"user_id":"User-withAddress", "address_id":"home"}`,
+			wantStatus:  http.StatusCreated,
+			wantUserID:  "User-withAddress",
+			wantCreated: true,
+			wantAddress: &home,
+		},
+		{
+			name:       "unknown address",
+			body:       `{"user_id":"User-withAddress", "address_id":"work"}`,
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "missing user_id",
 			body:       `{"status":1}`,
@@ -160,6 +212,7 @@ func TestCreateOrder(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			req, _ := http.NewRequest("POST", "/api/orders", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
@@ -173,6 +226,7 @@ func TestCreateOrder(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, got.Id)
 				assert.Equal(t, tc.wantUserID, got.UserID)
+				assert.Equal(t, tc.wantAddress, got.ShippingAddress)
 			}
 		})
 	}
@@ -181,7 +235,9 @@ func TestCreateOrder(t *testing.T) {
 func TestDeleteOrderByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	s := NewServer(":8080")
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
 	tests := []struct {
@@ -194,7 +250,7 @@ func TestDeleteOrderByID(t *testing.T) {
 			prepare: func() string {
 				// создаём новый заказ
 				order := model.NewOrder("user-test-delete")
-				repository.SaveStorable(order)
+				repository.Save(context.Background(), order)
 				return order.Id
 			},
 			wantStatus: http.StatusNoContent,
@@ -214,6 +270,7 @@ func TestDeleteOrderByID(t *testing.T) {
 			orderID := tc.prepare()
 
 			req, _ := http.NewRequest("DELETE", "/api/orders/"+orderID, nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -237,12 +294,14 @@ func TestOrderStatusHandlers(t *testing.T) {
 	orderCancelled.Status = model.OrderCancelled // статус Cancelled
 
 	// сохраняем в репозиторий
-	repository.SaveStorable(orderCreated)
-	repository.SaveStorable(orderConfirmed)
-	repository.SaveStorable(orderDelivered)
-	repository.SaveStorable(orderCancelled)
+	repository.Save(context.Background(), orderCreated)
+	repository.Save(context.Background(), orderConfirmed)
+	repository.Save(context.Background(), orderDelivered)
+	repository.Save(context.Background(), orderCancelled)
+
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
 
-	s := NewServer(":8080")
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
 	tests := []struct {
@@ -289,6 +348,7 @@ func TestOrderStatusHandlers(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			req, _ := http.NewRequest("POST", tc.route+tc.orderID, nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -296,7 +356,7 @@ func TestOrderStatusHandlers(t *testing.T) {
 
 			// проверяем статус в репозитории, если указан
 			if tc.wantRepoStatus != 0 {
-				order := repository.GetOrderByID(tc.orderID)
+				order, _ := repository.GetOrderByID(context.Background(), tc.orderID)
 				assert.NotNil(t, order)
 				assert.Equal(t, tc.wantRepoStatus, order.Status)
 			}
@@ -326,13 +386,15 @@ func TestGetUsers(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			// создаем сервер
-			s := NewServer(":8080")
+			s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+			require.NoError(t, err)
 
 			// получаем роутер
 			r := s.httpServer.Handler.(*gin.Engine)
 
 			// создаем запрос
 			req, _ := http.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			// выполняем запрос
@@ -343,7 +405,7 @@ func TestGetUsers(t *testing.T) {
 
 			// проверяем тело ответа
 			var got []model.User
-			err := json.Unmarshal(w.Body.Bytes(), &got)
+			err = json.Unmarshal(w.Body.Bytes(), &got)
 			assert.NoError(t, err)
 			assert.GreaterOrEqual(t, len(got), tc.expectedMin)
 		})
@@ -382,13 +444,15 @@ func TestGetUserByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			// создаем сервер
-			s := NewServer(":8080")
+			s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+			require.NoError(t, err)
 
 			// получаем роутер
 			r := s.httpServer.Handler.(*gin.Engine)
 
 			// создаем запрос
 			req, _ := http.NewRequest("GET", "/api/users/"+tc.userID, nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			// выполняем запрос
@@ -411,7 +475,9 @@ func TestGetUserByID(t *testing.T) {
 func TestCreateUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	s := NewServer(":8080")
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
 	tests := []struct {
@@ -422,10 +488,30 @@ func TestCreateUser(t *testing.T) {
 	}{
 		{
 			name:        "valid user",
-			body:        `{"id":"User-test", "name":"Гера"}`,
+			body:        `{"id":"User-test", "name":"Гера", "email":"Gera@Example.com", "phone":"+7 999 123-45-67"}`,
 			wantStatus:  http.StatusCreated,
 			wantCreated: true,
 		},
+		{
+			name:       "duplicate email",
+			body:       `{"name":"Гера 2", "email":"gera@example.com"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "missing email",
+			body:       `{"name":"Гера"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid phone",
+			body:       `{"name":"Гера", "email":"gera3@example.com", "phone":"call me"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "incomplete address",
+			body:       `{"name":"Гера", "email":"gera4@example.com", "addresses":[{"country":"RU","city":"Москва"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "invalid json",
 			body:       `{id:"User-test", "name": ""}`,
@@ -436,6 +522,7 @@ func TestCreateUser(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			req, _ := http.NewRequest("POST", "/api/users", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
@@ -448,6 +535,9 @@ func TestCreateUser(t *testing.T) {
 				err := json.Unmarshal(w.Body.Bytes(), &got)
 				assert.NoError(t, err)
 				assert.NotEmpty(t, got.Id)
+				assert.Equal(t, "gera@example.com", got.Email)
+				assert.Equal(t, "+79991234567", got.Phone)
+				assert.False(t, got.CreatedAt.IsZero())
 			}
 		})
 	}
@@ -456,7 +546,9 @@ func TestCreateUser(t *testing.T) {
 func TestDeleteUserByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	s := NewServer(":8080")
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
 	tests := []struct {
@@ -469,7 +561,7 @@ func TestDeleteUserByID(t *testing.T) {
 			prepare: func() string {
 				// создаём новый заказ
 				user := model.NewUser("user-test1-delete")
-				repository.SaveStorable(user)
+				repository.Save(context.Background(), user)
 				return user.Id
 			},
 			wantStatus: http.StatusNoContent,
@@ -489,6 +581,7 @@ func TestDeleteUserByID(t *testing.T) {
 			userID := tc.prepare()
 
 			req, _ := http.NewRequest("DELETE", "/api/users/"+userID, nil)
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -501,12 +594,15 @@ func TestDeleteUserByID(t *testing.T) {
 func TestUserUpdateByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	s := NewServer(":8080")
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
 	r := s.httpServer.Handler.(*gin.Engine)
 
 	// создаём пользователя для тестов
 	existingUserID := "u1"
-	repository.SaveStorable(&model.User{Id: existingUserID, Name: "Old Name"})
+	repository.Save(context.Background(), &model.User{Id: existingUserID, Name: "Old Name"})
+	repository.Save(context.Background(), &model.User{Id: "u2", Name: "Other", Email: "other@example.com"})
 
 	tests := []struct {
 		name           string
@@ -518,20 +614,26 @@ func TestUserUpdateByID(t *testing.T) {
 		{
 			name:           "успешное обновление существующего пользователя",
 			userID:         existingUserID,
-			body:           updateUserRequest{Name: "New Name"},
+			body:           updateUserRequest{Name: "New Name", Email: "new@example.com"},
 			expectedStatus: http.StatusOK,
 			expectedName:   "New Name",
 		},
 		{
 			name:           "пустое имя в запросе",
 			userID:         existingUserID,
-			body:           updateUserRequest{Name: ""},
+			body:           updateUserRequest{Name: "", Email: "new@example.com"},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "email другого пользователя",
+			userID:         existingUserID,
+			body:           updateUserRequest{Name: "New Name", Email: "other@example.com"},
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name:           "несуществующий пользователь",
 			userID:         "not-exist",
-			body:           updateUserRequest{Name: "Ghost"},
+			body:           updateUserRequest{Name: "Ghost", Email: "ghost@example.com"},
 			expectedStatus: http.StatusNotFound,
 		},
 		{
@@ -556,6 +658,7 @@ func TestUserUpdateByID(t *testing.T) {
 			}
 
 			req, _ := http.NewRequest(http.MethodPut, "/api/users/"+tc.userID, bytes.NewBuffer(reqBody))
+			req.Header.Set("Authorization", authtest.Bearer(t, "admin", "admin"))
 			req.Header.Set("Content-Type", "application/json")
 
 			w := httptest.NewRecorder()
@@ -569,10 +672,1277 @@ func TestUserUpdateByID(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.expectedName, updatedUser.Name)
 
-				user := repository.GetUserByID(tc.userID)
+				user, _ := repository.GetUserByID(context.Background(), tc.userID)
 				assert.NotNil(t, user)
 				assert.Equal(t, tc.expectedName, user.Name)
 			}
 		})
 	}
 }
+
+// тест ручки GET /readyz: статус по каждой зависимости и 503 при остановке
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	tests := []struct {
+		name         string
+		redisErr     error
+		shutdown     bool
+		expectedCode int
+		expected     health.Report
+	}{
+		{
+			name:         "all dependencies up",
+			expectedCode: http.StatusOK,
+			expected: health.Report{Status: health.StatusOK, Checks: map[string]health.DependencyStatus{
+				"mongo": {Status: health.StatusOK},
+				"redis": {Status: health.StatusOK},
+			}},
+		},
+		{
+			name:         "redis down",
+			redisErr:     errors.New("connection refused"),
+			expectedCode: http.StatusServiceUnavailable,
+			expected: health.Report{Status: health.StatusUnavailable, Checks: map[string]health.DependencyStatus{
+				"mongo": {Status: health.StatusOK},
+				"redis": {Status: health.StatusUnavailable},
+			}},
+		},
+		{
+			name:         "shutting down",
+			shutdown:     true,
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     health.Report{Status: health.StatusShuttingDown, Checks: map[string]health.DependencyStatus{}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := health.NewChecker(logging.Discard())
+			checker.Add("mongo", func(context.Context) error { return nil })
+			checker.Add("redis", func(context.Context) error { return tc.redisErr })
+			if tc.shutdown {
+				checker.Shutdown()
+			}
+
+			s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), checker, authtest.NewHS256(t), nil)
+
+			require.NoError(t, err)
+			r := s.httpServer.Handler.(*gin.Engine)
+
+			req, _ := http.NewRequest("GET", "/readyz", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			var got health.Report
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tc.expected, got)
+			assert.NotContains(t, w.Body.String(), "connection refused", "dependency errors are only logged")
+		})
+	}
+}
+
+// тест Shutdown: начатый запрос дорабатывает, новые соединения не принимаются
+func TestShutdownDrainsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	s, err := NewServer(address, repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	started := make(chan struct{})
+	s.httpServer.Handler.(*gin.Engine).GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.Serve(lis) }()
+
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + address + "/slow")
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		inFlight <- result{code: resp.StatusCode, body: string(body)}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+
+	got := <-inFlight
+	assert.NoError(t, got.err)
+	assert.Equal(t, http.StatusOK, got.code)
+	assert.Equal(t, "done", got.body)
+	assert.NoError(t, <-serveErr) // ErrServerClosed не считается ошибкой
+
+	_, err = http.Get("http://" + address + "/healthz")
+	assert.Error(t, err)
+}
+
+// тест аутентификации: без валидного токена доступны только документация и health
+func TestAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	expired := authtest.Mint(t, jwt.SigningMethodHS256, authtest.Secret, "", authtest.Claims("admin", -time.Hour, "admin"))
+	foreign := authtest.Mint(t, jwt.SigningMethodHS256, []byte("other-secret"), "", authtest.Claims("admin", time.Hour, "admin"))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		authorized    bool
+	}{
+		{"healthz is public", "/healthz", "", true},
+		{"swagger is public", "/swagger/index.html", "", true},
+		{"api without token", "/api/orders", "", false},
+		{"metrics without token", "/metrics", "", false},
+		{"not a bearer token", "/api/orders", "Basic YWRtaW46YWRtaW4=", false},
+		{"expired token", "/api/orders", "Bearer " + expired, false},
+		{"wrong signature", "/api/orders", "Bearer " + foreign, false},
+		{"valid token", "/api/orders", authtest.Bearer(t, "admin", "admin"), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tc.authorized {
+				assert.NotEqual(t, http.StatusUnauthorized, w.Code)
+				return
+			}
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+// тест режима без аутентификации: запросы выполняются от имени анонимного администратора
+func TestInsecureNoAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	s, err := NewServer(":8080", memory.NewMemoryRepo(logging.Discard()), events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), nil, nil)
+
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	for _, path := range []string{"/api/orders", "/api/users", "/api/admin/keys"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+// тест ролей: покупатель работает только со своими заказами, склад подтверждает, курьер доставляет
+func TestAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	own := model.NewOrder("alice")
+	foreign := model.NewOrder("bob")
+	_ = repo.Save(context.Background(), own)
+	_ = repo.Save(context.Background(), foreign)
+
+	alice := authtest.Bearer(t, "alice", "customer")
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		authorization string
+		expectedCode  int
+	}{
+		{"customer reads own order", "GET", "/api/orders/" + own.Id, "", alice, http.StatusOK},
+		{"customer cannot read foreign order", "GET", "/api/orders/" + foreign.Id, "", alice, http.StatusForbidden},
+		{"customer creates own order", "POST", "/api/orders", `{"user_id":"alice"}`, alice, http.StatusCreated},
+		{"customer cannot create order for others", "POST", "/api/orders", `{"user_id":"bob"}`, alice, http.StatusForbidden},
+		{"customer cannot confirm", "POST", "/api/orders/confirm/" + own.Id, "", alice, http.StatusForbidden},
+		{"customer cannot list users", "GET", "/api/users", "", alice, http.StatusForbidden},
+		{"customer reads own profile", "GET", "/api/users/alice", "", alice, http.StatusNotFound},
+		{"customer cannot read other profile", "GET", "/api/users/bob", "", alice, http.StatusForbidden},
+		{"courier cannot confirm", "POST", "/api/orders/confirm/" + own.Id, "", authtest.Bearer(t, "c-1", "courier"), http.StatusForbidden},
+		{"warehouse confirms", "POST", "/api/orders/confirm/" + own.Id, "", authtest.Bearer(t, "w-1", "warehouse"), http.StatusOK},
+		{"customer cannot deliver", "POST", "/api/orders/delivery/" + own.Id, "", alice, http.StatusForbidden},
+		{"courier delivers", "POST", "/api/orders/delivery/" + own.Id, "", authtest.Bearer(t, "c-1", "courier"), http.StatusOK},
+		{"warehouse cannot delete", "DELETE", "/api/orders/" + foreign.Id, "", authtest.Bearer(t, "w-1", "warehouse"), http.StatusForbidden},
+		{"customer cancels own order", "POST", "/api/orders/cancel/" + foreign.Id, "", authtest.Bearer(t, "bob", "customer"), http.StatusNoContent},
+		{"no roles", "GET", "/api/orders/" + own.Id, "", authtest.Bearer(t, "alice"), http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", tc.authorization)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+		})
+	}
+
+	// в списке покупатель видит только свои заказы
+	req, _ := http.NewRequest("GET", "/api/orders", nil)
+	req.Header.Set("Authorization", alice)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var got []model.Order
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.NotEmpty(t, got)
+	for _, o := range got {
+		assert.Equal(t, "alice", o.UserID)
+	}
+}
+
+// тест API-ключей: выпуск, вызов с X-API-Key в пределах области, перевыпуск и отзыв
+func TestAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	authn := authtest.NewHS256(t)
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authn, nil)
+	require.NoError(t, err)
+	authn.SetKeyVerifier(s.keys)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	order := model.NewOrder("alice")
+	_ = repo.Save(context.Background(), order)
+
+	do := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "root", "admin")
+
+	// выпускать ключи может только admin, роль и область проверяются
+	w := do("POST", "/api/admin/keys", `{"name":"wms","role":"warehouse"}`, "Authorization", authtest.Bearer(t, "w-1", "warehouse"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = do("POST", "/api/admin/keys", `{"name":"shop","role":"customer"}`, "Authorization", admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do("POST", "/api/admin/keys", `{"name":"wms","role":"warehouse","scopes":["order:fly"]}`, "Authorization", admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("POST", "/api/admin/keys", `{"name":"wms","role":"warehouse","scopes":["order:confirm"],"expires_in":"720h"}`, "Authorization", admin)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var issued apiKeySecretResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.NotEmpty(t, issued.Secret)
+	assert.Empty(t, issued.Key.Hash)
+	assert.NotNil(t, issued.Key.ExpiresAt)
+
+	// ключ работает в пределах роли и области
+	w = do("POST", "/api/orders/confirm/"+order.Id, "", apiKeyHeader, issued.Secret)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do("GET", "/api/orders/"+order.Id, "", apiKeyHeader, issued.Secret)
+	assert.Equal(t, http.StatusForbidden, w.Code) // order:read не входит в область
+	w = do("GET", "/api/orders", "", apiKeyHeader, issued.Secret+"x")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// в списке нет хешей, есть время последнего использования
+	w = do("GET", "/api/admin/keys", "", "Authorization", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var keys []model.APIKey
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	if assert.Len(t, keys, 1) {
+		assert.Empty(t, keys[0].Hash)
+		assert.NotNil(t, keys[0].LastUsedAt)
+	}
+
+	// после перевыпуска старый секрет не принимается
+	w = do("POST", "/api/admin/keys/"+issued.Key.Id+"/rotate", "", "Authorization", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rotated apiKeySecretResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, http.StatusUnauthorized, do("POST", "/api/orders/confirm/"+order.Id, "", apiKeyHeader, issued.Secret).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/orders/confirm/"+order.Id, "", apiKeyHeader, rotated.Secret).Code) // уже подтверждён
+
+	// отозванный ключ не принимается и не перевыпускается
+	assert.Equal(t, http.StatusOK, do("DELETE", "/api/admin/keys/"+issued.Key.Id, "", "Authorization", admin).Code)
+	assert.Equal(t, http.StatusUnauthorized, do("POST", "/api/orders/confirm/"+order.Id, "", apiKeyHeader, rotated.Secret).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/admin/keys/"+issued.Key.Id+"/rotate", "", "Authorization", admin).Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/admin/keys/Key-missing", "", "Authorization", admin).Code)
+}
+
+// тест лимита частоты: после burst запросов клиент получает 429 с Retry-After, другие клиенты — нет
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"orders": {Rate: 0.1, Burst: 2}}, logging.Discard())
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), limiter)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	get := func(path, subject string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", authtest.Bearer(t, subject, "admin"))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/orders", "alice").Code)
+	assert.Equal(t, http.StatusOK, get("/api/orders", "alice").Code)
+	w := get("/api/orders", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, get("/api/orders", "bob").Code)  // другой пользователь
+	assert.Equal(t, http.StatusOK, get("/api/users", "alice").Code) // группа без лимита
+	assert.Equal(t, http.StatusOK, get("/healthz", "alice").Code)   // health не ограничивается
+}
+
+// тест лимита по IP до аутентификации: перебор неверных API-ключей получает 429, подмена X-Forwarded-For не помогает
+func TestRateLimitBeforeAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{ratelimit.AuthGroup: {Rate: 0.1, Burst: 3}}, logging.Discard())
+	s, err := NewServer(":8080", repository, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), limiter)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	do := func(remoteAddr string, header ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/orders", nil)
+		req.RemoteAddr = remoteAddr
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, do("203.0.113.7:4000", apiKeyHeader, "guess-"+strconv.Itoa(i)).Code)
+	}
+	w := do("203.0.113.7:4000", apiKeyHeader, "guess-3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.7:4000", apiKeyHeader, "guess-4", "X-Forwarded-For", "198.51.100.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.7:4000", "Authorization", authtest.Bearer(t, "alice", "admin")).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, do("203.0.113.8:4000", apiKeyHeader, "guess-5").Code) // другой IP
+
+	// проверки здоровья не ограничиваются
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// тест ETag/If-Match и JSON Merge Patch: устаревшая версия даёт 412, патч меняет только переданные поля
+func TestOptimisticConcurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	user := model.NewUser("Оля")
+	user.Email = "olya@example.com"
+	user.Phone = "+79990000000"
+	_ = repo.Save(context.Background(), user)
+	order := model.NewOrder(user.Id)
+	_ = repo.Save(context.Background(), order)
+
+	admin := authtest.Bearer(t, "admin", "admin")
+	do := func(method, path, body, contentType, ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", admin)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/api/users/"+user.Id, "", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		ifMatch     string
+		wantCode    int
+		wantETag    string
+	}{
+		{"put with current version", "PUT", `{"name":"Оля","email":"olya@example.com"}`, "application/json", `"1"`, http.StatusOK, `"2"`},
+		{"put with stale version", "PUT", `{"name":"Ольга","email":"olya@example.com"}`, "application/json", `"1"`, http.StatusPreconditionFailed, ""},
+		{"garbage if-match", "PUT", `{"name":"Ольга","email":"olya@example.com"}`, "application/json", `"a", "b"`, http.StatusPreconditionFailed, ""},
+		{"patch without if-match", "PATCH", `{"phone":"+79991112233"}`, "application/merge-patch+json", "", http.StatusOK, `"3"`},
+		{"patch with weak etag", "PATCH", `{"name":"Ольга"}`, "application/merge-patch+json", `W/"3"`, http.StatusOK, `"4"`},
+		{"patch with stale version", "PATCH", `{"name":"Оля"}`, "application/merge-patch+json", `"3"`, http.StatusPreconditionFailed, ""},
+		{"patch cannot change id", "PATCH", `{"id":"other"}`, "application/merge-patch+json", "", http.StatusBadRequest, ""},
+		{"patch removes required email", "PATCH", `{"email":null}`, "application/merge-patch+json", "", http.StatusBadRequest, ""},
+		{"patch wrong content type", "PATCH", `name=x`, "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.method, "/api/users/"+user.Id, tc.body, tc.contentType, tc.ifMatch)
+			assert.Equal(t, tc.wantCode, w.Code, w.Body.String())
+			assert.Equal(t, tc.wantETag, w.Header().Get("ETag"))
+		})
+	}
+
+	// патч поменял только имя и телефон, адреса и email остались
+	got, _ := repo.GetUserByID(context.Background(), user.Id)
+	assert.Equal(t, "Ольга", got.Name)
+	assert.Equal(t, "olya@example.com", got.Email)
+	assert.Equal(t, "+79991112233", got.Phone)
+	assert.Equal(t, int64(4), got.Version)
+
+	// смена статуса заказа с устаревшей версией
+	w = do("POST", "/api/orders/confirm/"+order.Id, "", "", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = do("POST", "/api/orders/confirm/"+order.Id, "", "", `"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+// тест мягкого удаления и восстановления через админские ручки
+func TestSoftDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	user := model.NewUser("Оля")
+	user.Email = "olya@example.com"
+	_ = repo.Save(context.Background(), user)
+	order := model.NewOrder(user.Id)
+	_ = repo.Save(context.Background(), order)
+
+	do := func(method, path, authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "admin", "admin")
+	customer := authtest.Bearer(t, user.Id, "customer")
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/orders/"+order.Id, admin).Code)
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/users/"+user.Id, admin).Code)
+
+	// удалённые записи не видны обычным запросам
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/orders/"+order.Id, admin).Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/users/"+user.Id, admin).Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/orders/"+order.Id, admin).Code)
+
+	// админские ручки закрыты для покупателя
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/admin/deleted/orders", customer).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/admin/users/"+user.Id+"/restore", customer).Code)
+
+	w := do("GET", "/api/admin/deleted/orders", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var orders []model.Order
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &orders))
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, order.Id, orders[0].Id)
+		assert.NotNil(t, orders[0].DeletedAt)
+	}
+	w = do("GET", "/api/admin/deleted/users", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var users []model.User
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+	assert.Len(t, users, 1)
+
+	w = do("POST", "/api/admin/orders/"+order.Id+"/restore", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/admin/orders/"+order.Id+"/restore", admin).Code)
+	assert.Equal(t, http.StatusOK, do("POST", "/api/admin/users/"+user.Id+"/restore", admin).Code)
+
+	assert.Equal(t, http.StatusOK, do("GET", "/api/orders/"+order.Id, admin).Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/users/"+user.Id, admin).Code)
+	w = do("GET", "/api/admin/deleted/orders", admin)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+// тест выгрузки и обезличивания данных пользователя
+func TestUserExportErase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	base := memory.NewMemoryRepo(logging.Discard())
+	repo := audit.InstrumentRepository(base, logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	user := model.NewUser("Оля")
+	user.Email = "olya@example.com"
+	user.Phone = "+79990000000"
+	user.Addresses = []model.Address{{Id: "home", Country: "RU", City: "Москва", Street: "Тверская, 1"}}
+	_ = base.Save(context.Background(), user)
+
+	do := func(method, path, authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "admin", "admin")
+	customer := authtest.Bearer(t, user.Id, "customer")
+
+	req, _ := http.NewRequest("POST", "/api/orders", strings.NewReader(`{"user_id":"`+user.Id+`","address_id":"home"}`))
+	req.Header.Set("Authorization", customer)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var order model.Order
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, http.StatusOK, do("POST", "/api/orders/confirm/"+order.Id, admin).Code)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		wantCode      int
+	}{
+		{"foreign customer cannot export", "GET", "/api/users/" + user.Id + "/export", authtest.Bearer(t, "bob", "customer"), http.StatusForbidden},
+		{"unknown format", "GET", "/api/users/" + user.Id + "/export?format=xml", customer, http.StatusBadRequest},
+		{"missing user", "GET", "/api/users/User-missing/export", admin, http.StatusNotFound},
+		{"customer cannot erase", "POST", "/api/users/" + user.Id + "/erase", customer, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantCode, do(tc.method, tc.path, tc.authorization).Code)
+		})
+	}
+
+	// выгрузка своих данных: профиль, заказ и история статусов
+	w = do("GET", "/api/users/"+user.Id+"/export", customer)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var export service.UserExport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "olya@example.com", export.User.Email)
+	if assert.Len(t, export.Orders, 1) {
+		assert.NotNil(t, export.Orders[0].ShippingAddress)
+	}
+	var actions []string
+	for _, e := range export.History {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"order:create", "order:confirm"}, actions)
+
+	w = do("GET", "/api/users/"+user.Id+"/export?format=zip", customer)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"user.json", "orders.json", "deliveries.json", "history.json"}, names)
+
+	// обезличивание: контакты и адреса стёрты, заказ остался
+	w = do("POST", "/api/users/"+user.Id+"/erase", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var erased model.User
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &erased))
+	assert.Equal(t, model.ErasedName, erased.Name)
+	assert.Empty(t, erased.Email)
+	assert.Empty(t, erased.Phone)
+	assert.Empty(t, erased.Addresses)
+	assert.NotNil(t, erased.ErasedAt)
+
+	w = do("GET", "/api/orders/"+order.Id, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "shipping_address")
+
+	// обе операции попали в журнал
+	entries, err := base.GetAuditEntries(context.Background(), []string{user.Id})
+	assert.NoError(t, err)
+	actions = actions[:0]
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"user:export", "user:export", "user:erase"}, actions)
+}
+
+// тест пакетной загрузки, выгрузки и фильтров списка заказов
+func TestOrderImportExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	user := model.NewUser("Оля")
+	user.Email = "olya@example.com"
+	user.Addresses = []model.Address{{Id: "home", Country: "RU", City: "Москва", Street: "Тверская, 1"}}
+	_ = repo.Save(context.Background(), user)
+	existing := model.NewOrder(user.Id)
+	_ = repo.Save(context.Background(), existing)
+
+	do := func(method, path, body, authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "admin", "admin")
+
+	csvBody := "id,user_id,address_id,status,created_at,cancel_reason\n" +
+		"Old-1," + user.Id + ",home,confirmed,2024-01-02T03:04:05Z,\n" +
+		"Old-2," + user.Id + ",,cancelled,,expired\n" +
+		"Old-3,User-missing,,,,\n" +
+		"Old-4," + user.Id + ",office,,,\n" +
+		"Old-5," + user.Id + ",,shipped,,\n" +
+		"Old-1," + user.Id + ",,,,\n" +
+		existing.Id + "," + user.Id + ",,,,\n" +
+		"Old-6," + user.Id + ",,created,,expired\n"
+	wantErrors := []service.ImportRowError{
+		{Line: 4, ID: "Old-3", Error: "user User-missing not found"},
+		{Line: 5, ID: "Old-4", Error: "user " + user.Id + " has no address office"},
+		{Line: 6, ID: "Old-5", Error: `unknown order status "shipped"`},
+		{Line: 7, ID: "Old-1", Error: "order id already exists"},
+		{Line: 8, ID: existing.Id, Error: "order id already exists"},
+		{Line: 9, ID: "Old-6", Error: "cancel_reason is only allowed for cancelled orders"},
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		wantCode     int
+		wantImported int
+		wantOrders   int // сколько заказов в репозитории после запроса
+	}{
+		{"dry run writes nothing", "/api/orders/import?format=csv&dry_run=true", csvBody, http.StatusOK, 2, 1},
+		{"csv in batches", "/api/orders/import?format=csv&batch_size=1", csvBody, http.StatusOK, 2, 3},
+		{"unknown format", "/api/orders/import?format=xml", csvBody, http.StatusBadRequest, 0, 3},
+		{"csv without user_id column", "/api/orders/import?format=csv", "id,status\nOld-9,created\n", http.StatusBadRequest, 0, 3},
+		{"batch too large", "/api/orders/import?format=csv&batch_size=5000", csvBody, http.StatusBadRequest, 0, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do("POST", tc.path, tc.body, admin)
+			assert.Equal(t, tc.wantCode, w.Code, w.Body.String())
+			if tc.wantCode == http.StatusOK {
+				var res service.ImportResult
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, 8, res.Total)
+				assert.Equal(t, tc.wantImported, res.Imported)
+				assert.Equal(t, len(wantErrors), res.Failed)
+				assert.Equal(t, wantErrors, res.Errors)
+			}
+			orders, _ := repo.GetOrders(context.Background())
+			assert.Len(t, orders, tc.wantOrders)
+		})
+	}
+
+	imported, _ := repo.GetOrderByID(context.Background(), "Old-1")
+	if assert.NotNil(t, imported) {
+		assert.Equal(t, model.OrderConfirmed, imported.Status)
+		assert.Equal(t, "home", imported.ShippingAddress.Id)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), imported.CreatedAt.UTC())
+	}
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/orders/import?format=csv", csvBody, authtest.Bearer(t, user.Id, "customer")).Code)
+
+	// выгрузка NDJSON загружается обратно (ID уже заняты, поэтому все строки отклоняются как дубликаты)
+	w := do("GET", "/api/orders/export?status=confirmed", "", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 1)
+	w = do("POST", "/api/orders/import?format=ndjson&dry_run=true", w.Body.String(), admin)
+	assert.Contains(t, w.Body.String(), `"order id already exists"`)
+
+	w = do("GET", "/api/orders/export?format=csv&user_id="+user.Id, "", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,user_id,address_id,status,created_at,cancel_reason,version", strings.SplitN(w.Body.String(), "\n", 2)[0])
+	assert.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), 4)
+
+	// те же фильтры у списка
+	filters := []struct {
+		query string
+		want  int
+		code  int
+	}{
+		{"?status=cancelled", 1, http.StatusOK},
+		{"?status=0", 1, http.StatusOK},
+		{"?created_before=2025-01-01T00:00:00Z", 1, http.StatusOK},
+		{"?user_id=User-missing", 0, http.StatusOK},
+		{"?status=shipped", 0, http.StatusBadRequest},
+		{"?created_after=yesterday", 0, http.StatusBadRequest},
+	}
+	for _, f := range filters {
+		t.Run("list"+f.query, func(t *testing.T) {
+			w := do("GET", "/api/orders"+f.query, "", admin)
+			assert.Equal(t, f.code, w.Code)
+			if f.code == http.StatusOK {
+				var got []model.Order
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Len(t, got, f.want)
+			}
+		})
+	}
+}
+
+// sseEvent — событие, прочитанное из потока text/event-stream
+type sseEvent struct {
+	id, event string
+	data      streamMessage
+}
+
+// readSSE читает следующее событие потока; комментарии-пульсы считает в heartbeats
+func readSSE(t *testing.T, r *bufio.Reader, heartbeats *int) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream closed: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.event != "":
+			return e
+		case line == ": heartbeat":
+			*heartbeats++
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data))
+		}
+	}
+}
+
+// тест SSE и WebSocket: снимок, живые события, пульс, продолжение по Last-Event-ID и закрытие при остановке
+func TestOrderEventStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := events.NewBus(events.DefaultHistory)
+	repo := events.InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	s, err := NewServer(":8080", repo, bus, logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	s.heartbeat = 20 * time.Millisecond
+	ts := httptest.NewServer(s.httpServer.Handler)
+	defer ts.Close()
+
+	ctx := context.Background()
+	user := model.NewUser("Оля")
+	_ = repo.Save(ctx, user)
+	order := model.NewOrder(user.Id)
+	_ = repo.Save(ctx, order) // событие 1
+	token := authtest.Token(t, user.Id, "customer")
+
+	// open открывает SSE-поток; токен передаётся в access_token, как из EventSource
+	open := func(path, lastEventID string) *http.Response {
+		req, _ := http.NewRequest("GET", ts.URL+path+"?access_token="+token, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := open("/api/orders/"+order.Id+"/events", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	r := bufio.NewReader(resp.Body)
+	heartbeats := 0
+	e := readSSE(t, r, &heartbeats)
+	assert.Equal(t, "order.snapshot", e.event)
+	assert.Equal(t, "1", e.id)
+	assert.Equal(t, model.OrderCreated, e.data.Order.Status)
+
+	_, _ = repo.ConfirmOrder(ctx, order.Id, 0) // событие 2
+	e = readSSE(t, r, &heartbeats)
+	assert.Equal(t, "order.confirmed", e.event)
+	assert.Equal(t, "2", e.id)
+	assert.Equal(t, model.OrderConfirmed, e.data.Order.Status)
+
+	_ = repo.Save(ctx, model.NewOrder("bob")) // событие 3, чужой заказ в поток не попадает
+	time.Sleep(50 * time.Millisecond)
+	_, _ = repo.DeliverOrder(ctx, order.Id, 0) // событие 4
+	e = readSSE(t, r, &heartbeats)
+	assert.Equal(t, "4", e.id)
+	assert.Positive(t, heartbeats)
+	resp.Body.Close()
+
+	// переподключение: пропущенные события без снимка; слишком новый номер — снимок заново
+	resp = open("/api/orders/"+order.Id+"/events", "1")
+	e = readSSE(t, bufio.NewReader(resp.Body), &heartbeats)
+	assert.Equal(t, "order.confirmed", e.event)
+	resp.Body.Close()
+	resp = open("/api/orders/"+order.Id+"/events", "99")
+	e = readSSE(t, bufio.NewReader(resp.Body), &heartbeats)
+	assert.Equal(t, "order.snapshot", e.event)
+	assert.Equal(t, "4", e.id)
+	resp.Body.Close()
+
+	// поток пользователя: с неизвестного номера приходит resync, дальше — события его заказов
+	resp = open("/api/users/"+user.Id+"/orders/events", "99")
+	r = bufio.NewReader(resp.Body)
+	e = readSSE(t, r, &heartbeats)
+	assert.Equal(t, "resync", e.event)
+	second := model.NewOrder(user.Id)
+	_ = repo.Save(ctx, second) // событие 5
+	e = readSSE(t, r, &heartbeats)
+	assert.Equal(t, "order.created", e.event)
+	assert.Equal(t, second.Id, e.data.Order.Id)
+	resp.Body.Close()
+
+	// WebSocket: те же события в JSON, продолжение по last_event_id
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/orders/ws?user_id=" + user.Id + "&last_event_id=3&access_token=" + token
+	conn, err := websocket.Dial(wsURL, "", ts.URL)
+	if assert.NoError(t, err) {
+		var m streamMessage
+		assert.NoError(t, websocket.JSON.Receive(conn, &m))
+		assert.Equal(t, uint64(4), m.Seq)
+		assert.Equal(t, model.OrderDelivered, m.Order.Status)
+		assert.NoError(t, websocket.JSON.Receive(conn, &m))
+		assert.Equal(t, uint64(5), m.Seq)
+		assert.NoError(t, websocket.JSON.Receive(conn, &m))
+		assert.Equal(t, "heartbeat", m.Type)
+		conn.Close()
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		expectedCode int
+	}{
+		{"no token", "/api/orders/" + order.Id + "/events", map[string]string{"Accept": "text/event-stream"}, http.StatusUnauthorized},
+		{"token in query only for streams", "/api/orders/" + order.Id + "?access_token=" + token, nil, http.StatusUnauthorized},
+		{"foreign order", "/api/orders/" + order.Id + "/events", map[string]string{"Authorization": authtest.Bearer(t, "bob", "customer")}, http.StatusForbidden},
+		{"bad Last-Event-ID", "/api/orders/" + order.Id + "/events", map[string]string{"Authorization": "Bearer " + token, "Last-Event-ID": "x"}, http.StatusBadRequest},
+		{"unknown user", "/api/users/nope/orders/events", map[string]string{"Authorization": authtest.Bearer(t, "root", "admin")}, http.StatusNotFound},
+		{"websocket without filter", "/api/orders/ws", map[string]string{"Authorization": "Bearer " + token}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", ts.URL+tc.path, nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, tc.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+
+	// при остановке шина закрывается, и поток завершается, не задерживая Shutdown
+	resp = open("/api/orders/"+order.Id+"/events", "")
+	r = bufio.NewReader(resp.Body)
+	readSSE(t, r, &heartbeats)
+	bus.Close()
+	_, err = io.Copy(io.Discard, r)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+// тест Shutdown с открытым потоком SSE: сервер завершает поток сам, шина при этом остаётся открытой
+func TestShutdownEndsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := events.NewBus(events.DefaultHistory)
+	repo := events.InstrumentRepository(memory.NewMemoryRepo(logging.Discard()), bus, logging.Discard())
+	s, err := NewServer(":0", repo, bus, logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), nil, nil)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+
+	order := model.NewOrder("alice")
+	require.NoError(t, repo.Save(context.Background(), order))
+	req, _ := http.NewRequest("GET", "http://"+lis.Addr().String()+"/api/orders/"+order.Id+"/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+	var heartbeats int
+	assert.Equal(t, events.TypeOrderSnapshot, readSSE(t, r, &heartbeats).event)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+	_, err = io.Copy(io.Discard, r)
+	assert.NoError(t, err)
+
+	sub, _, err := bus.Subscribe(0, nil)
+	require.NoError(t, err)
+	sub.Close()
+}
+
+// тест управления вебхуками: секрет виден только при создании, журнал доставок и недоставленные
+func TestWebhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	do := func(method, path, body, authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "admin", "admin")
+
+	w := do("POST", "/api/webhooks", `{"url":"https://merchant.example/hook","events":["order.confirmed","order.cancelled"]}`, admin)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created model.Webhook
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, "admin", created.CreatedBy)
+
+	w = do("GET", "/api/webhooks", "", admin)
+	var list []model.Webhook
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, created.Id, list[0].Id)
+		assert.Empty(t, list[0].Secret, "secret is only returned on create")
+	}
+
+	dead := model.NewWebhookDelivery(created.Id, "order.cancelled", "Order-1", json.RawMessage(`{}`))
+	dead.Status = model.DeliveryDead
+	dead.NextAttemptAt = nil
+	_ = repo.SaveWebhookDelivery(context.Background(), dead)
+
+	w = do("GET", "/api/webhooks/dead-letters", "", admin)
+	var deliveries []model.WebhookDelivery
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, dead.Id, deliveries[0].Id)
+	}
+
+	w = do("POST", "/api/webhooks/deliveries/"+dead.Id+"/retry", "", admin)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	w = do("GET", "/api/webhooks/"+created.Id+"/deliveries?status=pending", "", admin)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	assert.Len(t, deliveries, 1)
+
+	tests := []struct {
+		name          string
+		method, path  string
+		body          string
+		authorization string
+		expectedCode  int
+	}{
+		{"invalid url", "POST", "/api/webhooks", `{"url":"merchant.example","events":["order.confirmed"]}`, admin, http.StatusBadRequest},
+		{"unknown event", "POST", "/api/webhooks", `{"url":"https://merchant.example/hook","events":["order.lost"]}`, admin, http.StatusBadRequest},
+		{"customer cannot manage webhooks", "GET", "/api/webhooks", "", authtest.Bearer(t, "alice", "customer"), http.StatusForbidden},
+		{"unknown delivery status", "GET", "/api/webhooks/" + created.Id + "/deliveries?status=lost", "", admin, http.StatusBadRequest},
+		{"unknown delivery", "POST", "/api/webhooks/deliveries/nope/retry", "", admin, http.StatusNotFound},
+		{"delete", "DELETE", "/api/webhooks/" + created.Id, "", admin, http.StatusNoContent},
+		{"deleted webhook", "GET", "/api/webhooks/" + created.Id, "", admin, http.StatusNotFound},
+		{"delete again", "DELETE", "/api/webhooks/" + created.Id, "", admin, http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.method, tc.path, tc.body, tc.authorization)
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+// тест общей смены статуса и ручек доставок и складов
+func TestOrderStatusAndLogistics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	order := model.NewOrder("alice")
+	delivery := model.NewDelivery(order.Id, "alice", "Москва", 0)
+	stock := model.NewWarehouse(order.Id, 1)
+	for _, st := range []model.Storable{order, delivery, stock} {
+		_ = repo.Save(context.Background(), st)
+	}
+
+	do := func(method, path, body, authorization, ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	customer := authtest.Bearer(t, "alice", "customer")
+	warehouse := authtest.Bearer(t, "w-1", "warehouse")
+	courier := authtest.Bearer(t, "c-1", "courier")
+	statusPath := "/api/orders/" + order.Id + "/status"
+	deliveryPath := "/api/deliveries/" + strconv.FormatInt(delivery.Id, 10)
+
+	tests := []struct {
+		name          string
+		method, path  string
+		body          string
+		authorization string
+		ifMatch       string
+		expectedCode  int
+	}{
+		{"unknown status", "PUT", statusPath, `{"status":"shipped"}`, warehouse, "", http.StatusBadRequest},
+		{"back to created", "PUT", statusPath, `{"status":"created"}`, warehouse, "", http.StatusBadRequest},
+		{"customer cannot confirm", "PUT", statusPath, `{"status":"confirmed"}`, customer, "", http.StatusForbidden},
+		{"deliver before confirm", "PUT", statusPath, `{"status":"delivered"}`, courier, "", http.StatusConflict},
+		{"stale If-Match", "PUT", statusPath, `{"status":"confirmed"}`, warehouse, `"7"`, http.StatusPreconditionFailed},
+		{"warehouse confirms", "PUT", statusPath, `{"status":"confirmed"}`, warehouse, `"1"`, http.StatusOK},
+		{"courier delivers by number", "PUT", statusPath, `{"status":"2"}`, courier, "", http.StatusOK},
+		{"unknown order", "PUT", "/api/orders/nope/status", `{"status":"cancelled"}`, customer, "", http.StatusNotFound},
+		{"customer reads own delivery", "GET", deliveryPath, "", customer, "", http.StatusOK},
+		{"foreign customer", "GET", deliveryPath, "", authtest.Bearer(t, "bob", "customer"), "", http.StatusForbidden},
+		{"delivery id is not a number", "GET", "/api/deliveries/abc", "", courier, "", http.StatusBadRequest},
+		{"unknown delivery", "GET", "/api/deliveries/42", "", courier, "", http.StatusNotFound},
+		{"customer cannot list warehouses", "GET", "/api/warehouses", "", customer, "", http.StatusForbidden},
+		{"warehouse reads record", "GET", "/api/warehouses/" + strconv.FormatInt(stock.Id, 10), "", warehouse, "", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.method, tc.path, tc.body, tc.authorization, tc.ifMatch)
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+		})
+	}
+
+	got, _ := repo.GetOrderByID(context.Background(), order.Id)
+	assert.Equal(t, model.OrderDelivered, got.Status)
+
+	w := do("GET", "/api/deliveries?order_id="+order.Id, "", authtest.Bearer(t, "bob", "customer"), "")
+	var deliveries []model.Delivery
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	assert.Empty(t, deliveries, "customer only sees own deliveries")
+
+	w = do("GET", "/api/warehouses?order_id="+order.Id, "", courier, "")
+	var warehouses []model.Warehouse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &warehouses))
+	assert.Len(t, warehouses, 1)
+}
+
+// тест маршрутов из аннотаций api.proto, прежних путей смены статуса и собранной документации
+func TestProtoRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer(":8080", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), nil)
+	require.NoError(t, err)
+	r := s.httpServer.Handler.(*gin.Engine)
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	annotated := map[string]bool{}
+	for _, route := range protoRoutes() {
+		annotated[string(route.RPC)] = true
+		assert.True(t, registered[route.Method+" "+route.Path], "%s is not routed", route.RPC)
+	}
+	for rpc := range s.rpcHandlers() {
+		assert.True(t, annotated[string(rpc)], "%s has a handler but no google.api.http annotation", rpc)
+	}
+	for _, alias := range []string{"POST /api/orders/confirm/:id", "POST /api/orders/delivery/:id", "POST /api/orders/cancel/:id"} {
+		assert.True(t, registered[alias], alias)
+	}
+
+	do := func(method, path, body, authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	admin := authtest.Bearer(t, "root", "admin")
+	first, second := model.NewOrder("alice"), model.NewOrder("alice")
+	_ = repo.Save(context.Background(), first)
+	_ = repo.Save(context.Background(), second)
+	user := &model.User{Id: "alice", Name: "Alice", Email: "alice@example.com"}
+	_ = repo.Save(context.Background(), user)
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		expectedCode int
+	}{
+		{"stale expected_version", "POST", "/api/orders/" + first.Id + "/confirm?expected_version=5", "", http.StatusPreconditionFailed},
+		{"confirm", "POST", "/api/orders/" + first.Id + "/confirm?expected_version=1", "", http.StatusOK},
+		{"deliver", "POST", "/api/orders/" + first.Id + "/deliver", "", http.StatusOK},
+		{"cancel delivered via alias", "POST", "/api/orders/cancel/" + first.Id, "", http.StatusConflict},
+		{"confirm via alias", "POST", "/api/orders/confirm/" + second.Id, "", http.StatusOK},
+		{"status as enum number", "PUT", "/api/orders/" + second.Id + "/status", `{"status":3,"expected_version":7}`, http.StatusPreconditionFailed},
+		{"status as enum name", "PUT", "/api/orders/" + second.Id + "/status", `{"status":"ORDER_CANCELLED","expected_version":2}`, http.StatusOK},
+		{"user with stale expected_version", "PUT", "/api/users/alice", `{"name":"Alice","email":"alice@example.com","expected_version":9}`, http.StatusPreconditionFailed},
+		{"cancel", "POST", "/api/orders/" + second.Id + "/cancel", "", http.StatusConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.method, tc.path, tc.body, admin)
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+		})
+	}
+
+	delivery := model.NewDelivery(first.Id, "alice", "Москва", 0)
+	_ = repo.Save(context.Background(), delivery)
+	w := do("GET", "/api/deliveries/"+strconv.FormatInt(delivery.Id, 10), "", admin)
+	assert.Contains(t, w.Body.String(), `"OrderId":"`+first.Id+`"`, "deliveries keep the field names of the REST API")
+
+	t.Run("rpc without handler", func(t *testing.T) {
+		handlers := s.rpcHandlers()
+		delete(handlers, "proto.OrderService.CancelOrder")
+		err := registerProtoRoutes(gin.New(), handlers, nil)
+		assert.ErrorContains(t, err, "no handler for proto.OrderService.CancelOrder")
+	})
+
+	t.Run("documentation", func(t *testing.T) {
+		doc, err := mergeOpenAPI(`{"swagger":"2.0","paths":{"/api/users/{id}":{"patch":{}}}}`, pb.OpenAPI)
+		assert.NoError(t, err)
+		var spec struct {
+			Paths map[string]map[string]struct {
+				Responses map[string]any `json:"responses"`
+				Security  []any          `json:"security"`
+			} `json:"paths"`
+			Definitions map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"definitions"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(doc), &spec))
+		for _, route := range protoRoutes() {
+			path := strings.ReplaceAll(route.Path, ":id", "{id}")
+			op, ok := spec.Paths[path][strings.ToLower(route.Method)]
+			if assert.True(t, ok, "%s %s is not documented", route.Method, path) {
+				assert.NotEmpty(t, op.Security, path)
+			}
+		}
+		assert.Contains(t, spec.Paths["/api/users/{id}"], "patch", "swag operations are kept")
+		assert.Contains(t, spec.Paths["/api/orders/{id}/confirm"]["post"].Responses, "200")
+		assert.NotContains(t, spec.Paths["/api/orders/{id}/cancel"]["post"].Responses, "200")
+		assert.Contains(t, spec.Paths["/api/orders/{id}/cancel"]["post"].Responses, "204")
+		assert.NotContains(t, spec.Paths["/api/orders"]["post"].Responses, "200")
+		// поля схем — как в JSON ответов REST
+		assert.ElementsMatch(t, []string{"id", "OrderId", "UserId", "Address", "status"}, slices.Collect(maps.Keys(spec.Definitions["Delivery"].Properties)))
+		assert.ElementsMatch(t, []string{"id", "orderId", "status"}, slices.Collect(maps.Keys(spec.Definitions["Warehouse"].Properties)))
+		assert.Contains(t, spec.Definitions["Order"].Properties, "user_id")
+	})
+}
+
+// тест mTLS: интеграция входит по сертификату клиента с ролью по CN, остальные — по токену
+func TestMutualTLS(t *testing.T) {
+	gin.SetMode(gin.TestMode) // чтобы не было лишних логов
+
+	dir := t.TempDir()
+	ca := certstest.NewCA(t)
+	certFile, keyFile := certstest.WriteFiles(t, dir, "server", ca.Server(t))
+	reloader, err := certs.NewReloader(certs.Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certstest.WriteCA(t, dir, "ca", ca)}, logging.Discard())
+	assert.NoError(t, err)
+
+	authn := authtest.NewHS256(t)
+	authn.SetClientCertRoles(map[string]string{"warehouse-gw": "warehouse"})
+	repo := memory.NewMemoryRepo(logging.Discard())
+	s, err := NewServer("127.0.0.1:0", repo, events.NewBus(events.DefaultHistory), logging.Discard(), metrics.New(), health.NewChecker(logging.Discard()), authn, nil)
+	require.NoError(t, err)
+	s.UseTLS(reloader.TLSConfig("h2", "http/1.1"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() { _ = s.httpServer.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	order := model.NewOrder("alice")
+	_ = repo.Save(context.Background(), order)
+	stranger := certstest.NewCA(t)
+
+	tests := []struct {
+		name          string
+		cert          *tls.Certificate
+		authorization string
+		expectedCode  int
+	}{
+		{"mapped certificate", ptr(ca.Client(t, "warehouse-gw")), "", http.StatusOK},
+		{"certificate without role", ptr(ca.Client(t, "unknown")), "", http.StatusUnauthorized},
+		{"token without certificate", nil, authtest.Bearer(t, "w-1", "warehouse"), http.StatusOK},
+		{"neither", nil, "", http.StatusUnauthorized},
+		{"certificate from another CA", ptr(stranger.Client(t, "warehouse-gw")), "", 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &tls.Config{RootCAs: ca.Pool(), MinVersion: tls.VersionTLS12}
+			if tc.cert != nil {
+				cfg.Certificates = []tls.Certificate{*tc.cert}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			defer client.CloseIdleConnections()
+
+			req, _ := http.NewRequest("GET", "https://"+ln.Addr().String()+"/api/orders/"+order.Id, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			resp, err := client.Do(req)
+			if tc.expectedCode == 0 {
+				assert.Error(t, err) // чужой сертификат отклоняется при рукопожатии
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedCode, resp.StatusCode)
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
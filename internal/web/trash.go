@@ -0,0 +1,129 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order-ms/internal/service"
+)
+
+// handleDeletedOrderList формирует список мягко удалённых заказов
+// @Summary Удалённые заказы
+// @Description Возвращает мягко удалённые заказы, которые ещё можно восстановить до окончательной очистки
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} model.Order "Удалённые заказы с deleted_at"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/deleted/orders [get]
+func (s *Server) handleDeletedOrderList(c *gin.Context) {
+	if !s.authorize(c, service.ActionOrderRestore, "") {
+		return
+	}
+	orders, err := s.repo.GetDeletedOrders(c.Request.Context())
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get deleted orders", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get deleted orders"})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// handleOrderRestore восстанавливает мягко удалённый заказ
+// @Summary Восстановить заказ
+// @Description Снимает отметку удаления с заказа
+// @Tags Admin
+// @Produce json
+// @Param id path string true "ID заказа"
+// @Success 200 {object} model.Order "Восстановленный заказ"
+// @Failure 404 {object} object "Удалённый заказ не найден"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/orders/{id}/restore [post]
+func (s *Server) handleOrderRestore(c *gin.Context) {
+	if !s.authorize(c, service.ActionOrderRestore, "") {
+		return
+	}
+	id := c.Param("id")
+	ok, err := s.repo.RestoreOrder(c.Request.Context(), id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot restore order", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot restore order"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted order not found"})
+		return
+	}
+	order, err := s.repo.GetOrderByID(c.Request.Context(), id)
+	if err != nil || order == nil {
+		s.logger.ErrorContext(c.Request.Context(), "failed to fetch restored order", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restored order"})
+		return
+	}
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
+// handleDeletedUserList формирует список мягко удалённых пользователей
+// @Summary Удалённые пользователи
+// @Description Возвращает мягко удалённых пользователей, которых ещё можно восстановить до окончательной очистки
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} model.User "Удалённые пользователи с deleted_at"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/deleted/users [get]
+func (s *Server) handleDeletedUserList(c *gin.Context) {
+	if !s.authorize(c, service.ActionUserRestore, "") {
+		return
+	}
+	users, err := s.repo.GetDeletedUsers(c.Request.Context())
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot get deleted users", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot get deleted users"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// handleUserRestore восстанавливает мягко удалённого пользователя
+// @Summary Восстановить пользователя
+// @Description Снимает отметку удаления с пользователя
+// @Tags Admin
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} model.User "Восстановленный пользователь"
+// @Failure 404 {object} object "Удалённый пользователь не найден"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/admin/users/{id}/restore [post]
+func (s *Server) handleUserRestore(c *gin.Context) {
+	if !s.authorize(c, service.ActionUserRestore, "") {
+		return
+	}
+	id := c.Param("id")
+	ok, err := s.repo.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		s.logger.ErrorContext(c.Request.Context(), "cannot restore user", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot restore user"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted user not found"})
+		return
+	}
+	user, found := s.getUser(c, id)
+	if !found {
+		return
+	}
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag ресурса — его версия в кавычках, например "3"
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag отдаёт версию ресурса в заголовке ETag, чтобы клиент мог прислать её в If-Match
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", etag(version))
+}
+
+// ifMatch разбирает заголовок If-Match и возвращает ожидаемую версию ресурса.
+// Без заголовка версию можно передать параметром expected_version, как в gRPC-запросах (см. api.proto).
+// Без обоих и для "*" — 0 (без проверки). Слабые ETag (W/"3") принимаются.
+// На несколько значений или мусор отвечает 412 и возвращает false
+func ifMatch(c *gin.Context) (int64, bool) {
+	h := strings.TrimSpace(c.GetHeader("If-Match"))
+	if h == "" {
+		h = c.Query("expected_version")
+	}
+	if h == "" || h == "*" {
+		return 0, true
+	}
+	v, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(h, "W/"), `"`), 10, 64)
+	if err != nil || v <= 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match must be a single ETag returned by the server"})
+		return 0, false
+	}
+	return v, true
+}
+
+// versionConflict — ответ на model.ErrVersionConflict: ресурс изменился с момента чтения
+func versionConflict(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource was modified, fetch it again and retry"})
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"order-ms/internal/webhooks"
+)
+
+type createWebhookRequest struct {
+	URL    string   `json:"url"`              // куда отправлять события, http или https
+	Events []string `json:"events"`           // например ["order.confirmed", "order.cancelled"]
+	Secret string   `json:"secret,omitempty"` // ключ подписи не короче 16 символов; пусто — сгенерировать
+}
+
+// webhookError переводит ошибку управления вебхуками в HTTP-ответ
+func (s *Server) webhookError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, webhooks.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, webhooks.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+	case errors.Is(err, webhooks.ErrDelivered):
+		c.JSON(http.StatusConflict, gin.H{"error": "Webhook delivery is already delivered"})
+	default:
+		s.logger.ErrorContext(c.Request.Context(), msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot manage webhooks"})
+	}
+}
+
+// handleWebhookCreate создаёт подписку на события заказов
+// @Summary Создать вебхук
+// @Description Подписывает URL на события order.created, order.confirmed, order.delivered, order.cancelled.
+// @Description Каждое событие уходит POST-запросом с JSON-телом и заголовками X-Webhook-Event, X-Webhook-Delivery,
+// @Description X-Webhook-Timestamp и X-Webhook-Signature: sha256=<hex HMAC-SHA256 секретом от "<timestamp>.<тело>">.
+// @Description Ответ не 2xx — повтор с удваивающейся паузой; когда попытки кончаются, доставка попадает в недоставленные.
+// @Description Секрет возвращается только в этом ответе
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body createWebhookRequest true "URL, типы событий и необязательный секрет"
+// @Success 201 {object} model.Webhook "Подписка с секретом"
+// @Failure 400 {object} object "Неверный JSON, URL, тип события или слишком короткий секрет"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks [post]
+func (s *Server) handleWebhookCreate(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookCreate, "") {
+		return
+	}
+	var req createWebhookRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	w, err := webhooks.Create(c.Request.Context(), s.repo, req.URL, req.Events, req.Secret)
+	if err != nil {
+		s.webhookError(c, "cannot create webhook", err)
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+// handleWebhookList возвращает все подписки
+// @Summary Список вебхуков
+// @Description Возвращает подписки без секретов
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} model.Webhook "Подписки"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks [get]
+func (s *Server) handleWebhookList(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookRead, "") {
+		return
+	}
+	hooks, err := s.repo.GetWebhooks(c.Request.Context())
+	if err != nil {
+		s.webhookError(c, "cannot list webhooks", err)
+		return
+	}
+	res := make([]*model.Webhook, 0, len(hooks))
+	for _, w := range hooks {
+		res = append(res, w.Redacted())
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// loadWebhook находит подписку по ID из пути; отвечает 404, если её нет
+func (s *Server) loadWebhook(c *gin.Context) (*model.Webhook, bool) {
+	w, err := s.repo.GetWebhookByID(c.Request.Context(), c.Param("id"))
+	if err == nil && w == nil {
+		err = webhooks.ErrWebhookNotFound
+	}
+	if err != nil {
+		s.webhookError(c, "cannot get webhook", err)
+		return nil, false
+	}
+	return w, true
+}
+
+// handleWebhookGet возвращает подписку
+// @Summary Вебхук
+// @Description Возвращает подписку без секрета
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {object} model.Webhook "Подписка"
+// @Failure 404 {object} object "Подписка не найдена"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks/{id} [get]
+func (s *Server) handleWebhookGet(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookRead, "") {
+		return
+	}
+	w, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, w.Redacted())
+}
+
+// handleWebhookDelete удаляет подписку
+// @Summary Удалить вебхук
+// @Description Новые события больше не отправляются, ожидающие повтора доставки уходят в недоставленные. Журнал доставок остаётся
+// @Tags Webhooks
+// @Param id path string true "ID подписки"
+// @Success 204 "Подписка удалена"
+// @Failure 404 {object} object "Подписка не найдена"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks/{id} [delete]
+func (s *Server) handleWebhookDelete(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookDelete, "") {
+		return
+	}
+	ok, err := s.repo.DeleteWebhook(c.Request.Context(), c.Param("id"))
+	if err == nil && !ok {
+		err = webhooks.ErrWebhookNotFound
+	}
+	if err != nil {
+		s.webhookError(c, "cannot delete webhook", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// deliveryStatus читает фильтр статуса доставок; отвечает 400 и возвращает false при неизвестном статусе
+func deliveryStatus(c *gin.Context) (string, bool) {
+	switch st := c.Query("status"); st {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+		return st, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or dead"})
+	return "", false
+}
+
+// handleWebhookDeliveries возвращает журнал доставок подписки
+// @Summary Журнал доставок вебхука
+// @Description Доставки подписки в порядке создания со всеми попытками: время, HTTP-код ответа и ошибка
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param status query string false "pending, delivered или dead"
+// @Success 200 {array} model.WebhookDelivery "Доставки"
+// @Failure 400 {object} object "Неизвестный статус"
+// @Failure 404 {object} object "Подписка не найдена"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks/{id}/deliveries [get]
+func (s *Server) handleWebhookDeliveries(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookRead, "") {
+		return
+	}
+	status, ok := deliveryStatus(c)
+	if !ok {
+		return
+	}
+	w, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+	deliveries, err := s.repo.GetWebhookDeliveries(c.Request.Context(), w.Id, status)
+	if err != nil {
+		s.webhookError(c, "cannot get webhook deliveries", err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// handleWebhookDeadLetters возвращает недоставленные события всех подписок
+// @Summary Недоставленные события
+// @Description Доставки, для которых кончились попытки или удалена подписка; их можно отправить повторно
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} model.WebhookDelivery "Недоставленные доставки"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks/dead-letters [get]
+func (s *Server) handleWebhookDeadLetters(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookRead, "") {
+		return
+	}
+	deliveries, err := s.repo.GetWebhookDeliveries(c.Request.Context(), "", model.DeliveryDead)
+	if err != nil {
+		s.webhookError(c, "cannot get dead letters", err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// handleWebhookRedeliver ставит недоставленное событие на повтор
+// @Summary Повторить доставку
+// @Description Ставит доставку в очередь: первая попытка — при ближайшем проходе очереди, дальше снова полный набор повторов
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID доставки"
+// @Success 202 {object} model.WebhookDelivery "Доставка в очереди"
+// @Failure 404 {object} object "Доставка или её подписка не найдена"
+// @Failure 409 {object} object "Событие уже доставлено"
+// @Failure 403 {object} object "Недостаточно прав"
+// @Failure 429 {object} object "Слишком много запросов, см. Retry-After"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/webhooks/deliveries/{id}/retry [post]
+func (s *Server) handleWebhookRedeliver(c *gin.Context) {
+	if !s.authorize(c, service.ActionWebhookRedeliver, "") {
+		return
+	}
+	d, err := webhooks.Redeliver(c.Request.Context(), s.repo, s.logger, c.Param("id"))
+	if err != nil {
+		s.webhookError(c, "cannot redeliver webhook", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
@@ -0,0 +1,308 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"order-ms/internal/events"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// имя аренды, под которой работает отправка вебхуков
+const leaseName = "webhook-delivery"
+
+// сколько запросов к подписчикам выполняется одновременно
+const workers = 8
+
+// Config — настройки отправки вебхуков
+type Config struct {
+	MaxAttempts int           // сколько попыток, прежде чем доставка попадёт в недоставленные
+	BaseDelay   time.Duration // пауза после первой неудачи; дальше удваивается
+	MaxDelay    time.Duration // потолок паузы между попытками
+	Timeout     time.Duration // таймаут одного запроса к подписчику
+	Interval    time.Duration // как часто проверять очередь повторов
+	Owner       string        // идентификатор реплики, которая держит аренду
+}
+
+// Dispatcher ставит события шины в очередь доставок подписчикам и отправляет их.
+// Очередь хранится в репозитории, поэтому повторы переживают перезапуск. Отправляет только реплика,
+// захватившая аренду, а в очередь события ставит каждая реплика — те, что прошли через неё
+type Dispatcher struct {
+	repo   service.Repository
+	bus    *events.Bus
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+	kick   chan struct{} // будит отправку, когда в очереди появились новые доставки
+}
+
+func NewDispatcher(repo service.Repository, bus *events.Bus, cfg Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo: repo,
+		bus:  bus,
+		cfg:  cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: newTransport(),
+			// по редиректу подписанное тело ушло бы на другой адрес; 3xx считаем неудачей
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger.With("component", "webhooks"),
+		kick:   make(chan struct{}, 1),
+	}
+}
+
+// newTransport — транспорт, который соединяется только с публичными адресами. Прокси из окружения
+// не используется: через него адрес подписчика было бы не проверить
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialControl}).DialContext
+	return t
+}
+
+// Run принимает события и отправляет доставки до отмены ctx. ctx должен жить, пока серверы
+// дорабатывают запросы при остановке, иначе их события не попадут в очередь
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.consume(ctx)
+	}()
+	defer wg.Wait()
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.kick:
+		}
+		ok, err := d.holdLease(ctx)
+		if err != nil {
+			d.logger.ErrorContext(ctx, "cannot acquire webhook lease", "err", err)
+			continue
+		}
+		if !ok {
+			continue // отправляет другая реплика
+		}
+		if _, err := d.DeliverOnce(ctx, time.Now()); err != nil {
+			d.logger.ErrorContext(ctx, "webhook delivery failed", "err", err)
+		}
+	}
+}
+
+// holdLease захватывает или продлевает аренду отправки. Она живёт дольше интервала и таймаута запроса:
+// DeliverOnce продлевает её перед каждой отправкой, так что запрос, начатый под арендой, успевает
+// закончиться до того, как её сможет взять другая реплика
+func (d *Dispatcher) holdLease(ctx context.Context) (bool, error) {
+	return d.repo.AcquireLease(ctx, leaseName, d.cfg.Owner, 2*d.cfg.Interval+d.cfg.Timeout)
+}
+
+// consume ставит в очередь события шины. Если подписку отключили как отстающую,
+// подписывается снова с последнего обработанного события
+func (d *Dispatcher) consume(ctx context.Context) {
+	var after uint64
+	for {
+		sub, _, err := d.bus.SubscribeBackground(after, nil)
+		if errors.Is(err, events.ErrEventsLost) {
+			d.logger.ErrorContext(ctx, "webhook events lost", "after_seq", after)
+			sub, _, err = d.bus.SubscribeBackground(0, nil)
+		}
+		if err != nil {
+			d.logger.ErrorContext(ctx, "cannot subscribe to order events", "err", err)
+			return
+		}
+		after = d.consumeSubscription(ctx, sub, after)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// consumeSubscription обрабатывает события до отмены ctx или отключения подписки и возвращает номер последнего
+func (d *Dispatcher) consumeSubscription(ctx context.Context, sub *events.Subscription, after uint64) uint64 {
+	defer sub.Close()
+	handle := func(ctx context.Context, e events.Event) {
+		if err := d.enqueue(ctx, e); err != nil {
+			d.logger.ErrorContext(ctx, "cannot enqueue webhook deliveries", "seq", e.Seq, "err", err)
+		}
+		after = e.Seq
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			// то, что уже пришло, ставим в очередь: отправит следующий запуск
+			for {
+				select {
+				case e, ok := <-sub.Events():
+					if !ok {
+						return after
+					}
+					handle(context.WithoutCancel(ctx), e)
+				default:
+					return after
+				}
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				d.logger.WarnContext(ctx, "webhook subscription dropped, resubscribing", "reason", sub.Err(), "after_seq", after)
+				return after
+			}
+			handle(ctx, e)
+			select {
+			case d.kick <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// enqueue создаёт по доставке для каждой подписки на событие этого типа
+func (d *Dispatcher) enqueue(ctx context.Context, e events.Event) error {
+	hooks, err := d.repo.GetWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, w := range hooks {
+		if !w.Subscribed(e.Type) {
+			continue
+		}
+		del := model.NewWebhookDelivery(w.Id, e.Type, e.Order.Id, nil)
+		del.Payload, err = json.Marshal(Payload{ID: del.Id, Event: e.Type, Seq: e.Seq, At: e.At, Order: e.Order})
+		if err != nil {
+			return err
+		}
+		if err := d.repo.SaveWebhookDelivery(ctx, del); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliverOnce отправляет доставки, срок попытки которых наступил к now. Возвращает число отправленных.
+// Перед каждой отправкой аренда продлевается; если её забрала другая реплика, проход останавливается
+func (d *Dispatcher) DeliverOnce(ctx context.Context, now time.Time) (int, error) {
+	pending, err := d.repo.GetWebhookDeliveries(ctx, "", model.DeliveryPending)
+	if err != nil {
+		return 0, err
+	}
+	hooks, err := d.repo.GetWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[string]*model.Webhook, len(hooks))
+	for _, w := range hooks {
+		byID[w.Id] = w
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	n := 0
+	for _, del := range pending {
+		if del.NextAttemptAt != nil && del.NextAttemptAt.After(now) {
+			continue
+		}
+		sem <- struct{}{}
+		ok, leaseErr := d.holdLease(ctx)
+		if leaseErr != nil || !ok {
+			<-sem
+			if leaseErr == nil {
+				d.logger.WarnContext(ctx, "webhook lease lost, delivery pass stopped", "owner", d.cfg.Owner)
+			}
+			err = leaseErr
+			break
+		}
+		n++
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			d.attempt(ctx, byID[del.WebhookID], del)
+		}()
+	}
+	wg.Wait()
+	return n, err
+}
+
+// attempt выполняет одну попытку и записывает её результат: доставлено, следующая попытка
+// через растущую паузу или, если попытки кончились, в недоставленные
+func (d *Dispatcher) attempt(ctx context.Context, w *model.Webhook, del *model.WebhookDelivery) {
+	at := time.Now()
+	a := model.WebhookAttempt{At: at}
+	if w == nil {
+		a.Error = "subscription deleted"
+	} else {
+		code, err := d.send(ctx, w, del, at)
+		a.StatusCode = code
+		switch {
+		case err != nil:
+			a.Error = err.Error()
+		case code < 200 || code > 299:
+			a.Error = fmt.Sprintf("unexpected status %d", code)
+		}
+	}
+	del.Attempts = append(del.Attempts, a)
+
+	switch {
+	case a.Error == "":
+		del.Status = model.DeliveryDelivered
+		del.DeliveredAt = &at
+		del.NextAttemptAt = nil
+	case w == nil || del.FailedAttempts() >= d.cfg.MaxAttempts:
+		del.Status = model.DeliveryDead
+		del.NextAttemptAt = nil
+		d.logger.WarnContext(ctx, "webhook delivery dead-lettered", "delivery_id", del.Id, "webhook_id", del.WebhookID, "err", a.Error)
+	default:
+		next := at.Add(d.backoff(del.FailedAttempts()))
+		del.NextAttemptAt = &next
+		d.logger.InfoContext(ctx, "webhook delivery failed, will retry", "delivery_id", del.Id, "webhook_id", del.WebhookID, "retry_at", next, "err", a.Error)
+	}
+	if err := d.repo.SaveWebhookDelivery(ctx, del); err != nil {
+		d.logger.ErrorContext(ctx, "cannot save webhook delivery", "delivery_id", del.Id, "err", err)
+	}
+}
+
+// backoff — пауза после failures неудачных попыток: BaseDelay, 2×BaseDelay, 4×BaseDelay... но не больше MaxDelay
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	delay := d.cfg.BaseDelay
+	for i := 1; i < failures && delay < d.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxDelay)
+}
+
+// send отправляет подписанное тело доставки и возвращает HTTP-код ответа
+func (d *Dispatcher) send(ctx context.Context, w *model.Webhook, del *model.WebhookDelivery, at time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "order-ms-webhooks")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, del.Id)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(w.Secret, ts, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // чтобы соединение вернулось в пул
+	return resp.StatusCode, nil
+}
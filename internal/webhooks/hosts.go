@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrForbiddenHost — адрес подписчика во внутренней сети: loopback, частные и link-local адреса
+// (в том числе метаданные облака 169.254.169.254). Через вебхук до них можно было бы достучаться изнутри
+var ErrForbiddenHost = errors.New("webhook host is not a public address")
+
+// allowPrivateHosts отключает проверку адресов; только для тестов с подписчиком на 127.0.0.1
+var allowPrivateHosts bool
+
+// диапазоны, которых нет среди методов netip.Addr
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // «этот» хост
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("198.18.0.0/15"), // тестовые сети
+}
+
+// checkAddr возвращает ErrForbiddenHost, если addr не публичный адрес
+func checkAddr(addr netip.Addr) error {
+	if allowPrivateHosts {
+		return nil
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, addr)
+	}
+	for _, p := range forbiddenPrefixes {
+		if p.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenHost, addr)
+		}
+	}
+	return nil
+}
+
+// checkHost проверяет адреса хоста из URL подписки при её создании. Если имя не разрешается,
+// подписка создаётся: адрес всё равно проверяется при каждом соединении (см. dialControl)
+func checkHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialControl проверяет адрес, к которому уже разрешено имя, прямо перед соединением:
+// так имя, которое после создания подписки стало указывать во внутреннюю сеть, тоже не пройдёт
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	return checkAddr(addr)
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"order-ms/internal/events"
+	"order-ms/internal/model"
+	"order-ms/internal/service"
+	"slices"
+	"time"
+)
+
+// заголовки запроса к подписчику
+const (
+	HeaderEvent     = "X-Webhook-Event"     // тип события
+	HeaderDelivery  = "X-Webhook-Delivery"  // ID доставки, одинаковый во всех попытках
+	HeaderTimestamp = "X-Webhook-Timestamp" // время попытки, секунды Unix; входит в подпись
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256 от "<timestamp>.<тело>">
+)
+
+// минимальная длина секрета, заданного клиентом
+const minSecretLength = 16
+
+var (
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDelivered        = errors.New("webhook delivery already delivered")
+)
+
+// EventTypes — события, на которые можно подписаться
+var EventTypes = []string{events.TypeOrderCreated, events.TypeOrderConfirmed, events.TypeOrderDelivered, events.TypeOrderCancelled}
+
+// Payload — тело запроса к подписчику
+type Payload struct {
+	ID    string      `json:"id"`    // ID доставки: при повторах тот же, по нему подписчик отбрасывает дубли
+	Event string      `json:"event"` // тип события
+	Seq   uint64      `json:"seq"`   // номер события в шине экземпляра сервиса
+	At    time.Time   `json:"at"`    // когда произошло событие
+	Order model.Order `json:"order"` // заказ после изменения
+}
+
+// Sign считает подпись тела запроса: HMAC-SHA256 секретом подписки от "<timestamp>.<body>".
+// Время входит в подпись, чтобы перехваченный запрос нельзя было повторить позже
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись на стороне подписчика; свежесть timestamp подписчик проверяет сам
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Create создаёт подписку. Пустой secret — сгенерировать; подписка с секретом возвращается один раз,
+// дальше API отдаёт её без секрета. Адреса во внутренней сети отклоняются (ErrForbiddenHost)
+func Create(ctx context.Context, repo service.Repository, rawURL string, eventTypes []string, secret string) (*model.Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if err := checkHost(ctx, u.Hostname()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWebhook, err)
+	}
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	var types []string
+	for _, t := range eventTypes {
+		if !slices.Contains(EventTypes, t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	switch {
+	case secret == "":
+		if secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	case len(secret) < minSecretLength:
+		return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, minSecretLength)
+	}
+
+	w := model.NewWebhook(rawURL, types, secret, service.Actor(ctx))
+	if err := repo.SaveWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Redeliver ставит недоставленное (или ещё ожидающее) событие на повтор: первая попытка — при ближайшем
+// проходе очереди, дальше снова MaxAttempts попыток с растущими паузами. Записывается в журнал аудита
+func Redeliver(ctx context.Context, repo service.Repository, logger *slog.Logger, id string) (*model.WebhookDelivery, error) {
+	d, err := repo.GetWebhookDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if d.Status == model.DeliveryDelivered {
+		return nil, ErrDelivered
+	}
+	w, err := repo.GetWebhookByID(ctx, d.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	now := time.Now()
+	d.Status = model.DeliveryPending
+	d.NextAttemptAt = &now
+	d.RequeuedAt = &now
+	if err := repo.SaveWebhookDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	service.Audit(ctx, repo, logger, service.ActionWebhookRedeliver, d.Id, "webhook="+d.WebhookID)
+	return d, nil
+}
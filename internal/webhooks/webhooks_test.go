@@ -0,0 +1,253 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"order-ms/internal/events"
+	"order-ms/internal/logging"
+	"order-ms/internal/model"
+	"order-ms/internal/repository/memory"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver — httptest-подписчик: отвечает кодами из codes по очереди (дальше 200) и запоминает запросы
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, codes ...int) *receiver {
+	allowLoopback(t)
+	rc := &receiver{codes: codes}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.requests = append(rc.requests, r)
+		rc.bodies = append(rc.bodies, body)
+		code := http.StatusOK
+		if len(rc.codes) > 0 {
+			code, rc.codes = rc.codes[0], rc.codes[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// allowLoopback разрешает подписки на 127.0.0.1 до конца теста
+func allowLoopback(t *testing.T) {
+	allowPrivateHosts = true
+	t.Cleanup(func() { allowPrivateHosts = false })
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func newDispatcher(repo *memory.MemoryRepo) *Dispatcher {
+	return NewDispatcher(repo, events.NewBus(events.DefaultHistory), Config{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Timeout:     time.Second,
+		Interval:    time.Second,
+		Owner:       "test",
+	}, logging.Discard())
+}
+
+// тест подписи: подписчик проверяет её секретом подписки
+func TestDeliverSigned(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepo(logging.Discard())
+	d := newDispatcher(repo)
+	rc := newReceiver(t)
+
+	w, err := Create(ctx, repo, rc.URL, []string{events.TypeOrderConfirmed}, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, w.Secret, "secret is generated")
+
+	o := model.NewOrder("User-1")
+	o.Status = model.OrderConfirmed
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 7, Type: events.TypeOrderCreated, Order: *o}))
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 8, Type: events.TypeOrderConfirmed, Order: *o}))
+
+	n, err := d.DeliverOnce(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only subscribed event types are delivered")
+	require.Equal(t, 1, rc.count())
+
+	req, body := rc.requests[0], rc.bodies[0]
+	assert.Equal(t, events.TypeOrderConfirmed, req.Header.Get(HeaderEvent))
+	assert.True(t, Verify(w.Secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body))
+	assert.False(t, Verify("wrong-secret-wrong-secret", req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body))
+
+	var p Payload
+	require.NoError(t, json.Unmarshal(body, &p))
+	assert.Equal(t, req.Header.Get(HeaderDelivery), p.ID)
+	assert.Equal(t, uint64(8), p.Seq)
+	assert.Equal(t, o.Id, p.Order.Id)
+
+	delivered, _ := repo.GetWebhookDeliveries(ctx, w.Id, model.DeliveryDelivered)
+	require.Len(t, delivered, 1)
+	assert.NotNil(t, delivered[0].DeliveredAt)
+}
+
+// тест повторов: паузы растут, после MaxAttempts доставка в недоставленных, ручной повтор доставляет
+func TestDeliverRetryAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepo(logging.Discard())
+	d := newDispatcher(repo)
+	rc := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+
+	w, err := Create(ctx, repo, rc.URL, []string{events.TypeOrderCancelled}, "0123456789abcdef")
+	require.NoError(t, err)
+	o := model.NewOrder("User-1")
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 1, Type: events.TypeOrderCancelled, Order: *o}))
+
+	// попытка 1 сейчас, попытка 2 через минуту, попытка 3 ещё через две
+	now := time.Now()
+	for i, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		n, _ := d.DeliverOnce(ctx, now)
+		assert.Equal(t, 1, n)
+		pending, _ := repo.GetWebhookDeliveries(ctx, w.Id, model.DeliveryPending)
+		require.Len(t, pending, 1, "attempt %d", i+1)
+		last := pending[0].Attempts[len(pending[0].Attempts)-1]
+		assert.Equal(t, wantDelay, pending[0].NextAttemptAt.Sub(last.At))
+		assert.NotZero(t, last.StatusCode)
+
+		n, _ = d.DeliverOnce(ctx, now)
+		assert.Zero(t, n, "retry is not due yet")
+		now = pending[0].NextAttemptAt.Add(time.Second)
+	}
+	_, _ = d.DeliverOnce(ctx, now)
+	assert.Equal(t, 3, rc.count())
+
+	dead, _ := repo.GetWebhookDeliveries(ctx, "", model.DeliveryDead)
+	require.Len(t, dead, 1)
+	assert.Len(t, dead[0].Attempts, 3)
+	assert.Nil(t, dead[0].NextAttemptAt)
+
+	_, err = Redeliver(ctx, repo, logging.Discard(), dead[0].Id)
+	require.NoError(t, err)
+	n, _ := d.DeliverOnce(ctx, time.Now())
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 4, rc.count())
+
+	got, _ := repo.GetWebhookDeliveryByID(ctx, dead[0].Id)
+	assert.Equal(t, model.DeliveryDelivered, got.Status)
+	assert.Len(t, got.Attempts, 4, "delivery log keeps every attempt")
+
+	_, err = Redeliver(ctx, repo, logging.Discard(), got.Id)
+	assert.ErrorIs(t, err, ErrDelivered)
+}
+
+// тест удаления подписки: ожидающие доставки уходят в недоставленные без запросов
+func TestDeliverDeletedWebhook(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepo(logging.Discard())
+	d := newDispatcher(repo)
+	rc := newReceiver(t)
+
+	w, err := Create(ctx, repo, rc.URL, []string{events.TypeOrderDelivered}, "")
+	require.NoError(t, err)
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 1, Type: events.TypeOrderDelivered, Order: *model.NewOrder("User-1")}))
+	_, _ = repo.DeleteWebhook(ctx, w.Id)
+
+	_, _ = d.DeliverOnce(ctx, time.Now())
+	assert.Zero(t, rc.count())
+	dead, _ := repo.GetWebhookDeliveries(ctx, w.Id, model.DeliveryDead)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "subscription deleted", dead[0].Attempts[0].Error)
+}
+
+func TestCreateValidation(t *testing.T) {
+	repo := memory.NewMemoryRepo(logging.Discard())
+	tests := []struct {
+		name   string
+		url    string
+		events []string
+		secret string
+	}{
+		{"relative url", "/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"unsupported scheme", "ftp://example.com/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"no events", "https://example.com/hook", nil, ""},
+		{"unknown event", "https://example.com/hook", []string{"order.shipped"}, ""},
+		{"short secret", "https://example.com/hook", []string{events.TypeOrderConfirmed}, "short"},
+		{"loopback", "http://127.0.0.1:8080/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"localhost", "http://localhost/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"ipv6 loopback", "http://[::1]/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data", []string{events.TypeOrderConfirmed}, ""},
+		{"private network", "https://10.1.2.3/hook", []string{events.TypeOrderConfirmed}, ""},
+		{"ipv4-mapped private", "https://[::ffff:192.168.0.1]/hook", []string{events.TypeOrderConfirmed}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Create(context.Background(), repo, tc.url, tc.events, tc.secret)
+			assert.ErrorIs(t, err, ErrInvalidWebhook)
+		})
+	}
+}
+
+// тест проверки адреса при соединении: подписка, чей хост теперь внутренний, не получает запросов
+func TestDeliverForbiddenHost(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepo(logging.Discard())
+	d := newDispatcher(repo)
+	rc := newReceiver(t)
+
+	w, err := Create(ctx, repo, rc.URL, []string{events.TypeOrderConfirmed}, "")
+	require.NoError(t, err)
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 1, Type: events.TypeOrderConfirmed, Order: *model.NewOrder("User-1")}))
+	allowPrivateHosts = false
+
+	n, err := d.DeliverOnce(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Zero(t, rc.count())
+	pending, _ := repo.GetWebhookDeliveries(ctx, w.Id, model.DeliveryPending)
+	require.Len(t, pending, 1)
+	assert.Contains(t, pending[0].Attempts[0].Error, ErrForbiddenHost.Error())
+}
+
+// тест аренды: пока её держит другая реплика, доставки не отправляются и остаются в очереди
+func TestDeliverWithoutLease(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepo(logging.Discard())
+	d := newDispatcher(repo)
+	rc := newReceiver(t)
+
+	w, err := Create(ctx, repo, rc.URL, []string{events.TypeOrderConfirmed}, "")
+	require.NoError(t, err)
+	require.NoError(t, d.enqueue(ctx, events.Event{Seq: 1, Type: events.TypeOrderConfirmed, Order: *model.NewOrder("User-1")}))
+	ok, err := repo.AcquireLease(ctx, leaseName, "other", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	n, err := d.DeliverOnce(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Zero(t, rc.count())
+	pending, _ := repo.GetWebhookDeliveries(ctx, w.Id, model.DeliveryPending)
+	require.Len(t, pending, 1)
+	assert.Empty(t, pending[0].Attempts)
+
+	_, _ = repo.AcquireLease(ctx, leaseName, "other", -time.Second) // аренда истекла
+	n, err = d.DeliverOnce(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, rc.count())
+}
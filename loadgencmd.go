@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"order-ms/internal/loadgen"
+	"order-ms/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// loadgenOptions — флаги loadgen
+type loadgenOptions struct {
+	protocol, addr    string
+	token, apiKey     string
+	useTLS            bool
+	tlsCA             string
+	rps               float64
+	duration, timeout time.Duration
+	concurrency       int
+	mix               string
+	seed              uint64
+	users             string
+	maxErrorRate      float64
+}
+
+// loadgenCommand — команда `order-ms loadgen`: нагружает запущенный сервис смесью запросов
+// через REST или gRPC с заданной частотой и печатает перцентили задержек и долю ошибок по видам запросов.
+// Код выхода 1 — доля ошибок выше --max-error-rate, 2 — неверные аргументы или прогон не начался
+func loadgenCommand() *cobra.Command {
+	o := &loadgenOptions{}
+	cmd := &cobra.Command{
+		Use:   "loadgen",
+		Short: "Send a mix of order requests to a running service and report latencies and errors",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.rps <= 0 || o.duration <= 0 {
+				return usageError(cmd, errors.New("--rps and --duration must be positive"))
+			}
+			return exitCode(runLoadgen(cmd.Context(), o, cmd.OutOrStdout(), cmd.ErrOrStderr()))
+		},
+	}
+	cmd.SetFlagErrorFunc(usageError)
+	f := cmd.Flags()
+	f.StringVar(&o.protocol, "protocol", "grpc", "grpc or rest")
+	f.StringVar(&o.addr, "addr", "", "Service address (default localhost:50051 for grpc, http://localhost:8080 for rest)")
+	f.StringVar(&o.token, "token", os.Getenv("ORDER_MS_TOKEN"), "Bearer token; admin or a role allowed every op in the mix (default $ORDER_MS_TOKEN)")
+	f.StringVar(&o.apiKey, "api-key", os.Getenv("ORDER_MS_API_KEY"), "API key instead of a bearer token (default $ORDER_MS_API_KEY)")
+	f.BoolVar(&o.useTLS, "tls", false, "Use TLS for grpc (for rest use an https:// address)")
+	f.StringVar(&o.tlsCA, "tls-ca", "", "CA certificate file to verify the server (default: system roots)")
+	f.Float64Var(&o.rps, "rps", 50, "Target requests per second")
+	f.DurationVar(&o.duration, "duration", 30*time.Second, "How long to send requests")
+	f.IntVar(&o.concurrency, "concurrency", loadgen.DefaultConcurrency, "Max requests in flight; requests over the limit are dropped")
+	f.DurationVar(&o.timeout, "timeout", loadgen.DefaultTimeout, "Timeout of a single request")
+	f.StringVar(&o.mix, "mix", loadgen.DefaultMix, "Relative weights of ops: create, confirm, deliver, cancel, list")
+	f.Uint64Var(&o.seed, "seed", 1, "Random seed for picking ops and users")
+	f.StringVar(&o.users, "users", "", "Comma-separated user IDs to create and list orders for (default: all users)")
+	f.Float64Var(&o.maxErrorRate, "max-error-rate", 1, "Exit with code 1 if the overall error rate is higher (0..1)")
+	return cmd
+}
+
+// runLoadgen выполняет прогон и возвращает код выхода команды
+func runLoadgen(ctx context.Context, o *loadgenOptions, stdout, stderr io.Writer) int {
+	mix, err := loadgen.ParseMix(o.mix)
+	if err != nil {
+		fmt.Fprintln(stderr, "loadgen: --mix:", err)
+		return 2
+	}
+	tlsConfig, err := loadgenTLS(o.useTLS || strings.HasPrefix(o.addr, "https://"), o.tlsCA)
+	if err != nil {
+		fmt.Fprintln(stderr, "loadgen:", err)
+		return 2
+	}
+
+	var target loadgen.Target
+	switch o.protocol {
+	case "grpc":
+		if o.addr == "" {
+			o.addr = "localhost:50051"
+		}
+		// без повторов: иначе задержки включают повторы, а ошибки перегрузки не видны
+		opts := []client.Option{client.WithRetry(client.RetryPolicy{MaxAttempts: 1})}
+		if tlsConfig != nil {
+			opts = append(opts, client.WithTLS(tlsConfig))
+		}
+		if o.apiKey != "" {
+			opts = append(opts, client.WithAPIKey(o.apiKey))
+		} else if o.token != "" {
+			opts = append(opts, client.WithBearerToken(o.token))
+		}
+		c, err := client.New(o.addr, opts...)
+		if err != nil {
+			fmt.Fprintln(stderr, "loadgen:", err)
+			return 2
+		}
+		defer c.Close()
+		target = loadgen.GRPC{Client: c}
+	case "rest":
+		if o.addr == "" {
+			o.addr = "http://localhost:8080"
+		}
+		if !strings.Contains(o.addr, "://") {
+			o.addr = "http://" + o.addr
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		// по умолчанию в пуле 2 соединения на хост — при открытой нагрузке запросы ждали бы соединения
+		transport.MaxIdleConnsPerHost = o.concurrency
+		target = loadgen.REST{BaseURL: o.addr, Client: &http.Client{Transport: transport}, Token: o.token, APIKey: o.apiKey}
+	default:
+		fmt.Fprintf(stderr, "loadgen: unknown protocol %q: want grpc or rest\n", o.protocol)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var userIDs []string
+	if o.users != "" {
+		userIDs = strings.Split(o.users, ",")
+	}
+	fmt.Fprintf(stderr, "loadgen: %s %s, %g rps for %s, mix %s\n", o.protocol, o.addr, o.rps, o.duration, mix)
+	report, err := loadgen.Run(ctx, target, loadgen.Options{
+		RPS: o.rps, Duration: o.duration, Concurrency: o.concurrency, Timeout: o.timeout,
+		Mix: mix, Seed: o.seed, Users: userIDs,
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, "loadgen:", err)
+		return 2
+	}
+	printLoadgenReport(stdout, report)
+	if report.Total.ErrorRate() > o.maxErrorRate {
+		fmt.Fprintf(stderr, "loadgen: error rate %.2f%% is above %.2f%%\n", 100*report.Total.ErrorRate(), 100*o.maxErrorRate)
+		return 1
+	}
+	return 0
+}
+
+// loadgenTLS возвращает настройки TLS или nil, если соединение не шифруется
+func loadgenTLS(enabled bool, caFile string) (*tls.Config, error) {
+	if !enabled && caFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", caFile)
+		}
+	}
+	return cfg, nil
+}
+
+// printLoadgenReport печатает таблицу по видам запросов, итог и причины ошибок по видам
+func printLoadgenReport(w io.Writer, report *loadgen.Report) {
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tERROR RATE\tP50\tP90\tP99\tMAX")
+	for _, s := range append(report.Ops, report.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%s\t%s\t%s\t%s\n",
+			s.Op, s.Requests, s.Errors, 100*s.ErrorRate(), ms(s.P50), ms(s.P90), ms(s.P99), ms(s.Max))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "sent %d requests in %s (%.1f rps), dropped %d over the concurrency limit\n",
+		report.Total.Requests, report.Elapsed.Round(time.Millisecond), report.RPS(), report.Dropped)
+
+	for _, s := range report.Ops {
+		for _, cause := range slices.Sorted(maps.Keys(s.Causes)) {
+			fmt.Fprintf(w, "%s: %d × %s\n", s.Op, s.Causes[cause], cause)
+		}
+	}
+}
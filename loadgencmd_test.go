@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"order-ms/internal/ctl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест команды loadgen против REST-заглушки: отчёт по видам запросов и код выхода по доле ошибок
+func TestRunLoadgen(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`[{"id":"alice"}]`))
+	})
+	mux.HandleFunc("POST /api/orders", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"Order-1"}`))
+	})
+	mux.HandleFunc("GET /api/orders", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"Too many requests"}`, http.StatusTooManyRequests)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := ctl.Run(append([]string{"loadgen"}, args...), &stdout, &stderr, commands()...)
+		return code, stdout.String(), stderr.String()
+	}
+	args := []string{"--protocol", "rest", "--addr", srv.URL, "--token", "secret", "--rps", "200", "--duration", "200ms"}
+
+	code, out, stderr := run(append(args, "--mix", "create=1")...)
+	require.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `(?m)^OP\s+REQUESTS\s+ERRORS\s+ERROR RATE\s+P50\s+P90\s+P99\s+MAX$`, out)
+	assert.Regexp(t, `(?m)^create\s+\d+\s+0\s+0.00%`, out)
+	assert.Regexp(t, `(?m)^total\s+\d+\s+0\s+0.00%`, out)
+
+	code, out, stderr = run(append(args, "--mix", "create=1,list=1", "--max-error-rate", "0.1")...)
+	assert.Equal(t, 1, code)
+	assert.Regexp(t, `(?m)^list: \d+ × HTTP 429$`, out)
+	assert.Contains(t, stderr, "is above 10.00%")
+
+	code, _, stderr = run(append(args, "--mix", "ship=1")...)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown op "ship"`)
+	code, _, stderr = run("--protocol", "soap")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown protocol")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"order-ms/internal/logging"
+	"order-ms/internal/migrate"
+	"order-ms/internal/repository/memory"
+	repository "order-ms/internal/repository/nosql"
+	"order-ms/internal/repository/postgres"
+	"order-ms/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateOptions — флаги migrate-data
+type migrateOptions struct {
+	from, to  string
+	dryRun    bool
+	batchSize int
+	redisAddr string
+}
+
+// migrateDataCommand — команда `order-ms migrate-data --from SPEC --to SPEC`: переносит все данные
+// из одного хранилища в другое и сверяет результат. SPEC — memory:КАТАЛОГ (JSON-файлы MemoryRepo),
+// postgres://... или mongodb://... Прерванный перенос продолжается повторным запуском.
+// Код выхода 1 — перенос не сверился, 2 — неверные аргументы или хранилище недоступно
+func migrateDataCommand() *cobra.Command {
+	o := &migrateOptions{}
+	cmd := &cobra.Command{
+		Use:   "migrate-data --from SPEC --to SPEC",
+		Short: "Copy all data from one storage to another and verify the result",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.from == "" || o.to == "" {
+				return usageError(cmd, errors.New("--from and --to are required"))
+			}
+			if isMongo(o.from) && isMongo(o.to) {
+				return usageError(cmd, errors.New("source and target cannot both be MongoDB"))
+			}
+			return exitCode(runMigrateData(cmd.Context(), o, cmd.OutOrStdout(), cmd.ErrOrStderr()))
+		},
+	}
+	cmd.SetFlagErrorFunc(usageError)
+	f := cmd.Flags()
+	f.StringVar(&o.from, "from", "", "Source storage: memory:DIR, postgres://... or mongodb://...")
+	f.StringVar(&o.to, "to", "", "Target storage, same forms as --from")
+	f.BoolVar(&o.dryRun, "dry-run", false, "Only compare source and target, write nothing")
+	f.IntVar(&o.batchSize, "batch-size", migrate.DefaultBatchSize, "Records per read page and orders per insert batch")
+	f.StringVar(&o.redisAddr, "redis-addr", repository.DefaultRedisAddr, "Redis address, needed when the target is MongoDB")
+	return cmd
+}
+
+// runMigrateData выполняет перенос и возвращает код выхода команды
+func runMigrateData(ctx context.Context, o *migrateOptions, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger := logging.New(stderr, slog.LevelInfo)
+
+	source, closeSource, err := openStore(o.from, false, false, o.redisAddr, logger)
+	if err != nil {
+		fmt.Fprintln(stderr, "migrate-data: source:", err)
+		return 2
+	}
+	defer closeSource()
+	// в dry-run приёмник только читается: без миграций схемы и подключения к Redis
+	target, closeTarget, err := openStore(o.to, true, !o.dryRun, o.redisAddr, logger)
+	if err != nil {
+		fmt.Fprintln(stderr, "migrate-data: target:", err)
+		return 2
+	}
+	defer closeTarget()
+
+	report, err := migrate.Run(ctx, source, target, migrate.Options{DryRun: o.dryRun, BatchSize: o.batchSize}, logger)
+	if report != nil {
+		printMigrateReport(stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "migrate-data:", err)
+		return 1
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+func isMongo(spec string) bool {
+	return strings.HasPrefix(spec, "mongodb://") || strings.HasPrefix(spec, "mongodb+srv://")
+}
+
+// openStore открывает хранилище по SPEC; target — это приёмник (каталога MemoryRepo может ещё не быть),
+// write — в него будут писать. close сохраняет MemoryRepo на диск (если в него писали) и закрывает соединения
+func openStore(spec string, target, write bool, redisAddr string, logger *slog.Logger) (service.Repository, func(), error) {
+	switch {
+	case strings.HasPrefix(spec, "memory:"):
+		dir := strings.TrimPrefix(spec, "memory:")
+		if dir == "" {
+			dir = "data"
+		}
+		if write {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, nil, err
+			}
+		}
+		info, err := os.Stat(dir)
+		if err == nil && !info.IsDir() || err != nil && !(target && errors.Is(err, os.ErrNotExist)) {
+			return nil, nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		repo := memory.NewMemoryRepoAt(dir, logger)
+		repo.LoadAllData()
+		return repo, func() {
+			if write {
+				repo.SaveAllData()
+			}
+		}, nil
+	case strings.HasPrefix(spec, "postgres://"), strings.HasPrefix(spec, "postgresql://"):
+		db, err := postgres.Connect(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		if write {
+			if err := postgres.Migrate(db); err != nil {
+				db.Close()
+				return nil, nil, err
+			}
+		}
+		return postgres.NewPostgresRepo(db, logger), func() { db.Close() }, nil
+	case isMongo(spec):
+		if err := repository.ConnectMongo(logger, spec); err != nil {
+			return nil, nil, err
+		}
+		if write {
+			// SaveUser и SaveOrder пишут события в Redis
+			if err := repository.ConnectRedis(logger, redisAddr); err != nil {
+				repository.CloseDB(logger)
+				return nil, nil, err
+			}
+		}
+		return repository.NewRepository(logger), func() { repository.CloseDB(logger) }, nil
+	}
+	return nil, nil, errors.New("unknown storage " + spec + ": want memory:DIR, postgres://... or mongodb://...")
+}
+
+// printMigrateReport печатает таблицу по типам записей, ошибки и итог
+func printMigrateReport(w io.Writer, report *migrate.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	copied := "COPIED"
+	if report.DryRun {
+		copied = "TO COPY"
+	}
+	fmt.Fprintf(tw, "KIND\tSOURCE\tEXISTING\t%s\tFAILED\tIN TARGET\tCHECKSUM\n", copied)
+	for _, res := range report.Results {
+		checksum := "ok"
+		if report.DryRun {
+			checksum = "-" // сверяется после записи
+		} else if res.SourceChecksum != res.TargetChecksum {
+			checksum = "differs"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", res.Kind, res.Source, res.Existing, res.Copied, res.Failed, res.Target, checksum)
+	}
+	tw.Flush()
+
+	for _, res := range report.Results {
+		for _, e := range res.Errors {
+			fmt.Fprintf(w, "%s %s\n", res.Kind, e)
+		}
+		for _, id := range res.Mismatched {
+			fmt.Fprintf(w, "%s %s: target differs from source and was not overwritten\n", res.Kind, id)
+		}
+	}
+	switch {
+	case report.DryRun && report.OK():
+		fmt.Fprintln(w, "dry run: nothing written")
+	case report.DryRun:
+		fmt.Fprintln(w, "dry run: target has conflicting records")
+	case report.OK():
+		fmt.Fprintln(w, "migration verified: counts and checksums match")
+	default:
+		fmt.Fprintln(w, "migration NOT verified")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"order-ms/internal/ctl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест команды migrate-data между каталогами MemoryRepo: dry-run ничего не пишет, перенос сверяется
+func TestRunMigrateData(t *testing.T) {
+	from, to := t.TempDir(), filepath.Join(t.TempDir(), "target")
+	require.NoError(t, os.WriteFile(filepath.Join(from, "users.json"),
+		[]byte(`[{"id":"User-1","name":"Alice","email":"alice@example.com","created_at":"2024-03-01T10:00:00.123456Z","version":2}]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(from, "orders.json"),
+		[]byte(`[{"id":"Order-1","user_id":"User-1","status":1,"created_at":"2024-03-02T11:30:00Z","version":2}]`), 0o600))
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := ctl.Run(append([]string{"migrate-data"}, args...), &stdout, &stderr, commands()...)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, stderr := run("--from", "memory:"+from, "--to", "memory:"+to, "--dry-run")
+	assert.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `orders\s+1\s+0\s+1\s+0\s+0\s+-`, out)
+	assert.Contains(t, out, "dry run: nothing written")
+	assert.NoDirExists(t, to)
+
+	code, out, stderr = run("--from", "memory:"+from, "--to", "memory:"+to)
+	assert.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `users\s+1\s+0\s+1\s+0\s+1\s+ok`, out)
+	assert.Contains(t, out, "migration verified")
+	orders, err := os.ReadFile(filepath.Join(to, "orders.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(orders), `"created_at": "2024-03-02T11:30:00Z"`)
+
+	// повторный запуск ничего не копирует
+	code, out, _ = run("--from", "memory:"+from, "--to", "memory:"+to)
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `orders\s+1\s+1\s+0\s+0\s+1\s+ok`, out)
+
+	code, _, stderr = run("--from", "memory:"+from, "--to", "redis://localhost")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown storage")
+	code, _, _ = run("--from", "memory:"+from)
+	assert.Equal(t, 2, code)
+}
@@ -0,0 +1,222 @@
+// Package client — Go-клиент gRPC API сервиса заказов.
+//
+// Методы принимают context вызывающего и возвращают ошибки, которые сравниваются через errors.Is
+// с ErrNotFound, ErrConflict и другими (см. errors.go). Идемпотентные вызовы (чтение) при
+// недоступности сервера и превышении лимита частоты повторяются с экспоненциальной задержкой
+package client
+
+import (
+	"context"
+
+	pb "order-ms/pkg/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Client — соединение с сервисом заказов; безопасен для использования из нескольких горутин
+type Client struct {
+	conn       *grpc.ClientConn
+	users      pb.UserServiceClient
+	orders     pb.OrderServiceClient
+	deliveries pb.DeliveryServiceClient
+	warehouses pb.WarehouseServiceClient
+}
+
+// New создаёт клиента для адреса target (например "localhost:50051"). Соединение устанавливается
+// лениво, при первом вызове. Без WithTLS соединение не шифруется
+func New(target string, opts ...Option) (*Client, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	conn, err := grpc.NewClient(target, cfg.dialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn:       conn,
+		users:      pb.NewUserServiceClient(conn),
+		orders:     pb.NewOrderServiceClient(conn),
+		deliveries: pb.NewDeliveryServiceClient(conn),
+		warehouses: pb.NewWarehouseServiceClient(conn),
+	}, nil
+}
+
+// Close закрывает соединение
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Пользователи
+
+// CreateUser создаёт пользователя
+func (c *Client) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	resp, err := c.users.CreateUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetUser(), nil
+}
+
+// GetUser возвращает пользователя по ID
+func (c *Client) GetUser(ctx context.Context, id string) (*pb.User, error) {
+	return c.users.GetUser(ctx, &pb.GetUserRequest{Id: id})
+}
+
+// ListUsers возвращает всех пользователей
+func (c *Client) ListUsers(ctx context.Context) ([]*pb.User, error) {
+	resp, err := c.users.ListUsers(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetUsers(), nil
+}
+
+// UpdateUser заменяет профиль целиком; с req.ExpectedVersion > 0 — только если профиль в этой версии (иначе ErrConflict)
+func (c *Client) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	return c.users.UpdateUser(ctx, req)
+}
+
+// DeleteUser мягко удаляет пользователя
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id})
+	return err
+}
+
+// ExportUser выгружает все данные пользователя
+func (c *Client) ExportUser(ctx context.Context, id string) (*pb.UserExport, error) {
+	return c.users.ExportUser(ctx, &pb.GetUserRequest{Id: id})
+}
+
+// EraseUser обезличивает пользователя
+func (c *Client) EraseUser(ctx context.Context, id string) (*pb.User, error) {
+	return c.users.EraseUser(ctx, &pb.GetUserRequest{Id: id})
+}
+
+// Заказы
+
+// CreateOrder создаёт заказ
+func (c *Client) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.Order, error) {
+	resp, err := c.orders.CreateOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetOrder(), nil
+}
+
+// GetOrder возвращает заказ по ID
+func (c *Client) GetOrder(ctx context.Context, id string) (*pb.Order, error) {
+	return c.orders.GetOrder(ctx, &pb.GetOrderRequest{Id: id})
+}
+
+// ListOrders возвращает заказы по фильтру; nil — все доступные вызывающему
+func (c *Client) ListOrders(ctx context.Context, filter *pb.ListOrdersRequest) ([]*pb.Order, error) {
+	if filter == nil {
+		filter = &pb.ListOrdersRequest{}
+	}
+	resp, err := c.orders.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetOrders(), nil
+}
+
+// DeleteOrder мягко удаляет заказ
+func (c *Client) DeleteOrder(ctx context.Context, id string) error {
+	_, err := c.orders.DeleteOrder(ctx, &pb.DeleteOrderRequest{Id: id})
+	return err
+}
+
+// ConfirmOrder подтверждает заказ. expectedVersion > 0 — только если заказ в этой версии (иначе ErrConflict)
+func (c *Client) ConfirmOrder(ctx context.Context, id string, expectedVersion int64) (*pb.Order, error) {
+	return c.orders.ConfirmOrder(ctx, &pb.OrderActionRequest{Id: id, ExpectedVersion: expectedVersion})
+}
+
+// DeliverOrder отмечает заказ доставленным
+func (c *Client) DeliverOrder(ctx context.Context, id string, expectedVersion int64) (*pb.Order, error) {
+	return c.orders.DeliverOrder(ctx, &pb.OrderActionRequest{Id: id, ExpectedVersion: expectedVersion})
+}
+
+// CancelOrder отменяет заказ
+func (c *Client) CancelOrder(ctx context.Context, id string, expectedVersion int64) error {
+	_, err := c.orders.CancelOrder(ctx, &pb.OrderActionRequest{Id: id, ExpectedVersion: expectedVersion})
+	return err
+}
+
+// UpdateOrderStatus переводит заказ в статус to теми же правилами, что ConfirmOrder, DeliverOrder и CancelOrder
+func (c *Client) UpdateOrderStatus(ctx context.Context, id string, to pb.OrderStatus, expectedVersion int64) (*pb.Order, error) {
+	return c.orders.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{Id: id, Status: to, ExpectedVersion: expectedVersion})
+}
+
+// WatchOrder присылает текущее состояние заказа и дальнейшие смены его статуса.
+// afterSeq > 0 — продолжить после события с этим номером. Поток живёт, пока не отменён ctx
+func (c *Client) WatchOrder(ctx context.Context, id string, afterSeq uint64) (*EventStream, error) {
+	stream, err := c.orders.WatchOrder(ctx, &pb.WatchOrderRequest{Id: id, AfterSeq: afterSeq})
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{stream: stream}, nil
+}
+
+// WatchOrders присылает смены статусов заказов по фильтру
+func (c *Client) WatchOrders(ctx context.Context, filter *pb.WatchOrdersRequest) (*EventStream, error) {
+	if filter == nil {
+		filter = &pb.WatchOrdersRequest{}
+	}
+	stream, err := c.orders.WatchOrders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{stream: stream}, nil
+}
+
+// EventStream — поток событий заказов
+type EventStream struct {
+	stream interface {
+		Recv() (*pb.OrderEvent, error)
+	}
+}
+
+// Recv ждёт следующее событие. Ошибки приведены к тем же типам, что у остальных методов;
+// после отмены ctx возвращает ошибку с кодом Canceled
+func (s *EventStream) Recv() (*pb.OrderEvent, error) {
+	e, err := s.stream.Recv()
+	if err != nil {
+		return nil, fromStatus(err, nil)
+	}
+	return e, nil
+}
+
+// Доставки и склад
+
+// ListDeliveries возвращает доставки по фильтру; nil — все доступные вызывающему
+func (c *Client) ListDeliveries(ctx context.Context, filter *pb.ListDeliveriesRequest) ([]*pb.Delivery, error) {
+	if filter == nil {
+		filter = &pb.ListDeliveriesRequest{}
+	}
+	resp, err := c.deliveries.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetDeliveries(), nil
+}
+
+// GetDelivery возвращает доставку по ID
+func (c *Client) GetDelivery(ctx context.Context, id int64) (*pb.Delivery, error) {
+	return c.deliveries.GetDelivery(ctx, &pb.GetDeliveryRequest{Id: id})
+}
+
+// ListWarehouses возвращает складские записи; orderID не пустой — только по этому заказу
+func (c *Client) ListWarehouses(ctx context.Context, orderID string) ([]*pb.Warehouse, error) {
+	resp, err := c.warehouses.ListWarehouses(ctx, &pb.ListWarehousesRequest{OrderId: orderID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetWarehouses(), nil
+}
+
+// GetWarehouse возвращает складскую запись по ID
+func (c *Client) GetWarehouse(ctx context.Context, id int64) (*pb.Warehouse, error) {
+	return c.warehouses.GetWarehouse(ctx, &pb.GetWarehouseRequest{Id: id})
+}
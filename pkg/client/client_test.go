@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-ms/internal/auth/authtest"
+	"order-ms/internal/certs"
+	"order-ms/internal/certs/certstest"
+	"order-ms/internal/events"
+	grpcServer "order-ms/internal/grpc"
+	"order-ms/internal/health"
+	"order-ms/internal/logging"
+	"order-ms/internal/metrics"
+	"order-ms/internal/ratelimit"
+	"order-ms/internal/repository/memory"
+	pb "order-ms/pkg/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve запускает s в памяти и возвращает опцию, которая направляет клиента к нему
+func serve(t *testing.T, s *grpc.Server) Option {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+}
+
+func newClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	c, err := New("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// тест клиента против настоящего gRPC-сервера: учётные данные, методы и типизированные ошибки
+func TestClient(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"logistics": {Rate: 0.001, Burst: 1}}, logging.Discard())
+	srv := grpcServer.NewGrpcServer(memory.NewMemoryRepo(logging.Discard()), events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authtest.NewHS256(t), limiter)
+	dial := serve(t, srv.Server)
+	ctx := context.Background()
+
+	anonymous := newClient(t, dial)
+	_, err := anonymous.ListOrders(ctx, nil)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	alice := newClient(t, dial, WithBearerToken(authtest.Token(t, "alice", "customer")))
+	warehouse := newClient(t, dial, WithBearerToken(authtest.Token(t, "w-1", "warehouse")))
+
+	order, err := alice.CreateOrder(ctx, &pb.CreateOrderRequest{UserId: "alice"})
+	require.NoError(t, err)
+	_, err = alice.ConfirmOrder(ctx, order.GetId(), 0)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = warehouse.ConfirmOrder(ctx, order.GetId(), order.GetVersion()+1)
+	assert.ErrorIs(t, err, ErrConflict)
+	confirmed, err := warehouse.ConfirmOrder(ctx, order.GetId(), order.GetVersion())
+	require.NoError(t, err)
+	assert.Equal(t, pb.OrderStatus_ORDER_CONFIRMED, confirmed.GetStatus())
+	_, err = warehouse.ConfirmOrder(ctx, order.GetId(), 0)
+	assert.ErrorIs(t, err, ErrFailedPrecondition)
+
+	orders, err := alice.ListOrders(ctx, &pb.ListOrdersRequest{Status: pb.OrderStatus_ORDER_CONFIRMED.Enum()})
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	_, err = alice.GetOrder(ctx, "nope")
+	var e *Error
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, codes.NotFound, e.Code)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+
+	// второй вызов упирается в лимит; ждать Retry-After дольше дедлайна клиент не станет
+	_, err = warehouse.ListWarehouses(ctx, "")
+	require.NoError(t, err)
+	deadline, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	started := time.Now()
+	_, err = warehouse.ListWarehouses(deadline, "")
+	assert.ErrorIs(t, err, ErrRateLimited)
+	if assert.ErrorAs(t, err, &e) {
+		assert.Greater(t, e.RetryAfter, time.Second)
+	}
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+// flakyOrders отвечает Unavailable первые failures вызовов
+type flakyOrders struct {
+	pb.UnimplementedOrderServiceServer
+	failures int32
+	calls    atomic.Int32
+}
+
+func (s *flakyOrders) fail() error {
+	if s.calls.Add(1) <= s.failures {
+		return status.Error(codes.Unavailable, "try again")
+	}
+	return nil
+}
+
+func (s *flakyOrders) GetOrder(_ context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return &pb.Order{Id: req.GetId()}, nil
+}
+
+func (s *flakyOrders) ConfirmOrder(_ context.Context, req *pb.OrderActionRequest) (*pb.Order, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return &pb.Order{Id: req.GetId()}, nil
+}
+
+// тест повторов: чтение повторяется с задержкой, смена статуса — нет
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+	tests := []struct {
+		name          string
+		failures      int32
+		call          func(*Client) error
+		expectedErr   error
+		expectedCalls int32
+	}{
+		{"read recovers", 2, func(c *Client) error { _, err := c.GetOrder(context.Background(), "1"); return err }, nil, 3},
+		{"read gives up", 5, func(c *Client) error { _, err := c.GetOrder(context.Background(), "1"); return err }, ErrUnavailable, 3},
+		{"write is not retried", 1, func(c *Client) error { _, err := c.ConfirmOrder(context.Background(), "1", 0); return err }, ErrUnavailable, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			orders := &flakyOrders{failures: tc.failures}
+			s := grpc.NewServer()
+			pb.RegisterOrderServiceServer(s, orders)
+			c := newClient(t, serve(t, s), WithRetry(policy))
+
+			err := tc.call(c)
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.expectedErr), "got %v", err)
+			}
+			assert.Equal(t, tc.expectedCalls, orders.calls.Load())
+		})
+	}
+}
+
+// тест mTLS: склад входит по сертификату клиента без токена, без сертификата соединение не устанавливается
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := certstest.NewCA(t)
+	certFile, keyFile := certstest.WriteFiles(t, dir, "server", ca.Server(t))
+	reloader, err := certs.NewReloader(certs.Config{
+		CertFile: certFile, KeyFile: keyFile, ClientCAFile: certstest.WriteCA(t, dir, "ca", ca), RequireClientCert: true,
+	}, logging.Discard())
+	require.NoError(t, err)
+
+	authn := authtest.NewHS256(t)
+	authn.SetClientCertRoles(map[string]string{"warehouse-gw": "warehouse"})
+	repo := memory.NewMemoryRepo(logging.Discard())
+	srv := grpcServer.NewGrpcServer(repo, events.NewBus(events.DefaultHistory), logging.Discard(),
+		metrics.New(), health.NewChecker(logging.Discard()), authn, nil, grpc.Creds(credentials.NewTLS(reloader.TLSConfig("h2"))))
+	dial := serve(t, srv.Server)
+	ctx := context.Background()
+
+	tlsConfig := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{RootCAs: ca.Pool(), ServerName: "localhost", Certificates: certs, MinVersion: tls.VersionTLS12}
+	}
+	noRetry := WithRetry(RetryPolicy{MaxAttempts: 1})
+
+	warehouse := newClient(t, dial, WithTLS(tlsConfig(ca.Client(t, "warehouse-gw"))))
+	_, err = warehouse.ListOrders(ctx, nil)
+	assert.NoError(t, err)
+
+	unknown := newClient(t, dial, noRetry, WithTLS(tlsConfig(ca.Client(t, "unknown"))))
+	_, err = unknown.ListOrders(ctx, nil)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	anonymous := newClient(t, dial, noRetry, WithTLS(tlsConfig()), WithBearerToken(authtest.Token(t, "w-1", "warehouse")))
+	_, err = anonymous.ListOrders(ctx, nil)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
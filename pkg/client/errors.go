@@ -0,0 +1,94 @@
+package client
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Виды ошибок сервиса; проверяются через errors.Is. Подробности (код, сообщение сервера,
+// Retry-After) — в *Error через errors.As
+var (
+	ErrInvalidArgument    = errors.New("invalid argument")                  // запрос не прошёл проверку
+	ErrUnauthenticated    = errors.New("unauthenticated")                   // нет или неверные токен или API-ключ
+	ErrPermissionDenied   = errors.New("permission denied")                 // роли не хватает прав
+	ErrNotFound           = errors.New("not found")                         // заказа, пользователя или записи нет
+	ErrAlreadyExists      = errors.New("already exists")                    // например, email уже занят
+	ErrConflict           = errors.New("version conflict")                  // ресурс изменился: ExpectedVersion устарела
+	ErrFailedPrecondition = errors.New("failed precondition")               // переход статуса из текущего невозможен
+	ErrRateLimited        = errors.New("rate limited")                      // превышен лимит частоты, см. Error.RetryAfter
+	ErrUnavailable        = errors.New("service unavailable")               // сервер недоступен или перегружен
+	ErrInternal           = errors.New("internal server error")             // ошибка на стороне сервиса
+	ErrUnknown            = errors.New("unexpected error from the service") // остальные коды gRPC
+)
+
+// Error — ошибка вызова, полученная от сервиса
+type Error struct {
+	Code       codes.Code    // код gRPC
+	Message    string        // сообщение сервера
+	RetryAfter time.Duration // для ErrRateLimited: через сколько повторить (0 — сервер не сообщил)
+	kind       error
+}
+
+func (e *Error) Error() string {
+	return e.kind.Error() + ": " + e.Message
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrNotFound и другими видами через errors.Is
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// виды ошибок по кодам gRPC
+var kinds = map[codes.Code]error{
+	codes.InvalidArgument:    ErrInvalidArgument,
+	codes.OutOfRange:         ErrInvalidArgument,
+	codes.Unauthenticated:    ErrUnauthenticated,
+	codes.PermissionDenied:   ErrPermissionDenied,
+	codes.NotFound:           ErrNotFound,
+	codes.AlreadyExists:      ErrAlreadyExists,
+	codes.Aborted:            ErrConflict,
+	codes.FailedPrecondition: ErrFailedPrecondition,
+	codes.ResourceExhausted:  ErrRateLimited,
+	codes.Unavailable:        ErrUnavailable,
+	codes.Internal:           ErrInternal,
+	codes.DataLoss:           ErrInternal,
+}
+
+// fromStatus переводит ошибку gRPC в *Error. Отмена и истечение ctx возвращаются как есть,
+// с кодами Canceled и DeadlineExceeded
+func fromStatus(err error, header metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.Canceled, codes.DeadlineExceeded:
+		return err
+	}
+	kind, ok := kinds[st.Code()]
+	if !ok {
+		kind = ErrUnknown
+	}
+	return &Error{Code: st.Code(), Message: st.Message(), RetryAfter: retryAfter(header), kind: kind}
+}
+
+// retryAfter читает задержку из metadata "retry-after" (в секундах), которую сервер ставит при превышении лимита
+func retryAfter(md metadata.MD) time.Duration {
+	vals := md.Get("retry-after")
+	if len(vals) == 0 {
+		return 0
+	}
+	secs, err := strconv.Atoi(vals[0])
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Option настраивает клиента в New
+type Option func(*config)
+
+type config struct {
+	transport credentials.TransportCredentials
+	perRPC    []credentials.PerRPCCredentials
+	retry     RetryPolicy
+	dial      []grpc.DialOption
+}
+
+func defaultConfig() config {
+	return config{
+		transport: insecure.NewCredentials(),
+		retry:     DefaultRetryPolicy,
+	}
+}
+
+// dialOptions собирает опции соединения: сначала повторы, затем перевод ошибок — чтобы повторы видели коды gRPC
+func (c config) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(c.transport),
+		grpc.WithChainUnaryInterceptor(errorInterceptor(), retryInterceptor(c.retry)),
+		grpc.WithChainStreamInterceptor(streamErrorInterceptor()),
+	}
+	for _, p := range c.perRPC {
+		opts = append(opts, grpc.WithPerRPCCredentials(p))
+	}
+	return append(opts, c.dial...)
+}
+
+// WithTLS включает TLS. nil — проверка сертификата сервера по системным корневым сертификатам;
+// для mTLS передайте tls.Config с Certificates
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *config) {
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		c.transport = credentials.NewTLS(cfg)
+	}
+}
+
+// WithBearerToken передаёт JWT в metadata "authorization" каждого вызова
+func WithBearerToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) { return token, nil })
+}
+
+// WithTokenSource запрашивает JWT перед каждым вызовом — например, чтобы обновлять истекающий токен
+func WithTokenSource(source func(ctx context.Context) (string, error)) Option {
+	return func(c *config) {
+		c.perRPC = append(c.perRPC, tokenCredentials{source: source})
+	}
+}
+
+// WithAPIKey передаёт API-ключ сервиса в metadata "x-api-key" каждого вызова
+func WithAPIKey(key string) Option {
+	return func(c *config) {
+		c.perRPC = append(c.perRPC, apiKeyCredentials(key))
+	}
+}
+
+// WithRetry задаёт политику повторов идемпотентных вызовов; RetryPolicy{MaxAttempts: 1} отключает повторы
+func WithRetry(p RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = p
+	}
+}
+
+// WithDialOptions добавляет опции grpc — например, свой dialer или interceptor
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *config) {
+		c.dial = append(c.dial, opts...)
+	}
+}
+
+// tokenCredentials — bearer-токен для каждого вызова. Токен передаётся и без TLS:
+// сервис может стоять за балансировщиком, который сам завершает TLS
+type tokenCredentials struct {
+	source func(ctx context.Context) (string, error)
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := t.source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (tokenCredentials) RequireTransportSecurity() bool { return false }
+
+// apiKeyCredentials — API-ключ для каждого вызова
+type apiKeyCredentials string
+
+func (k apiKeyCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-api-key": string(k)}, nil
+}
+
+func (apiKeyCredentials) RequireTransportSecurity() bool { return false }
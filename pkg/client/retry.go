@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	pb "order-ms/pkg/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy — повторы идемпотентных вызовов. Задержка перед n-й повторной попыткой —
+// InitialBackoff·Multiplier^(n-1), но не больше MaxBackoff, со случайным разбросом ±20%.
+// Если сервер прислал Retry-After, ждём не меньше него
+type RetryPolicy struct {
+	MaxAttempts    int           // всего попыток, включая первую; 0 и 1 — без повторов
+	InitialBackoff time.Duration // задержка перед первым повтором
+	MaxBackoff     time.Duration // потолок задержки
+	Multiplier     float64       // во сколько раз растёт задержка
+	// коды, при которых вызов повторяется; nil — Unavailable и ResourceExhausted
+	RetryableCodes []codes.Code
+}
+
+// DefaultRetryPolicy — политика по умолчанию: до 4 попыток, задержки 100ms, 200ms, 400ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+}
+
+// идемпотентные методы: только чтение. Смены статуса, создание и удаление не повторяются —
+// повтор после потерянного ответа вернул бы ошибку или создал дубликат
+var idempotentMethods = map[string]bool{
+	pb.UserService_GetUser_FullMethodName:             true,
+	pb.UserService_ListUsers_FullMethodName:           true,
+	pb.UserService_ExportUser_FullMethodName:          true,
+	pb.OrderService_GetOrder_FullMethodName:           true,
+	pb.OrderService_ListOrders_FullMethodName:         true,
+	pb.DeliveryService_ListDeliveries_FullMethodName:  true,
+	pb.DeliveryService_GetDelivery_FullMethodName:     true,
+	pb.WarehouseService_ListWarehouses_FullMethodName: true,
+	pb.WarehouseService_GetWarehouse_FullMethodName:   true,
+}
+
+func (p RetryPolicy) retryable(code codes.Code) bool {
+	if p.RetryableCodes == nil {
+		return code == codes.Unavailable || code == codes.ResourceExhausted
+	}
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff — задержка перед повтором номер attempt (с 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	return time.Duration(d * (0.8 + 0.4*rand.Float64()))
+}
+
+// retryInterceptor повторяет идемпотентные вызовы по политике p
+func retryInterceptor(p RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if p.MaxAttempts <= 1 || !idempotentMethods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		for attempt := 1; ; attempt++ {
+			var header metadata.MD
+			err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+			if err == nil || attempt >= p.MaxAttempts || !p.retryable(status.Code(err)) {
+				return err
+			}
+			wait := max(p.backoff(attempt), retryAfter(header))
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return err // не дождёмся: отдаём ошибку сразу, а не DeadlineExceeded
+			}
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// errorInterceptor переводит ошибки вызовов в *Error
+func errorInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var header metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		return fromStatus(err, header)
+	}
+}
+
+// streamErrorInterceptor переводит ошибки открытия потока; ошибки Recv переводит EventStream
+func streamErrorInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		return s, fromStatus(err, nil)
+	}
+}
@@ -7,9 +7,12 @@
 package proto
 
 import (
+	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -74,18 +77,110 @@ func (OrderStatus) EnumDescriptor() ([]byte, []int) {
 	return file_pkg_proto_api_proto_rawDescGZIP(), []int{0}
 }
 
+// Адрес доставки
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // при создании/обновлении пользователя можно не задавать — будет выдан сервером
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Country       string                 `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	Street        string                 `protobuf:"bytes,5,opt,name=street,proto3" json:"street,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,6,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_pkg_proto_api_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Address) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Address) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetStreet() string {
+	if x != nil {
+		return x.Street
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
 // Сущность пользователя
 type User struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Phone         string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses     []*Address             `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`                  // растёт при каждом изменении профиля
+	ErasedAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=erased_at,json=erasedAt,proto3" json:"erased_at,omitempty"` // задано, если личные данные обезличены
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *User) Reset() {
 	*x = User{}
-	mi := &file_pkg_proto_api_proto_msgTypes[0]
+	mi := &file_pkg_proto_api_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -97,7 +192,7 @@ func (x *User) String() string {
 func (*User) ProtoMessage() {}
 
 func (x *User) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[0]
+	mi := &file_pkg_proto_api_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -110,7 +205,7 @@ func (x *User) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use User.ProtoReflect.Descriptor instead.
 func (*User) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{0}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *User) GetId() string {
@@ -127,17 +222,69 @@ func (x *User) GetName() string {
 	return ""
 }
 
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *User) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *User) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *User) GetErasedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ErasedAt
+	}
+	return nil
+}
+
 // Запрос на создание пользователя
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Phone         string                 `protobuf:"bytes,3,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses     []*Address             `protobuf:"bytes,4,rep,name=addresses,proto3" json:"addresses,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateUserRequest) Reset() {
 	*x = CreateUserRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[1]
+	mi := &file_pkg_proto_api_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -149,7 +296,7 @@ func (x *CreateUserRequest) String() string {
 func (*CreateUserRequest) ProtoMessage() {}
 
 func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[1]
+	mi := &file_pkg_proto_api_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -162,7 +309,7 @@ func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
 func (*CreateUserRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{1}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *CreateUserRequest) GetName() string {
@@ -172,6 +319,27 @@ func (x *CreateUserRequest) GetName() string {
 	return ""
 }
 
+func (x *CreateUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
 // Ответ с созданным пользователем
 type CreateUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -182,7 +350,7 @@ type CreateUserResponse struct {
 
 func (x *CreateUserResponse) Reset() {
 	*x = CreateUserResponse{}
-	mi := &file_pkg_proto_api_proto_msgTypes[2]
+	mi := &file_pkg_proto_api_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -194,7 +362,7 @@ func (x *CreateUserResponse) String() string {
 func (*CreateUserResponse) ProtoMessage() {}
 
 func (x *CreateUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[2]
+	mi := &file_pkg_proto_api_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -207,7 +375,7 @@ func (x *CreateUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserResponse.ProtoReflect.Descriptor instead.
 func (*CreateUserResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{2}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreateUserResponse) GetUser() *User {
@@ -227,7 +395,7 @@ type GetUserRequest struct {
 
 func (x *GetUserRequest) Reset() {
 	*x = GetUserRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[3]
+	mi := &file_pkg_proto_api_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -239,7 +407,7 @@ func (x *GetUserRequest) String() string {
 func (*GetUserRequest) ProtoMessage() {}
 
 func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[3]
+	mi := &file_pkg_proto_api_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -252,7 +420,7 @@ func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
 func (*GetUserRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{3}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetUserRequest) GetId() string {
@@ -272,7 +440,7 @@ type ListUsersResponse struct {
 
 func (x *ListUsersResponse) Reset() {
 	*x = ListUsersResponse{}
-	mi := &file_pkg_proto_api_proto_msgTypes[4]
+	mi := &file_pkg_proto_api_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -284,7 +452,7 @@ func (x *ListUsersResponse) String() string {
 func (*ListUsersResponse) ProtoMessage() {}
 
 func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[4]
+	mi := &file_pkg_proto_api_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -297,7 +465,7 @@ func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
 func (*ListUsersResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{4}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ListUsersResponse) GetUsers() []*User {
@@ -307,18 +475,22 @@ func (x *ListUsersResponse) GetUsers() []*User {
 	return nil
 }
 
-// Запрос на обновление пользователя
+// Запрос на обновление пользователя (профиль заменяется целиком)
 type UpdateUserRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email           string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Phone           string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses       []*Address             `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,6,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // > 0 — обновить, только если профиль в этой версии (иначе ABORTED); в REST также заголовок If-Match
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpdateUserRequest) Reset() {
 	*x = UpdateUserRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[5]
+	mi := &file_pkg_proto_api_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -330,7 +502,7 @@ func (x *UpdateUserRequest) String() string {
 func (*UpdateUserRequest) ProtoMessage() {}
 
 func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[5]
+	mi := &file_pkg_proto_api_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -343,7 +515,7 @@ func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
 func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{5}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *UpdateUserRequest) GetId() string {
@@ -360,6 +532,34 @@ func (x *UpdateUserRequest) GetName() string {
 	return ""
 }
 
+func (x *UpdateUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *UpdateUserRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 // запрос на удаление пользователя
 type DeleteUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -370,7 +570,7 @@ type DeleteUserRequest struct {
 
 func (x *DeleteUserRequest) Reset() {
 	*x = DeleteUserRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[6]
+	mi := &file_pkg_proto_api_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -382,7 +582,7 @@ func (x *DeleteUserRequest) String() string {
 func (*DeleteUserRequest) ProtoMessage() {}
 
 func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[6]
+	mi := &file_pkg_proto_api_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -395,7 +595,7 @@ func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
 func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{6}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteUserRequest) GetId() string {
@@ -407,17 +607,22 @@ func (x *DeleteUserRequest) GetId() string {
 
 // Сущность заказа
 type Order struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Status        OrderStatus            `protobuf:"varint,3,opt,name=status,proto3,enum=proto.OrderStatus" json:"status,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status          OrderStatus            `protobuf:"varint,3,opt,name=status,proto3,enum=proto.OrderStatus" json:"status,omitempty"`
+	ShippingAddress *Address               `protobuf:"bytes,4,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"` // копия адреса пользователя на момент заказа
+	Version         int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`                                       // растёт при каждой смене статуса
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CancelReason    string                 `protobuf:"bytes,7,opt,name=cancel_reason,json=cancelReason,proto3" json:"cancel_reason,omitempty"` // причина отмены, например expired
+	DeletedAt       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`          // задано только у мягко удалённых заказов (в выгрузке данных пользователя)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_pkg_proto_api_proto_msgTypes[7]
+	mi := &file_pkg_proto_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -429,7 +634,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[7]
+	mi := &file_pkg_proto_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -442,7 +647,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{7}
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Order) GetId() string {
@@ -466,73 +671,67 @@ func (x *Order) GetStatus() OrderStatus {
 	return OrderStatus_ORDER_CREATED
 }
 
-// Запрос на создание заказа
-type CreateOrderRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *CreateOrderRequest) Reset() {
-	*x = CreateOrderRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[8]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Order) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
 }
 
-func (x *CreateOrderRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Order) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
 }
 
-func (*CreateOrderRequest) ProtoMessage() {}
-
-func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[8]
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.CreatedAt
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use CreateOrderRequest.ProtoReflect.Descriptor instead.
-func (*CreateOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{8}
+func (x *Order) GetCancelReason() string {
+	if x != nil {
+		return x.CancelReason
+	}
+	return ""
 }
 
-func (x *CreateOrderRequest) GetUserId() string {
+func (x *Order) GetDeletedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.UserId
+		return x.DeletedAt
 	}
-	return ""
+	return nil
 }
 
-// Ответ с созданным заказом
-type CreateOrderResponse struct {
+// Доставка заказа
+type Delivery struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=OrderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=UserId,proto3" json:"user_id,omitempty"`
+	Address       string                 `protobuf:"bytes,4,opt,name=address,json=Address,proto3" json:"address,omitempty"`
+	Status        int32                  `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateOrderResponse) Reset() {
-	*x = CreateOrderResponse{}
+func (x *Delivery) Reset() {
+	*x = Delivery{}
 	mi := &file_pkg_proto_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateOrderResponse) String() string {
+func (x *Delivery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateOrderResponse) ProtoMessage() {}
+func (*Delivery) ProtoMessage() {}
 
-func (x *CreateOrderResponse) ProtoReflect() protoreflect.Message {
+func (x *Delivery) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_proto_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -544,41 +743,762 @@ func (x *CreateOrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateOrderResponse.ProtoReflect.Descriptor instead.
-func (*CreateOrderResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Delivery.ProtoReflect.Descriptor instead.
+func (*Delivery) Descriptor() ([]byte, []int) {
 	return file_pkg_proto_api_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *CreateOrderResponse) GetOrder() *Order {
+func (x *Delivery) GetId() int64 {
 	if x != nil {
-		return x.Order
+		return x.Id
 	}
-	return nil
+	return 0
 }
 
-// Запрос для получения заказа по ID
-type GetOrderRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GetOrderRequest) Reset() {
-	*x = GetOrderRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[10]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Delivery) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Delivery) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Delivery) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Delivery) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+// Складская запись по заказу
+type Warehouse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status        int32                  `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Warehouse) Reset() {
+	*x = Warehouse{}
+	mi := &file_pkg_proto_api_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Warehouse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Warehouse) ProtoMessage() {}
+
+func (x *Warehouse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Warehouse.ProtoReflect.Descriptor instead.
+func (*Warehouse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Warehouse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Warehouse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Warehouse) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+// Запись журнала аудита
+type AuditEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`     // subject вызывающего или "system" для фоновых задач
+	Action        string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`   // например order:confirm
+	Subject       string                 `protobuf:"bytes,5,opt,name=subject,proto3" json:"subject,omitempty"` // ID заказа или пользователя
+	Details       string                 `protobuf:"bytes,6,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_pkg_proto_api_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AuditEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *AuditEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+// Выгрузка всех данных пользователя
+type UserExport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExportedAt    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=exported_at,json=exportedAt,proto3" json:"exported_at,omitempty"`
+	User          *User                  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Orders        []*Order               `protobuf:"bytes,3,rep,name=orders,proto3" json:"orders,omitempty"` // в том числе удалённые
+	Deliveries    []*Delivery            `protobuf:"bytes,4,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
+	History       []*AuditEntry          `protobuf:"bytes,5,rep,name=history,proto3" json:"history,omitempty"` // журнал аудита по пользователю и его заказам
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserExport) Reset() {
+	*x = UserExport{}
+	mi := &file_pkg_proto_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserExport) ProtoMessage() {}
+
+func (x *UserExport) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserExport.ProtoReflect.Descriptor instead.
+func (*UserExport) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UserExport) GetExportedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExportedAt
+	}
+	return nil
+}
+
+func (x *UserExport) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UserExport) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *UserExport) GetDeliveries() []*Delivery {
+	if x != nil {
+		return x.Deliveries
+	}
+	return nil
+}
+
+func (x *UserExport) GetHistory() []*AuditEntry {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+// Запрос на создание заказа
+type CreateOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AddressId     string                 `protobuf:"bytes,2,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"` // ID сохранённого адреса пользователя; пусто — без адреса
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrderRequest) Reset() {
+	*x = CreateOrderRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrderRequest) ProtoMessage() {}
+
+func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrderRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CreateOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetAddressId() string {
+	if x != nil {
+		return x.AddressId
+	}
+	return ""
+}
+
+// Ответ с созданным заказом
+type CreateOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrderResponse) Reset() {
+	*x = CreateOrderResponse{}
+	mi := &file_pkg_proto_api_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrderResponse) ProtoMessage() {}
+
+func (x *CreateOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrderResponse.ProtoReflect.Descriptor instead.
+func (*CreateOrderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+// Запрос для получения заказа по ID
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GetOrderRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetOrderRequest) ProtoMessage() {}
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetOrderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Запрос на подтверждение, доставку или отмену заказа
+type OrderActionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // > 0 — только если заказ в этой версии (иначе ABORTED); в REST также заголовок If-Match
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *OrderActionRequest) Reset() {
+	*x = OrderActionRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderActionRequest) ProtoMessage() {}
+
+func (x *OrderActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderActionRequest.ProtoReflect.Descriptor instead.
+func (*OrderActionRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *OrderActionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *OrderActionRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+// Подписка на события одного заказа
+type WatchOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AfterSeq      uint64                 `protobuf:"varint,3,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"` // > 0 — продолжить после события с этим номером, 0 — начать с текущего состояния
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrderRequest) Reset() {
+	*x = WatchOrderRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrderRequest) ProtoMessage() {}
+
+func (x *WatchOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrderRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchOrderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WatchOrderRequest) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+// Запрос списка заказов; пустые поля не ограничивают выборку
+type ListOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        *OrderStatus           `protobuf:"varint,2,opt,name=status,proto3,enum=proto.OrderStatus,oneof" json:"status,omitempty"`
+	CreatedAfter  *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`    // включительно
+	CreatedBefore *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"` // не включительно
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersRequest) ProtoMessage() {}
+
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetStatus() OrderStatus {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return OrderStatus_ORDER_CREATED
+}
+
+func (x *ListOrdersRequest) GetCreatedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetCreatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return nil
+}
+
+// запрос на удаление заказа
+type DeleteOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteOrderRequest) Reset() {
+	*x = DeleteOrderRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteOrderRequest) ProtoMessage() {}
+
+func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteOrderRequest.ProtoReflect.Descriptor instead.
+func (*DeleteOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteOrderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Список заказов
+type ListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_pkg_proto_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+// Запрос на обновление статуса заказа
+type UpdateOrderStatusRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status          OrderStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=proto.OrderStatus" json:"status,omitempty"`                   // ORDER_CONFIRMED, ORDER_DELIVERED или ORDER_CANCELLED
+	ExpectedVersion int64                  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // > 0 — только если заказ в этой версии (иначе ABORTED); в REST также заголовок If-Match
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusRequest) Reset() {
+	*x = UpdateOrderStatusRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusRequest) ProtoMessage() {}
+
+func (x *UpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateOrderStatusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateOrderStatusRequest) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_CREATED
+}
+
+func (x *UpdateOrderStatusRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+// Запрос списка доставок; пустые поля не ограничивают выборку
+type ListDeliveriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeliveriesRequest) Reset() {
+	*x = ListDeliveriesRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeliveriesRequest) ProtoMessage() {}
 
-func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[10]
+func (x *ListDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -589,41 +1509,48 @@ func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
-func (*GetOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ListDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*ListDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *GetOrderRequest) GetId() string {
+func (x *ListDeliveriesRequest) GetOrderId() string {
 	if x != nil {
-		return x.Id
+		return x.OrderId
 	}
 	return ""
 }
 
-// запрос на удаление заказа
-type DeleteOrderRequest struct {
+func (x *ListDeliveriesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Список доставок
+type ListDeliveriesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Deliveries    []*Delivery            `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteOrderRequest) Reset() {
-	*x = DeleteOrderRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[11]
+func (x *ListDeliveriesResponse) Reset() {
+	*x = ListDeliveriesResponse{}
+	mi := &file_pkg_proto_api_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteOrderRequest) String() string {
+func (x *ListDeliveriesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteOrderRequest) ProtoMessage() {}
+func (*ListDeliveriesResponse) ProtoMessage() {}
 
-func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[11]
+func (x *ListDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -634,41 +1561,131 @@ func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteOrderRequest.ProtoReflect.Descriptor instead.
-func (*DeleteOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use ListDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*ListDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *DeleteOrderRequest) GetId() string {
+func (x *ListDeliveriesResponse) GetDeliveries() []*Delivery {
+	if x != nil {
+		return x.Deliveries
+	}
+	return nil
+}
+
+// Запрос доставки по ID
+type GetDeliveryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeliveryRequest) Reset() {
+	*x = GetDeliveryRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeliveryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeliveryRequest) ProtoMessage() {}
+
+func (x *GetDeliveryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeliveryRequest.ProtoReflect.Descriptor instead.
+func (*GetDeliveryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetDeliveryRequest) GetId() int64 {
 	if x != nil {
 		return x.Id
 	}
+	return 0
+}
+
+// Запрос списка складских записей; пустой order_id — все
+type ListWarehousesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWarehousesRequest) Reset() {
+	*x = ListWarehousesRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWarehousesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWarehousesRequest) ProtoMessage() {}
+
+func (x *ListWarehousesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWarehousesRequest.ProtoReflect.Descriptor instead.
+func (*ListWarehousesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListWarehousesRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
 	return ""
 }
 
-// Список заказов
-type ListOrdersResponse struct {
+// Список складских записей
+type ListWarehousesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Warehouses    []*Warehouse           `protobuf:"bytes,1,rep,name=warehouses,proto3" json:"warehouses,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListOrdersResponse) Reset() {
-	*x = ListOrdersResponse{}
-	mi := &file_pkg_proto_api_proto_msgTypes[12]
+func (x *ListWarehousesResponse) Reset() {
+	*x = ListWarehousesResponse{}
+	mi := &file_pkg_proto_api_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListOrdersResponse) String() string {
+func (x *ListWarehousesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListOrdersResponse) ProtoMessage() {}
+func (*ListWarehousesResponse) ProtoMessage() {}
 
-func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[12]
+func (x *ListWarehousesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -679,42 +1696,41 @@ func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
-func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ListWarehousesResponse.ProtoReflect.Descriptor instead.
+func (*ListWarehousesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *ListOrdersResponse) GetOrders() []*Order {
+func (x *ListWarehousesResponse) GetWarehouses() []*Warehouse {
 	if x != nil {
-		return x.Orders
+		return x.Warehouses
 	}
 	return nil
 }
 
-// Запрос на обновление статуса заказа
-type UpdateOrderStatusRequest struct {
+// Запрос складской записи по ID
+type GetWarehouseRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Status        OrderStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=proto.OrderStatus" json:"status,omitempty"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateOrderStatusRequest) Reset() {
-	*x = UpdateOrderStatusRequest{}
-	mi := &file_pkg_proto_api_proto_msgTypes[13]
+func (x *GetWarehouseRequest) Reset() {
+	*x = GetWarehouseRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateOrderStatusRequest) String() string {
+func (x *GetWarehouseRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateOrderStatusRequest) ProtoMessage() {}
+func (*GetWarehouseRequest) ProtoMessage() {}
 
-func (x *UpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_api_proto_msgTypes[13]
+func (x *GetWarehouseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -725,86 +1741,453 @@ func (x *UpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateOrderStatusRequest.ProtoReflect.Descriptor instead.
-func (*UpdateOrderStatusRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_api_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use GetWarehouseRequest.ProtoReflect.Descriptor instead.
+func (*GetWarehouseRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *UpdateOrderStatusRequest) GetId() string {
+func (x *GetWarehouseRequest) GetId() int64 {
 	if x != nil {
 		return x.Id
 	}
+	return 0
+}
+
+// Подписка на события всех видимых вызывающему заказов
+type WatchOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                      // пусто — заказы всех пользователей
+	Statuses      []OrderStatus          `protobuf:"varint,2,rep,packed,name=statuses,proto3,enum=proto.OrderStatus" json:"statuses,omitempty"` // только переходы в эти статусы; пусто — все
+	AfterSeq      uint64                 `protobuf:"varint,3,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`               // > 0 — продолжить после события с этим номером, 0 — только новые события
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrdersRequest) Reset() {
+	*x = WatchOrdersRequest{}
+	mi := &file_pkg_proto_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrdersRequest) ProtoMessage() {}
+
+func (x *WatchOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrdersRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *WatchOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
 	return ""
 }
 
-func (x *UpdateOrderStatusRequest) GetStatus() OrderStatus {
+func (x *WatchOrdersRequest) GetStatuses() []OrderStatus {
 	if x != nil {
-		return x.Status
+		return x.Statuses
 	}
-	return OrderStatus_ORDER_CREATED
+	return nil
+}
+
+func (x *WatchOrdersRequest) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+// Событие жизненного цикла заказа
+type OrderEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`  // сквозной номер события; передаётся в after_seq при переподключении
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // order.created, order.confirmed, order.delivered, order.cancelled или order.snapshot
+	At            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Order         *Order                 `protobuf:"bytes,4,opt,name=order,proto3" json:"order,omitempty"` // заказ после изменения
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_pkg_proto_api_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_api_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_api_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *OrderEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
 }
 
 var File_pkg_proto_api_proto protoreflect.FileDescriptor
 
 const file_pkg_proto_api_proto_rawDesc = "" +
 	"\n" +
-	"\x13pkg/proto/api.proto\x12\x05proto\x1a\x1bgoogle/protobuf/empty.proto\"*\n" +
+	"\x13pkg/proto/api.proto\x12\x05proto\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\x96\x01\n" +
+	"\aAddress\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x18\n" +
+	"\acountry\x18\x03 \x01(\tR\acountry\x12\x12\n" +
+	"\x04city\x18\x04 \x01(\tR\x04city\x12\x16\n" +
+	"\x06street\x18\x05 \x01(\tR\x06street\x12\x1f\n" +
+	"\vpostal_code\x18\x06 \x01(\tR\n" +
+	"postalCode\"\xd6\x02\n" +
 	"\x04User\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\"'\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12,\n" +
+	"\taddresses\x18\x05 \x03(\v2\x0e.proto.AddressR\taddresses\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12!\n" +
+	"\aversion\x18\b \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\aversion\x127\n" +
+	"\terased_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\berasedAt\"\x81\x01\n" +
 	"\x11CreateUserRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"5\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x03 \x01(\tR\x05phone\x12,\n" +
+	"\taddresses\x18\x04 \x03(\v2\x0e.proto.AddressR\taddresses\"5\n" +
 	"\x12CreateUserResponse\x12\x1f\n" +
 	"\x04user\x18\x01 \x01(\v2\v.proto.UserR\x04user\" \n" +
 	"\x0eGetUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"6\n" +
 	"\x11ListUsersResponse\x12!\n" +
-	"\x05users\x18\x01 \x03(\v2\v.proto.UserR\x05users\"7\n" +
+	"\x05users\x18\x01 \x03(\v2\v.proto.UserR\x05users\"\xc5\x01\n" +
 	"\x11UpdateUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\"#\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12,\n" +
+	"\taddresses\x18\x05 \x03(\v2\x0e.proto.AddressR\taddresses\x122\n" +
+	"\x10expected_version\x18\x06 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x0fexpectedVersion\"#\n" +
 	"\x11DeleteUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\\\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xd5\x02\n" +
 	"\x05Order\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12*\n" +
-	"\x06status\x18\x03 \x01(\x0e2\x12.proto.OrderStatusR\x06status\"-\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x12.proto.OrderStatusR\x06status\x129\n" +
+	"\x10shipping_address\x18\x04 \x01(\v2\x0e.proto.AddressR\x0fshippingAddress\x12!\n" +
+	"\aversion\x18\x05 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12#\n" +
+	"\rcancel_reason\x18\a \x01(\tR\fcancelReason\x129\n" +
+	"\n" +
+	"deleted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"\x89\x01\n" +
+	"\bDelivery\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x02id\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aOrderId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06UserId\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aAddress\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\x05R\x06status\"W\n" +
+	"\tWarehouse\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x02id\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\x05R\x06status\"\xaa\x01\n" +
+	"\n" +
+	"AuditEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x18\n" +
+	"\asubject\x18\x05 \x01(\tR\asubject\x12\x18\n" +
+	"\adetails\x18\x06 \x01(\tR\adetails\"\xee\x01\n" +
+	"\n" +
+	"UserExport\x12;\n" +
+	"\vexported_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"exportedAt\x12\x1f\n" +
+	"\x04user\x18\x02 \x01(\v2\v.proto.UserR\x04user\x12$\n" +
+	"\x06orders\x18\x03 \x03(\v2\f.proto.OrderR\x06orders\x12/\n" +
+	"\n" +
+	"deliveries\x18\x04 \x03(\v2\x0f.proto.DeliveryR\n" +
+	"deliveries\x12+\n" +
+	"\ahistory\x18\x05 \x03(\v2\x11.proto.AuditEntryR\ahistory\"L\n" +
 	"\x12CreateOrderRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"9\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x02 \x01(\tR\taddressId\"9\n" +
 	"\x13CreateOrderResponse\x12\"\n" +
-	"\x05order\x18\x01 \x01(\v2\f.proto.OrderR\x05order\"!\n" +
+	"\x05order\x18\x01 \x01(\v2\f.proto.OrderR\x05order\"-\n" +
 	"\x0fGetOrderRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"$\n" +
+	"\x02id\x18\x01 \x01(\tR\x02idJ\x04\b\x02\x10\x03J\x04\b\x03\x10\x04\"X\n" +
+	"\x12OrderActionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x122\n" +
+	"\x10expected_version\x18\x02 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x0fexpectedVersion\"F\n" +
+	"\x11WatchOrderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tafter_seq\x18\x03 \x01(\x04R\bafterSeqJ\x04\b\x02\x10\x03\"\xec\x01\n" +
+	"\x11ListOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12/\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x12.proto.OrderStatusH\x00R\x06status\x88\x01\x01\x12?\n" +
+	"\rcreated_after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedAfter\x12A\n" +
+	"\x0ecreated_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\rcreatedBeforeB\t\n" +
+	"\a_status\"$\n" +
 	"\x12DeleteOrderRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\":\n" +
 	"\x12ListOrdersResponse\x12$\n" +
-	"\x06orders\x18\x01 \x03(\v2\f.proto.OrderR\x06orders\"V\n" +
+	"\x06orders\x18\x01 \x03(\v2\f.proto.OrderR\x06orders\"\x8a\x01\n" +
 	"\x18UpdateOrderStatusRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
-	"\x06status\x18\x02 \x01(\x0e2\x12.proto.OrderStatusR\x06status*_\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x12.proto.OrderStatusR\x06status\x122\n" +
+	"\x10expected_version\x18\x03 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x0fexpectedVersion\"K\n" +
+	"\x15ListDeliveriesRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"I\n" +
+	"\x16ListDeliveriesResponse\x12/\n" +
+	"\n" +
+	"deliveries\x18\x01 \x03(\v2\x0f.proto.DeliveryR\n" +
+	"deliveries\"-\n" +
+	"\x12GetDeliveryRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x02id\"2\n" +
+	"\x15ListWarehousesRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"J\n" +
+	"\x16ListWarehousesResponse\x120\n" +
+	"\n" +
+	"warehouses\x18\x01 \x03(\v2\x10.proto.WarehouseR\n" +
+	"warehouses\".\n" +
+	"\x13GetWarehouseRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\x92A\x04\x9a\x02\x01\x03R\x02id\"z\n" +
+	"\x12WatchOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
+	"\bstatuses\x18\x02 \x03(\x0e2\x12.proto.OrderStatusR\bstatuses\x12\x1b\n" +
+	"\tafter_seq\x18\x03 \x01(\x04R\bafterSeq\"\x8b\x01\n" +
+	"\n" +
+	"OrderEvent\x12\x19\n" +
+	"\x03seq\x18\x01 \x01(\x04B\a\x92A\x04\x9a\x02\x01\x03R\x03seq\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\"\n" +
+	"\x05order\x18\x04 \x01(\v2\f.proto.OrderR\x05order*_\n" +
 	"\vOrderStatus\x12\x11\n" +
 	"\rORDER_CREATED\x10\x00\x12\x13\n" +
 	"\x0fORDER_CONFIRMED\x10\x01\x12\x13\n" +
 	"\x0fORDER_DELIVERED\x10\x02\x12\x13\n" +
-	"\x0fORDER_CANCELLED\x10\x032\xb3\x02\n" +
-	"\vUserService\x12A\n" +
+	"\x0fORDER_CANCELLED\x10\x032\xd6\f\n" +
+	"\vUserService\x12\xa8\x02\n" +
+	"\n" +
+	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\"\xe4\x01\x92A\xc5\x01JE\n" +
+	"\x03201\x12>\n" +
+	"+Созданный пользователь\x12\x0f\n" +
+	"\r\x1a\v.proto.UserJZ\n" +
+	"\x03400\x12S\n" +
+	"QНеверный JSON или некорректные данные профиляJ \n" +
+	"\x03409\x12\x19\n" +
+	"\x17Email уже занят\x82\xd3\xe4\x93\x02\x15:\x01*b\x04user\"\n" +
+	"/api/users\x12\xc7\x01\n" +
+	"\aGetUser\x12\x15.proto.GetUserRequest\x1a\v.proto.User\"\x97\x01\x92A}JF\n" +
+	"\x03200\x12?\x1a=\n" +
+	"\x04ETag\x125\n" +
+	"+Версия профиля для If-Match\x12\x06stringJ3\n" +
+	"\x03404\x12,\n" +
+	"*Пользователь не найден\x82\xd3\xe4\x93\x02\x11\x12\x0f/api/users/{id}\x12X\n" +
+	"\tListUsers\x12\x16.google.protobuf.Empty\x1a\x18.proto.ListUsersResponse\"\x19\x82\xd3\xe4\x93\x02\x13b\x05users\x12\n" +
+	"/api/users\x12\xe4\x03\n" +
+	"\n" +
+	"UpdateUser\x12\x18.proto.UpdateUserRequest\x1a\v.proto.User\"\xae\x03\x92A\x90\x03JA\n" +
+	"\x03200\x12:\x1a8\n" +
+	"\x04ETag\x120\n" +
+	"&Новая версия профиля\x12\x06stringJZ\n" +
+	"\x03400\x12S\n" +
+	"QНеверный JSON или некорректные данные профиляJ3\n" +
+	"\x03404\x12,\n" +
+	"*Пользователь не найденJ \n" +
+	"\x03409\x12\x19\n" +
+	"\x17Email уже занятJI\n" +
+	"\x03412\x12B\n" +
+	"@Профиль изменился с момента чтенияrM\n" +
+	"K\n" +
+	"\bIf-Match\x12=ETag профиля, полученный при чтении\x18\x01\x82\xd3\xe4\x93\x02\x14:\x01*\x1a\x0f/api/users/{id}\x12\xbf\x01\n" +
+	"\n" +
+	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x16.google.protobuf.Empty\"\x7f\x92AeJ.\n" +
+	"\x03204\x12'\n" +
+	"%Пользователь удалёнJ3\n" +
+	"\x03404\x12,\n" +
+	"*Пользователь не найден\x82\xd3\xe4\x93\x02\x11*\x0f/api/users/{id}\x12\x8e\x01\n" +
+	"\n" +
+	"ExportUser\x12\x15.proto.GetUserRequest\x1a\x11.proto.UserExport\"V\x92A5J3\n" +
+	"\x03404\x12,\n" +
+	"*Пользователь не найден\x82\xd3\xe4\x93\x02\x18\x12\x16/api/users/{id}/export\x12\x86\x01\n" +
+	"\tEraseUser\x12\x15.proto.GetUserRequest\x1a\v.proto.User\"U\x92A5J3\n" +
+	"\x03404\x12,\n" +
+	"*Пользователь не найден\x82\xd3\xe4\x93\x02\x17\"\x15/api/users/{id}/erase\x1a5\x92A2\n" +
+	"\x05Users\x12)Профили пользователей2\xb8\x16\n" +
+	"\fOrderService\x12\xa1\x02\n" +
+	"\vCreateOrder\x12\x19.proto.CreateOrderRequest\x1a\x1a.proto.CreateOrderResponse\"\xda\x01\x92A\xb9\x01J8\n" +
+	"\x03201\x121\n" +
+	"\x1dСозданный заказ\x12\x10\n" +
+	"\x0e\x1a\f.proto.OrderJ}\n" +
+	"\x03400\x12v\n" +
+	"tНеверный JSON, не указан user_id или у пользователя нет такого адреса\x82\xd3\xe4\x93\x02\x17:\x01*b\x05order\"\v/api/orders\x12\x89\x01\n" +
+	"\n" +
+	"ListOrders\x12\x18.proto.ListOrdersRequest\x1a\x19.proto.ListOrdersResponse\"F\x92A(J&\n" +
+	"\x03400\x12\x1f\n" +
+	"\x1dНеверный фильтр\x82\xd3\xe4\x93\x02\x15b\x06orders\x12\v/api/orders\x12\xbb\x01\n" +
+	"\bGetOrder\x12\x16.proto.GetOrderRequest\x1a\f.proto.Order\"\x88\x01\x92AmJD\n" +
+	"\x03200\x12=\x1a;\n" +
+	"\x04ETag\x123\n" +
+	")Версия заказа для If-Match\x12\x06stringJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найден\x82\xd3\xe4\x93\x02\x12\x12\x10/api/orders/{id}\x12\xa6\x01\n" +
+	"\vDeleteOrder\x12\x19.proto.DeleteOrderRequest\x1a\x16.google.protobuf.Empty\"d\x92AIJ \n" +
+	"\x03204\x12\x19\n" +
+	"\x17Заказ удалёнJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найден\x82\xd3\xe4\x93\x02\x12*\x10/api/orders/{id}\x12\xbc\x03\n" +
+	"\fConfirmOrder\x12\x19.proto.OrderActionRequest\x1a\f.proto.Order\"\x82\x03\x92A\xde\x02J?\n" +
+	"\x03200\x128\x1a6\n" +
+	"\x04ETag\x12.\n" +
+	"$Новая версия заказа\x12\x06stringJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найденJ9\n" +
+	"\x03409\x122\n" +
+	"0Заказ не в статусе \"создан\"JE\n" +
+	"\x03412\x12>\n" +
+	"<Заказ изменился с момента чтенияrr\n" +
+	"p\n" +
+	"\bIf-Match\x12bETag заказа: смена статуса, только если заказ не менялся\x18\x01\x82\xd3\xe4\x93\x02\x1a\"\x18/api/orders/{id}/confirm\x12\xc6\x03\n" +
+	"\fDeliverOrder\x12\x19.proto.OrderActionRequest\x1a\f.proto.Order\"\x8c\x03\x92A\xe8\x02J?\n" +
+	"\x03200\x128\x1a6\n" +
+	"\x04ETag\x12.\n" +
+	"$Новая версия заказа\x12\x06stringJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найденJC\n" +
+	"\x03409\x12<\n" +
+	":Заказ не в статусе \"подтверждён\"JE\n" +
+	"\x03412\x12>\n" +
+	"<Заказ изменился с момента чтенияrr\n" +
+	"p\n" +
+	"\bIf-Match\x12bETag заказа: смена статуса, только если заказ не менялся\x18\x01\x82\xd3\xe4\x93\x02\x1a\"\x18/api/orders/{id}/deliver\x12\xb1\x03\n" +
+	"\vCancelOrder\x12\x19.proto.OrderActionRequest\x1a\x16.google.protobuf.Empty\"\xee\x02\x92A\xcb\x02J\"\n" +
+	"\x03204\x12\x1b\n" +
+	"\x19Заказ отменёнJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найденJC\n" +
+	"\x03409\x12<\n" +
+	":Заказ уже доставлен или отменёнJE\n" +
+	"\x03412\x12>\n" +
+	"<Заказ изменился с момента чтенияrr\n" +
+	"p\n" +
+	"\bIf-Match\x12bETag заказа: смена статуса, только если заказ не менялся\x18\x01\x82\xd3\xe4\x93\x02\x19\"\x17/api/orders/{id}/cancel\x12\x99\x04\n" +
+	"\x11UpdateOrderStatus\x12\x1f.proto.UpdateOrderStatusRequest\x1a\f.proto.Order\"\xd4\x03\x92A\xae\x03J?\n" +
+	"\x03200\x128\x1a6\n" +
+	"\x04ETag\x12.\n" +
+	"$Новая версия заказа\x12\x06stringJ2\n" +
+	"\x03400\x12+\n" +
+	")Неверный JSON или статусJ%\n" +
+	"\x03404\x12\x1e\n" +
+	"\x1cЗаказ не найденJU\n" +
+	"\x03409\x12N\n" +
+	"LИз текущего статуса в этот перейти нельзяJE\n" +
+	"\x03412\x12>\n" +
+	"<Заказ изменился с момента чтенияrr\n" +
+	"p\n" +
+	"\bIf-Match\x12bETag заказа: смена статуса, только если заказ не менялся\x18\x01\x82\xd3\xe4\x93\x02\x1c:\x01*\x1a\x17/api/orders/{id}/status\x12;\n" +
+	"\n" +
+	"WatchOrder\x12\x18.proto.WatchOrderRequest\x1a\x11.proto.OrderEvent0\x01\x12=\n" +
+	"\vWatchOrders\x12\x19.proto.WatchOrdersRequest\x1a\x11.proto.OrderEvent0\x01\x1a=\x92A:\n" +
+	"\x06Orders\x120Заказы и смена их статусов2\xe0\x02\n" +
+	"\x0fDeliveryService\x12r\n" +
+	"\x0eListDeliveries\x12\x1c.proto.ListDeliveriesRequest\x1a\x1d.proto.ListDeliveriesResponse\"#\x82\xd3\xe4\x93\x02\x1db\n" +
+	"deliveries\x12\x0f/api/deliveries\x12\xa6\x01\n" +
+	"\vGetDelivery\x12\x19.proto.GetDeliveryRequest\x1a\x0f.proto.Delivery\"k\x92ALJ\x1b\n" +
+	"\x03400\x12\x14\n" +
+	"\x12ID не числоJ-\n" +
+	"\x03404\x12&\n" +
+	"$Доставка не найдена\x82\xd3\xe4\x93\x02\x16\x12\x14/api/deliveries/{id}\x1a0\x92A-\n" +
+	"\n" +
+	"Deliveries\x12\x1fДоставки заказов2\x87\x03\n" +
+	"\x10WarehouseService\x12r\n" +
+	"\x0eListWarehouses\x12\x1c.proto.ListWarehousesRequest\x1a\x1d.proto.ListWarehousesResponse\"#\x82\xd3\xe4\x93\x02\x1db\n" +
+	"warehouses\x12\x0f/api/warehouses\x12\xb8\x01\n" +
+	"\fGetWarehouse\x12\x1a.proto.GetWarehouseRequest\x1a\x10.proto.Warehouse\"z\x92A[J\x1b\n" +
+	"\x03400\x12\x14\n" +
+	"\x12ID не числоJ<\n" +
+	"\x03404\x125\n" +
+	"3Складская запись не найдена\x82\xd3\xe4\x93\x02\x16\x12\x14/api/warehouses/{id}\x1aD\x92AA\n" +
+	"\n" +
+	"Warehouses\x123Складские записи по заказамB\xa8\x03\x92A\x93\x03RC\n" +
+	"\x03401\x12<\n" +
+	":Нет или неверные учётные данныеR*\n" +
+	"\x03403\x12#\n" +
+	"!Недостаточно правRF\n" +
+	"\x03429\x12?\n" +
+	"=Слишком много запросов, см. Retry-AfterZ\xb3\x01\n" +
+	"f\n" +
 	"\n" +
-	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\x12-\n" +
-	"\aGetUser\x12\x15.proto.GetUserRequest\x1a\v.proto.User\x12=\n" +
-	"\tListUsers\x12\x16.google.protobuf.Empty\x1a\x18.proto.ListUsersResponse\x123\n" +
+	"APIKeyAuth\x12X\b\x02\x12GAPI-ключ сервиса (выпускается в /api/admin/keys)\x1a\tX-API-Key \x02\n" +
+	"I\n" +
 	"\n" +
-	"UpdateUser\x12\x18.proto.UpdateUserRequest\x1a\v.proto.User\x12>\n" +
+	"BearerAuth\x12;\b\x02\x12&JWT в формате \"Bearer <token>\"\x1a\rAuthorization \x02b\x10\n" +
+	"\x0e\n" +
 	"\n" +
-	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x16.google.protobuf.Empty2\xb4\x03\n" +
-	"\fOrderService\x12D\n" +
-	"\vCreateOrder\x12\x19.proto.CreateOrderRequest\x1a\x1a.proto.CreateOrderResponse\x12?\n" +
+	"BearerAuth\x12\x00b\x10\n" +
+	"\x0e\n" +
 	"\n" +
-	"ListOrders\x12\x16.google.protobuf.Empty\x1a\x19.proto.ListOrdersResponse\x120\n" +
-	"\bGetOrder\x12\x16.proto.GetOrderRequest\x1a\f.proto.Order\x12@\n" +
-	"\vDeleteOrder\x12\x19.proto.DeleteOrderRequest\x1a\x16.google.protobuf.Empty\x124\n" +
-	"\fConfirmOrder\x12\x16.proto.GetOrderRequest\x1a\f.proto.Order\x124\n" +
-	"\fDeliverOrder\x12\x16.proto.GetOrderRequest\x1a\f.proto.Order\x12=\n" +
-	"\vCancelOrder\x12\x16.proto.GetOrderRequest\x1a\x16.google.protobuf.EmptyB\x11Z\x0fpkg/proto;protob\x06proto3"
+	"APIKeyAuth\x12\x00Z\x0fpkg/proto;protob\x06proto3"
 
 var (
 	file_pkg_proto_api_proto_rawDescOnce sync.Once
@@ -819,61 +2202,119 @@ func file_pkg_proto_api_proto_rawDescGZIP() []byte {
 }
 
 var file_pkg_proto_api_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pkg_proto_api_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_pkg_proto_api_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_pkg_proto_api_proto_goTypes = []any{
 	(OrderStatus)(0),                 // 0: proto.OrderStatus
-	(*User)(nil),                     // 1: proto.User
-	(*CreateUserRequest)(nil),        // 2: proto.CreateUserRequest
-	(*CreateUserResponse)(nil),       // 3: proto.CreateUserResponse
-	(*GetUserRequest)(nil),           // 4: proto.GetUserRequest
-	(*ListUsersResponse)(nil),        // 5: proto.ListUsersResponse
-	(*UpdateUserRequest)(nil),        // 6: proto.UpdateUserRequest
-	(*DeleteUserRequest)(nil),        // 7: proto.DeleteUserRequest
-	(*Order)(nil),                    // 8: proto.Order
-	(*CreateOrderRequest)(nil),       // 9: proto.CreateOrderRequest
-	(*CreateOrderResponse)(nil),      // 10: proto.CreateOrderResponse
-	(*GetOrderRequest)(nil),          // 11: proto.GetOrderRequest
-	(*DeleteOrderRequest)(nil),       // 12: proto.DeleteOrderRequest
-	(*ListOrdersResponse)(nil),       // 13: proto.ListOrdersResponse
-	(*UpdateOrderStatusRequest)(nil), // 14: proto.UpdateOrderStatusRequest
-	(*emptypb.Empty)(nil),            // 15: google.protobuf.Empty
+	(*Address)(nil),                  // 1: proto.Address
+	(*User)(nil),                     // 2: proto.User
+	(*CreateUserRequest)(nil),        // 3: proto.CreateUserRequest
+	(*CreateUserResponse)(nil),       // 4: proto.CreateUserResponse
+	(*GetUserRequest)(nil),           // 5: proto.GetUserRequest
+	(*ListUsersResponse)(nil),        // 6: proto.ListUsersResponse
+	(*UpdateUserRequest)(nil),        // 7: proto.UpdateUserRequest
+	(*DeleteUserRequest)(nil),        // 8: proto.DeleteUserRequest
+	(*Order)(nil),                    // 9: proto.Order
+	(*Delivery)(nil),                 // 10: proto.Delivery
+	(*Warehouse)(nil),                // 11: proto.Warehouse
+	(*AuditEntry)(nil),               // 12: proto.AuditEntry
+	(*UserExport)(nil),               // 13: proto.UserExport
+	(*CreateOrderRequest)(nil),       // 14: proto.CreateOrderRequest
+	(*CreateOrderResponse)(nil),      // 15: proto.CreateOrderResponse
+	(*GetOrderRequest)(nil),          // 16: proto.GetOrderRequest
+	(*OrderActionRequest)(nil),       // 17: proto.OrderActionRequest
+	(*WatchOrderRequest)(nil),        // 18: proto.WatchOrderRequest
+	(*ListOrdersRequest)(nil),        // 19: proto.ListOrdersRequest
+	(*DeleteOrderRequest)(nil),       // 20: proto.DeleteOrderRequest
+	(*ListOrdersResponse)(nil),       // 21: proto.ListOrdersResponse
+	(*UpdateOrderStatusRequest)(nil), // 22: proto.UpdateOrderStatusRequest
+	(*ListDeliveriesRequest)(nil),    // 23: proto.ListDeliveriesRequest
+	(*ListDeliveriesResponse)(nil),   // 24: proto.ListDeliveriesResponse
+	(*GetDeliveryRequest)(nil),       // 25: proto.GetDeliveryRequest
+	(*ListWarehousesRequest)(nil),    // 26: proto.ListWarehousesRequest
+	(*ListWarehousesResponse)(nil),   // 27: proto.ListWarehousesResponse
+	(*GetWarehouseRequest)(nil),      // 28: proto.GetWarehouseRequest
+	(*WatchOrdersRequest)(nil),       // 29: proto.WatchOrdersRequest
+	(*OrderEvent)(nil),               // 30: proto.OrderEvent
+	(*timestamppb.Timestamp)(nil),    // 31: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),            // 32: google.protobuf.Empty
 }
 var file_pkg_proto_api_proto_depIdxs = []int32{
-	1,  // 0: proto.CreateUserResponse.user:type_name -> proto.User
-	1,  // 1: proto.ListUsersResponse.users:type_name -> proto.User
-	0,  // 2: proto.Order.status:type_name -> proto.OrderStatus
-	8,  // 3: proto.CreateOrderResponse.order:type_name -> proto.Order
-	8,  // 4: proto.ListOrdersResponse.orders:type_name -> proto.Order
-	0,  // 5: proto.UpdateOrderStatusRequest.status:type_name -> proto.OrderStatus
-	2,  // 6: proto.UserService.CreateUser:input_type -> proto.CreateUserRequest
-	4,  // 7: proto.UserService.GetUser:input_type -> proto.GetUserRequest
-	15, // 8: proto.UserService.ListUsers:input_type -> google.protobuf.Empty
-	6,  // 9: proto.UserService.UpdateUser:input_type -> proto.UpdateUserRequest
-	7,  // 10: proto.UserService.DeleteUser:input_type -> proto.DeleteUserRequest
-	9,  // 11: proto.OrderService.CreateOrder:input_type -> proto.CreateOrderRequest
-	15, // 12: proto.OrderService.ListOrders:input_type -> google.protobuf.Empty
-	11, // 13: proto.OrderService.GetOrder:input_type -> proto.GetOrderRequest
-	12, // 14: proto.OrderService.DeleteOrder:input_type -> proto.DeleteOrderRequest
-	11, // 15: proto.OrderService.ConfirmOrder:input_type -> proto.GetOrderRequest
-	11, // 16: proto.OrderService.DeliverOrder:input_type -> proto.GetOrderRequest
-	11, // 17: proto.OrderService.CancelOrder:input_type -> proto.GetOrderRequest
-	3,  // 18: proto.UserService.CreateUser:output_type -> proto.CreateUserResponse
-	1,  // 19: proto.UserService.GetUser:output_type -> proto.User
-	5,  // 20: proto.UserService.ListUsers:output_type -> proto.ListUsersResponse
-	1,  // 21: proto.UserService.UpdateUser:output_type -> proto.User
-	15, // 22: proto.UserService.DeleteUser:output_type -> google.protobuf.Empty
-	10, // 23: proto.OrderService.CreateOrder:output_type -> proto.CreateOrderResponse
-	13, // 24: proto.OrderService.ListOrders:output_type -> proto.ListOrdersResponse
-	8,  // 25: proto.OrderService.GetOrder:output_type -> proto.Order
-	15, // 26: proto.OrderService.DeleteOrder:output_type -> google.protobuf.Empty
-	8,  // 27: proto.OrderService.ConfirmOrder:output_type -> proto.Order
-	8,  // 28: proto.OrderService.DeliverOrder:output_type -> proto.Order
-	15, // 29: proto.OrderService.CancelOrder:output_type -> google.protobuf.Empty
-	18, // [18:30] is the sub-list for method output_type
-	6,  // [6:18] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	1,  // 0: proto.User.addresses:type_name -> proto.Address
+	31, // 1: proto.User.created_at:type_name -> google.protobuf.Timestamp
+	31, // 2: proto.User.updated_at:type_name -> google.protobuf.Timestamp
+	31, // 3: proto.User.erased_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: proto.CreateUserRequest.addresses:type_name -> proto.Address
+	2,  // 5: proto.CreateUserResponse.user:type_name -> proto.User
+	2,  // 6: proto.ListUsersResponse.users:type_name -> proto.User
+	1,  // 7: proto.UpdateUserRequest.addresses:type_name -> proto.Address
+	0,  // 8: proto.Order.status:type_name -> proto.OrderStatus
+	1,  // 9: proto.Order.shipping_address:type_name -> proto.Address
+	31, // 10: proto.Order.created_at:type_name -> google.protobuf.Timestamp
+	31, // 11: proto.Order.deleted_at:type_name -> google.protobuf.Timestamp
+	31, // 12: proto.AuditEntry.at:type_name -> google.protobuf.Timestamp
+	31, // 13: proto.UserExport.exported_at:type_name -> google.protobuf.Timestamp
+	2,  // 14: proto.UserExport.user:type_name -> proto.User
+	9,  // 15: proto.UserExport.orders:type_name -> proto.Order
+	10, // 16: proto.UserExport.deliveries:type_name -> proto.Delivery
+	12, // 17: proto.UserExport.history:type_name -> proto.AuditEntry
+	9,  // 18: proto.CreateOrderResponse.order:type_name -> proto.Order
+	0,  // 19: proto.ListOrdersRequest.status:type_name -> proto.OrderStatus
+	31, // 20: proto.ListOrdersRequest.created_after:type_name -> google.protobuf.Timestamp
+	31, // 21: proto.ListOrdersRequest.created_before:type_name -> google.protobuf.Timestamp
+	9,  // 22: proto.ListOrdersResponse.orders:type_name -> proto.Order
+	0,  // 23: proto.UpdateOrderStatusRequest.status:type_name -> proto.OrderStatus
+	10, // 24: proto.ListDeliveriesResponse.deliveries:type_name -> proto.Delivery
+	11, // 25: proto.ListWarehousesResponse.warehouses:type_name -> proto.Warehouse
+	0,  // 26: proto.WatchOrdersRequest.statuses:type_name -> proto.OrderStatus
+	31, // 27: proto.OrderEvent.at:type_name -> google.protobuf.Timestamp
+	9,  // 28: proto.OrderEvent.order:type_name -> proto.Order
+	3,  // 29: proto.UserService.CreateUser:input_type -> proto.CreateUserRequest
+	5,  // 30: proto.UserService.GetUser:input_type -> proto.GetUserRequest
+	32, // 31: proto.UserService.ListUsers:input_type -> google.protobuf.Empty
+	7,  // 32: proto.UserService.UpdateUser:input_type -> proto.UpdateUserRequest
+	8,  // 33: proto.UserService.DeleteUser:input_type -> proto.DeleteUserRequest
+	5,  // 34: proto.UserService.ExportUser:input_type -> proto.GetUserRequest
+	5,  // 35: proto.UserService.EraseUser:input_type -> proto.GetUserRequest
+	14, // 36: proto.OrderService.CreateOrder:input_type -> proto.CreateOrderRequest
+	19, // 37: proto.OrderService.ListOrders:input_type -> proto.ListOrdersRequest
+	16, // 38: proto.OrderService.GetOrder:input_type -> proto.GetOrderRequest
+	20, // 39: proto.OrderService.DeleteOrder:input_type -> proto.DeleteOrderRequest
+	17, // 40: proto.OrderService.ConfirmOrder:input_type -> proto.OrderActionRequest
+	17, // 41: proto.OrderService.DeliverOrder:input_type -> proto.OrderActionRequest
+	17, // 42: proto.OrderService.CancelOrder:input_type -> proto.OrderActionRequest
+	22, // 43: proto.OrderService.UpdateOrderStatus:input_type -> proto.UpdateOrderStatusRequest
+	18, // 44: proto.OrderService.WatchOrder:input_type -> proto.WatchOrderRequest
+	29, // 45: proto.OrderService.WatchOrders:input_type -> proto.WatchOrdersRequest
+	23, // 46: proto.DeliveryService.ListDeliveries:input_type -> proto.ListDeliveriesRequest
+	25, // 47: proto.DeliveryService.GetDelivery:input_type -> proto.GetDeliveryRequest
+	26, // 48: proto.WarehouseService.ListWarehouses:input_type -> proto.ListWarehousesRequest
+	28, // 49: proto.WarehouseService.GetWarehouse:input_type -> proto.GetWarehouseRequest
+	4,  // 50: proto.UserService.CreateUser:output_type -> proto.CreateUserResponse
+	2,  // 51: proto.UserService.GetUser:output_type -> proto.User
+	6,  // 52: proto.UserService.ListUsers:output_type -> proto.ListUsersResponse
+	2,  // 53: proto.UserService.UpdateUser:output_type -> proto.User
+	32, // 54: proto.UserService.DeleteUser:output_type -> google.protobuf.Empty
+	13, // 55: proto.UserService.ExportUser:output_type -> proto.UserExport
+	2,  // 56: proto.UserService.EraseUser:output_type -> proto.User
+	15, // 57: proto.OrderService.CreateOrder:output_type -> proto.CreateOrderResponse
+	21, // 58: proto.OrderService.ListOrders:output_type -> proto.ListOrdersResponse
+	9,  // 59: proto.OrderService.GetOrder:output_type -> proto.Order
+	32, // 60: proto.OrderService.DeleteOrder:output_type -> google.protobuf.Empty
+	9,  // 61: proto.OrderService.ConfirmOrder:output_type -> proto.Order
+	9,  // 62: proto.OrderService.DeliverOrder:output_type -> proto.Order
+	32, // 63: proto.OrderService.CancelOrder:output_type -> google.protobuf.Empty
+	9,  // 64: proto.OrderService.UpdateOrderStatus:output_type -> proto.Order
+	30, // 65: proto.OrderService.WatchOrder:output_type -> proto.OrderEvent
+	30, // 66: proto.OrderService.WatchOrders:output_type -> proto.OrderEvent
+	24, // 67: proto.DeliveryService.ListDeliveries:output_type -> proto.ListDeliveriesResponse
+	10, // 68: proto.DeliveryService.GetDelivery:output_type -> proto.Delivery
+	27, // 69: proto.WarehouseService.ListWarehouses:output_type -> proto.ListWarehousesResponse
+	11, // 70: proto.WarehouseService.GetWarehouse:output_type -> proto.Warehouse
+	50, // [50:71] is the sub-list for method output_type
+	29, // [29:50] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_pkg_proto_api_proto_init() }
@@ -881,15 +2322,16 @@ func file_pkg_proto_api_proto_init() {
 	if File_pkg_proto_api_proto != nil {
 		return
 	}
+	file_pkg_proto_api_proto_msgTypes[18].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_proto_api_proto_rawDesc), len(file_pkg_proto_api_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   14,
+			NumMessages:   30,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   4,
 		},
 		GoTypes:           file_pkg_proto_api_proto_goTypes,
 		DependencyIndexes: file_pkg_proto_api_proto_depIdxs,
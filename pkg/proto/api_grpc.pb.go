@@ -25,19 +25,40 @@ const (
 	UserService_ListUsers_FullMethodName  = "/proto.UserService/ListUsers"
 	UserService_UpdateUser_FullMethodName = "/proto.UserService/UpdateUser"
 	UserService_DeleteUser_FullMethodName = "/proto.UserService/DeleteUser"
+	UserService_ExportUser_FullMethodName = "/proto.UserService/ExportUser"
+	UserService_EraseUser_FullMethodName  = "/proto.UserService/EraseUser"
 )
 
 // UserServiceClient is the client API for UserService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Определение сервиса
+// Пользователи
 type UserServiceClient interface {
+	// Создать пользователя
+	//
+	// Имя и email обязательны, email должен быть уникальным
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
+	// Получить пользователя по ID
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	// Получить список пользователей
 	ListUsers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	// Обновить пользователя
+	//
+	// Профиль заменяется целиком. С If-Match или expected_version профиль меняется, только если его версия совпадает
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*User, error)
+	// Удалить пользователя
+	//
+	// Мягко удаляет пользователя; администратор может восстановить его до окончательной очистки
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Выгрузить данные пользователя
+	//
+	// Профиль, заказы (в том числе удалённые), доставки и история из журнала аудита. Выгрузка пишется в журнал аудита
+	ExportUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserExport, error)
+	// Обезличить пользователя
+	//
+	// Личные данные профиля и адреса в заказах и доставках стираются, заказы остаются для учёта
+	EraseUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
 }
 
 type userServiceClient struct {
@@ -98,17 +119,56 @@ func (c *userServiceClient) DeleteUser(ctx context.Context, in *DeleteUserReques
 	return out, nil
 }
 
+func (c *userServiceClient) ExportUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserExport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserExport)
+	err := c.cc.Invoke(ctx, UserService_ExportUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) EraseUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_EraseUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
 //
-// Определение сервиса
+// Пользователи
 type UserServiceServer interface {
+	// Создать пользователя
+	//
+	// Имя и email обязательны, email должен быть уникальным
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
+	// Получить пользователя по ID
 	GetUser(context.Context, *GetUserRequest) (*User, error)
+	// Получить список пользователей
 	ListUsers(context.Context, *emptypb.Empty) (*ListUsersResponse, error)
+	// Обновить пользователя
+	//
+	// Профиль заменяется целиком. С If-Match или expected_version профиль меняется, только если его версия совпадает
 	UpdateUser(context.Context, *UpdateUserRequest) (*User, error)
+	// Удалить пользователя
+	//
+	// Мягко удаляет пользователя; администратор может восстановить его до окончательной очистки
 	DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error)
+	// Выгрузить данные пользователя
+	//
+	// Профиль, заказы (в том числе удалённые), доставки и история из журнала аудита. Выгрузка пишется в журнал аудита
+	ExportUser(context.Context, *GetUserRequest) (*UserExport, error)
+	// Обезличить пользователя
+	//
+	// Личные данные профиля и адреса в заказах и доставках стираются, заказы остаются для учёта
+	EraseUser(context.Context, *GetUserRequest) (*User, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -134,6 +194,12 @@ func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserReq
 func (UnimplementedUserServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUserServiceServer) ExportUser(context.Context, *GetUserRequest) (*UserExport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUser not implemented")
+}
+func (UnimplementedUserServiceServer) EraseUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUser not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -245,6 +311,42 @@ func _UserService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_ExportUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ExportUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ExportUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ExportUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_EraseUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).EraseUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_EraseUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).EraseUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -272,32 +374,72 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _UserService_DeleteUser_Handler,
 		},
+		{
+			MethodName: "ExportUser",
+			Handler:    _UserService_ExportUser_Handler,
+		},
+		{
+			MethodName: "EraseUser",
+			Handler:    _UserService_EraseUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/api.proto",
 }
 
 const (
-	OrderService_CreateOrder_FullMethodName  = "/proto.OrderService/CreateOrder"
-	OrderService_ListOrders_FullMethodName   = "/proto.OrderService/ListOrders"
-	OrderService_GetOrder_FullMethodName     = "/proto.OrderService/GetOrder"
-	OrderService_DeleteOrder_FullMethodName  = "/proto.OrderService/DeleteOrder"
-	OrderService_ConfirmOrder_FullMethodName = "/proto.OrderService/ConfirmOrder"
-	OrderService_DeliverOrder_FullMethodName = "/proto.OrderService/DeliverOrder"
-	OrderService_CancelOrder_FullMethodName  = "/proto.OrderService/CancelOrder"
+	OrderService_CreateOrder_FullMethodName       = "/proto.OrderService/CreateOrder"
+	OrderService_ListOrders_FullMethodName        = "/proto.OrderService/ListOrders"
+	OrderService_GetOrder_FullMethodName          = "/proto.OrderService/GetOrder"
+	OrderService_DeleteOrder_FullMethodName       = "/proto.OrderService/DeleteOrder"
+	OrderService_ConfirmOrder_FullMethodName      = "/proto.OrderService/ConfirmOrder"
+	OrderService_DeliverOrder_FullMethodName      = "/proto.OrderService/DeliverOrder"
+	OrderService_CancelOrder_FullMethodName       = "/proto.OrderService/CancelOrder"
+	OrderService_UpdateOrderStatus_FullMethodName = "/proto.OrderService/UpdateOrderStatus"
+	OrderService_WatchOrder_FullMethodName        = "/proto.OrderService/WatchOrder"
+	OrderService_WatchOrders_FullMethodName       = "/proto.OrderService/WatchOrders"
 )
 
 // OrderServiceClient is the client API for OrderService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Заказы
 type OrderServiceClient interface {
+	// Создать заказ
+	//
+	// Создаёт заказ пользователя; с address_id в заказ копируется сохранённый адрес
 	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*CreateOrderResponse, error)
-	ListOrders(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// Получить список заказов
+	//
+	// Возвращает заказы, подходящие под фильтры; покупатель видит только свои
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// Получить заказ по ID
 	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	// Удалить заказ
+	//
+	// Мягко удаляет заказ; администратор может восстановить его до окончательной очистки
 	DeleteOrder(ctx context.Context, in *DeleteOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	ConfirmOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
-	DeliverOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
-	CancelOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Подтвердить заказ
+	//
+	// Склад переводит заказ из статуса "создан" в "подтверждён". Старый путь /api/orders/confirm/{id} оставлен для совместимости
+	ConfirmOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*Order, error)
+	// Отметить заказ доставленным
+	//
+	// Курьер переводит заказ из статуса "подтверждён" в "доставлен". Старый путь /api/orders/delivery/{id} оставлен для совместимости
+	DeliverOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*Order, error)
+	// Отменить заказ
+	//
+	// Отменяет заказ в статусе "создан" или "подтверждён". Старый путь /api/orders/cancel/{id} оставлен для совместимости
+	CancelOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Сменить статус заказа
+	//
+	// Общая форма подтверждения, доставки и отмены: те же переходы и те же права. Вернуть заказ в статус "создан" нельзя
+	UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusRequest, opts ...grpc.CallOption) (*Order, error)
+	// Текущее состояние и дальнейшие смены статуса заказа. В REST — поток SSE /api/orders/{id}/events
+	WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
+	// Смены статусов заказов по фильтру. В REST — /api/users/{id}/orders/events и WebSocket /api/orders/ws
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
 }
 
 type orderServiceClient struct {
@@ -318,7 +460,7 @@ func (c *orderServiceClient) CreateOrder(ctx context.Context, in *CreateOrderReq
 	return out, nil
 }
 
-func (c *orderServiceClient) ListOrders(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+func (c *orderServiceClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListOrdersResponse)
 	err := c.cc.Invoke(ctx, OrderService_ListOrders_FullMethodName, in, out, cOpts...)
@@ -348,7 +490,7 @@ func (c *orderServiceClient) DeleteOrder(ctx context.Context, in *DeleteOrderReq
 	return out, nil
 }
 
-func (c *orderServiceClient) ConfirmOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+func (c *orderServiceClient) ConfirmOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*Order, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Order)
 	err := c.cc.Invoke(ctx, OrderService_ConfirmOrder_FullMethodName, in, out, cOpts...)
@@ -358,7 +500,7 @@ func (c *orderServiceClient) ConfirmOrder(ctx context.Context, in *GetOrderReque
 	return out, nil
 }
 
-func (c *orderServiceClient) DeliverOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+func (c *orderServiceClient) DeliverOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*Order, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Order)
 	err := c.cc.Invoke(ctx, OrderService_DeliverOrder_FullMethodName, in, out, cOpts...)
@@ -368,7 +510,7 @@ func (c *orderServiceClient) DeliverOrder(ctx context.Context, in *GetOrderReque
 	return out, nil
 }
 
-func (c *orderServiceClient) CancelOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+func (c *orderServiceClient) CancelOrder(ctx context.Context, in *OrderActionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, OrderService_CancelOrder_FullMethodName, in, out, cOpts...)
@@ -378,17 +520,94 @@ func (c *orderServiceClient) CancelOrder(ctx context.Context, in *GetOrderReques
 	return out, nil
 }
 
+func (c *orderServiceClient) UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, OrderService_UpdateOrderStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[0], OrderService_WatchOrder_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrderRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrderClient = grpc.ServerStreamingClient[OrderEvent]
+
+func (c *orderServiceClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[1], OrderService_WatchOrders_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrdersRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersClient = grpc.ServerStreamingClient[OrderEvent]
+
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility.
+//
+// Заказы
 type OrderServiceServer interface {
+	// Создать заказ
+	//
+	// Создаёт заказ пользователя; с address_id в заказ копируется сохранённый адрес
 	CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error)
-	ListOrders(context.Context, *emptypb.Empty) (*ListOrdersResponse, error)
+	// Получить список заказов
+	//
+	// Возвращает заказы, подходящие под фильтры; покупатель видит только свои
+	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	// Получить заказ по ID
 	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	// Удалить заказ
+	//
+	// Мягко удаляет заказ; администратор может восстановить его до окончательной очистки
 	DeleteOrder(context.Context, *DeleteOrderRequest) (*emptypb.Empty, error)
-	ConfirmOrder(context.Context, *GetOrderRequest) (*Order, error)
-	DeliverOrder(context.Context, *GetOrderRequest) (*Order, error)
-	CancelOrder(context.Context, *GetOrderRequest) (*emptypb.Empty, error)
+	// Подтвердить заказ
+	//
+	// Склад переводит заказ из статуса "создан" в "подтверждён". Старый путь /api/orders/confirm/{id} оставлен для совместимости
+	ConfirmOrder(context.Context, *OrderActionRequest) (*Order, error)
+	// Отметить заказ доставленным
+	//
+	// Курьер переводит заказ из статуса "подтверждён" в "доставлен". Старый путь /api/orders/delivery/{id} оставлен для совместимости
+	DeliverOrder(context.Context, *OrderActionRequest) (*Order, error)
+	// Отменить заказ
+	//
+	// Отменяет заказ в статусе "создан" или "подтверждён". Старый путь /api/orders/cancel/{id} оставлен для совместимости
+	CancelOrder(context.Context, *OrderActionRequest) (*emptypb.Empty, error)
+	// Сменить статус заказа
+	//
+	// Общая форма подтверждения, доставки и отмены: те же переходы и те же права. Вернуть заказ в статус "создан" нельзя
+	UpdateOrderStatus(context.Context, *UpdateOrderStatusRequest) (*Order, error)
+	// Текущее состояние и дальнейшие смены статуса заказа. В REST — поток SSE /api/orders/{id}/events
+	WatchOrder(*WatchOrderRequest, grpc.ServerStreamingServer[OrderEvent]) error
+	// Смены статусов заказов по фильтру. В REST — /api/users/{id}/orders/events и WebSocket /api/orders/ws
+	WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error
 	mustEmbedUnimplementedOrderServiceServer()
 }
 
@@ -402,7 +621,7 @@ type UnimplementedOrderServiceServer struct{}
 func (UnimplementedOrderServiceServer) CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
 }
-func (UnimplementedOrderServiceServer) ListOrders(context.Context, *emptypb.Empty) (*ListOrdersResponse, error) {
+func (UnimplementedOrderServiceServer) ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
 }
 func (UnimplementedOrderServiceServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
@@ -411,15 +630,24 @@ func (UnimplementedOrderServiceServer) GetOrder(context.Context, *GetOrderReques
 func (UnimplementedOrderServiceServer) DeleteOrder(context.Context, *DeleteOrderRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrder not implemented")
 }
-func (UnimplementedOrderServiceServer) ConfirmOrder(context.Context, *GetOrderRequest) (*Order, error) {
+func (UnimplementedOrderServiceServer) ConfirmOrder(context.Context, *OrderActionRequest) (*Order, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ConfirmOrder not implemented")
 }
-func (UnimplementedOrderServiceServer) DeliverOrder(context.Context, *GetOrderRequest) (*Order, error) {
+func (UnimplementedOrderServiceServer) DeliverOrder(context.Context, *OrderActionRequest) (*Order, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeliverOrder not implemented")
 }
-func (UnimplementedOrderServiceServer) CancelOrder(context.Context, *GetOrderRequest) (*emptypb.Empty, error) {
+func (UnimplementedOrderServiceServer) CancelOrder(context.Context, *OrderActionRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
 }
+func (UnimplementedOrderServiceServer) UpdateOrderStatus(context.Context, *UpdateOrderStatusRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrderStatus not implemented")
+}
+func (UnimplementedOrderServiceServer) WatchOrder(*WatchOrderRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrder not implemented")
+}
+func (UnimplementedOrderServiceServer) WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
 func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
 func (UnimplementedOrderServiceServer) testEmbeddedByValue()                      {}
 
@@ -460,7 +688,7 @@ func _OrderService_CreateOrder_Handler(srv interface{}, ctx context.Context, dec
 }
 
 func _OrderService_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(ListOrdersRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -472,7 +700,7 @@ func _OrderService_ListOrders_Handler(srv interface{}, ctx context.Context, dec
 		FullMethod: OrderService_ListOrders_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(OrderServiceServer).ListOrders(ctx, req.(*emptypb.Empty))
+		return srv.(OrderServiceServer).ListOrders(ctx, req.(*ListOrdersRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -514,7 +742,7 @@ func _OrderService_DeleteOrder_Handler(srv interface{}, ctx context.Context, dec
 }
 
 func _OrderService_ConfirmOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetOrderRequest)
+	in := new(OrderActionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -526,13 +754,13 @@ func _OrderService_ConfirmOrder_Handler(srv interface{}, ctx context.Context, de
 		FullMethod: OrderService_ConfirmOrder_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(OrderServiceServer).ConfirmOrder(ctx, req.(*GetOrderRequest))
+		return srv.(OrderServiceServer).ConfirmOrder(ctx, req.(*OrderActionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _OrderService_DeliverOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetOrderRequest)
+	in := new(OrderActionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -544,13 +772,13 @@ func _OrderService_DeliverOrder_Handler(srv interface{}, ctx context.Context, de
 		FullMethod: OrderService_DeliverOrder_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(OrderServiceServer).DeliverOrder(ctx, req.(*GetOrderRequest))
+		return srv.(OrderServiceServer).DeliverOrder(ctx, req.(*OrderActionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _OrderService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetOrderRequest)
+	in := new(OrderActionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -562,11 +790,51 @@ func _OrderService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec
 		FullMethod: OrderService_CancelOrder_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(OrderServiceServer).CancelOrder(ctx, req.(*GetOrderRequest))
+		return srv.(OrderServiceServer).CancelOrder(ctx, req.(*OrderActionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderService_UpdateOrderStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrderStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).UpdateOrderStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_UpdateOrderStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).UpdateOrderStatus(ctx, req.(*UpdateOrderStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_WatchOrder_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchOrder(m, &grpc.GenericServerStream[WatchOrderRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrderServer = grpc.ServerStreamingServer[OrderEvent]
+
+func _OrderService_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchOrders(m, &grpc.GenericServerStream[WatchOrdersRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersServer = grpc.ServerStreamingServer[OrderEvent]
+
 // OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -602,6 +870,321 @@ var OrderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelOrder",
 			Handler:    _OrderService_CancelOrder_Handler,
 		},
+		{
+			MethodName: "UpdateOrderStatus",
+			Handler:    _OrderService_UpdateOrderStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrder",
+			Handler:       _OrderService_WatchOrder_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchOrders",
+			Handler:       _OrderService_WatchOrders_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/proto/api.proto",
+}
+
+const (
+	DeliveryService_ListDeliveries_FullMethodName = "/proto.DeliveryService/ListDeliveries"
+	DeliveryService_GetDelivery_FullMethodName    = "/proto.DeliveryService/GetDelivery"
+)
+
+// DeliveryServiceClient is the client API for DeliveryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Доставки
+type DeliveryServiceClient interface {
+	// Список доставок
+	//
+	// Возвращает доставки, подходящие под фильтры; покупатель видит только свои
+	ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error)
+	// Доставка по ID
+	GetDelivery(ctx context.Context, in *GetDeliveryRequest, opts ...grpc.CallOption) (*Delivery, error)
+}
+
+type deliveryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeliveryServiceClient(cc grpc.ClientConnInterface) DeliveryServiceClient {
+	return &deliveryServiceClient{cc}
+}
+
+func (c *deliveryServiceClient) ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeliveriesResponse)
+	err := c.cc.Invoke(ctx, DeliveryService_ListDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) GetDelivery(ctx context.Context, in *GetDeliveryRequest, opts ...grpc.CallOption) (*Delivery, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Delivery)
+	err := c.cc.Invoke(ctx, DeliveryService_GetDelivery_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeliveryServiceServer is the server API for DeliveryService service.
+// All implementations must embed UnimplementedDeliveryServiceServer
+// for forward compatibility.
+//
+// Доставки
+type DeliveryServiceServer interface {
+	// Список доставок
+	//
+	// Возвращает доставки, подходящие под фильтры; покупатель видит только свои
+	ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error)
+	// Доставка по ID
+	GetDelivery(context.Context, *GetDeliveryRequest) (*Delivery, error)
+	mustEmbedUnimplementedDeliveryServiceServer()
+}
+
+// UnimplementedDeliveryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDeliveryServiceServer struct{}
+
+func (UnimplementedDeliveryServiceServer) ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeliveries not implemented")
+}
+func (UnimplementedDeliveryServiceServer) GetDelivery(context.Context, *GetDeliveryRequest) (*Delivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDelivery not implemented")
+}
+func (UnimplementedDeliveryServiceServer) mustEmbedUnimplementedDeliveryServiceServer() {}
+func (UnimplementedDeliveryServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeDeliveryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeliveryServiceServer will
+// result in compilation errors.
+type UnsafeDeliveryServiceServer interface {
+	mustEmbedUnimplementedDeliveryServiceServer()
+}
+
+func RegisterDeliveryServiceServer(s grpc.ServiceRegistrar, srv DeliveryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDeliveryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DeliveryService_ServiceDesc, srv)
+}
+
+func _DeliveryService_ListDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).ListDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeliveryService_ListDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).ListDeliveries(ctx, req.(*ListDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_GetDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).GetDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeliveryService_GetDelivery_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).GetDelivery(ctx, req.(*GetDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DeliveryService_ServiceDesc is the grpc.ServiceDesc for DeliveryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeliveryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.DeliveryService",
+	HandlerType: (*DeliveryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDeliveries",
+			Handler:    _DeliveryService_ListDeliveries_Handler,
+		},
+		{
+			MethodName: "GetDelivery",
+			Handler:    _DeliveryService_GetDelivery_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/proto/api.proto",
+}
+
+const (
+	WarehouseService_ListWarehouses_FullMethodName = "/proto.WarehouseService/ListWarehouses"
+	WarehouseService_GetWarehouse_FullMethodName   = "/proto.WarehouseService/GetWarehouse"
+)
+
+// WarehouseServiceClient is the client API for WarehouseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Складские записи по заказам
+type WarehouseServiceClient interface {
+	// Список складских записей
+	ListWarehouses(ctx context.Context, in *ListWarehousesRequest, opts ...grpc.CallOption) (*ListWarehousesResponse, error)
+	// Складская запись по ID
+	GetWarehouse(ctx context.Context, in *GetWarehouseRequest, opts ...grpc.CallOption) (*Warehouse, error)
+}
+
+type warehouseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWarehouseServiceClient(cc grpc.ClientConnInterface) WarehouseServiceClient {
+	return &warehouseServiceClient{cc}
+}
+
+func (c *warehouseServiceClient) ListWarehouses(ctx context.Context, in *ListWarehousesRequest, opts ...grpc.CallOption) (*ListWarehousesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWarehousesResponse)
+	err := c.cc.Invoke(ctx, WarehouseService_ListWarehouses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *warehouseServiceClient) GetWarehouse(ctx context.Context, in *GetWarehouseRequest, opts ...grpc.CallOption) (*Warehouse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Warehouse)
+	err := c.cc.Invoke(ctx, WarehouseService_GetWarehouse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WarehouseServiceServer is the server API for WarehouseService service.
+// All implementations must embed UnimplementedWarehouseServiceServer
+// for forward compatibility.
+//
+// Складские записи по заказам
+type WarehouseServiceServer interface {
+	// Список складских записей
+	ListWarehouses(context.Context, *ListWarehousesRequest) (*ListWarehousesResponse, error)
+	// Складская запись по ID
+	GetWarehouse(context.Context, *GetWarehouseRequest) (*Warehouse, error)
+	mustEmbedUnimplementedWarehouseServiceServer()
+}
+
+// UnimplementedWarehouseServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWarehouseServiceServer struct{}
+
+func (UnimplementedWarehouseServiceServer) ListWarehouses(context.Context, *ListWarehousesRequest) (*ListWarehousesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWarehouses not implemented")
+}
+func (UnimplementedWarehouseServiceServer) GetWarehouse(context.Context, *GetWarehouseRequest) (*Warehouse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWarehouse not implemented")
+}
+func (UnimplementedWarehouseServiceServer) mustEmbedUnimplementedWarehouseServiceServer() {}
+func (UnimplementedWarehouseServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeWarehouseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WarehouseServiceServer will
+// result in compilation errors.
+type UnsafeWarehouseServiceServer interface {
+	mustEmbedUnimplementedWarehouseServiceServer()
+}
+
+func RegisterWarehouseServiceServer(s grpc.ServiceRegistrar, srv WarehouseServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWarehouseServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WarehouseService_ServiceDesc, srv)
+}
+
+func _WarehouseService_ListWarehouses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWarehousesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WarehouseServiceServer).ListWarehouses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WarehouseService_ListWarehouses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WarehouseServiceServer).ListWarehouses(ctx, req.(*ListWarehousesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WarehouseService_GetWarehouse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWarehouseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WarehouseServiceServer).GetWarehouse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WarehouseService_GetWarehouse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WarehouseServiceServer).GetWarehouse(ctx, req.(*GetWarehouseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WarehouseService_ServiceDesc is the grpc.ServiceDesc for WarehouseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WarehouseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.WarehouseService",
+	HandlerType: (*WarehouseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListWarehouses",
+			Handler:    _WarehouseService_ListWarehouses_Handler,
+		},
+		{
+			MethodName: "GetWarehouse",
+			Handler:    _WarehouseService_GetWarehouse_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/api.proto",
@@ -0,0 +1,11 @@
+package proto
+
+import _ "embed"
+
+// Код и спецификация генерируются из корня репозитория; google/api и protoc-gen-openapiv2/options лежат в third_party
+//go:generate protoc -I ../.. -I ../../third_party/googleapis -I ../../third_party/grpc-gateway --go_out=../.. --go-grpc_out=../.. --openapiv2_out=../.. --openapiv2_opt=json_names_for_fields=false,enums_as_ints=true,openapi_naming_strategy=simple,disable_default_errors=true,preserve_rpc_order=true pkg/proto/api.proto
+
+// OpenAPI — спецификация REST-маршрутов из аннотаций google.api.http в api.proto (Swagger 2.0)
+//
+//go:embed api.swagger.json
+var OpenAPI []byte
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"order-ms/internal/ctl"
+	"order-ms/internal/logging"
+	"order-ms/internal/migrate"
+	repository "order-ms/internal/repository/nosql"
+	"order-ms/internal/seed"
+
+	"github.com/spf13/cobra"
+)
+
+// seedOptions — флаги seed
+type seedOptions struct {
+	to            string
+	seed          uint64
+	users, orders int
+	start         string
+	days          int
+	batchSize     int
+	redisAddr     string
+}
+
+// seedCommand — команда `order-ms seed`: генерирует пользователей, заказы, доставки и складские записи
+// и пишет их в хранилище (SPEC как у migrate-data). Одно и то же --seed даёт те же данные, повторный запуск
+// ничего не дублирует. MemoryRepo читает файлы только при старте, поэтому сервис на том же каталоге
+// нужно перезапустить. Код выхода 1 — запись не удалась, 2 — неверные аргументы или хранилище недоступно
+func seedCommand() *cobra.Command {
+	o := &seedOptions{}
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Generate users, orders, deliveries and warehouse records into a storage",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.users <= 0 || o.orders < 0 || o.days <= 0 {
+				return usageError(cmd, errors.New("--users and --days must be positive, --orders non-negative"))
+			}
+			from, err := time.Parse(time.DateOnly, o.start)
+			if err != nil {
+				return &ctl.ExitError{Code: 2, Err: fmt.Errorf("--start: %w", err)}
+			}
+			return exitCode(runSeed(cmd.Context(), o, from, cmd.OutOrStdout(), cmd.ErrOrStderr()))
+		},
+	}
+	cmd.SetFlagErrorFunc(usageError)
+	f := cmd.Flags()
+	f.StringVar(&o.to, "to", "memory:data", "Target storage: memory:DIR, postgres://... or mongodb://...")
+	f.Uint64Var(&o.seed, "seed", 1, "Random seed; the same seed produces the same records")
+	f.IntVar(&o.users, "users", seed.DefaultUsers, "Number of users")
+	f.IntVar(&o.orders, "orders", seed.DefaultOrders, "Number of orders, spread unevenly across users")
+	f.StringVar(&o.start, "start", seed.DefaultStart.Format(time.DateOnly), "First day of the period records are created in (YYYY-MM-DD)")
+	f.IntVar(&o.days, "days", seed.DefaultDays, "Length of the period in days")
+	f.IntVar(&o.batchSize, "batch-size", migrate.DefaultBatchSize, "Orders per insert batch")
+	f.StringVar(&o.redisAddr, "redis-addr", repository.DefaultRedisAddr, "Redis address, needed when the target is MongoDB")
+	return cmd
+}
+
+// runSeed генерирует данные с первым днём from, пишет их и возвращает код выхода команды
+func runSeed(ctx context.Context, o *seedOptions, from time.Time, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger := logging.New(stderr, slog.LevelInfo)
+
+	repo, closeRepo, err := openStore(o.to, true, true, o.redisAddr, logger)
+	if err != nil {
+		fmt.Fprintln(stderr, "seed:", err)
+		return 2
+	}
+	defer closeRepo()
+
+	ds := seed.Generate(seed.Options{Seed: o.seed, Users: o.users, Orders: o.orders, Start: from, Days: o.days})
+	counts, err := seed.Write(ctx, repo, ds, o.batchSize)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tGENERATED\tEXISTING\tWRITTEN")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", c.Kind, c.Total, c.Existing, c.Total-c.Existing)
+	}
+	tw.Flush()
+	if err != nil {
+		fmt.Fprintln(stderr, "seed:", err)
+		return 1
+	}
+	return 0
+}
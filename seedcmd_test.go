@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"order-ms/internal/ctl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тест команды seed: данные пишутся в каталог MemoryRepo, повтор с тем же зерном ничего не добавляет
+func TestRunSeed(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := ctl.Run(append([]string{"seed"}, args...), &stdout, &stderr, commands()...)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, stderr := run("--to", "memory:"+dir, "--seed", "5", "--users", "3", "--orders", "10")
+	require.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `users\s+3\s+0\s+3`, out)
+	assert.Regexp(t, `orders\s+10\s+0\s+10`, out)
+	first, err := os.ReadFile(filepath.Join(dir, "orders.json"))
+	require.NoError(t, err)
+
+	code, out, _ = run("--to", "memory:"+dir, "--seed", "5", "--users", "3", "--orders", "10")
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `orders\s+10\s+10\s+0`, out)
+	second, err := os.ReadFile(filepath.Join(dir, "orders.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+
+	code, _, stderr = run("--start", "yesterday")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "--start")
+	code, _, _ = run("--users", "0")
+	assert.Equal(t, 2, code)
+}